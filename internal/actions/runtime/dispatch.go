@@ -2,8 +2,11 @@ package runtime
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/cshaiku/goshi/internal/fs"
 )
@@ -22,12 +25,41 @@ var (
 
 // Dispatcher routes actions to concrete implementations.
 type Dispatcher struct {
-	guard *fs.Guard
+	guard             *fs.Guard
+	backupOnWrite     bool
+	backupRoot        string
+	workDir           string // Where write proposals are stored ("" = process working directory)
+	maxRecursiveBytes int
 }
 
 // NewDispatcher creates a dispatcher scoped to a filesystem guard.
 func NewDispatcher(guard *fs.Guard) *Dispatcher {
-	return &Dispatcher{guard: guard}
+	return &Dispatcher{guard: guard, backupRoot: guard.Root()}
+}
+
+// SetBackupOnWrite controls whether fs.write snapshots the existing file
+// content before proposing a change.
+func (d *Dispatcher) SetBackupOnWrite(enabled bool) {
+	d.backupOnWrite = enabled
+}
+
+// SetBackupRoot sets the repo root whose .goshi/backups directory holds
+// write backups. It defaults to the guard's root.
+func (d *Dispatcher) SetBackupRoot(root string) {
+	d.backupRoot = root
+}
+
+// SetWorkingDir sets the directory whose .goshi/proposals holds write
+// proposals. It defaults to the process working directory.
+func (d *Dispatcher) SetWorkingDir(dir string) {
+	d.workDir = dir
+}
+
+// SetMaxRecursiveBytes caps the size of fs.list-recursive output, in bytes.
+// Listings that hit the cap are cut short and flagged as truncated.
+// 0 disables the cap.
+func (d *Dispatcher) SetMaxRecursiveBytes(n int) {
+	d.maxRecursiveBytes = n
 }
 
 // Dispatch executes a named action with validated inputs.
@@ -57,23 +89,48 @@ func (d *Dispatcher) Dispatch(action string, in ActionInput) (ActionOutput, erro
 			return nil, ErrInvalidInput
 		}
 
+		// Names-only by default to keep listings cheap in the model's context
+		detail, _ := in["detail"].(bool)
+
 		res, err := fs.List(d.guard, path)
 		if err != nil {
 			return nil, err
 		}
 
+		if !detail {
+			names := make([]string, 0, len(res.Entries))
+			for _, e := range res.Entries {
+				if e.IsDir {
+					names = append(names, e.Name+"/")
+				} else {
+					names = append(names, e.Name)
+				}
+			}
+
+			out := ActionOutput{"path": encodedPath(res.Path)}
+			out["entries"] = encodeNames(out, "encoded_entries", names)
+			return out, nil
+		}
+
 		entries := make([]ActionOutput, 0, len(res.Entries))
 		for _, e := range res.Entries {
-			entries = append(entries, ActionOutput{
-				"name":   e.Name,
-				"path":   e.Path,
-				"is_dir": e.IsDir,
-				"size":   e.Size,
-			})
+			name, encoding := fs.EncodeName(e.Name)
+			entry := ActionOutput{
+				"name":    name,
+				"path":    encodedPath(e.Path),
+				"is_dir":  e.IsDir,
+				"size":    e.Size,
+				"mode":    e.Mode.String(),
+				"modtime": e.ModTime.UTC().Format(time.RFC3339),
+			}
+			if encoding != "" {
+				entry["name_encoding"] = encoding
+			}
+			entries = append(entries, entry)
 		}
 
 		return ActionOutput{
-			"path":    res.Path,
+			"path":    encodedPath(res.Path),
 			"entries": entries,
 		}, nil
 
@@ -84,45 +141,109 @@ func (d *Dispatcher) Dispatch(action string, in ActionInput) (ActionOutput, erro
 			return nil, ErrInvalidInput
 		}
 
-		resolved, err := d.guard.Resolve(path)
+		p, before, err := d.proposeWrite(path, content)
 		if err != nil {
 			return nil, err
 		}
 
-		var (
-			isNew    = true
-			baseHash = ""
-		)
-
-		if data, err := os.ReadFile(resolved); err == nil {
-			isNew = false
-			baseHash = fs.ComputeHash(data)
-		} else if !os.IsNotExist(err) {
+		if err := fs.SaveProposalIn(d.workDir, p); err != nil {
 			return nil, err
 		}
 
-		contentHash := fs.ComputeHash([]byte(content))
+		out := ActionOutput{
+			"id":           p.ID,
+			"path":         p.Path,
+			"is_new_file":  p.IsNewFile,
+			"base_hash":    p.BaseHash,
+			"content_hash": p.ContentHash,
+			"diff":         p.Diff,
+			"generated_at": p.GeneratedAt,
+		}
+
+		if d.backupOnWrite && !p.IsNewFile {
+			backupPath, err := fs.SaveBackup(d.backupRoot, p.ID, before)
+			if err != nil {
+				return nil, err
+			}
+			out["backup_path"] = backupPath
+		}
+
+		return out, nil
+
+	case "fs.write-many":
+		entries, ok := in["files"].([]any)
+		if !ok || len(entries) == 0 {
+			return nil, ErrInvalidInput
+		}
+
+		// Validate and diff every file before anything is saved, so a bad
+		// entry leaves no partial proposal behind
+		writes := make([]fs.Proposal, 0, len(entries))
+		befores := make([][]byte, 0, len(entries))
+		seen := map[string]bool{}
+		for _, entry := range entries {
+			file, ok := entry.(map[string]any)
+			if !ok {
+				return nil, ErrInvalidInput
+			}
+			path, ok1 := file["path"].(string)
+			content, ok2 := file["content"].(string)
+			if !ok1 || !ok2 {
+				return nil, ErrInvalidInput
+			}
+
+			p, before, err := d.proposeWrite(path, content)
+			if err != nil {
+				return nil, err
+			}
+			if seen[p.Path] {
+				return nil, fmt.Errorf("%w: %s appears more than once", ErrInvalidInput, path)
+			}
+			seen[p.Path] = true
+
+			writes = append(writes, p)
+			befores = append(befores, before)
+		}
+
+		diffs := make([]string, 0, len(writes))
+		for _, w := range writes {
+			diffs = append(diffs, w.Diff)
+		}
 
-		p := fs.Proposal{
-			ID:          fs.ProposalID(resolved, isNew, baseHash, contentHash),
-			Path:        resolved,
-			IsNewFile:   isNew,
-			BaseHash:    baseHash,
-			ContentHash: contentHash,
+		batch := fs.Proposal{
+			ID:          fs.BatchProposalID(writes),
+			Diff:        strings.Join(diffs, ""),
 			GeneratedAt: time.Now().UTC(),
+			Writes:      writes,
 		}
 
-		if err := fs.SaveProposal(p); err != nil {
+		if err := fs.SaveProposalIn(d.workDir, batch); err != nil {
 			return nil, err
 		}
 
+		files := make([]ActionOutput, 0, len(writes))
+		for i, w := range writes {
+			file := ActionOutput{
+				"path":         w.Path,
+				"is_new_file":  w.IsNewFile,
+				"base_hash":    w.BaseHash,
+				"content_hash": w.ContentHash,
+			}
+			if d.backupOnWrite && !w.IsNewFile {
+				backupPath, err := fs.SaveBackup(d.backupRoot, w.ID, befores[i])
+				if err != nil {
+					return nil, err
+				}
+				file["backup_path"] = backupPath
+			}
+			files = append(files, file)
+		}
+
 		return ActionOutput{
-			"id":           p.ID,
-			"path":         p.Path,
-			"is_new_file":  p.IsNewFile,
-			"base_hash":    p.BaseHash,
-			"content_hash": p.ContentHash,
-			"generated_at": p.GeneratedAt,
+			"id":           batch.ID,
+			"files":        files,
+			"diff":         batch.Diff,
+			"generated_at": batch.GeneratedAt,
 		}, nil
 
 	case "fs.list-recursive":
@@ -131,18 +252,89 @@ func (d *Dispatcher) Dispatch(action string, in ActionInput) (ActionOutput, erro
 			return nil, ErrInvalidInput
 		}
 
-		res, err := fs.ListRecursive(d.guard, path)
+		res, err := fs.ListRecursiveLimit(d.guard, path, d.maxRecursiveBytes)
 		if err != nil {
 			return nil, err
 		}
 
-		return ActionOutput{
-			"path":  res.Path,
-			"files": res.Files,
-			"count": res.Count,
-		}, nil
+		out := ActionOutput{
+			"path":      encodedPath(res.Path),
+			"count":     res.Count,
+			"truncated": res.Truncated,
+		}
+		out["files"] = encodeNames(out, "encoded_files", res.Files)
+		return out, nil
 
 	default:
 		return nil, ErrUnknownAction
 	}
 }
+
+// proposeWrite builds the proposal for writing content to path within the
+// guard's root, returning the file's current content alongside it
+func (d *Dispatcher) proposeWrite(path, content string) (fs.Proposal, []byte, error) {
+	resolved, err := d.guard.Resolve(path)
+	if err != nil {
+		return fs.Proposal{}, nil, err
+	}
+
+	var (
+		isNew    = true
+		baseHash = ""
+		before   []byte
+	)
+
+	if data, err := os.ReadFile(resolved); err == nil {
+		isNew = false
+		baseHash = fs.ComputeHash(data)
+		before = data
+	} else if !os.IsNotExist(err) {
+		return fs.Proposal{}, nil, err
+	}
+
+	contentHash := fs.ComputeHash([]byte(content))
+
+	// Only text content gets a line diff; binary writes are still proposed intact
+	diff := ""
+	if utf8.Valid(before) && utf8.ValidString(content) {
+		diff = fs.UnifiedDiff(path, before, []byte(content))
+	}
+
+	return fs.Proposal{
+		ID:          fs.ProposalID(resolved, isNew, baseHash, contentHash),
+		Path:        resolved,
+		IsNewFile:   isNew,
+		BaseHash:    baseHash,
+		ContentHash: contentHash,
+		Content:     []byte(content),
+		Diff:        diff,
+		GeneratedAt: time.Now().UTC(),
+	}, before, nil
+}
+
+// encodeNames makes file names JSON-safe. Names that are not valid UTF-8 are
+// percent-escaped; when any were, out records the encoding and lists the
+// escaped names under key so callers can decode them with fs.DecodeName.
+func encodeNames(out ActionOutput, key string, names []string) []string {
+	encodedNames := make([]string, 0, len(names))
+	var escaped []string
+	for _, name := range names {
+		encoded, encoding := fs.EncodeName(name)
+		if encoding != "" {
+			escaped = append(escaped, encoded)
+		}
+		encodedNames = append(encodedNames, encoded)
+	}
+
+	if len(escaped) > 0 {
+		out["name_encoding"] = fs.NameEncodingPercent
+		out[key] = escaped
+	}
+	return encodedNames
+}
+
+// encodedPath returns a JSON-safe form of a resolved path
+func encodedPath(path string) string {
+	encoded, _ := fs.EncodeName(path)
+	return encoded
+}
@@ -7,6 +7,13 @@ const (
 	CapFSWrite Capability = "FS_WRITE"
 )
 
+// ReadOnly reports whether a capability only observes the filesystem.
+// Tools that require a read-only capability (fs.read, fs.list, ...) may be
+// auto-approved while write tools still prompt.
+func (c Capability) ReadOnly() bool {
+	return c == CapFSRead
+}
+
 type Capabilities struct {
 	granted map[Capability]bool
 }
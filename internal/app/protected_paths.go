@@ -0,0 +1,122 @@
+package app
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// protectedPathArgs lists, per mutating tool, the arguments that name a
+// target path and must be checked against the protected path policy
+var protectedPathArgs = map[string][]string{
+	"fs.write":  {"path"},
+	"fs.delete": {"path"},
+	"fs.move":   {"from", "to"},
+}
+
+// protectedListArgs lists, per mutating tool, array arguments whose object
+// entries name a target path under the given field
+var protectedListArgs = map[string][2]string{
+	"fs.write-many": {"files", "path"},
+}
+
+// ProtectedPaths refuses mutations to paths matching any of its globs.
+// Patterns are matched against slash-separated paths relative to root.
+// A "**" segment matches any number of directories, and a pattern without
+// a slash (e.g. "*.key") matches the file name at any depth.
+type ProtectedPaths struct {
+	root     string
+	patterns []string
+}
+
+// NewProtectedPaths creates a protected path policy scoped to root
+func NewProtectedPaths(root string, patterns []string) *ProtectedPaths {
+	return &ProtectedPaths{root: root, patterns: patterns}
+}
+
+// Check returns an error if the tool call would mutate a protected path
+func (p *ProtectedPaths) Check(toolName string, args map[string]any) error {
+	if p == nil || len(p.patterns) == 0 {
+		return nil
+	}
+
+	for _, target := range targetPaths(toolName, args) {
+		rel := p.relative(target)
+		for _, pattern := range p.patterns {
+			if matchProtectedPath(pattern, rel) {
+				return fmt.Errorf("path %q is protected by policy (%s)", target, pattern)
+			}
+		}
+	}
+
+	return nil
+}
+
+// targetPaths returns the paths a mutating tool call would write to
+func targetPaths(toolName string, args map[string]any) []string {
+	var targets []string
+	for _, arg := range protectedPathArgs[toolName] {
+		if target, ok := args[arg].(string); ok && target != "" {
+			targets = append(targets, target)
+		}
+	}
+
+	if list, ok := protectedListArgs[toolName]; ok {
+		entries, _ := args[list[0]].([]any)
+		for _, entry := range entries {
+			fields, _ := entry.(map[string]any)
+			if target, ok := fields[list[1]].(string); ok && target != "" {
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	return targets
+}
+
+// relative normalizes a tool path argument to a slash-separated path under root
+func (p *ProtectedPaths) relative(target string) string {
+	if filepath.IsAbs(target) && p.root != "" {
+		if rel, err := filepath.Rel(p.root, target); err == nil {
+			target = rel
+		}
+	}
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(target)), "./")
+}
+
+// matchProtectedPath reports whether rel matches a protected path glob
+func matchProtectedPath(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+
+	return len(parts) == 0
+}
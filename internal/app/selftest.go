@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cshaiku/goshi/internal/actions/runtime"
+	"github.com/cshaiku/goshi/internal/audit"
+	"github.com/cshaiku/goshi/internal/fs"
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// SelfTestCase is a canned call that exercises one tool in the self-test
+// workspace. Check (optional) validates the shape of a successful result.
+type SelfTestCase struct {
+	Tool  string
+	Args  map[string]any
+	Check func(out runtime.ActionOutput) error
+}
+
+// SelfTestResult reports whether one tool passed the self-test
+type SelfTestResult struct {
+	Tool   string `json:"tool"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// selfTestFile is seeded into the workspace for the read and list cases
+const selfTestFile = "selftest.txt"
+
+// DefaultSelfTestCases returns canned calls for the standard tools
+func DefaultSelfTestCases() []SelfTestCase {
+	return []SelfTestCase{
+		{
+			Tool:  FSReadTool.ID,
+			Args:  map[string]any{"path": selfTestFile},
+			Check: requireKeys("path", "content", "size"),
+		},
+		{
+			Tool: FSListTool.ID,
+			Args: map[string]any{"path": "."},
+			Check: func(out runtime.ActionOutput) error {
+				entries, ok := out["entries"].([]string)
+				if !ok {
+					return fmt.Errorf("expected entries to be a name list, got %T", out["entries"])
+				}
+				for _, e := range entries {
+					if e == selfTestFile {
+						return nil
+					}
+				}
+				return fmt.Errorf("expected %s in listing %v", selfTestFile, entries)
+			},
+		},
+		{
+			Tool:  FSWriteTool.ID,
+			Args:  map[string]any{"path": "written.txt", "content": "self-test\n"},
+			Check: requireKeys("id", "path", "diff", "content_hash"),
+		},
+		{
+			Tool: FSWriteManyTool.ID,
+			Args: map[string]any{"files": []any{
+				map[string]any{"path": "written-a.txt", "content": "self-test a\n"},
+				map[string]any{"path": "written-b.txt", "content": "self-test b\n"},
+			}},
+			Check: requireKeys("id", "files", "diff"),
+		},
+		{
+			Tool:  AuditQueryTool.ID,
+			Args:  map[string]any{"limit": float64(5)},
+			Check: requireKeys("events", "count"),
+		},
+	}
+}
+
+// requireKeys checks that a result carries every key
+func requireKeys(keys ...string) func(runtime.ActionOutput) error {
+	return func(out runtime.ActionOutput) error {
+		for _, key := range keys {
+			if _, ok := out[key]; !ok {
+				return fmt.Errorf("result is missing %q", key)
+			}
+		}
+		return nil
+	}
+}
+
+// RunSelfTest runs every tool in registry through its canned case in a
+// temporary workspace, checking that the case matches the tool's schema,
+// that calls are refused until the required capability is granted, and
+// that the granted call succeeds with the expected result shape. No model
+// is contacted: a mock backend requests each call, which is parsed and
+// validated like a real model's. Write proposals are kept in the
+// workspace, never in the real repository.
+func RunSelfTest(registry *ToolRegistry, cases []SelfTestCase) ([]SelfTestResult, error) {
+	dir, err := os.MkdirTemp("", "goshi-selftest-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create self-test workspace: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, selfTestFile), []byte("hello from the self-test\n"), 0644); err != nil {
+		return nil, err
+	}
+
+	guard, err := fs.NewGuard(dir)
+	if err != nil {
+		return nil, err
+	}
+	dispatcher := runtime.NewDispatcher(guard)
+	dispatcher.SetWorkingDir(dir)
+
+	logger, err := audit.NewLogger(audit.Config{Enabled: true, Dir: filepath.Join(dir, ".goshi", "audit")}, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create self-test audit log: %w", err)
+	}
+	defer logger.Close()
+
+	byTool := make(map[string]SelfTestCase, len(cases))
+	for _, c := range cases {
+		byTool[c.Tool] = c
+	}
+
+	tools := registry.All()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].ID < tools[j].ID })
+
+	results := make([]SelfTestResult, 0, len(tools))
+	for _, tool := range tools {
+		result := SelfTestResult{Tool: tool.ID, Passed: true}
+		c, ok := byTool[tool.ID]
+		if !ok {
+			result.Passed = false
+			result.Error = "no self-test case for this tool"
+		} else if err := runSelfTestCase(registry, dispatcher, logger, dir, tool, c); err != nil {
+			result.Passed = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// runSelfTestCase exercises one tool with its canned case
+func runSelfTestCase(registry *ToolRegistry, dispatcher *runtime.Dispatcher, logger *audit.Logger, dir string, tool ToolDefinition, c SelfTestCase) error {
+	// Schema: the canned call validates, and missing required args do not
+	if err := registry.ValidateCall(tool.ID, c.Args); err != nil {
+		return fmt.Errorf("schema rejected the canned call: %w", err)
+	}
+	if len(tool.Schema.Required) > 0 {
+		if err := registry.ValidateCall(tool.ID, map[string]any{}); err == nil {
+			return fmt.Errorf("schema accepted a call without required arguments %v", tool.Schema.Required)
+		}
+	}
+
+	caps := NewCapabilities()
+	router := NewToolRouterWithRegistry(dispatcher, registry, caps)
+	router.SetAuditLogger(logger, dir)
+	call, err := requestSelfTestCall(registry, c)
+	if err != nil {
+		return err
+	}
+
+	// Permission gating: refused until the capability is granted
+	if tool.RequiredPermission != "" {
+		denied := router.Execute(call)
+		if denied.Success || !strings.Contains(denied.Error, "permission denied") {
+			return fmt.Errorf("call without %s was not refused (success=%v, error=%q)", tool.RequiredPermission, denied.Success, denied.Error)
+		}
+		caps.Grant(tool.RequiredPermission)
+	}
+
+	// Result shape: the granted call succeeds with a structured result
+	result := router.Execute(call)
+	if !result.Success {
+		return fmt.Errorf("call failed: %s", result.Error)
+	}
+	out, ok := result.Value.(runtime.ActionOutput)
+	if !ok {
+		return fmt.Errorf("expected a structured result, got %T", result.Value)
+	}
+	if c.Check != nil {
+		if err := c.Check(out); err != nil {
+			return fmt.Errorf("unexpected result: %w", err)
+		}
+	}
+	return nil
+}
+
+// requestSelfTestCall has the mock backend request the canned call and
+// parses the answer the way a chat turn parses a model's. Permissions are
+// left to the router, which the caller checks separately.
+func requestSelfTestCall(registry *ToolRegistry, c SelfTestCase) (ToolCall, error) {
+	action, err := json.Marshal(map[string]any{
+		"type":   "action",
+		"action": map[string]any{"tool": c.Tool, "args": c.Args},
+	})
+	if err != nil {
+		return ToolCall{}, fmt.Errorf("failed to encode the canned call: %w", err)
+	}
+
+	system, err := llm.NewSystemPrompt("goshi self-test")
+	if err != nil {
+		return ToolCall{}, err
+	}
+	client := llm.NewClientWithTools(system, selfTestBackend{response: string(action)})
+	raw, err := client.CollectStream(context.Background(), []llm.Message{{Role: "user", Content: "Run " + c.Tool}})
+	if err != nil {
+		return ToolCall{}, err
+	}
+
+	parser := llm.NewStructuredParser()
+	parser.SetToolValidator(registry.ValidateCall)
+	resp, err := parser.ParseAndValidate(raw)
+	if err != nil {
+		return ToolCall{}, fmt.Errorf("model request was rejected: %w", err)
+	}
+	if resp.Type != llm.ResponseTypeAction || resp.Action == nil {
+		return ToolCall{}, fmt.Errorf("model request parsed as %s, not an action", resp.Type)
+	}
+	return ToolCall{Name: resp.Action.Tool, Args: resp.Action.Args}, nil
+}
+
+// selfTestBackend is a mock model that answers every request with one
+// canned response
+type selfTestBackend struct {
+	response string
+}
+
+func (b selfTestBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	return &selfTestStream{response: b.response}, nil
+}
+
+type selfTestStream struct {
+	response string
+	sent     bool
+}
+
+func (s *selfTestStream) Recv() (string, error) {
+	if s.sent {
+		return "", io.EOF
+	}
+	s.sent = true
+	return s.response, nil
+}
+
+func (s *selfTestStream) Close() error { return nil }
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/actions/runtime"
+)
+
+func TestRunSelfTest_StandardToolsPass(t *testing.T) {
+	wd := t.TempDir()
+	t.Chdir(wd)
+
+	results, err := RunSelfTest(NewDefaultToolRegistry(), DefaultSelfTestCases())
+	if err != nil {
+		t.Fatalf("RunSelfTest failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(wd, ".goshi")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing written to the working directory, got %v", err)
+	}
+	if len(results) != len(NewDefaultToolRegistry().All()) {
+		t.Fatalf("expected one result per registered tool, got %d", len(results))
+	}
+	for _, r := range results {
+		if !r.Passed {
+			t.Errorf("%s failed: %s", r.Tool, r.Error)
+		}
+	}
+}
+
+func TestRunSelfTest_DetectsMisbehavingTools(t *testing.T) {
+	registry := NewDefaultToolRegistry()
+
+	// A tool whose handler does not exist
+	registry.Register(ToolDefinition{
+		ID:                 "fs.broken",
+		Name:               "Broken",
+		Description:        "Always fails",
+		RequiredPermission: CapFSRead,
+		Schema:             JSONSchema{Type: "object", Properties: map[string]JSONSchema{}},
+	})
+	// A tool registered without a canned self-test call
+	registry.Register(ToolDefinition{
+		ID:          "fs.untested",
+		Name:        "Untested",
+		Description: "Has no self-test case",
+		Schema:      JSONSchema{Type: "object", Properties: map[string]JSONSchema{}},
+	})
+
+	cases := DefaultSelfTestCases()
+	cases = append(cases, SelfTestCase{Tool: "fs.broken", Args: map[string]any{}})
+	for i := range cases {
+		// fs.list returns a result of the wrong shape for this check
+		if cases[i].Tool == FSListTool.ID {
+			cases[i].Check = func(out runtime.ActionOutput) error {
+				if _, ok := out["files"]; !ok {
+					return fmt.Errorf("result is missing \"files\"")
+				}
+				return nil
+			}
+		}
+	}
+
+	results, err := RunSelfTest(registry, cases)
+	if err != nil {
+		t.Fatalf("RunSelfTest failed: %v", err)
+	}
+
+	want := map[string]string{
+		"fs.broken":   "call failed",
+		"fs.list":     "unexpected result",
+		"fs.untested": "no self-test case",
+	}
+	for _, r := range results {
+		expected, shouldFail := want[r.Tool]
+		if !shouldFail {
+			if !r.Passed {
+				t.Errorf("%s should pass, failed: %s", r.Tool, r.Error)
+			}
+			continue
+		}
+		if r.Passed || !strings.Contains(r.Error, expected) {
+			t.Errorf("%s: expected failure containing %q, got passed=%v error=%q", r.Tool, expected, r.Passed, r.Error)
+		}
+	}
+}
+
+func TestRunSelfTest_DetectsSchemaMismatch(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(FSReadTool)
+
+	results, err := RunSelfTest(registry, []SelfTestCase{{Tool: FSReadTool.ID, Args: map[string]any{"file": "x"}}})
+	if err != nil {
+		t.Fatalf("RunSelfTest failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Passed || !strings.Contains(results[0].Error, "schema rejected") {
+		t.Errorf("expected a schema failure for a mismatched canned call, got %+v", results)
+	}
+}
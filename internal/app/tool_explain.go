@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/cshaiku/goshi/internal/fs"
+)
+
+// toolPathArgs lists, per tool, the arguments that name a path inside the
+// repository jail
+var toolPathArgs = map[string][]string{
+	"fs.read":  {"path"},
+	"fs.list":  {"path"},
+	"fs.write": {"path"},
+}
+
+// ToolExplanation describes what a tool call would do without executing it
+type ToolExplanation struct {
+	Tool        string            `json:"tool"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Capability  Capability        `json:"required_capability"`
+	Args        map[string]any    `json:"args"`
+	Paths       map[string]string `json:"resolved_paths,omitempty"` // arg name -> absolute path in the jail
+	Effect      string            `json:"effect"`
+	Refusal     string            `json:"refusal,omitempty"` // set when policy would refuse the call
+}
+
+// ExplainToolCall validates a tool call against its schema, resolves its
+// path arguments within the guard's root and describes the effect.
+// Schema and path errors are returned; protected path refusals are
+// reported in the explanation so the caller can see why.
+func ExplainToolCall(registry *ToolRegistry, guard *fs.Guard, protected *ProtectedPaths, call ToolCall) (*ToolExplanation, error) {
+	toolDef, ok := registry.Get(call.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown tool: %s", call.Name)
+	}
+
+	if err := registry.ValidateCall(call.Name, call.Args); err != nil {
+		return nil, fmt.Errorf("invalid tool call: %w", err)
+	}
+
+	exp := &ToolExplanation{
+		Tool:        toolDef.ID,
+		Name:        toolDef.Name,
+		Description: toolDef.Description,
+		Capability:  toolDef.RequiredPermission,
+		Args:        call.Args,
+		Paths:       map[string]string{},
+	}
+
+	for _, arg := range toolPathArgs[call.Name] {
+		target, ok := call.Args[arg].(string)
+		if !ok {
+			continue
+		}
+		resolved, err := guard.Resolve(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", arg, target, err)
+		}
+		exp.Paths[arg] = resolved
+	}
+
+	if call.Name == "fs.write-many" {
+		files, _ := call.Args["files"].([]any)
+		for i, entry := range files {
+			target, _ := entry.(map[string]any)["path"].(string)
+			resolved, err := guard.Resolve(target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid files[%d].path %q: %w", i, target, err)
+			}
+			exp.Paths[fmt.Sprintf("files[%d].path", i)] = resolved
+		}
+	}
+
+	if err := protected.Check(call.Name, call.Args); err != nil {
+		exp.Refusal = err.Error()
+	}
+
+	exp.Effect = describeEffect(call, exp.Paths)
+	return exp, nil
+}
+
+// describeEffect summarizes what executing the call would do
+func describeEffect(call ToolCall, paths map[string]string) string {
+	switch call.Name {
+	case "fs.read":
+		return fmt.Sprintf("Read %s and return its content", paths["path"])
+	case "fs.list":
+		if detail, _ := call.Args["detail"].(bool); detail {
+			return fmt.Sprintf("List the entries of %s with size, mode and modification time", paths["path"])
+		}
+		return fmt.Sprintf("List the entry names of %s", paths["path"])
+	case "fs.write":
+		content, _ := call.Args["content"].(string)
+		return fmt.Sprintf("Propose writing %d bytes to %s; nothing changes until the proposal is applied", len(content), paths["path"])
+	case "fs.write-many":
+		files, _ := call.Args["files"].([]any)
+		return fmt.Sprintf("Propose writing %d files as one all-or-nothing change; nothing changes until the proposal is applied", len(files))
+	case "audit.query":
+		return "Summarize this session's most recent tool calls from the audit log"
+	default:
+		return "Execute " + call.Name
+	}
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/fs"
+)
+
+func TestExplainToolCall_Valid(t *testing.T) {
+	root := t.TempDir()
+	guard, err := fs.NewGuard(root)
+	if err != nil {
+		t.Fatalf("guard: %v", err)
+	}
+	realRoot, _ := filepath.EvalSymlinks(root)
+
+	exp, err := ExplainToolCall(NewDefaultToolRegistry(), guard, nil, ToolCall{
+		Name: "fs.write",
+		Args: map[string]any{"path": "notes/todo.txt", "content": "hello"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if exp.Capability != CapFSWrite {
+		t.Errorf("expected FS_WRITE capability, got %s", exp.Capability)
+	}
+	want := filepath.Join(realRoot, "notes", "todo.txt")
+	if exp.Paths["path"] != want {
+		t.Errorf("expected resolved path %s, got %s", want, exp.Paths["path"])
+	}
+	if !strings.Contains(exp.Effect, "5 bytes") || !strings.Contains(exp.Effect, want) {
+		t.Errorf("unexpected effect: %s", exp.Effect)
+	}
+	if exp.Refusal != "" {
+		t.Errorf("expected no refusal, got %s", exp.Refusal)
+	}
+}
+
+func TestExplainToolCall_Invalid(t *testing.T) {
+	guard, _ := fs.NewGuard(t.TempDir())
+	registry := NewDefaultToolRegistry()
+
+	if _, err := ExplainToolCall(registry, guard, nil, ToolCall{Name: "fs.read", Args: map[string]any{}}); err == nil ||
+		!strings.Contains(err.Error(), "missing required argument: path") {
+		t.Errorf("expected schema validation error, got %v", err)
+	}
+
+	if _, err := ExplainToolCall(registry, guard, nil, ToolCall{Name: "fs.read", Args: map[string]any{"path": "../outside"}}); err == nil {
+		t.Error("expected error for a path outside the jail")
+	}
+
+	if _, err := ExplainToolCall(registry, guard, nil, ToolCall{Name: "nope", Args: map[string]any{}}); err == nil {
+		t.Error("expected error for an unknown tool")
+	}
+}
+
+func TestExplainToolCall_ReportsProtectedPath(t *testing.T) {
+	root := t.TempDir()
+	guard, _ := fs.NewGuard(root)
+
+	exp, err := ExplainToolCall(NewDefaultToolRegistry(), guard, NewProtectedPaths(root, []string{"*.key"}), ToolCall{
+		Name: "fs.write",
+		Args: map[string]any{"path": "id.key", "content": "x"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(exp.Refusal, "protected") {
+		t.Errorf("expected protected path refusal, got %q", exp.Refusal)
+	}
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -14,6 +15,7 @@ type JSONSchema struct {
 	Required             []string              `json:"required,omitempty"`
 	AdditionalProperties bool                  `json:"additionalProperties"`
 	Pattern              string                `json:"pattern,omitempty"`
+	Items                *JSONSchema           `json:"items,omitempty"` // Element schema for arrays
 }
 
 // ToolDefinition describes a tool that the LLM can invoke
@@ -80,38 +82,53 @@ func (r *ToolRegistry) All() []ToolDefinition {
 
 // ValidateCall validates that the provided arguments match the tool's schema
 func (r *ToolRegistry) ValidateCall(id string, args map[string]any) error {
+	if errs := r.ValidationErrors(id, args); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidationErrors checks the provided arguments against the tool's schema
+// and returns every violation: missing required arguments first, then
+// unexpected and invalid arguments in field order
+func (r *ToolRegistry) ValidationErrors(id string, args map[string]any) []error {
 	toolDef, ok := r.Get(id)
 	if !ok {
-		return fmt.Errorf("unknown tool: %s", id)
+		return []error{fmt.Errorf("unknown tool: %s", id)}
 	}
 
+	var errs []error
+
 	// Check required fields
 	for _, field := range toolDef.Schema.Required {
 		if _, ok := args[field]; !ok {
-			return fmt.Errorf("missing required argument: %s", field)
+			errs = append(errs, fmt.Errorf("missing required argument: %s", field))
 		}
 	}
 
-	// Check that no extra fields are provided (if additionalProperties is false)
-	if !toolDef.Schema.AdditionalProperties {
-		for arg := range args {
-			_, ok := toolDef.Schema.Properties[arg]
-			if !ok {
-				return fmt.Errorf("unexpected argument: %s", arg)
+	fields := make([]string, 0, len(args))
+	for field := range args {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		schema, ok := toolDef.Schema.Properties[field]
+		if !ok {
+			// Check that no extra fields are provided (if additionalProperties is false)
+			if !toolDef.Schema.AdditionalProperties {
+				errs = append(errs, fmt.Errorf("unexpected argument: %s", field))
 			}
+			continue
 		}
-	}
 
-	// Basic type validation for each argument
-	for field, schema := range toolDef.Schema.Properties {
-		if val, ok := args[field]; ok {
-			if err := validateValue(val, schema); err != nil {
-				return fmt.Errorf("invalid value for %s: %v", field, err)
-			}
+		// Basic type validation for each argument
+		if err := validateValue(args[field], schema); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value for %s: %v", field, err))
 		}
 	}
 
-	return nil
+	return errs
 }
 
 func validateValue(val any, schema JSONSchema) error {
@@ -132,13 +149,47 @@ func validateValue(val any, schema JSONSchema) error {
 			return fmt.Errorf("expected boolean, got %T", val)
 		}
 	case "array":
-		if _, ok := val.([]any); !ok {
+		items, ok := val.([]any)
+		if !ok {
 			return fmt.Errorf("expected array, got %T", val)
 		}
+		if schema.Items != nil {
+			for i, item := range items {
+				if err := validateValue(item, *schema.Items); err != nil {
+					return fmt.Errorf("item %d: %v", i, err)
+				}
+			}
+		}
 	case "object":
-		if _, ok := val.(map[string]any); !ok {
+		obj, ok := val.(map[string]any)
+		if !ok {
 			return fmt.Errorf("expected object, got %T", val)
 		}
+		if schema.Properties != nil {
+			return validateObject(obj, schema)
+		}
+	}
+	return nil
+}
+
+// validateObject checks a nested object's fields against its schema
+func validateObject(obj map[string]any, schema JSONSchema) error {
+	for _, field := range schema.Required {
+		if _, ok := obj[field]; !ok {
+			return fmt.Errorf("missing required field: %s", field)
+		}
+	}
+	for field, val := range obj {
+		fieldSchema, ok := schema.Properties[field]
+		if !ok {
+			if !schema.AdditionalProperties {
+				return fmt.Errorf("unexpected field: %s", field)
+			}
+			continue
+		}
+		if err := validateValue(val, fieldSchema); err != nil {
+			return fmt.Errorf("invalid value for %s: %v", field, err)
+		}
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -112,6 +113,7 @@ func TestToolRegistry_ValidateCall_Failures(t *testing.T) {
 	registry := NewToolRegistry()
 	registry.Register(FSReadTool)
 	registry.Register(FSWriteTool)
+	registry.Register(FSWriteManyTool)
 
 	tests := []struct {
 		name   string
@@ -149,6 +151,18 @@ func TestToolRegistry_ValidateCall_Failures(t *testing.T) {
 			args:   map[string]any{"path": 123},
 			errMsg: "invalid value for path",
 		},
+		{
+			name:   "fs.write-many entry missing content",
+			toolID: "fs.write-many",
+			args:   map[string]any{"files": []any{map[string]any{"path": "a.txt"}}},
+			errMsg: "item 0: missing required field: content",
+		},
+		{
+			name:   "fs.write-many entry not an object",
+			toolID: "fs.write-many",
+			args:   map[string]any{"files": []any{"a.txt"}},
+			errMsg: "item 0: expected object",
+		},
 	}
 
 	for _, tt := range tests {
@@ -161,12 +175,35 @@ func TestToolRegistry_ValidateCall_Failures(t *testing.T) {
 	}
 }
 
+func TestToolRegistry_ValidationErrors_ReportsEveryViolation(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(FSWriteTool)
+
+	errs := registry.ValidationErrors("fs.write", map[string]any{"path": 42, "mode": "x"})
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"missing required argument: content",
+		"unexpected argument: mode",
+		"invalid value for path: expected string, got int",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected errors:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	if errs := registry.ValidationErrors("fs.write", map[string]any{"path": "a.txt", "content": "hi"}); len(errs) != 0 {
+		t.Errorf("expected a valid call to have no errors, got %v", errs)
+	}
+}
+
 func TestDefaultToolRegistry(t *testing.T) {
 	registry := NewDefaultToolRegistry()
 	tools := registry.All()
 
-	if len(tools) != 3 {
-		t.Fatalf("expected 3 default tools, got %d", len(tools))
+	if len(tools) != 5 {
+		t.Fatalf("expected 5 default tools, got %d", len(tools))
 	}
 
 	// Verify each tool has correct permission requirement
@@ -180,10 +217,20 @@ func TestDefaultToolRegistry(t *testing.T) {
 		t.Errorf("fs.write should require CapFSWrite")
 	}
 
+	fsWriteMany, _ := registry.Get("fs.write-many")
+	if fsWriteMany.RequiredPermission != CapFSWrite {
+		t.Errorf("fs.write-many should require CapFSWrite")
+	}
+
 	fsList, _ := registry.Get("fs.list")
 	if fsList.RequiredPermission != CapFSRead {
 		t.Errorf("fs.list should require CapFSRead")
 	}
+
+	auditQuery, _ := registry.Get("audit.query")
+	if auditQuery.RequiredPermission != CapFSRead {
+		t.Errorf("audit.query should require CapFSRead")
+	}
 }
 
 func TestToolRegistry_ToOpenAIFormat(t *testing.T) {
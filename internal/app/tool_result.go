@@ -0,0 +1,67 @@
+package app
+
+import (
+	"time"
+
+	"github.com/cshaiku/goshi/internal/actions/runtime"
+)
+
+// ToolResult is the canonical outcome of a tool call. Tool handlers may
+// return any shape; the router normalizes them into a ToolResult so the
+// TUI, CLI and conversation history render results consistently.
+type ToolResult struct {
+	Success  bool          `json:"success"`
+	Value    any           `json:"value,omitempty"` // Structured result when Success
+	Error    string        `json:"error,omitempty"` // Failure reason when !Success
+	Duration time.Duration `json:"duration"`        // Wall time the tool ran (0 if unknown or refused)
+}
+
+// Map returns the result in the router's map form: {"result": value} on
+// success, {"error": message} on failure
+func (r ToolResult) Map() map[string]any {
+	if !r.Success {
+		return map[string]any{"error": r.Error}
+	}
+	return map[string]any{"result": r.Value}
+}
+
+// NormalizeToolResult converts a handler's return value into a ToolResult.
+// It understands the router's {"result": ...} / {"error": ...} maps, action
+// outputs, errors and ToolResult itself; any other value is a successful
+// result carried as-is.
+func NormalizeToolResult(v any) ToolResult {
+	switch result := v.(type) {
+	case ToolResult:
+		return result
+	case *ToolResult:
+		if result == nil {
+			return ToolResult{Error: "tool returned no result"}
+		}
+		return *result
+	case nil:
+		return ToolResult{Error: "tool returned no result"}
+	case error:
+		return ToolResult{Error: result.Error()}
+	case runtime.ActionOutput:
+		return ToolResult{Success: true, Value: result}
+	case map[string]any:
+		if errStr, ok := result["error"].(string); ok {
+			return ToolResult{Error: errStr}
+		}
+		if value, ok := result["result"]; ok && len(result) == 1 {
+			return ToolResult{Success: true, Value: value}
+		}
+		return ToolResult{Success: true, Value: result}
+	default:
+		return ToolResult{Success: true, Value: v}
+	}
+}
+
+// Execute runs a tool call like Handle and returns the normalized result
+// with the time the tool ran, as recorded in the audit log
+func (r *ToolRouter) Execute(call ToolCall) ToolResult {
+	out, elapsed := r.handle(call)
+	result := NormalizeToolResult(out)
+	result.Duration = elapsed
+	return result
+}
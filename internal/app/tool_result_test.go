@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/actions/runtime"
+)
+
+func TestNormalizeToolResult(t *testing.T) {
+	output := runtime.ActionOutput{"path": "README.md", "size": 12}
+
+	tests := []struct {
+		name  string
+		input any
+		want  ToolResult
+	}{
+		{"router success map", map[string]any{"result": output}, ToolResult{Success: true, Value: output}},
+		{"router error map", map[string]any{"error": "permission denied"}, ToolResult{Error: "permission denied"}},
+		{"action output", output, ToolResult{Success: true, Value: output}},
+		{"plain map", map[string]any{"a": 1, "b": 2}, ToolResult{Success: true, Value: map[string]any{"a": 1, "b": 2}}},
+		{"string", "done", ToolResult{Success: true, Value: "done"}},
+		{"error", errors.New("boom"), ToolResult{Error: "boom"}},
+		{"nil", nil, ToolResult{Error: "tool returned no result"}},
+		{"typed result", ToolResult{Success: true, Value: 3}, ToolResult{Success: true, Value: 3}},
+		{"typed pointer", &ToolResult{Error: "bad"}, ToolResult{Error: "bad"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeToolResult(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeToolResult(%v) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolResultMap(t *testing.T) {
+	ok := ToolResult{Success: true, Value: "x"}
+	if got := ok.Map(); !reflect.DeepEqual(got, map[string]any{"result": "x"}) {
+		t.Errorf("unexpected success map: %v", got)
+	}
+	failed := ToolResult{Error: "nope"}
+	if got := failed.Map(); !reflect.DeepEqual(got, map[string]any{"error": "nope"}) {
+		t.Errorf("unexpected error map: %v", got)
+	}
+}
+
+func TestToolRouter_ExecuteNormalizes(t *testing.T) {
+	router, _ := createTestToolRouter()
+
+	result := router.Execute(ToolCall{Name: "nonexistent.tool", Args: map[string]any{}})
+	if result.Success || result.Error != "unknown tool: nonexistent.tool" {
+		t.Errorf("expected normalized unknown-tool failure, got %+v", result)
+	}
+
+	router, caps := createTestToolRouter()
+	caps.Grant(CapFSRead)
+	result = router.Execute(ToolCall{Name: "fs.list", Args: map[string]any{"path": "."}})
+	if !result.Success {
+		t.Fatalf("expected fs.list to succeed, got %+v", result)
+	}
+	if _, ok := result.Value.(runtime.ActionOutput); !ok {
+		t.Errorf("expected the action output as the value, got %T", result.Value)
+	}
+	if result.Duration <= 0 {
+		t.Error("expected the call duration to be recorded")
+	}
+}
@@ -1,7 +1,10 @@
 package app
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/cshaiku/goshi/internal/actions/runtime"
 	"github.com/cshaiku/goshi/internal/audit"
@@ -12,15 +15,24 @@ type ToolCall struct {
 	Args map[string]any
 }
 
+// ActionDispatcher runs an action once the router has validated and
+// authorized the call. *runtime.Dispatcher is the real implementation.
+type ActionDispatcher interface {
+	Dispatch(action string, in runtime.ActionInput) (runtime.ActionOutput, error)
+}
+
 type ToolRouter struct {
-	dispatcher *runtime.Dispatcher
+	dispatcher ActionDispatcher
 	registry   *ToolRegistry
 	caps       *Capabilities
 	auditLog   *audit.Logger
 	auditCwd   string
+	protected  *ProtectedPaths
+	maxArgs    int        // Max serialized argument size in bytes (0 = unlimited)
+	filter     ToolFilter // Tools that may be used
 }
 
-func NewToolRouter(dispatcher *runtime.Dispatcher, caps *Capabilities) *ToolRouter {
+func NewToolRouter(dispatcher ActionDispatcher, caps *Capabilities) *ToolRouter {
 	return &ToolRouter{
 		dispatcher: dispatcher,
 		registry:   NewDefaultToolRegistry(),
@@ -29,7 +41,7 @@ func NewToolRouter(dispatcher *runtime.Dispatcher, caps *Capabilities) *ToolRout
 }
 
 // NewToolRouterWithRegistry creates a tool router with a custom registry
-func NewToolRouterWithRegistry(dispatcher *runtime.Dispatcher, registry *ToolRegistry, caps *Capabilities) *ToolRouter {
+func NewToolRouterWithRegistry(dispatcher ActionDispatcher, registry *ToolRegistry, caps *Capabilities) *ToolRouter {
 	return &ToolRouter{
 		dispatcher: dispatcher,
 		registry:   registry,
@@ -43,25 +55,125 @@ func (r *ToolRouter) SetAuditLogger(logger *audit.Logger, cwd string) {
 	r.auditCwd = cwd
 }
 
+// SetProtectedPaths configures globs that mutating tools may never touch,
+// regardless of granted permissions.
+func (r *ToolRouter) SetProtectedPaths(root string, patterns []string) {
+	r.protected = NewProtectedPaths(root, patterns)
+}
+
+// SetMaxArgsBytes caps the serialized size of tool call arguments.
+// Zero disables the limit.
+func (r *ToolRouter) SetMaxArgsBytes(n int) {
+	r.maxArgs = n
+}
+
+// ToolFilter is an allow/deny list of tool IDs. The zero value permits
+// every tool.
+type ToolFilter struct {
+	allowed  map[string]bool // Tools that may be used (nil = all)
+	disabled map[string]bool // Tools that may never be used
+}
+
+// NewToolFilter builds a tool filter. An empty allow list permits every
+// tool; deny always wins over allow.
+func NewToolFilter(allow, deny []string) ToolFilter {
+	var f ToolFilter
+	if len(allow) > 0 {
+		f.allowed = make(map[string]bool, len(allow))
+		for _, id := range allow {
+			f.allowed[id] = true
+		}
+	}
+	f.disabled = make(map[string]bool, len(deny))
+	for _, id := range deny {
+		f.disabled[id] = true
+	}
+	return f
+}
+
+// Enabled reports whether the filter permits a tool
+func (f ToolFilter) Enabled(id string) bool {
+	if f.disabled[id] {
+		return false
+	}
+	return f.allowed == nil || f.allowed[id]
+}
+
+// Apply returns the tools the filter permits, in order
+func (f ToolFilter) Apply(tools []ToolDefinition) []ToolDefinition {
+	enabled := make([]ToolDefinition, 0, len(tools))
+	for _, tool := range tools {
+		if f.Enabled(tool.ID) {
+			enabled = append(enabled, tool)
+		}
+	}
+	return enabled
+}
+
+// SetToolFilter restricts which registered tools are offered and executed.
+// An empty allow list permits every tool; deny always wins over allow.
+func (r *ToolRouter) SetToolFilter(allow, deny []string) {
+	r.filter = NewToolFilter(allow, deny)
+}
+
+// toolEnabled reports whether the tool filter permits a tool
+func (r *ToolRouter) toolEnabled(id string) bool {
+	return r.filter.Enabled(id)
+}
+
+// checkArgsSize refuses arguments whose JSON encoding exceeds the cap
+func (r *ToolRouter) checkArgsSize(args map[string]any) error {
+	if r.maxArgs <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("invalid tool call: arguments are not serializable: %w", err)
+	}
+	if len(data) > r.maxArgs {
+		return fmt.Errorf("tool call arguments too large: %d bytes (max %d)", len(data), r.maxArgs)
+	}
+	return nil
+}
+
 // Handle executes a tool call requested by the LLM.
 // It validates the tool exists, validates the arguments against the schema,
 // checks permissions, and then executes the tool via the dispatcher.
 func (r *ToolRouter) Handle(call ToolCall) any {
+	result, _ := r.handle(call)
+	return result
+}
+
+// handle executes a tool call as Handle does, also returning how long the
+// tool ran (0 if it was refused before running)
+func (r *ToolRouter) handle(call ToolCall) (any, time.Duration) {
 	// Step 1: Look up tool definition
 	toolDef, ok := r.registry.Get(call.Name)
 	if !ok {
 		r.logTool(call.Name, audit.StatusError, "unknown tool", call.Args)
 		return map[string]any{
 			"error": fmt.Sprintf("unknown tool: %s", call.Name),
-		}
+		}, 0
+	}
+	if !r.toolEnabled(toolDef.ID) {
+		r.logTool(call.Name, audit.StatusError, "tool disabled by configuration", call.Args)
+		return map[string]any{
+			"error": fmt.Sprintf("tool disabled by configuration: %s", toolDef.ID),
+		}, 0
 	}
 
-	// Step 2: Validate call arguments against schema
+	// Step 2: Validate call arguments against size cap and schema
+	if err := r.checkArgsSize(call.Args); err != nil {
+		r.logTool(call.Name, audit.StatusError, err.Error(), nil)
+		return map[string]any{
+			"error": err.Error(),
+		}, 0
+	}
 	if err := r.registry.ValidateCall(call.Name, call.Args); err != nil {
 		r.logTool(call.Name, audit.StatusError, fmt.Sprintf("invalid tool call: %v", err), call.Args)
 		return map[string]any{
 			"error": fmt.Sprintf("invalid tool call: %v", err),
-		}
+		}, 0
 	}
 
 	// Step 3: Check capability/permission enforcement
@@ -69,30 +181,108 @@ func (r *ToolRouter) Handle(call ToolCall) any {
 		r.logTool(call.Name, audit.StatusError, "permission denied", call.Args)
 		return map[string]any{
 			"error": fmt.Sprintf("permission denied for tool: %s", toolDef.ID),
-		}
+		}, 0
 	}
 
-	// Step 4: Execute the tool
-	out, err := r.dispatcher.Dispatch(call.Name, runtime.ActionInput(call.Args))
-	if err != nil {
+	// Step 4: Refuse mutations to protected paths
+	if err := r.protected.Check(call.Name, call.Args); err != nil {
 		r.logTool(call.Name, audit.StatusError, err.Error(), call.Args)
 		return map[string]any{
 			"error": err.Error(),
-		}
+		}, 0
 	}
 
-	r.logTool(call.Name, audit.StatusOK, "ok", call.Args)
+	// Step 5: Execute the tool, timing it for the audit log and the result
+	start := time.Now()
+	var out runtime.ActionOutput
+	var err error
+	if call.Name == AuditQueryTool.ID {
+		out, err = r.queryAudit(call.Args)
+	} else {
+		out, err = r.dispatcher.Dispatch(call.Name, runtime.ActionInput(call.Args))
+	}
+	elapsed := time.Since(start)
+	if err != nil {
+		r.logToolTimed(call.Name, audit.StatusError, err.Error(), call.Args, elapsed)
+		return map[string]any{
+			"error": err.Error(),
+		}, elapsed
+	}
+
+	r.logToolTimed(call.Name, audit.StatusOK, "ok", call.Args, elapsed)
 
 	return map[string]any{
 		"result": out,
+	}, elapsed
+}
+
+// Audit query bounds
+const (
+	defaultAuditQueryLimit = 10
+	maxAuditQueryLimit     = 50
+)
+
+// queryAudit summarizes the most recent tool events in this session's audit log
+func (r *ToolRouter) queryAudit(args map[string]any) (runtime.ActionOutput, error) {
+	path := ""
+	if r.auditLog != nil {
+		path = r.auditLog.FilePath()
+	}
+	if !strings.HasSuffix(path, ".jsonl") {
+		return nil, fmt.Errorf("audit log is not available in this session")
+	}
+
+	limit := defaultAuditQueryLimit
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(n)
+	}
+	if limit > maxAuditQueryLimit {
+		limit = maxAuditQueryLimit
 	}
+
+	events, err := audit.ReadEvents(path, audit.Filter{
+		Types: map[audit.EventType]bool{audit.EventTypeTool: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+
+	summary := make([]map[string]any, 0, len(events))
+	for _, event := range events {
+		entry := map[string]any{
+			"time":    event.Timestamp.Format(time.RFC3339),
+			"tool":    event.Action,
+			"status":  string(event.Status),
+			"message": event.Message,
+		}
+		if args, ok := event.Details["args"]; ok {
+			entry["args"] = args
+		}
+		if ms, ok := event.Details["duration_ms"]; ok {
+			entry["duration_ms"] = ms
+		}
+		summary = append(summary, entry)
+	}
+
+	return runtime.ActionOutput{
+		"events": summary,
+		"count":  len(summary),
+	}, nil
 }
 
 func (r *ToolRouter) logTool(name string, status audit.EventStatus, message string, args map[string]any) {
+	r.logToolTimed(name, status, message, args, 0)
+}
+
+// logToolTimed logs a tool that ran along with how long it took
+func (r *ToolRouter) logToolTimed(name string, status audit.EventStatus, message string, args map[string]any, duration time.Duration) {
 	if r.auditLog == nil {
 		return
 	}
-	r.auditLog.LogTool(name, status, message, args, r.auditCwd)
+	r.auditLog.LogToolTimed(name, status, message, args, duration, r.auditCwd)
 }
 
 // ValidateToolCall validates that a tool call is valid without executing it.
@@ -103,8 +293,14 @@ func (r *ToolRouter) ValidateToolCall(toolName string, args map[string]any) erro
 	if !ok {
 		return fmt.Errorf("unknown tool: %s", toolName)
 	}
+	if !r.toolEnabled(toolDef.ID) {
+		return fmt.Errorf("tool disabled by configuration: %s", toolDef.ID)
+	}
 
-	// Step 2: Validate call arguments against schema
+	// Step 2: Validate call arguments against size cap and schema
+	if err := r.checkArgsSize(args); err != nil {
+		return err
+	}
 	if err := r.registry.ValidateCall(toolName, args); err != nil {
 		return fmt.Errorf("invalid tool call: %w", err)
 	}
@@ -114,11 +310,22 @@ func (r *ToolRouter) ValidateToolCall(toolName string, args map[string]any) erro
 		return fmt.Errorf("permission denied for tool: %s", toolDef.ID)
 	}
 
+	// Step 4: Refuse mutations to protected paths
+	if err := r.protected.Check(toolName, args); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// GetToolDefinitions returns all available tool definitions
+// ToolDefinition returns the definition of a registered tool
+func (r *ToolRouter) ToolDefinition(id string) (ToolDefinition, bool) {
+	return r.registry.Get(id)
+}
+
+// GetToolDefinitions returns all available tool definitions, omitting
+// tools disabled by the tool filter
 // Useful for sending to LLM as function calling definitions
 func (r *ToolRouter) GetToolDefinitions() []ToolDefinition {
-	return r.registry.All()
+	return r.filter.Apply(r.registry.All())
 }
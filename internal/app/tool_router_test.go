@@ -1,9 +1,19 @@
 package app
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/cshaiku/goshi/internal/actions/runtime"
+	"github.com/cshaiku/goshi/internal/audit"
+	"github.com/cshaiku/goshi/internal/config"
 	"github.com/cshaiku/goshi/internal/fs"
 )
 
@@ -115,12 +125,73 @@ func TestToolRouter_Handle_FSWrite_Permission(t *testing.T) {
 	}
 }
 
+func TestToolRouter_Handle_FSWriteMany(t *testing.T) {
+	dir := t.TempDir()
+	oldwd, _ := os.Getwd()
+	defer os.Chdir(oldwd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("old a\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	guard, err := fs.NewGuard(dir)
+	if err != nil {
+		t.Fatalf("guard: %v", err)
+	}
+	caps := NewCapabilities()
+	caps.Grant(CapFSWrite)
+	router := NewToolRouter(runtime.NewDispatcher(guard), caps)
+	router.SetProtectedPaths(dir, []string{"*.key"})
+
+	result := router.Handle(ToolCall{Name: "fs.write-many", Args: map[string]any{
+		"files": []any{
+			map[string]any{"path": "a.txt", "content": "new a\n"},
+			map[string]any{"path": "b.txt", "content": "new b\n"},
+		},
+	}})
+	out, ok := result.(map[string]any)["result"].(runtime.ActionOutput)
+	if !ok {
+		t.Fatalf("expected result, got %v", result)
+	}
+	if files, _ := out["files"].([]runtime.ActionOutput); len(files) != 2 {
+		t.Fatalf("expected 2 proposed files, got %v", out["files"])
+	}
+
+	// Nothing is written until the single proposal is applied
+	if _, err := os.Stat(filepath.Join(dir, "b.txt")); !os.IsNotExist(err) {
+		t.Fatal("expected b.txt not to exist before apply")
+	}
+	id, _ := out["id"].(string)
+	if err := fs.ApplyWriteProposal(id); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	for name, want := range map[string]string{"a.txt": "new a\n", "b.txt": "new b\n"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(got) != want {
+			t.Errorf("expected %s to contain %q, got %q (%v)", name, want, got, err)
+		}
+	}
+
+	// One protected entry refuses the whole call
+	result = router.Handle(ToolCall{Name: "fs.write-many", Args: map[string]any{
+		"files": []any{
+			map[string]any{"path": "c.txt", "content": "c"},
+			map[string]any{"path": "server.key", "content": "secret"},
+		},
+	}})
+	if errStr, _ := result.(map[string]any)["error"].(string); !strings.Contains(errStr, "protected") {
+		t.Errorf("expected protected path error, got %v", result)
+	}
+}
+
 func TestToolRouter_GetToolDefinitions(t *testing.T) {
 	router, _ := createTestToolRouter()
 
 	tools := router.GetToolDefinitions()
-	if len(tools) != 3 {
-		t.Fatalf("expected 3 default tools, got %d", len(tools))
+	if len(tools) != 5 {
+		t.Fatalf("expected 5 default tools, got %d", len(tools))
 	}
 
 	toolNames := make(map[string]bool)
@@ -128,7 +199,7 @@ func TestToolRouter_GetToolDefinitions(t *testing.T) {
 		toolNames[tool.ID] = true
 	}
 
-	expected := []string{"fs.read", "fs.write", "fs.list"}
+	expected := []string{"fs.read", "fs.write", "fs.write-many", "fs.list", "audit.query"}
 	for _, name := range expected {
 		if !toolNames[name] {
 			t.Errorf("expected tool %s in definitions", name)
@@ -136,6 +207,57 @@ func TestToolRouter_GetToolDefinitions(t *testing.T) {
 	}
 }
 
+func TestToolRouter_ToolFilter(t *testing.T) {
+	router, caps := createTestToolRouter()
+	caps.Grant(CapFSRead)
+	caps.Grant(CapFSWrite)
+	router.SetToolFilter(nil, []string{"fs.write"})
+
+	for _, tool := range router.GetToolDefinitions() {
+		if tool.ID == "fs.write" {
+			t.Fatal("expected disabled fs.write to be omitted from definitions")
+		}
+	}
+	if len(router.GetToolDefinitions()) != 4 {
+		t.Errorf("expected the other 4 tools to remain, got %d", len(router.GetToolDefinitions()))
+	}
+
+	args := map[string]any{"path": "file.txt", "content": "data"}
+	result := router.Handle(ToolCall{Name: "fs.write", Args: args})
+	if errStr, _ := result.(map[string]any)["error"].(string); !strings.Contains(errStr, "disabled") {
+		t.Errorf("expected disabled tool to be refused, got %v", result)
+	}
+	if err := router.ValidateToolCall("fs.write", args); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("expected validation to refuse disabled tool, got %v", err)
+	}
+
+	// An allow list permits only the listed tools, and deny still wins
+	router.SetToolFilter([]string{"fs.read", "fs.write"}, []string{"fs.write"})
+	defs := router.GetToolDefinitions()
+	if len(defs) != 1 || defs[0].ID != "fs.read" {
+		t.Errorf("expected only fs.read to be offered, got %v", defs)
+	}
+	result = router.Handle(ToolCall{Name: "fs.list", Args: map[string]any{"path": "."}})
+	if errStr, _ := result.(map[string]any)["error"].(string); !strings.Contains(errStr, "disabled") {
+		t.Errorf("expected tool outside the allow list to be refused, got %v", result)
+	}
+}
+
+// TestToolIDsMatchDefaultRegistry tests that config validation accepts
+// exactly the registered tools in the allow/deny lists
+func TestToolIDsMatchDefaultRegistry(t *testing.T) {
+	var ids []string
+	for _, tool := range NewDefaultToolRegistry().All() {
+		ids = append(ids, tool.ID)
+	}
+	known := append([]string(nil), config.ToolIDs...)
+	sort.Strings(ids)
+	sort.Strings(known)
+	if !reflect.DeepEqual(ids, known) {
+		t.Errorf("config.ToolIDs %v does not match the registered tools %v", known, ids)
+	}
+}
+
 func TestNewToolRouterWithRegistry(t *testing.T) {
 	guard, _ := fs.NewGuard(".")
 	dispatcher := runtime.NewDispatcher(guard)
@@ -156,3 +278,285 @@ func TestNewToolRouterWithRegistry(t *testing.T) {
 		t.Errorf("expected fs.read tool, got %s", tools[0].ID)
 	}
 }
+
+func TestToolRouter_ValidateToolCall_ProtectedPaths(t *testing.T) {
+	router, caps := createTestToolRouter()
+	caps.Grant(CapFSRead)
+	caps.Grant(CapFSWrite)
+	router.SetProtectedPaths("/repo", []string{".git/**", ".goshi/**", "*.key"})
+
+	tests := []struct {
+		name      string
+		tool      string
+		path      string
+		protected bool
+	}{
+		{"git config", "fs.write", ".git/config", true},
+		{"nested goshi file", "fs.write", ".goshi/audit/session.jsonl", true},
+		{"key at root", "fs.write", "server.key", true},
+		{"key in subdirectory", "fs.write", "certs/tls/server.key", true},
+		{"dot-slash prefix", "fs.write", "./.git/HEAD", true},
+		{"absolute path under root", "fs.write", "/repo/.git/HEAD", true},
+		{"traversal into protected dir", "fs.write", "src/../.git/HEAD", true},
+		{"regular file", "fs.write", "src/main.go", false},
+		{"similar name", "fs.write", ".github/workflows/ci.yml", false},
+		{"key-like extension", "fs.write", "keys.keyring", false},
+		{"reads are not restricted", "fs.read", ".git/config", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := map[string]any{"path": test.path}
+			if test.tool == "fs.write" {
+				args["content"] = "data"
+			}
+
+			err := router.ValidateToolCall(test.tool, args)
+			if test.protected && err == nil {
+				t.Errorf("expected %s to %s to be refused", test.tool, test.path)
+			}
+			if !test.protected && err != nil {
+				t.Errorf("expected %s to %s to be allowed, got %v", test.tool, test.path, err)
+			}
+		})
+	}
+}
+
+func TestToolRouter_Handle_ProtectedPathRefused(t *testing.T) {
+	router, caps := createTestToolRouter()
+	caps.Grant(CapFSWrite)
+	router.SetProtectedPaths(".", []string{"*.key"})
+
+	result := router.Handle(ToolCall{
+		Name: "fs.write",
+		Args: map[string]any{"path": "secret.key", "content": "data"},
+	})
+
+	resultMap, ok := result.(map[string]any)
+	if !ok {
+		t.Fatal("expected result to be a map")
+	}
+
+	errStr, ok := resultMap["error"].(string)
+	if !ok || !strings.Contains(errStr, "protected") {
+		t.Fatalf("expected protected path error, got %v", resultMap)
+	}
+}
+
+func TestToolRouter_MaxArgsBytes(t *testing.T) {
+	router, caps := createTestToolRouter()
+	caps.Grant(CapFSWrite)
+	router.SetMaxArgsBytes(1024)
+
+	err := router.ValidateToolCall("fs.write", map[string]any{"path": "out.txt", "content": "small"})
+	if err != nil {
+		t.Errorf("expected normal call to pass, got %v", err)
+	}
+
+	big := map[string]any{"path": "out.txt", "content": strings.Repeat("x", 4096)}
+	err = router.ValidateToolCall("fs.write", big)
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("expected oversized call to be refused, got %v", err)
+	}
+
+	result := router.Handle(ToolCall{Name: "fs.write", Args: big})
+	resultMap, _ := result.(map[string]any)
+	if errStr, ok := resultMap["error"].(string); !ok || !strings.Contains(errStr, "too large") {
+		t.Errorf("expected Handle to refuse oversized call, got %v", resultMap)
+	}
+}
+
+func TestToolRouter_Handle_FSListDetail(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	guard, err := fs.NewGuard(dir)
+	if err != nil {
+		t.Fatalf("guard: %v", err)
+	}
+	caps := NewCapabilities()
+	caps.Grant(CapFSRead)
+	router := NewToolRouter(runtime.NewDispatcher(guard), caps)
+
+	// Names-only is the default
+	result := router.Handle(ToolCall{Name: "fs.list", Args: map[string]any{"path": "."}})
+	out, ok := result.(map[string]any)["result"].(runtime.ActionOutput)
+	if !ok {
+		t.Fatalf("expected result, got %v", result)
+	}
+	names, ok := out["entries"].([]string)
+	if !ok {
+		t.Fatalf("expected names-only entries, got %T", out["entries"])
+	}
+	if strings.Join(names, ",") != "a.txt,sub/" {
+		t.Errorf("unexpected names: %v", names)
+	}
+
+	// detail=true returns size, mode and modtime
+	result = router.Handle(ToolCall{Name: "fs.list", Args: map[string]any{"path": ".", "detail": true}})
+	out, ok = result.(map[string]any)["result"].(runtime.ActionOutput)
+	if !ok {
+		t.Fatalf("expected result, got %v", result)
+	}
+	entries, ok := out["entries"].([]runtime.ActionOutput)
+	if !ok || len(entries) != 2 {
+		t.Fatalf("expected 2 detailed entries, got %v", out["entries"])
+	}
+	file := entries[0]
+	if file["name"] != "a.txt" || file["size"] != int64(5) || file["is_dir"] != false {
+		t.Errorf("unexpected file entry: %v", file)
+	}
+	for _, key := range []string{"mode", "modtime", "path"} {
+		if _, ok := file[key]; !ok {
+			t.Errorf("expected %q in detailed entry", key)
+		}
+	}
+	if entries[1]["is_dir"] != true {
+		t.Errorf("expected sub to be a directory: %v", entries[1])
+	}
+}
+
+func TestToolRouter_Handle_FSListNonUTF8Names(t *testing.T) {
+	dir := t.TempDir()
+	raw := "data\xfe\xff.bin"
+	if err := os.WriteFile(filepath.Join(dir, raw), []byte("x"), 0644); err != nil {
+		t.Skipf("filesystem rejects non-UTF-8 names: %v", err)
+	}
+
+	guard, err := fs.NewGuard(dir)
+	if err != nil {
+		t.Fatalf("guard: %v", err)
+	}
+	caps := NewCapabilities()
+	caps.Grant(CapFSRead)
+	router := NewToolRouter(runtime.NewDispatcher(guard), caps)
+
+	for _, detail := range []bool{false, true} {
+		result := router.Handle(ToolCall{Name: "fs.list", Args: map[string]any{"path": ".", "detail": detail}})
+		out, ok := result.(map[string]any)["result"].(runtime.ActionOutput)
+		if !ok {
+			t.Fatalf("expected result, got %v", result)
+		}
+
+		encoded, err := json.Marshal(out)
+		if err != nil || !utf8.Valid(encoded) || strings.Contains(string(encoded), "\\ufffd") {
+			t.Fatalf("expected clean JSON (detail=%v), got %s (%v)", detail, encoded, err)
+		}
+
+		var name, encoding string
+		if detail {
+			entry := out["entries"].([]runtime.ActionOutput)[0]
+			name, _ = entry["name"].(string)
+			encoding, _ = entry["name_encoding"].(string)
+		} else {
+			name = out["entries"].([]string)[0]
+			encoding, _ = out["name_encoding"].(string)
+			if escaped, _ := out["encoded_entries"].([]string); len(escaped) != 1 || escaped[0] != name {
+				t.Errorf("expected encoded_entries to list %q, got %v", name, out["encoded_entries"])
+			}
+		}
+
+		if name != "data%FE%FF.bin" || encoding != fs.NameEncodingPercent {
+			t.Errorf("detail=%v: name = %q (%q)", detail, name, encoding)
+		}
+		if decoded, err := fs.DecodeName(name, encoding); err != nil || decoded != raw {
+			t.Errorf("detail=%v: decoded %q, %v; want original bytes", detail, decoded, err)
+		}
+	}
+}
+
+func TestToolRouter_Handle_AuditQuery(t *testing.T) {
+	router, caps := createTestToolRouter()
+	logger, err := audit.NewLogger(audit.Config{Enabled: true, Dir: t.TempDir()}, "")
+	if err != nil {
+		t.Fatalf("failed to create audit logger: %v", err)
+	}
+	defer logger.Close()
+	router.SetAuditLogger(logger, ".")
+
+	// Gated like other read-only tools
+	result := router.Handle(ToolCall{Name: "audit.query", Args: map[string]any{}})
+	if resultMap, _ := result.(map[string]any); resultMap["error"] == nil {
+		t.Fatalf("expected audit.query to require FS_READ, got %v", resultMap)
+	}
+
+	caps.Grant(CapFSRead)
+	router.Handle(ToolCall{Name: "fs.list", Args: map[string]any{"path": "."}})
+	router.Handle(ToolCall{Name: "fs.read", Args: map[string]any{"path": "missing-file.txt"}})
+	router.Handle(ToolCall{Name: "fs.read", Args: map[string]any{"path": "tool_router.go"}})
+
+	result = router.Handle(ToolCall{Name: "audit.query", Args: map[string]any{"limit": float64(2)}})
+	resultMap, _ := result.(map[string]any)
+	out, ok := resultMap["result"].(runtime.ActionOutput)
+	if !ok {
+		t.Fatalf("expected audit.query result, got %v", resultMap)
+	}
+
+	events, _ := out["events"].([]map[string]any)
+	if len(events) != 2 {
+		t.Fatalf("expected the 2 most recent events, got %v", out["events"])
+	}
+	if events[0]["tool"] != "fs.read" || events[0]["status"] != "error" {
+		t.Errorf("expected the failed read first, got %v", events[0])
+	}
+	if events[1]["tool"] != "fs.read" || events[1]["status"] != "ok" {
+		t.Errorf("expected the successful read last, got %v", events[1])
+	}
+}
+
+func TestToolRouter_Handle_AuditQueryWithoutLogger(t *testing.T) {
+	router, caps := createTestToolRouter()
+	caps.Grant(CapFSRead)
+
+	result := router.Handle(ToolCall{Name: "audit.query", Args: map[string]any{}})
+	resultMap, _ := result.(map[string]any)
+	if errStr, _ := resultMap["error"].(string); !strings.Contains(errStr, "not available") {
+		t.Errorf("expected unavailable error, got %v", resultMap)
+	}
+}
+
+// slowDispatcher is a deliberately slow fake for every action
+type slowDispatcher struct {
+	delay time.Duration
+}
+
+func (d slowDispatcher) Dispatch(string, runtime.ActionInput) (runtime.ActionOutput, error) {
+	time.Sleep(d.delay)
+	return runtime.ActionOutput{"content": "slow"}, nil
+}
+
+func TestToolRouter_RecordsToolDuration(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	caps := NewCapabilities()
+	caps.Grant(CapFSRead)
+	router := NewToolRouter(slowDispatcher{delay: delay}, caps)
+	logger, err := audit.NewLogger(audit.Config{Enabled: true, Dir: t.TempDir()}, "")
+	if err != nil {
+		t.Fatalf("failed to create audit logger: %v", err)
+	}
+	defer logger.Close()
+	router.SetAuditLogger(logger, ".")
+
+	result := router.Execute(ToolCall{Name: "fs.read", Args: map[string]any{"path": "slow.txt"}})
+	if !result.Success {
+		t.Fatalf("expected the fake tool to succeed, got %+v", result)
+	}
+	if result.Duration < delay {
+		t.Errorf("expected a duration of at least %v, got %v", delay, result.Duration)
+	}
+
+	events, err := audit.ReadEvents(logger.FilePath(), audit.Filter{Types: map[audit.EventType]bool{audit.EventTypeTool: true}})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected one tool event, got %v (%v)", events, err)
+	}
+	// Both report the same measurement
+	want := float64(result.Duration.Microseconds()) / 1000
+	if ms, _ := events[0].Details["duration_ms"].(float64); ms != want {
+		t.Errorf("expected the audit event to record the result's %vms, got %v", want, events[0].Details["duration_ms"])
+	}
+}
@@ -51,6 +51,42 @@ var (
 		MaxRetries: 0,
 	}
 
+	// FSWriteManyTool writes several files as one all-or-nothing proposal
+	FSWriteManyTool = ToolDefinition{
+		ID:                 "fs.write-many",
+		Name:               "Write Multiple Files",
+		Description:        "Write or create several files in the repository as a single proposal that is applied all-or-nothing. Paths must be relative to the repository root.",
+		RequiredPermission: CapFSWrite,
+		Schema: JSONSchema{
+			Type:        "object",
+			Description: "Arguments for writing multiple files",
+			Properties: map[string]JSONSchema{
+				"files": {
+					Type:        "array",
+					Description: "Files to write, each with a path and content",
+					Items: &JSONSchema{
+						Type: "object",
+						Properties: map[string]JSONSchema{
+							"path": {
+								Type:        "string",
+								Description: "Relative path to the file within the repository",
+							},
+							"content": {
+								Type:        "string",
+								Description: "Content to write to the file",
+							},
+						},
+						Required:             []string{"path", "content"},
+						AdditionalProperties: false,
+					},
+				},
+			},
+			Required:             []string{"files"},
+			AdditionalProperties: false,
+		},
+		MaxRetries: 0,
+	}
+
 	// FSListTool lists files in a directory
 	FSListTool = ToolDefinition{
 		ID:                 "fs.list",
@@ -65,12 +101,37 @@ var (
 					Type:        "string",
 					Description: "Relative path to the directory within the repository",
 				},
+				"detail": {
+					Type:        "boolean",
+					Description: "Include size, mode and modification time for each entry (default: names only, directories end with /)",
+				},
 			},
 			Required:             []string{"path"},
 			AdditionalProperties: false,
 		},
 		MaxRetries: 0,
 	}
+
+	// AuditQueryTool lets the model review its own recent tool calls
+	AuditQueryTool = ToolDefinition{
+		ID:                 "audit.query",
+		Name:               "Query Audit Trail",
+		Description:        "List this session's most recent tool calls from the audit log, oldest first. Use it to avoid repeating actions that already ran or failed.",
+		RequiredPermission: CapFSRead,
+		Schema: JSONSchema{
+			Type:        "object",
+			Description: "Arguments for querying the audit trail",
+			Properties: map[string]JSONSchema{
+				"limit": {
+					Type:        "number",
+					Description: "Maximum number of recent tool events to return (default 10, max 50)",
+				},
+			},
+			Required:             []string{},
+			AdditionalProperties: false,
+		},
+		MaxRetries: 0,
+	}
 )
 
 // NewToolRegistry creates a new registry with all default tools registered
@@ -78,6 +139,8 @@ func NewDefaultToolRegistry() *ToolRegistry {
 	registry := NewToolRegistry()
 	registry.Register(FSReadTool)
 	registry.Register(FSWriteTool)
+	registry.Register(FSWriteManyTool)
 	registry.Register(FSListTool)
+	registry.Register(AuditQueryTool)
 	return registry
 }
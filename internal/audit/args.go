@@ -26,6 +26,15 @@ func FormatToolArgs(args map[string]any, style string, redact bool) map[string]a
 	}
 }
 
+// Redact returns a copy of fields with the values of secret-looking keys
+// replaced, as the audit log does when redaction is on
+func Redact(fields map[string]any) map[string]any {
+	if fields == nil {
+		return nil
+	}
+	return sanitizeMap(fields, true)
+}
+
 func summarizeMap(input map[string]any) map[string]any {
 	out := make(map[string]any, len(input))
 	for key, value := range input {
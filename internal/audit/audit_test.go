@@ -1,8 +1,13 @@
 package audit
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFormatToolArgsSummariesRedacts(t *testing.T) {
@@ -59,3 +64,286 @@ func TestLoggerWritesEvents(t *testing.T) {
 		t.Fatalf("expected session event, got %s", events[0].Type)
 	}
 }
+
+func TestLoggerFormats(t *testing.T) {
+	event := Event{
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Type:      EventTypeSession,
+		Action:    "START",
+		Status:    StatusOK,
+		Message:   "session started\nsecond line",
+		Cwd:       "/tmp",
+	}
+	wantText := "2024-05-01T12:00:00Z session    START            ok    session started\\nsecond line\n"
+
+	tests := []struct {
+		format    string
+		wantJSONL bool
+		wantText  bool
+	}{
+		{FormatJSONL, true, false},
+		{FormatText, false, true},
+		{FormatBoth, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			dir := t.TempDir()
+			logger, err := NewLogger(Config{Enabled: true, Dir: dir, Format: test.format}, "")
+			if err != nil {
+				t.Fatalf("failed to create logger: %v", err)
+			}
+			logger.LogEvent(event)
+			logger.Close()
+
+			jsonlFiles, _ := filepath.Glob(filepath.Join(dir, "session-*.jsonl"))
+			if (len(jsonlFiles) == 1) != test.wantJSONL {
+				t.Fatalf("expected jsonl file present=%v, got %v", test.wantJSONL, jsonlFiles)
+			}
+			if test.wantJSONL {
+				events, err := ReadEvents(jsonlFiles[0], Filter{})
+				if err != nil || len(events) != 1 || events[0].Action != "START" {
+					t.Errorf("expected one JSON event, got %v (err=%v)", events, err)
+				}
+			}
+
+			textFiles, _ := filepath.Glob(filepath.Join(dir, "session-*.log"))
+			if (len(textFiles) == 1) != test.wantText {
+				t.Fatalf("expected text file present=%v, got %v", test.wantText, textFiles)
+			}
+			if test.wantText {
+				data, err := os.ReadFile(textFiles[0])
+				if err != nil {
+					t.Fatalf("failed to read text log: %v", err)
+				}
+				if string(data) != wantText {
+					t.Errorf("unexpected text log:\n%q\nwant:\n%q", string(data), wantText)
+				}
+				if logger.TextFilePath() != textFiles[0] {
+					t.Errorf("expected TextFilePath %s, got %s", textFiles[0], logger.TextFilePath())
+				}
+			}
+		})
+	}
+}
+
+func TestLoggerCapsToolEventsPerTurn(t *testing.T) {
+	logger, err := NewLogger(Config{Enabled: true, Dir: t.TempDir(), MaxToolEventsPerTurn: 2}, "")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	// First turn: 5 calls, 2 logged
+	logger.LogMessage("turn one", "/tmp")
+	for i := 0; i < 5; i++ {
+		logger.LogTool("fs.read", StatusOK, "ok", nil, "/tmp")
+	}
+	logger.EndTurn("/tmp")
+
+	// Second turn starts with a fresh budget; the summary lands when the
+	// next user message implicitly ends it
+	logger.LogMessage("turn two", "/tmp")
+	for i := 0; i < 3; i++ {
+		logger.LogTool("fs.list", StatusOK, "ok", nil, "/tmp")
+	}
+	logger.LogMessage("turn three", "/tmp")
+
+	events, err := ReadEvents(logger.FilePath(), Filter{Types: map[EventType]bool{EventTypeTool: true}})
+	if err != nil {
+		t.Fatalf("failed to read events: %v", err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Action+": "+e.Message)
+	}
+	want := []string{
+		"fs.read: ok",
+		"fs.read: ok",
+		"suppressed: ...3 more suppressed (max_tool_events_per_turn=2)",
+		"fs.list: ok",
+		"fs.list: ok",
+		"suppressed: ...1 more suppressed (max_tool_events_per_turn=2)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected tool events:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLoggerCloseIsIdempotent(t *testing.T) {
+	logger, err := NewLogger(Config{Enabled: true, Dir: t.TempDir()}, "")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	logger.LogSession("START", "session started", "/tmp")
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("expected a second Close to be a no-op, got %v", err)
+	}
+	if !logger.Closed() {
+		t.Error("expected Closed to report true")
+	}
+
+	// Events after Close are dropped
+	logger.LogSession("LATE", "after close", "/tmp")
+	events, err := ReadEvents(logger.FilePath(), Filter{})
+	if err != nil {
+		t.Fatalf("failed to read events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("expected only the event logged before Close, got %d", len(events))
+	}
+}
+
+func TestLoggerUnknownFormat(t *testing.T) {
+	if _, err := NewLogger(Config{Enabled: true, Dir: t.TempDir(), Format: "xml"}, ""); err == nil {
+		t.Fatal("expected error for unknown audit format")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ages := map[string]time.Duration{
+		"session-a.jsonl": 1 * time.Hour,
+		"session-b.jsonl": 2 * 24 * time.Hour,
+		"session-c.log":   10 * 24 * time.Hour,
+		"session-d.jsonl": 30 * 24 * time.Hour,
+		"notes.txt":       60 * 24 * time.Hour,
+	}
+	setup := func(t *testing.T) string {
+		dir := t.TempDir()
+		for name, age := range ages {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+				t.Fatalf("write %s: %v", name, err)
+			}
+			stamp := time.Now().Add(-age)
+			if err := os.Chtimes(path, stamp, stamp); err != nil {
+				t.Fatalf("chtimes %s: %v", name, err)
+			}
+		}
+		return dir
+	}
+
+	tests := []struct {
+		name      string
+		olderThan time.Duration
+		keep      int
+		remaining []string
+	}{
+		{"older than", 7 * 24 * time.Hour, 0, []string{"notes.txt", "session-a.jsonl", "session-b.jsonl"}},
+		{"keep", 0, 1, []string{"notes.txt", "session-a.jsonl"}},
+		{"both", 20 * 24 * time.Hour, 2, []string{"notes.txt", "session-a.jsonl", "session-b.jsonl"}},
+		{"none", 0, 0, []string{"notes.txt", "session-a.jsonl", "session-b.jsonl", "session-c.log", "session-d.jsonl"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setup(t)
+			removed, err := Prune(dir, tt.olderThan, tt.keep)
+			if err != nil {
+				t.Fatalf("Prune failed: %v", err)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			var remaining []string
+			for _, entry := range entries {
+				remaining = append(remaining, entry.Name())
+			}
+			if strings.Join(remaining, ",") != strings.Join(tt.remaining, ",") {
+				t.Errorf("expected remaining %v, got %v", tt.remaining, remaining)
+			}
+			if len(removed)+len(remaining) != len(ages) {
+				t.Errorf("expected removed %v to account for all deleted files", removed)
+			}
+		})
+	}
+}
+
+func TestPruneGroupsSessionFiles(t *testing.T) {
+	dir := t.TempDir()
+	ages := map[string]time.Duration{
+		"session-a.jsonl": 1 * time.Hour,
+		"session-a.log":   1 * time.Hour,
+		"session-b.jsonl": 2 * time.Hour,
+		"session-b.log":   2 * time.Hour,
+	}
+	for name, age := range ages {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		stamp := time.Now().Add(-age)
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatalf("chtimes %s: %v", name, err)
+		}
+	}
+
+	// Keeping one session keeps both of its files
+	removed, err := Prune(dir, 0, 1)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	sort.Strings(removed)
+	if strings.Join(removed, ",") != "session-b.jsonl,session-b.log" {
+		t.Errorf("expected both files of the older session removed, got %v", removed)
+	}
+	for _, name := range []string{"session-a.jsonl", "session-a.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be kept: %v", name, err)
+		}
+	}
+}
+
+func TestPruneMissingDir(t *testing.T) {
+	removed, err := Prune(filepath.Join(t.TempDir(), "missing"), time.Hour, 1)
+	if err != nil || len(removed) != 0 {
+		t.Errorf("expected missing dir to be a no-op, got %v, %v", removed, err)
+	}
+}
+
+// shortWriteFile simulates a disk-full write that persists only half a line
+type shortWriteFile struct {
+	*os.File
+	fail bool
+}
+
+func (f *shortWriteFile) Write(p []byte) (int, error) {
+	if !f.fail {
+		return f.File.Write(p)
+	}
+	n, _ := f.File.Write(p[:len(p)/2])
+	return n, errors.New("no space left on device")
+}
+
+func TestLoggerShortWriteLeavesNoPartialLine(t *testing.T) {
+	logger, err := NewLogger(Config{Enabled: true, Dir: t.TempDir()}, "")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	file := &shortWriteFile{File: logger.file.(*os.File)}
+	logger.file = file
+
+	logger.LogSession("START", "first", "/tmp")
+	file.fail = true
+	logger.LogSession("WRITE", "lost to a full disk", "/tmp")
+	file.fail = false
+	logger.LogSession("END", "last", "/tmp")
+
+	events, err := ReadEvents(logger.FilePath(), Filter{})
+	if err != nil {
+		t.Fatalf("expected log to stay parseable, got %v", err)
+	}
+	if len(events) != 2 || events[0].Action != "START" || events[1].Action != "END" {
+		t.Errorf("expected only the complete events, got %+v", events)
+	}
+
+	data, _ := os.ReadFile(logger.FilePath())
+	if strings.Contains(string(data), "full disk") {
+		t.Error("expected the partial line to be truncated away")
+	}
+}
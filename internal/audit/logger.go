@@ -3,13 +3,22 @@ package audit
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+// On-disk audit log formats
+const (
+	FormatJSONL = "jsonl" // one JSON event per line (session-<id>.jsonl)
+	FormatText  = "text"  // human-readable lines (session-<id>.log)
+	FormatBoth  = "both"  // write both files
+)
+
 type Config struct {
 	Enabled            bool
 	Dir                string
@@ -17,16 +26,33 @@ type Config struct {
 	MaxSessions        int
 	Redact             bool
 	ToolArgumentsStyle string
+	Format             string // jsonl (default), text, or both
+	// MaxToolEventsPerTurn caps tool events logged per turn; 0 is unlimited
+	MaxToolEventsPerTurn int
 }
 
 type Logger struct {
 	cfg       Config
 	dir       string
 	filePath  string
+	textPath  string
 	sessionID string
-	file      *os.File
+	file      logFile // JSONL log, nil when format is text
+	textFile  logFile // text log, nil when format is jsonl
 	mu        sync.Mutex
 	enabled   bool
+	closed    bool
+
+	// Per-turn tool event accounting for MaxToolEventsPerTurn
+	turnToolEvents int
+	suppressed     int
+}
+
+// logFile is the subset of *os.File the logger writes through
+type logFile interface {
+	io.WriteCloser
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
 }
 
 func NewLogger(cfg Config, repoRoot string) (*Logger, error) {
@@ -47,42 +73,95 @@ func NewLogger(cfg Config, repoRoot string) (*Logger, error) {
 		return nil, err
 	}
 
-	sessionID := newSessionID()
-	filePath := filepath.Join(dir, fmt.Sprintf("session-%s.jsonl", sessionID))
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	format := cfg.Format
+	if format == "" {
+		format = FormatJSONL
 	}
 
+	sessionID := newSessionID()
 	logger := &Logger{
 		cfg:       cfg,
 		dir:       dir,
-		filePath:  filePath,
 		sessionID: sessionID,
-		file:      file,
 		enabled:   true,
 	}
 
+	if format == FormatJSONL || format == FormatBoth {
+		logger.filePath = filepath.Join(dir, fmt.Sprintf("session-%s.jsonl", sessionID))
+		file, err := os.OpenFile(logger.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		logger.file = file
+	}
+
+	if format == FormatText || format == FormatBoth {
+		logger.textPath = filepath.Join(dir, fmt.Sprintf("session-%s.log", sessionID))
+		textFile, err := os.OpenFile(logger.textPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			if logger.file != nil {
+				logger.file.Close()
+			}
+			return nil, fmt.Errorf("failed to open audit text log: %w", err)
+		}
+		logger.textFile = textFile
+		// Text-only logs still report a primary path
+		if logger.filePath == "" {
+			logger.filePath = logger.textPath
+		}
+	}
+
+	if logger.file == nil && logger.textFile == nil {
+		return nil, fmt.Errorf("unknown audit log format: %s", cfg.Format)
+	}
+
 	return logger, nil
 }
 
+// Close flushes any pending turn summary and closes the log files. Events
+// logged after Close are dropped; closing again is a no-op.
 func (l *Logger) Close() error {
+	l.EndTurn("")
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	if l.file == nil {
+	if l.closed {
 		return nil
 	}
-	return l.file.Close()
+	l.closed = true
+	var err error
+	if l.file != nil {
+		err = l.file.Close()
+	}
+	if l.textFile != nil {
+		if textErr := l.textFile.Close(); err == nil {
+			err = textErr
+		}
+	}
+	return err
+}
+
+// Closed reports whether Close has been called
+func (l *Logger) Closed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
 }
 
 func (l *Logger) SessionID() string {
 	return l.sessionID
 }
 
+// FilePath returns the primary log path: the JSONL file, or the text file
+// when the format is text only
 func (l *Logger) FilePath() string {
 	return l.filePath
 }
 
+// TextFilePath returns the human-readable log path ("" if not enabled)
+func (l *Logger) TextFilePath() string {
+	return l.textPath
+}
+
 func (l *Logger) LogEvent(event Event) {
 	if !l.enabled {
 		return
@@ -90,6 +169,9 @@ func (l *Logger) LogEvent(event Event) {
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
 
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now().UTC()
@@ -101,12 +183,53 @@ func (l *Logger) LogEvent(event Event) {
 		event.SessionID = l.sessionID
 	}
 
-	data, err := json.Marshal(event)
+	if l.file != nil {
+		data, err := json.Marshal(event)
+		if err == nil {
+			_ = appendLine(l.file, append(data, '\n'))
+		}
+	}
+
+	if l.textFile != nil {
+		_ = appendLine(l.textFile, []byte(FormatTextLine(event)))
+	}
+}
+
+// appendLine writes a complete line or nothing: if the write comes up short
+// (e.g. disk full), the partial bytes are truncated away so the log never
+// contains a half-line that would break the JSONL reader
+func appendLine(f logFile, line []byte) error {
+	info, err := f.Stat()
 	if err != nil {
-		return
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	n, err := f.Write(line)
+	if err == nil && n == len(line) {
+		return nil
+	}
+	if err == nil {
+		err = io.ErrShortWrite
 	}
+	if n > 0 {
+		if truncErr := f.Truncate(info.Size()); truncErr != nil {
+			return fmt.Errorf("failed to roll back partial audit write: %w", truncErr)
+		}
+	}
+	return fmt.Errorf("failed to write audit log: %w", err)
+}
 
-	_, _ = l.file.Write(append(data, '\n'))
+// FormatTextLine renders an event as a single human-readable log line
+func FormatTextLine(event Event) string {
+	// Keep one event per line so the text log stays greppable
+	message := strings.ReplaceAll(event.Message, "\n", "\\n")
+	return fmt.Sprintf("%s %-10s %-16s %-5s %s\n",
+		event.Timestamp.Format(time.RFC3339),
+		event.Type,
+		event.Action,
+		event.Status,
+		message,
+	)
 }
 
 func (l *Logger) LogPermission(action string, capability string, reason string, cwd string) {
@@ -124,15 +247,28 @@ func (l *Logger) LogPermission(action string, capability string, reason string,
 }
 
 func (l *Logger) LogTool(name string, status EventStatus, message string, args map[string]any, cwd string) {
+	l.LogToolTimed(name, status, message, args, 0, cwd)
+}
+
+// LogToolTimed is LogTool for a tool that ran, recording how long it took
+// as details.duration_ms (omitted when zero)
+func (l *Logger) LogToolTimed(name string, status EventStatus, message string, args map[string]any, duration time.Duration, cwd string) {
+	if !l.admitToolEvent() {
+		return
+	}
+	details := map[string]any{
+		"args": FormatToolArgs(args, l.cfg.ToolArgumentsStyle, l.cfg.Redact),
+	}
+	if duration > 0 {
+		details["duration_ms"] = float64(duration.Microseconds()) / 1000
+	}
 	l.LogEvent(Event{
 		Type:    EventTypeTool,
 		Action:  name,
 		Status:  status,
 		Message: message,
 		Cwd:     cwd,
-		Details: map[string]any{
-			"args": FormatToolArgs(args, l.cfg.ToolArgumentsStyle, l.cfg.Redact),
-		},
+		Details: details,
 	})
 }
 
@@ -166,7 +302,46 @@ func (l *Logger) LogSession(action string, message string, cwd string) {
 	})
 }
 
+// admitToolEvent counts a tool event against the per-turn cap, reporting
+// whether it should be written
+func (l *Logger) admitToolEvent() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cfg.MaxToolEventsPerTurn > 0 && l.turnToolEvents >= l.cfg.MaxToolEventsPerTurn {
+		l.suppressed++
+		return false
+	}
+	l.turnToolEvents++
+	return true
+}
+
+// EndTurn closes the current turn's tool event accounting. If events were
+// suppressed by MaxToolEventsPerTurn, a single summary event records how
+// many. A new user message ends the previous turn implicitly.
+func (l *Logger) EndTurn(cwd string) {
+	l.mu.Lock()
+	suppressed := l.suppressed
+	l.turnToolEvents = 0
+	l.suppressed = 0
+	l.mu.Unlock()
+
+	if suppressed == 0 {
+		return
+	}
+	l.LogEvent(Event{
+		Type:    EventTypeTool,
+		Action:  "suppressed",
+		Status:  StatusWarn,
+		Message: fmt.Sprintf("...%d more suppressed (max_tool_events_per_turn=%d)", suppressed, l.cfg.MaxToolEventsPerTurn),
+		Cwd:     cwd,
+		Details: map[string]any{
+			"suppressed": suppressed,
+		},
+	})
+}
+
 func (l *Logger) LogMessage(content string, cwd string) {
+	l.EndTurn(cwd)
 	l.LogEvent(Event{
 		Type:    EventTypeMessage,
 		Action:  "user_message",
@@ -214,54 +389,91 @@ func resolveDir(dir string, repoRoot string) (string, error) {
 }
 
 func cleanupOldSessions(dir string, retentionDays int, maxSessions int) error {
+	_, _ = Prune(dir, time.Duration(retentionDays)*24*time.Hour, maxSessions)
+	return nil
+}
+
+// Prune removes sessions in dir that are older than olderThan and, after
+// that, all but the keep most recent ones. A session is all the files
+// sharing its ID (session-<id>.jsonl and session-<id>.log with the "both"
+// format), aged by the newest of them and removed together. A zero value
+// disables either rule. It returns the names of the removed files.
+func Prune(dir string, olderThan time.Duration, keep int) ([]string, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read audit dir: %w", err)
 	}
 
-	now := time.Now()
-	type fileEntry struct {
-		name string
-		info os.FileInfo
+	type sessionFiles struct {
+		names   []string
+		modTime time.Time
 	}
-	var files []fileEntry
+	byID := make(map[string]*sessionFiles)
+	var sessions []*sessionFiles
 
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
 		name := entry.Name()
-		if !stringsHasPrefix(name, "session-") || !stringsHasSuffix(name, ".jsonl") {
+		if !stringsHasPrefix(name, "session-") || (!stringsHasSuffix(name, ".jsonl") && !stringsHasSuffix(name, ".log")) {
 			continue
 		}
 		info, err := entry.Info()
 		if err != nil {
 			continue
 		}
-		files = append(files, fileEntry{name: name, info: info})
+		id := strings.TrimSuffix(name, filepath.Ext(name))
+		session, ok := byID[id]
+		if !ok {
+			session = &sessionFiles{}
+			byID[id] = session
+			sessions = append(sessions, session)
+		}
+		session.names = append(session.names, name)
+		if info.ModTime().After(session.modTime) {
+			session.modTime = info.ModTime()
+		}
 	}
 
-	if retentionDays > 0 {
-		cutoff := now.Add(-time.Duration(retentionDays) * 24 * time.Hour)
-		for _, file := range files {
-			if file.info.ModTime().Before(cutoff) {
-				_ = os.Remove(filepath.Join(dir, file.name))
+	// Newest first, so the keep rule retains the most recent sessions
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].modTime.After(sessions[j].modTime)
+	})
+
+	var removed []string
+	remove := func(session *sessionFiles) {
+		for _, name := range session.names {
+			if err := os.Remove(filepath.Join(dir, name)); err == nil {
+				removed = append(removed, name)
 			}
 		}
 	}
 
-	if maxSessions > 0 {
-		sort.Slice(files, func(i, j int) bool {
-			return files[i].info.ModTime().After(files[j].info.ModTime())
-		})
-		if len(files) > maxSessions {
-			for _, file := range files[maxSessions:] {
-				_ = os.Remove(filepath.Join(dir, file.name))
+	var kept []*sessionFiles
+	if olderThan > 0 {
+		cutoff := time.Now().Add(-olderThan)
+		for _, session := range sessions {
+			if session.modTime.Before(cutoff) {
+				remove(session)
+				continue
 			}
+			kept = append(kept, session)
 		}
+	} else {
+		kept = sessions
 	}
 
-	return nil
+	if keep > 0 && len(kept) > keep {
+		for _, session := range kept[keep:] {
+			remove(session)
+		}
+	}
+
+	return removed, nil
 }
 
 func newSessionID() string {
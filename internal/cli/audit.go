@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -38,37 +39,14 @@ EXAMPLES:
   goshi audit --since=1h --type=tool
   goshi audit --session=session-20260210-153000.000-1234`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg := config.Load()
-			repoRoot := cfg.Behavior.RepoRoot
-			if repoRoot == "" {
-				cwd, err := os.Getwd()
-				if err != nil {
-					return err
-				}
-				repoRoot = cwd
-			}
-
-			auditDir := cfg.Audit.Dir
-			if auditDir == "" {
-				auditDir = ".goshi/audit"
-			}
-			if !filepath.IsAbs(auditDir) {
-				auditDir = filepath.Join(repoRoot, auditDir)
+			auditDir, err := resolveAuditDir(config.Load())
+			if err != nil {
+				return err
 			}
 
-			filePath := ""
-			if session != "" {
-				if strings.HasSuffix(session, ".jsonl") {
-					filePath = filepath.Join(auditDir, session)
-				} else {
-					filePath = filepath.Join(auditDir, fmt.Sprintf("%s.jsonl", session))
-				}
-			} else {
-				latest, err := audit.LatestSessionFile(auditDir)
-				if err != nil {
-					return err
-				}
-				filePath = latest
+			filePath, err := resolveSessionFile(auditDir, session)
+			if err != nil {
+				return err
 			}
 
 			filter := audit.Filter{}
@@ -148,9 +126,115 @@ EXAMPLES:
 	cmd.Flags().StringVar(&types, "type", "", "Comma-separated event types (permission, tool, safety, diagnostic, session)")
 	cmd.Flags().StringVar(&status, "status", "", "Comma-separated status filters (ok, warn, error)")
 	cmd.Flags().BoolVar(&unsafe, "unsafe", false, "Reserved: allow unredacted output if available")
+
+	cmd.AddCommand(newAuditPruneCommand())
+	return cmd
+}
+
+func newAuditPruneCommand() *cobra.Command {
+	var olderThan string
+	var keep int
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove old audit sessions",
+		Long: `Apply retention to the audit directory on demand.
+
+Without flags, the configured audit.retention_days and audit.max_sessions
+are used. --older-than accepts Go durations or days (e.g. 72h or 7d).
+
+EXAMPLES:
+  goshi audit prune
+  goshi audit prune --older-than=7d
+  goshi audit prune --keep=10`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Load()
+			auditDir, err := resolveAuditDir(cfg)
+			if err != nil {
+				return err
+			}
+
+			age := time.Duration(cfg.Audit.RetentionDays) * 24 * time.Hour
+			if cmd.Flags().Changed("older-than") {
+				age, err = parseAge(olderThan)
+				if err != nil {
+					return err
+				}
+			}
+			if !cmd.Flags().Changed("keep") {
+				keep = cfg.Audit.MaxSessions
+			}
+			if keep < 0 {
+				return fmt.Errorf("--keep must be >= 0, got %d", keep)
+			}
+
+			removed, err := audit.Prune(auditDir, age, keep)
+			if err != nil {
+				return err
+			}
+			for _, name := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), "removed %s\n", name)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Pruned %d audit file(s) from %s\n", len(removed), auditDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Remove sessions older than this duration (e.g. 72h or 7d; default: audit.retention_days)")
+	cmd.Flags().IntVar(&keep, "keep", 0, "Keep only the N most recent session files (default: audit.max_sessions)")
 	return cmd
 }
 
+// resolveAuditDir returns the absolute audit directory for the repository
+func resolveAuditDir(cfg config.Config) (string, error) {
+	repoRoot := cfg.Behavior.RepoRoot
+	if repoRoot == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		repoRoot = cwd
+	}
+
+	auditDir := cfg.Audit.Dir
+	if auditDir == "" {
+		auditDir = ".goshi/audit"
+	}
+	if !filepath.IsAbs(auditDir) {
+		auditDir = filepath.Join(repoRoot, auditDir)
+	}
+	return auditDir, nil
+}
+
+// resolveSessionFile returns the JSONL log for a session ID or filename,
+// or the latest session's log when session is empty
+func resolveSessionFile(auditDir, session string) (string, error) {
+	if session == "" {
+		return audit.LatestSessionFile(auditDir)
+	}
+	if strings.HasSuffix(session, ".jsonl") {
+		return filepath.Join(auditDir, session), nil
+	}
+	return filepath.Join(auditDir, fmt.Sprintf("%s.jsonl", session)), nil
+}
+
+// parseAge parses a Go duration, also accepting a whole number of days ("7d")
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid --older-than value: %s", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid --older-than value: %s", value)
+	}
+	return duration, nil
+}
+
 func parseTimeOrDuration(value string) (time.Time, error) {
 	if strings.HasSuffix(value, "h") || strings.HasSuffix(value, "m") || strings.HasSuffix(value, "s") {
 		duration, err := time.ParseDuration(value)
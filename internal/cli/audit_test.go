@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/config"
+)
+
+func TestAuditPruneCommand(t *testing.T) {
+	tmp := t.TempDir()
+	auditDir := filepath.Join(tmp, "audit")
+	if err := os.MkdirAll(auditDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	ages := map[string]time.Duration{
+		"session-new.jsonl": time.Hour,
+		"session-mid.jsonl": 3 * 24 * time.Hour,
+		"session-old.jsonl": 10 * 24 * time.Hour,
+	}
+	for name, age := range ages {
+		path := filepath.Join(auditDir, name)
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		stamp := time.Now().Add(-age)
+		os.Chtimes(path, stamp, stamp)
+	}
+
+	cfgPath := filepath.Join(tmp, "goshi.yaml")
+	if err := os.WriteFile(cfgPath, []byte("audit:\n  dir: "+auditDir+"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	run := func(args ...string) string {
+		cmd := newAuditPruneCommand()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("prune %v failed: %v", args, err)
+		}
+		return out.String()
+	}
+
+	out := run("--older-than=7d", "--keep=0")
+	if !strings.Contains(out, "removed session-old.jsonl") || strings.Contains(out, "session-mid") {
+		t.Errorf("expected only the old session removed, got:\n%s", out)
+	}
+
+	out = run("--older-than=0s", "--keep=1")
+	if !strings.Contains(out, "removed session-mid.jsonl") || strings.Contains(out, "session-new") {
+		t.Errorf("expected only the newest session kept, got:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(auditDir, "session-new.jsonl")); err != nil {
+		t.Errorf("expected newest session to remain: %v", err)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	if d, err := parseAge("7d"); err != nil || d != 7*24*time.Hour {
+		t.Errorf("expected 7 days, got %v, %v", d, err)
+	}
+	if d, err := parseAge("90m"); err != nil || d != 90*time.Minute {
+		t.Errorf("expected 90 minutes, got %v, %v", d, err)
+	}
+	if _, err := parseAge("soon"); err == nil {
+		t.Error("expected invalid age to fail")
+	}
+}
@@ -2,7 +2,13 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
 
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/config"
 	"github.com/cshaiku/goshi/internal/llm"
 	"github.com/cshaiku/goshi/internal/llm/ollama"
 	"github.com/cshaiku/goshi/internal/llm/openai"
@@ -14,6 +20,13 @@ import (
 type BackendFactory struct {
 	provider string
 	model    string
+	logprobs bool
+	reuse    bool // Reuse the pre-marshaled request template
+	timeout  time.Duration
+	idle     time.Duration
+	toolMode string
+	tools    app.ToolFilter // Tools offered to the model
+	pricing  string         // Cost estimate pricing for models without known pricing
 }
 
 // NewBackendFactory creates a factory for the specified provider
@@ -29,6 +42,49 @@ func NewBackendFactory(provider, model string) *BackendFactory {
 	}
 }
 
+// WithLogprobs requests token logprobs from backends that support them
+func (f *BackendFactory) WithLogprobs(enabled bool) *BackendFactory {
+	f.logprobs = enabled
+	return f
+}
+
+// WithReuseRequestTemplate reuses a pre-marshaled request body across
+// calls on backends that support it
+func (f *BackendFactory) WithReuseRequestTemplate(enabled bool) *BackendFactory {
+	f.reuse = enabled
+	return f
+}
+
+// WithTimeouts sets the overall request timeout and the idle timeout
+// between streamed chunks for backends that support them
+func (f *BackendFactory) WithTimeouts(request, idle time.Duration) *BackendFactory {
+	f.timeout = request
+	f.idle = idle
+	return f
+}
+
+// WithToolMode selects how tools are offered to backends that support it
+// (instructions, native, or none)
+func (f *BackendFactory) WithToolMode(mode string) *BackendFactory {
+	f.toolMode = mode
+	return f
+}
+
+// WithToolFilter offers only the tools permitted by the allow/deny lists
+// (safety.allowed_tools and safety.disabled_tools) to backends that
+// describe tools to the model
+func (f *BackendFactory) WithToolFilter(allow, deny []string) *BackendFactory {
+	f.tools = app.NewToolFilter(allow, deny)
+	return f
+}
+
+// WithUnknownModelPricing selects the pricing used to estimate costs for
+// models with no known pricing ("free" or the name of a priced model)
+func (f *BackendFactory) WithUnknownModelPricing(name string) *BackendFactory {
+	f.pricing = name
+	return f
+}
+
 // Create instantiates the appropriate backend implementation
 // Returns Backend interface, maintaining abstraction
 func (f *BackendFactory) Create() (llm.Backend, error) {
@@ -37,13 +93,90 @@ func (f *BackendFactory) Create() (llm.Backend, error) {
 		return ollama.New(f.model), nil
 
 	case "openai":
-		return openai.New(f.model)
+		client, err := openai.New(f.model)
+		if err != nil {
+			return nil, err
+		}
+		client.SetLogprobs(f.logprobs)
+		client.SetReuseRequestTemplate(f.reuse)
+		client.SetToolFilter(f.tools)
+		if err := client.SetToolMode(f.toolMode); err != nil {
+			return nil, err
+		}
+		if f.timeout > 0 {
+			client.SetTimeouts(f.timeout, f.idle)
+		}
+		if f.pricing != "" {
+			if err := client.SetUnknownModelPricing(f.pricing); err != nil {
+				return nil, err
+			}
+		}
+		return client, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported LLM provider: %s (supported: ollama, openai)", f.provider)
 	}
 }
 
+// BuildSystemPrompt returns the combined system prompt the provider's
+// backend sends, without needing credentials or a connection
+func (f *BackendFactory) BuildSystemPrompt(system string) (string, error) {
+	switch f.provider {
+	case "ollama":
+		return ollama.BuildSystemPrompt(system), nil
+	case "openai":
+		return openai.BuildSystemPromptForMode(system, f.toolMode, f.tools), nil
+	default:
+		return "", fmt.Errorf("unsupported LLM provider: %s (supported: ollama, openai)", f.provider)
+	}
+}
+
+// resolveProvider returns the provider and model for a session. A provider
+// of "auto" is settled by detection and the choice reported on stderr; if
+// the configured model is another provider's default, the chosen
+// provider's default model is used instead. A paid provider gets a
+// one-time cost warning on stderr.
+func resolveProvider(cfg config.Config) (provider, model string) {
+	provider, model = detectProvider(cfg)
+	warnPaidProvider(os.Stderr, cfg, provider)
+	return provider, model
+}
+
+// paidWarning limits the paid-provider warning to once per process
+var paidWarning sync.Once
+
+// warnPaidProvider writes the paid-provider warning to w, once
+func warnPaidProvider(w io.Writer, cfg config.Config, provider string) {
+	warning := llm.PaidProviderWarning(cfg, provider)
+	if warning == "" {
+		return
+	}
+	paidWarning.Do(func() {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	})
+}
+
+// detectProvider settles the provider and model, auto-detecting the
+// provider when it is unset or "auto"
+func detectProvider(cfg config.Config) (provider, model string) {
+	provider, model = cfg.LLMProvider, cfg.Model
+	if provider != "" && provider != "auto" {
+		return provider, model
+	}
+
+	cfg.LLM.Provider = "auto"
+	choice := llm.SelectProvider(cfg)
+	fmt.Fprintf(os.Stderr, "provider auto-detection: using %s (%s)\n", choice.Provider, choice.Reason)
+
+	for p, defaultModel := range config.DefaultModels {
+		if model == "" || (p != choice.Provider && model == defaultModel) {
+			model = config.DefaultModelFor(choice.Provider)
+			break
+		}
+	}
+	return choice.Provider, model
+}
+
 // SupportedProviders returns list of available providers
 func SupportedProviders() []string {
 	return []string{"ollama", "openai"}
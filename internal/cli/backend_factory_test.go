@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/config"
+)
+
+func TestWarnPaidProvider(t *testing.T) {
+	t.Cleanup(func() { paidWarning = sync.Once{} })
+	cfg := config.LoadDefaults()
+
+	var out bytes.Buffer
+	paidWarning = sync.Once{}
+	warnPaidProvider(&out, cfg, "ollama")
+	if out.Len() != 0 {
+		t.Errorf("expected no warning for Ollama, got %q", out.String())
+	}
+
+	warnPaidProvider(&out, cfg, "openai")
+	warnPaidProvider(&out, cfg, "openai")
+	if got := strings.Count(out.String(), "openai is a paid provider"); got != 1 {
+		t.Errorf("expected the warning exactly once, got %d in %q", got, out.String())
+	}
+
+	out.Reset()
+	paidWarning = sync.Once{}
+	cfg.LLM.WarnPaid = false
+	warnPaidProvider(&out, cfg, "openai")
+	if out.Len() != 0 {
+		t.Errorf("expected warn_paid_provider: false to silence the warning, got %q", out.String())
+	}
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/llm"
+	"github.com/cshaiku/goshi/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+// benchPrompt is the trivial request each benchmark iteration sends
+const benchPrompt = "Reply with the single word OK."
+
+// benchQuantiles are the latency percentiles a benchmark reports
+var benchQuantiles = []float64{0.5, 0.9, 0.99}
+
+func newBenchCommand() *cobra.Command {
+	var provider string
+	var model string
+	var count int
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark backend latency with a series of trivial requests",
+		Long: `Send N trivial requests to a backend, one after another, and report
+latency percentiles, token usage and, for paid providers, the estimated
+cost. Useful for comparing models and providers.
+
+Latency is measured from sending each request to the end of its response.
+Paid providers are billed for every request.
+
+EXAMPLES:
+  $ goshi bench
+
+  $ goshi bench --provider openai --model gpt-4o-mini --n 20
+
+EXIT CODES:
+  0   - Benchmark completed
+  1   - Backend could not be created or every request failed`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if count <= 0 {
+				return fmt.Errorf("--n must be positive, got %d", count)
+			}
+
+			cfg := config.Load()
+			if provider != "" && provider != cfg.LLMProvider {
+				cfg.LLMProvider = provider
+				cfg.Model = config.DefaultModelFor(provider)
+			}
+			if model != "" {
+				cfg.Model = model
+			}
+			provider, model := resolveProvider(cfg)
+
+			factory := NewBackendFactory(provider, model).
+				WithUnknownModelPricing(cfg.LLM.UnknownPricing).
+				WithTimeouts(time.Duration(cfg.LLM.RequestTimeout)*time.Second, time.Duration(cfg.LLM.IdleTimeout)*time.Second)
+			backend, err := factory.Create()
+			if err != nil {
+				return fmt.Errorf("failed to initialize LLM backend (supported providers: %s): %w", strings.Join(SupportedProviders(), ", "), err)
+			}
+
+			fmt.Fprintf(os.Stdout, "Benchmarking %s (%s) with %d requests...\n", provider, model, count)
+			snapshot := runBench(cmd.Context(), backend, count)
+			printBenchReport(os.Stdout, snapshot)
+			if snapshot.Failures == snapshot.Requests {
+				return fmt.Errorf("all %d requests failed", snapshot.Requests)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&provider, "provider", "", "Provider to benchmark (default: the configured provider)")
+	cmd.Flags().StringVar(&model, "model", "", "Model to benchmark (default: the configured model, or the provider's default)")
+	cmd.Flags().IntVar(&count, "n", 10, "Number of requests to send")
+	return cmd
+}
+
+// runBench sends count trivial requests to backend in turn and returns the
+// metrics collected for them
+func runBench(ctx context.Context, backend llm.Backend, count int) metrics.Snapshot {
+	collector := metrics.NewCollector()
+	instrumented := collector.Instrument(backend)
+	messages := []llm.Message{{Role: "user", Content: benchPrompt}}
+
+	for i := 0; i < count; i++ {
+		stream, err := instrumented.Stream(ctx, "", messages)
+		if err != nil {
+			continue // Recorded as a failure
+		}
+		for {
+			if _, err := stream.Recv(); err != nil {
+				break
+			}
+		}
+		stream.Close()
+	}
+	return collector.Snapshot()
+}
+
+// printBenchReport writes a benchmark's request count, latency percentiles,
+// token usage and estimated cost
+func printBenchReport(w io.Writer, s metrics.Snapshot) {
+	fmt.Fprintf(w, "Requests: %d (%d failed)\n", s.Requests, s.Failures)
+
+	var mean time.Duration
+	if s.Requests > 0 {
+		mean = s.Latency / time.Duration(s.Requests)
+	}
+	fmt.Fprintf(w, "Latency:  mean %s", formatBenchLatency(mean))
+	for _, q := range benchQuantiles {
+		fmt.Fprintf(w, "  p%.0f %s", q*100, formatBenchLatency(s.Percentile(q)))
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "Tokens:   %d prompt, %d completion\n", s.Usage.PromptTokens, s.Usage.CompletionTokens)
+	if s.HasCost {
+		fmt.Fprintf(w, "Cost:     $%.4f (estimated)\n", s.Cost)
+	}
+}
+
+// formatBenchLatency renders a latency to the millisecond
+func formatBenchLatency(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
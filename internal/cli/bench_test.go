@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// benchBackend answers each request after the next scripted delay and
+// reports a fixed cost per request
+type benchBackend struct {
+	delays []time.Duration
+	calls  int
+}
+
+func (b *benchBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	delay := b.delays[b.calls%len(b.delays)]
+	b.calls++
+	return &benchStream{delay: delay}, nil
+}
+
+func (b *benchBackend) TotalCost() float64 {
+	return 0.0001 * float64(b.calls)
+}
+
+type benchStream struct {
+	delay time.Duration
+	sent  bool
+}
+
+func (s *benchStream) Recv() (string, error) {
+	if s.sent {
+		return "", io.EOF
+	}
+	time.Sleep(s.delay)
+	s.sent = true
+	return "OK", nil
+}
+
+func (s *benchStream) Close() error { return nil }
+
+func TestRunBenchCountsRequests(t *testing.T) {
+	backend := &benchBackend{delays: []time.Duration{time.Millisecond}}
+
+	snapshot := runBench(context.Background(), backend, 5)
+	if backend.calls != 5 || snapshot.Requests != 5 || snapshot.Failures != 0 {
+		t.Errorf("expected 5 successful requests, got calls=%d requests=%d failures=%d", backend.calls, snapshot.Requests, snapshot.Failures)
+	}
+	if len(snapshot.Latencies) != 5 {
+		t.Errorf("expected 5 recorded latencies, got %d", len(snapshot.Latencies))
+	}
+}
+
+func TestRunBenchPercentilesComeFromRecordedLatencies(t *testing.T) {
+	backend := &benchBackend{delays: []time.Duration{
+		30 * time.Millisecond, 2 * time.Millisecond, 10 * time.Millisecond, 5 * time.Millisecond,
+	}}
+
+	snapshot := runBench(context.Background(), backend, 4)
+	if len(snapshot.Latencies) != 4 {
+		t.Fatalf("expected 4 recorded latencies, got %d", len(snapshot.Latencies))
+	}
+
+	// Latencies are sorted, so each one covers at least its own delay
+	for i, floor := range []time.Duration{2, 5, 10, 30} {
+		if snapshot.Latencies[i] < floor*time.Millisecond {
+			t.Errorf("expected latency %d to be at least %dms, got %v", i, floor, snapshot.Latencies[i])
+		}
+	}
+	if p50 := snapshot.Percentile(0.5); p50 != snapshot.Latencies[1] {
+		t.Errorf("expected p50 to be the second of four latencies, got %v from %v", p50, snapshot.Latencies)
+	}
+	if p99 := snapshot.Percentile(0.99); p99 != snapshot.Latencies[3] {
+		t.Errorf("expected p99 to be the slowest latency, got %v from %v", p99, snapshot.Latencies)
+	}
+
+	var out bytes.Buffer
+	printBenchReport(&out, snapshot)
+	report := out.String()
+	for _, want := range []string{
+		"Requests: 4 (0 failed)",
+		"p50 " + formatBenchLatency(snapshot.Latencies[1]),
+		"p99 " + formatBenchLatency(snapshot.Latencies[3]),
+		"Cost:     $0.0004 (estimated)",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q in report, got:\n%s", want, report)
+		}
+	}
+}
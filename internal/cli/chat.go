@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/cshaiku/goshi/internal/app"
 	"github.com/cshaiku/goshi/internal/config"
 	"github.com/cshaiku/goshi/internal/detect"
 	"github.com/cshaiku/goshi/internal/llm"
@@ -18,16 +21,9 @@ import (
 func printStatus(systemPrompt string, perms *session.Permissions) {
 	display := DefaultDisplayConfig()
 	metrics := selfmodel.ComputeLawMetrics(systemPrompt)
-	label := "ENFORCEMENT STAGED"
+	label := "ENFORCEMENT " + perms.EnforcementStatus()
 	color := ColorYellow
-	if perms.FSRead && perms.FSWrite {
-		label = "ENFORCEMENT ACTIVE (FS_READ + FS_WRITE)"
-		color = ColorGreen
-	} else if perms.FSRead {
-		label = "ENFORCEMENT ACTIVE (FS_READ)"
-		color = ColorGreen
-	} else if perms.FSWrite {
-		label = "ENFORCEMENT ACTIVE (FS_WRITE)"
+	if perms.FSRead || perms.FSWrite {
 		color = ColorGreen
 	}
 	fmt.Printf("Self-Model Law Index: %d lines · %d constraints · %s\n",
@@ -46,8 +42,16 @@ func runTUIMode(systemPrompt string) {
 	cfg := config.Load()
 	ctx := context.Background()
 
-	// Initialize LLM backend
-	factory := NewBackendFactory(cfg.LLMProvider, cfg.Model)
+	// Initialize LLM backend. The paid-provider warning is shown in the
+	// TUI, since stderr is hidden behind the alternate screen.
+	provider, model := detectProvider(cfg)
+	factory := NewBackendFactory(provider, model).
+		WithLogprobs(cfg.LLM.Logprobs || logprobsMode).
+		WithToolMode(cfg.LLM.ToolMode).
+		WithToolFilter(cfg.Safety.AllowedTools, cfg.Safety.DisabledTools).
+		WithReuseRequestTemplate(cfg.LLM.ReuseTemplate).
+		WithUnknownModelPricing(cfg.LLM.UnknownPricing).
+		WithTimeouts(time.Duration(cfg.LLM.RequestTimeout)*time.Second, time.Duration(cfg.LLM.IdleTimeout)*time.Second)
 	backend, err := factory.Create()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to initialize LLM backend: %v\n", err)
@@ -61,8 +65,10 @@ func runTUIMode(systemPrompt string) {
 		fmt.Fprintf(os.Stderr, "failed to initialize chat session: %v\n", err)
 		return
 	}
+	sess.Provider, sess.Model = provider, model
+	sess.PaidWarning = llm.PaidProviderWarning(cfg, provider)
 
-	// Launch TUI
+	// Launch TUI; it shuts the session down on quit and on SIGINT/SIGTERM
 	if err := tui.Run(systemPrompt, sess); err != nil {
 		fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
 		os.Exit(1)
@@ -71,10 +77,18 @@ func runTUIMode(systemPrompt string) {
 
 func runChat(systemPrompt string) {
 	cfg := config.Load()
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	// Initialize LLM backend using factory (Dependency Inversion Principle)
-	factory := NewBackendFactory(cfg.LLMProvider, cfg.Model)
+	provider, model := resolveProvider(cfg)
+	factory := NewBackendFactory(provider, model).
+		WithLogprobs(cfg.LLM.Logprobs || logprobsMode).
+		WithToolMode(cfg.LLM.ToolMode).
+		WithToolFilter(cfg.Safety.AllowedTools, cfg.Safety.DisabledTools).
+		WithReuseRequestTemplate(cfg.LLM.ReuseTemplate).
+		WithUnknownModelPricing(cfg.LLM.UnknownPricing).
+		WithTimeouts(time.Duration(cfg.LLM.RequestTimeout)*time.Second, time.Duration(cfg.LLM.IdleTimeout)*time.Second)
 	backend, err := factory.Create()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to initialize LLM backend: %v\n", err)
@@ -88,12 +102,33 @@ func runChat(systemPrompt string) {
 		fmt.Fprintf(os.Stderr, "failed to initialize chat session: %v\n", err)
 		return
 	}
+	sess.Provider, sess.Model = provider, model
+	if sess.AuditWarning != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", sess.AuditWarning)
+	}
+	// Turns hold this while they change the session, so a signal's
+	// shutdown waits for the aborted turn before saving
+	var turns sync.Mutex
+	stopSignals := shutdownOnSignal(sess, cancel, &turns)
+	defer stopSignals()
+	defer shutdownSession(sess)
 
 	printStatus(systemPrompt, sess.Permissions)
+	if sess.MOTD != "" {
+		fmt.Println("\n" + sess.MOTD)
+	}
 	reader := bufio.NewReader(os.Stdin)
 	permHandler := NewPermissionHandler(sess.WorkingDir, DefaultDisplayConfig())
+	progressAfter := time.Duration(cfg.Behavior.ToolProgressAfterMs) * time.Millisecond
+	awaitingClarification := false
 
 	for {
+		// Stop accepting input once the configured turn limit is used up
+		if sess.TurnLimitReached() {
+			fmt.Println(sess.WrapUpMessage())
+			return
+		}
+
 		fmt.Print("You: ")
 		line, _ := reader.ReadString('\n')
 		line = strings.TrimSpace(line)
@@ -101,54 +136,109 @@ func runChat(systemPrompt string) {
 			continue
 		}
 
-		// PHASE 1: Listen - Record user input
-		sess.AddUserMessage(line)
-
-		// PHASE 2: Detect intent - Check for implicit capability requests
-		// This is a transition mechanism; eventually LLM should handle all intent
-		detected := detect.DetectCapabilities(line, detect.FSReadRules)
-		detected = append(detected, detect.DetectCapabilities(line, detect.FSWriteRules)...)
-
-		// Handle permissions using extracted handler (Single Responsibility)
-		if !permHandler.HandleDetected(detected, sess, systemPrompt) {
+		// /continue extends a response cut off by the output token limit
+		isContinue := line == "/continue"
+		if isContinue && !sess.CanContinue() {
+			fmt.Println("Nothing to continue: the last response was not cut off.")
 			continue
 		}
 
-		// PHASE 3: Plan - Get LLM response with streaming
-		collector := llm.NewResponseCollector(llm.NewStructuredParser())
-		stream, err := sess.Client.Backend().Stream(ctx, sess.Client.System().Raw(), sess.ConvertMessagesToLegacy())
+		// Drive the turn through Listen/Detect/Plan/Parse/Act/Report
+		turns.Lock()
+		turn := sess.NewTurn(line, session.TurnHooks{
+			// Handle permissions using extracted handler (Single Responsibility)
+			Detect: func(matches []detect.Match) bool {
+				return permHandler.HandleDetected(matches, sess, systemPrompt)
+			},
+			OnPhase: func(_ *session.ChatTurn, phase session.Phase) {
+				if phase == session.PhasePlan {
+					fmt.Print("Goshi: ")
+				}
+			},
+			OnChunk: func(chunk string) {
+				fmt.Print(chunk)
+			},
+			// Run requested tools; each result is fed back to the model
+			// until it answers
+			Act: func(action *llm.ActionCall) any {
+				return runToolAction(sess, action, progressAfter)
+			},
+		})
+		turn.ClarificationAnswer = awaitingClarification
+		turn.Continue = isContinue
+		awaitingClarification = false
+
+		err := turn.Run()
+		turns.Unlock()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "LLM error: %v\n", err)
+			// A cancelled context means a signal is shutting the session down
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "LLM error: %v\n", err)
+			}
 			continue
 		}
-
-		fmt.Print("Goshi: ")
-		for {
-			chunk, err := stream.Recv()
-			if err != nil {
-				break
-			}
-			fmt.Print(chunk)
-			collector.AddChunk(chunk)
+		if turn.Stopped {
+			continue
 		}
 		fmt.Println()
-		stream.Close()
+		if turn.FinishReason == llm.FinishReasonLength {
+			fmt.Println(DefaultDisplayConfig().Colorize("response cut off at the token limit; type /continue to extend it", ColorYellow))
+		}
 
-		// Parse response
-		parseResult, parseErr := collector.Parse()
-		if parseErr != nil || parseResult == nil {
+		resp := turn.Response
+		if resp == nil {
 			fmt.Println("-----------------------------------------------------")
 			continue
 		}
 
-		// Store text response in session
-		if textContent := parseResult.Response.Text; textContent != "" {
-			sess.AddAssistantTextMessage(textContent)
+		// Clarification requests: show the question and treat the next input as the answer
+		if resp.Type == llm.ResponseTypeClarification {
+			fmt.Printf("\n%s %s\n", DefaultDisplayConfig().Colorize("?", ColorYellow), resp.Clarification.Question)
+			for i, hint := range resp.Clarification.Hints {
+				fmt.Printf("  %d) %s\n", i+1, hint)
+			}
+			awaitingClarification = true
+			fmt.Println("-----------------------------------------------------")
+			continue
+		}
+
+		// Refusals and other model-reported errors
+		if resp.Type == llm.ResponseTypeError {
+			fmt.Printf("%s %s\n", DefaultDisplayConfig().Colorize("✗", ColorRed), resp.Error)
+			fmt.Println("-----------------------------------------------------")
+			continue
 		}
 
-		// TODO Phase 4: Full tool execution integration
-		// For now, skip actions - Phase 3 focuses on TUI mode detection
+		// The step limit ended the turn before a final answer
+		if turn.LimitReached {
+			fmt.Printf("%s %s\n", DefaultDisplayConfig().Colorize("!", ColorYellow), resp.Text)
+		}
 
 		fmt.Println("-----------------------------------------------------")
 	}
 }
+
+// runToolAction runs a tool the model requested, first asking for its
+// capability if the session does not hold it, with a spinner once the tool
+// has been running for progressAfter
+func runToolAction(sess *session.ChatSession, action *llm.ActionCall, progressAfter time.Duration) any {
+	fmt.Println()
+	// A denial is recorded and the router then refuses the call
+	sess.RequestToolPermission(action.Tool)
+	result := runWithProgress(os.Stderr, "Running "+action.Tool, progressAfter, progressInterval, func() any {
+		return sess.ToolRouter.Execute(app.ToolCall{Name: action.Tool, Args: action.Args})
+	})
+	reportToolResult(action.Tool, result)
+	return result
+}
+
+// reportToolResult prints the outcome of a tool the model ran
+func reportToolResult(tool string, result any) {
+	if result, ok := result.(app.ToolResult); ok {
+		if result.Success {
+			fmt.Printf("%s Tool executed: %s\n", DefaultDisplayConfig().Colorize("✓", ColorGreen), tool)
+		} else {
+			fmt.Printf("%s Tool failed: %s: %s\n", DefaultDisplayConfig().Colorize("✗", ColorRed), tool, result.Error)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cshaiku/goshi/internal/app"
 	"github.com/cshaiku/goshi/internal/config"
@@ -26,8 +28,9 @@ func TestMain(m *testing.M) {
 
 // MockLLMBackend implements llm.Backend for testing with scripted responses
 type MockLLMBackend struct {
-	responses []string // Pre-scripted responses to return in order
-	callCount int      // Track how many times Stream was called
+	responses []string        // Pre-scripted responses to return in order
+	callCount int             // Track how many times Stream was called
+	requests  [][]llm.Message // Messages sent with each call
 	t         *testing.T
 }
 
@@ -46,6 +49,7 @@ func (m *MockLLMBackend) Stream(ctx context.Context, system string, messages []l
 
 	response := m.responses[m.callCount]
 	m.callCount++
+	m.requests = append(m.requests, messages)
 
 	return &MockStream{
 		Data: []string{response},
@@ -60,7 +64,7 @@ type MockStream struct {
 
 func (m *MockStream) Recv() (string, error) {
 	if m.Index >= len(m.Data) {
-		return "", &MockStreamError{}
+		return "", io.EOF
 	}
 	chunk := m.Data[m.Index]
 	m.Index++
@@ -71,12 +75,6 @@ func (m *MockStream) Close() error {
 	return nil
 }
 
-type MockStreamError struct{}
-
-func (e *MockStreamError) Error() string   { return "end of stream" }
-func (e *MockStreamError) Timeout() bool   { return false }
-func (e *MockStreamError) Temporary() bool { return false }
-
 // ==============================================================================
 // Test Helpers
 // ==============================================================================
@@ -735,3 +733,155 @@ func TestIntegration_ToolRegistry_SchemaValidation(t *testing.T) {
 		}
 	}
 }
+
+// ==============================================================================
+// Integration Tests - Multi-step Turns
+// ==============================================================================
+
+// newToolLoopTurn builds a chat turn that runs tools through the session's
+// router, as the CLI chat loop does
+func newToolLoopTurn(sess *session.ChatSession, input string) *session.ChatTurn {
+	return sess.NewTurn(input, session.TurnHooks{
+		Act: func(action *llm.ActionCall) any {
+			return sess.ToolRouter.Execute(app.ToolCall{Name: action.Tool, Args: action.Args})
+		},
+	})
+}
+
+func TestIntegration_ToolResultFedBackUntilText(t *testing.T) {
+	tmpDir, cleanup := createTestDir(t)
+	defer cleanup()
+	createTestFiles(t, tmpDir)
+
+	oldCwd, _ := os.Getwd()
+	defer os.Chdir(oldCwd)
+	os.Chdir(tmpDir)
+
+	backend := NewMockLLMBackend(t,
+		`{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`,
+		`{"type": "action", "action": {"tool": "fs.read", "args": {"path": "readme.txt"}}}`,
+		`{"type": "text", "text": "The readme says: This is a readme file"}`,
+	)
+	sess, err := session.NewChatSession(context.Background(), "You are a helpful assistant.", backend)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	sess.GrantPermission("FS_READ")
+
+	turn := newToolLoopTurn(sess, "What does the readme say?")
+	if err := turn.Run(); err != nil {
+		t.Fatalf("turn failed: %v", err)
+	}
+
+	if backend.callCount != 3 {
+		t.Fatalf("expected 3 model calls (action, action, text), got %d", backend.callCount)
+	}
+	if strings.Join(turn.Tools, ",") != "fs.list,fs.read" {
+		t.Errorf("expected fs.list then fs.read, got %v", turn.Tools)
+	}
+	if turn.LimitReached {
+		t.Error("expected the turn to end on text, not the step limit")
+	}
+	if turn.Response == nil || turn.Response.Type != llm.ResponseTypeText || turn.Response.Text != "The readme says: This is a readme file" {
+		t.Fatalf("expected the final text response, got %+v", turn.Response)
+	}
+
+	// The final call carries the file contents read by the previous step
+	final := backend.requests[2]
+	if last := final[len(final)-1]; !strings.Contains(last.Content, "This is a readme file") {
+		t.Errorf("expected the fs.read result fed back to the model, got %+v", last)
+	}
+}
+
+// answeringPrompter answers permission requests with grant, recording them
+type answeringPrompter struct {
+	grant bool
+	asked []string
+}
+
+func (p *answeringPrompter) Ask(capability string, cwd string) bool {
+	p.asked = append(p.asked, capability)
+	return p.grant
+}
+
+func TestIntegration_ToolActionRequestsPermission(t *testing.T) {
+	tmpDir, cleanup := createTestDir(t)
+	defer cleanup()
+	createTestFiles(t, tmpDir)
+
+	oldCwd, _ := os.Getwd()
+	defer os.Chdir(oldCwd)
+	os.Chdir(tmpDir)
+
+	for _, grant := range []bool{true, false} {
+		backend := NewMockLLMBackend(t,
+			`{"type": "action", "action": {"tool": "fs.read", "args": {"path": "readme.txt"}}}`,
+			`{"type": "text", "text": "done"}`,
+		)
+		sess, err := session.NewChatSession(context.Background(), "You are a helpful assistant.", backend)
+		if err != nil {
+			t.Fatalf("failed to create session: %v", err)
+		}
+		// FS_READ is not granted up front: the tool call must ask for it
+		prompter := &answeringPrompter{grant: grant}
+		sess.Prompter = prompter
+
+		turn := sess.NewTurn("What does the readme say?", session.TurnHooks{
+			Act: func(action *llm.ActionCall) any {
+				return runToolAction(sess, action, time.Hour)
+			},
+		})
+		if err := turn.Run(); err != nil {
+			t.Fatalf("turn failed: %v", err)
+		}
+
+		if strings.Join(prompter.asked, ",") != "FS_READ" {
+			t.Errorf("grant=%v: expected FS_READ to be requested once, got %v", grant, prompter.asked)
+		}
+		if sess.HasPermission("FS_READ") != grant {
+			t.Errorf("grant=%v: expected the decision recorded in the session", grant)
+		}
+		if !grant {
+			// The denial ends the turn without running the tool
+			if !turn.Stopped || backend.callCount != 1 {
+				t.Errorf("expected the denial to stop the turn, got stopped=%v calls=%d", turn.Stopped, backend.callCount)
+			}
+			continue
+		}
+		fed := backend.requests[1]
+		if last := fed[len(fed)-1].Content; !strings.Contains(last, "This is a readme file") {
+			t.Errorf("expected the granted read fed back to the model, got %q", last)
+		}
+	}
+}
+
+func TestIntegration_ToolLoopStopsAtStepLimit(t *testing.T) {
+	tmpDir, cleanup := createTestDir(t)
+	defer cleanup()
+	createTestFiles(t, tmpDir)
+
+	oldCwd, _ := os.Getwd()
+	defer os.Chdir(oldCwd)
+	os.Chdir(tmpDir)
+
+	action := `{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`
+	backend := NewMockLLMBackend(t, action, action)
+	sess, err := session.NewChatSession(context.Background(), "You are a helpful assistant.", backend)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	sess.GrantPermission("FS_READ")
+	sess.MaxSteps = 1
+
+	turn := newToolLoopTurn(sess, "List the files forever")
+	if err := turn.Run(); err != nil {
+		t.Fatalf("turn failed: %v", err)
+	}
+
+	if backend.callCount != 2 || len(turn.Tools) != 1 {
+		t.Errorf("expected 1 tool over 2 model calls, got %d tools over %d calls", len(turn.Tools), backend.callCount)
+	}
+	if !turn.LimitReached || turn.Response == nil || !strings.Contains(turn.Response.Text, "1 tool steps") {
+		t.Errorf("expected the step-limit summary, got limit=%v response=%+v", turn.LimitReached, turn.Response)
+	}
+}
@@ -29,14 +29,18 @@ QUICK START:
   $ goshi config show --format=yaml  # Show as YAML
   $ goshi config validate          # Validate current config
   $ goshi config init --output ~/.goshi/config.yaml  # Generate template
+  $ goshi config diff a.yaml b.yaml  # Compare two config files
 
 SEE ALSO:
   goshi help config show      - Display configuration
   goshi help config validate  - Validate configuration file
   goshi help config init      - Generate config template
+  goshi help config diff      - Compare two config files
 
 ENVIRONMENT:
-  GOSHI_CONFIG        - Path to configuration file (overrides file search)
+  GOSHI_CONFIG        - Path or https URL of the configuration file (overrides file search;
+                        goshi refuses to start if the URL cannot be loaded)
+  GOSHI_CONFIG_INSECURE - Set to true to allow an http:// GOSHI_CONFIG URL
   GOSHI_MODEL         - LLM model to use (overrides config file)
   GOSHI_LLM_PROVIDER  - LLM provider: ollama, openai, etc. (overrides config file)
   GOSHI_OLLAMA_URL    - Ollama server URL (overrides config file)
@@ -47,6 +51,7 @@ ENVIRONMENT:
 		newConfigShowCommand(),
 		newConfigValidateCommand(),
 		newConfigInitCommand(),
+		newConfigDiffCommand(),
 	)
 
 	return cmd
@@ -54,6 +59,7 @@ ENVIRONMENT:
 
 func newConfigShowCommand() *cobra.Command {
 	var format string
+	var warnOverrides bool
 
 	cmd := &cobra.Command{
 		Use:   "show",
@@ -74,6 +80,8 @@ EXAMPLES:
 
   $ goshi config show | jq '.llm.model'
 
+  $ goshi config show --warn-overrides
+
 ENVIRONMENT:
   GOSHI_MODEL         - Overrides LLM model setting
   GOSHI_LLM_PROVIDER  - Overrides LLM provider setting
@@ -87,6 +95,12 @@ EXIT CODES:
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg := GetConfig()
 
+			if warnOverrides {
+				for _, line := range formatOverrideWarnings(config.EnvOverrides()) {
+					fmt.Fprintln(os.Stderr, line)
+				}
+			}
+
 			switch format {
 			case "json":
 				enc := json.NewEncoder(os.Stdout)
@@ -108,9 +122,19 @@ EXIT CODES:
 	}
 
 	cmd.Flags().StringVar(&format, "format", "json", "Output format (json or yaml)")
+	cmd.Flags().BoolVar(&warnOverrides, "warn-overrides", false, "Warn about config values overridden by environment variables")
 	return cmd
 }
 
+// formatOverrideWarnings renders one warning line per env-overridden field
+func formatOverrideWarnings(overrides []config.Override) []string {
+	lines := make([]string, 0, len(overrides))
+	for _, o := range overrides {
+		lines = append(lines, fmt.Sprintf("warning: %s overridden by %s (file: %q, env: %q)", o.Field, o.Env, o.FileValue, o.EnvValue))
+	}
+	return lines
+}
+
 func newConfigValidateCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "validate [config-file]",
@@ -288,3 +312,61 @@ EXIT CODES:
 	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing file if present")
 	return cmd
 }
+
+func newConfigDiffCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "diff <a.yaml> <b.yaml>",
+		Short: "Show field-level differences between two config files",
+		Long: `Load two config files and print the fields whose values differ.
+
+Each file is loaded over the built-in defaults, so a field set explicitly
+to its default value in one file and omitted from the other is not
+reported. Environment variable overrides are not applied.
+
+EXAMPLES:
+  $ goshi config diff goshi.yaml ~/.goshi/config.yaml
+  llm.model: "llama3.1:8b" → "gpt-4o-mini"
+  llm.provider: "ollama" → "openai"
+
+  $ goshi config diff --format=json dev.yaml prod.yaml`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			a, err := config.LoadFile(args[0])
+			if err != nil {
+				return err
+			}
+			b, err := config.LoadFile(args[1])
+			if err != nil {
+				return err
+			}
+			diffs := config.Diff(a, b)
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "json":
+				if diffs == nil {
+					diffs = []config.FieldDiff{}
+				}
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(diffs)
+			case "human", "":
+				if len(diffs) == 0 {
+					fmt.Fprintln(out, "No differences.")
+					return nil
+				}
+				for _, d := range diffs {
+					fmt.Fprintf(out, "%s: %s → %s\n", d.Field, d.A, d.B)
+				}
+				return nil
+			default:
+				return fmt.Errorf("unknown format: %s (use human or json)", format)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "human", "Output format: human or json")
+	return cmd
+}
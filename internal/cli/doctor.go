@@ -12,6 +12,7 @@ import (
 	"github.com/cshaiku/goshi/internal/diagnose"
 	"github.com/cshaiku/goshi/internal/diagnostics/integrity"
 	"github.com/cshaiku/goshi/internal/diagnostics/modules"
+	"github.com/cshaiku/goshi/internal/selfmodel"
 	"github.com/cshaiku/goshi/internal/version"
 	"gopkg.in/yaml.v3"
 )
@@ -35,6 +36,7 @@ CHECKS PERFORMED:
   - Environmental dependencies
   - Go module integrity
 	- Source file integrity (via .goshi/goshi.manifest)
+  - Self-model version matches what goshi expects
 
 SEVERITY LEVELS:
   OK      - No issues detected
@@ -116,6 +118,11 @@ SEE ALSO:
 			integrityIssues := integrityDiag.Run()
 			diag.Issues = append(diag.Issues, integrityIssues...)
 
+			// --- self-model version ---
+			if runtime != nil && runtime.SystemPrompt != nil {
+				diag.Issues = append(diag.Issues, diagnose.CheckSelfModelVersion(runtime.SystemPrompt.Raw(), selfmodel.ExpectedVersion)...)
+			}
+
 			// Output format selection
 			outFmt := format
 			if outFmt == "" && jsonCompat {
@@ -146,15 +153,7 @@ SEE ALSO:
 					return nil
 				}
 				fmt.Println("Detected issues:")
-				for _, issue := range diag.Issues {
-					fmt.Printf(
-						" - [%s][%s] %s (suggested: %s)\n",
-						issue.Severity,
-						issue.Code,
-						issue.Message,
-						issue.Strategy,
-					)
-				}
+				fmt.Print(diagnose.FormatHuman(diag.Issues))
 			default:
 				return fmt.Errorf("unknown format: %s (use 'json', 'yaml', or 'human')", outFmt)
 			}
@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/cshaiku/goshi/internal/app"
 	"github.com/cshaiku/goshi/internal/audit"
@@ -75,6 +77,7 @@ func initAuditLogger() *audit.Logger {
 		MaxSessions:        cfg.Audit.MaxSessions,
 		Redact:             cfg.Audit.Redact,
 		ToolArgumentsStyle: cfg.Audit.ToolArgumentsStyle,
+		Format:             cfg.Audit.Format,
 	}, repoRoot)
 	if err != nil {
 		// Silently fail if audit logger can't be initialized; don't break fs commands
@@ -128,6 +131,7 @@ EXIT CODES:
 				}
 				return err
 			}
+			svc.Dispatcher().SetMaxRecursiveBytes(config.Load().Safety.MaxRecursiveReadBytes)
 
 			path := args[0]
 
@@ -175,7 +179,9 @@ EXIT CODES:
 }
 
 func newFSListCommand() *cobra.Command {
-	return &cobra.Command{
+	var namesOnly bool
+
+	cmd := &cobra.Command{
 		Use:   "list [path]",
 		Short: "List a directory safely",
 		Long: `List directory contents safely within repository bounds.
@@ -190,6 +196,8 @@ EXAMPLES:
 
   $ goshi fs list | jq '.files'
 
+  $ goshi fs list --names-only
+
 EXIT CODES:
   0   - Success: Directory listed successfully
   1   - Error: Directory not found or access denied`,
@@ -215,7 +223,8 @@ EXIT CODES:
 			}
 
 			out, err := svc.RunAction("fs.list", map[string]any{
-				"path": path,
+				"path":   path,
+				"detail": !namesOnly,
 			})
 			if err != nil {
 				if auditLogger != nil {
@@ -230,10 +239,15 @@ EXIT CODES:
 			return printJSON(out)
 		},
 	}
+
+	cmd.Flags().BoolVar(&namesOnly, "names-only", false, "List entry names only (directories end with /)")
+	return cmd
 }
 
 func newFSWriteCommand() *cobra.Command {
-	return &cobra.Command{
+	var useBase64 bool
+
+	cmd := &cobra.Command{
 		Use:   "write <path>",
 		Short: "Propose a write (content read from stdin)",
 		Long: `Propose a file write operation with content from stdin.
@@ -249,9 +263,14 @@ EXAMPLES:
 
 	$ goshi fs write goshi.yaml < new-config.yml
 
+  $ base64 logo.png | goshi fs write assets/logo.png --base64
+
+FLAGS:
+  --base64   Decode stdin as base64 before proposing (for binary content)
+
 EXIT CODES:
   0   - Success: Write proposal created
-  1   - Error: No stdin provided or invalid path`,
+  1   - Error: No stdin provided, invalid base64 input, or invalid path`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cwd, _ := os.Getwd()
@@ -274,6 +293,14 @@ EXIT CODES:
 				return fmt.Errorf("no input on stdin (pipe content into fs write)")
 			}
 
+			b, err = decodeWriteInput(b, useBase64)
+			if err != nil {
+				if auditLogger != nil {
+					auditLogger.LogTool("fs.write", audit.StatusError, err.Error(), map[string]any{}, cwd)
+				}
+				return err
+			}
+
 			svc, err := app.NewActionService(".")
 			if err != nil {
 				if auditLogger != nil {
@@ -300,10 +327,42 @@ EXIT CODES:
 			return printJSON(out)
 		},
 	}
+
+	cmd.Flags().BoolVar(&useBase64, "base64", false, "Decode stdin as base64 before writing")
+
+	return cmd
 }
 
+// decodeWriteInput returns the content to propose for fs write.
+// With base64 enabled, stdin is decoded (surrounding whitespace and line
+// wrapping are ignored) so binary content survives the text-oriented pipeline.
+func decodeWriteInput(b []byte, useBase64 bool) ([]byte, error) {
+	if !useBase64 {
+		return b, nil
+	}
+
+	encoded := strings.Join(strings.Fields(string(b)), "")
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 input on stdin: %w", err)
+	}
+	if len(decoded) == 0 {
+		return nil, fmt.Errorf("base64 input on stdin decoded to empty content")
+	}
+	return decoded, nil
+}
+
+// printJSON prints v to stdout, indented unless --compact or
+// logging.compact_json asks for single-line output
 func printJSON(v any) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
+	return writeJSON(os.Stdout, v, compactMode || GetConfig().Logging.CompactJSON)
+}
+
+// writeJSON encodes v as one line when compact, otherwise indented
+func writeJSON(w io.Writer, v any, compact bool) error {
+	enc := json.NewEncoder(w)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
 	return enc.Encode(v)
 }
@@ -15,6 +15,10 @@ Applies the changes specified in the proposal. The proposal ID is provided
 when you run 'goshi fs write'. Use '--dry-run' to preview changes without
 applying them.
 
+Multi-file proposals (from the fs.write-many tool) are applied
+all-or-nothing: if any file has drifted or cannot be written, none of the
+files are changed.
+
 EXAMPLES:
   $ goshi fs apply abc123def456
 
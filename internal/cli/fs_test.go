@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/fs"
+)
+
+func TestDecodeWriteInput_Plain(t *testing.T) {
+	in := []byte("hello\n")
+	out, err := decodeWriteInput(in, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(out, in) {
+		t.Errorf("expected input to pass through unchanged, got %q", out)
+	}
+}
+
+func TestDecodeWriteInput_Base64Invalid(t *testing.T) {
+	_, err := decodeWriteInput([]byte("not*valid*base64"), true)
+	if err == nil {
+		t.Fatal("expected error for invalid base64 input")
+	}
+	if !strings.Contains(err.Error(), "invalid base64") {
+		t.Errorf("expected clear decode error, got %v", err)
+	}
+}
+
+func TestFSWrite_Base64BinaryRoundTrip(t *testing.T) {
+	workspace := t.TempDir()
+	oldwd, _ := os.Getwd()
+	defer os.Chdir(oldwd)
+	if err := os.Chdir(workspace); err != nil {
+		t.Fatalf("chdir failed: %v", err)
+	}
+
+	original := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe, '\r', '\n', 0x1a}
+	encoded := base64.StdEncoding.EncodeToString(original)
+
+	// Simulate wrapped base64 output as produced by the base64 utility
+	decoded, err := decodeWriteInput([]byte(encoded[:8]+"\n"+encoded[8:]+"\n"), true)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	svc, err := app.NewActionService(".")
+	if err != nil {
+		t.Fatalf("failed to create action service: %v", err)
+	}
+
+	out, err := svc.RunAction("fs.write", map[string]any{
+		"path":    "logo.bin",
+		"content": string(decoded),
+	})
+	if err != nil {
+		t.Fatalf("fs.write failed: %v", err)
+	}
+
+	id, _ := out["id"].(string)
+	if err := fs.ApplyWriteProposal(id); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+
+	written, err := os.ReadFile(filepath.Join(workspace, "logo.bin"))
+	if err != nil {
+		t.Fatalf("failed to read written file: %v", err)
+	}
+	if !bytes.Equal(written, original) {
+		t.Errorf("written bytes %v do not match original %v", written, original)
+	}
+}
+
+func TestWriteJSON_CompactAndPretty(t *testing.T) {
+	v := map[string]any{"path": "a.txt", "entries": []string{"x", "y"}}
+
+	var compact bytes.Buffer
+	if err := writeJSON(&compact, v, true); err != nil {
+		t.Fatalf("compact encode failed: %v", err)
+	}
+	out := strings.TrimSuffix(compact.String(), "\n")
+	if strings.Contains(out, "\n") || strings.Contains(out, "  ") {
+		t.Errorf("expected single-line JSON, got %q", compact.String())
+	}
+
+	var pretty bytes.Buffer
+	if err := writeJSON(&pretty, v, false); err != nil {
+		t.Fatalf("pretty encode failed: %v", err)
+	}
+	if !strings.Contains(pretty.String(), "\n  \"entries\": [\n    \"x\",") {
+		t.Errorf("expected two-space indentation, got %q", pretty.String())
+	}
+}
@@ -35,6 +35,7 @@ func newHealCmd(cfg *config.Config) *cobra.Command {
 	var jsonCompat bool
 	var dryRun bool
 	var yes bool
+	var minSeverity string
 	cmd := &cobra.Command{
 		Use:   "heal",
 		Short: "Repair detected environment issues",
@@ -57,6 +58,7 @@ repairs. Confirmation is required before execution (unless --yes is given).
 FLAGS:
   --dry-run=true      Run in dry-run mode (default: true for safety)
   --yes               Skip confirmation prompts and proceed automatically
+  --min-severity=warn Only repair issues at or above this severity: warn or error
   --format=human      Output format: json, yaml, or human (default: human)
   --json              (DEPRECATED) Use --format=json instead
 
@@ -78,7 +80,10 @@ EXAMPLES:
      $ goshi heal --format=json
      Returns JSON output showing the mode and status.
 
-  5. Full pipeline: preview, then execute:
+  5. Repair only errors, ignoring warnings, in an automated run:
+     $ goshi heal --dry-run=false --yes --min-severity=error
+
+  6. Full pipeline: preview, then execute:
      $ goshi heal                           # First, see what needs fixing
      $ goshi heal --dry-run=false --yes    # Then execute with confirmation skipped
 
@@ -101,6 +106,10 @@ SEE ALSO:
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg.DryRun = dryRun
 			cfg.Yes = yes
+			threshold := diagnose.Severity(minSeverity)
+			if threshold != diagnose.SeverityWarn && threshold != diagnose.SeverityError {
+				return fmt.Errorf("unknown severity: %s (use 'warn' or 'error')", minSeverity)
+			}
 			outFmt := format
 			if outFmt == "" && jsonCompat {
 				outFmt = "json"
@@ -161,6 +170,11 @@ SEE ALSO:
 					return err
 				}
 
+				// Issues below the threshold are left out of the plan
+				if kept := diagnose.FilterBySeverity(diag.Issues, threshold); len(kept) < len(diag.Issues) {
+					fmt.Printf("Skipping %d issue(s) below %s severity\n", len(diag.Issues)-len(kept), threshold)
+				}
+
 				// --- integrity diagnostics ---
 				integrityDiag := integrity.NewIntegrityDiagnostic()
 				manifest, integrityResult, integrityErr := integrityDiag.PlanRepair()
@@ -189,7 +203,7 @@ SEE ALSO:
 				}
 
 				// --- plan ---
-				r := &repair.BasicRepairer{}
+				r := &repair.BasicRepairer{MinSeverity: threshold}
 				plan, err := r.Plan(diag)
 				if err != nil {
 					return err
@@ -285,5 +299,6 @@ SEE ALSO:
 	cmd.Flags().BoolVar(&jsonCompat, "json", false, "(DEPRECATED) Output JSON (use --format=json)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", true, "Run in dry-run mode (default: true)")
 	cmd.Flags().BoolVar(&yes, "yes", false, "Skip confirmation prompts")
+	cmd.Flags().StringVar(&minSeverity, "min-severity", string(diagnose.SeverityWarn), "Only repair issues at or above this severity: warn or error")
 	return cmd
 }
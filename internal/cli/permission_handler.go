@@ -2,8 +2,11 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
 
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/config"
 	"github.com/cshaiku/goshi/internal/detect"
 	"github.com/cshaiku/goshi/internal/session"
 )
@@ -13,6 +16,7 @@ import (
 type PermissionHandler struct {
 	display    *DisplayConfig
 	workingDir string
+	out        io.Writer // Where detection reasons and refusals are shown
 }
 
 // NewPermissionHandler creates a permission handler
@@ -20,37 +24,29 @@ func NewPermissionHandler(workingDir string, display *DisplayConfig) *Permission
 	return &PermissionHandler{
 		workingDir: workingDir,
 		display:    display,
+		out:        os.Stderr,
 	}
 }
 
-// HandleDetected processes detected capabilities and requests permission from user
-func (h *PermissionHandler) HandleDetected(detected []detect.Capability, sess *session.ChatSession, systemPrompt string) bool {
-	for _, cap := range detected {
-		switch cap {
-		case detect.CapabilityFSRead:
-			if !session.RequestFSReadPermission(h.workingDir) {
-				return h.refuseFSRead(detected, sess)
-			}
-			sess.GrantPermission(string(cap))
-		case detect.CapabilityFSWrite:
-			if !session.RequestFSWritePermission(h.workingDir) {
-				return h.refuseFSWrite(detected, sess)
-			}
-			sess.GrantPermission(string(cap))
+// HandleDetected requests permission for each detected capability. Unless
+// safety.explain_detection is off, the rule that triggered a request is
+// shown before the user is prompted.
+func (h *PermissionHandler) HandleDetected(matches []detect.Match, sess *session.ChatSession, systemPrompt string) bool {
+	var explain func(capability, reason string)
+	if config.Load().Safety.ExplainDetection {
+		explain = func(_, reason string) {
+			fmt.Fprintf(h.out, "%s\n", h.display.Colorize(reason, ColorYellow))
 		}
 	}
+	// The session's prompter asks the user and records each decision
+	if denied := sess.RequestDetectedPermissions(matches, explain); denied != "" {
+		return h.refuse(app.Capability(denied))
+	}
 	return true
 }
 
-func (h *PermissionHandler) refuseFSRead(detected []detect.Capability, sess *session.ChatSession) bool {
-	fmt.Fprintf(os.Stderr, "%s\n", h.display.Colorize("Permission denied: FS_READ", ColorRed))
-	sess.DenyPermission(string(detect.CapabilityFSRead))
-	return false
-}
-
-func (h *PermissionHandler) refuseFSWrite(detected []detect.Capability, sess *session.ChatSession) bool {
-	fmt.Fprintf(os.Stderr, "%s\n", h.display.Colorize("Permission denied: FS_WRITE", ColorRed))
-	sess.DenyPermission(string(detect.CapabilityFSWrite))
+func (h *PermissionHandler) refuse(permission app.Capability) bool {
+	fmt.Fprintf(h.out, "%s\n", h.display.Colorize("Permission denied: "+string(permission), ColorRed))
 	return false
 }
 
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/detect"
+	"github.com/cshaiku/goshi/internal/llm"
+	"github.com/cshaiku/goshi/internal/session"
+)
+
+// recordingPrompter captures what had been shown when the prompt appeared
+type recordingPrompter struct {
+	out   *bytes.Buffer
+	shown []string
+}
+
+func (p *recordingPrompter) Ask(capability string, cwd string) bool {
+	p.shown = append(p.shown, p.out.String())
+	return true
+}
+
+// noopBackend satisfies llm.Backend for sessions that never call the model
+type noopBackend struct{}
+
+func (noopBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	return nil, nil
+}
+
+func newPermissionTestSession(t *testing.T, yaml string) *session.ChatSession {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), "goshi.yaml")
+	if err := os.WriteFile(cfgPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	t.Setenv("GOSHI_AUDIT_ENABLED", "false")
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	sess, err := session.NewChatSession(context.Background(), "test", noopBackend{})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	return sess
+}
+
+func TestHandleDetected_ShowsTriggeringRuleBeforePrompt(t *testing.T) {
+	sess := newPermissionTestSession(t, "safety:\n  explain_detection: true\n")
+	var out bytes.Buffer
+	prompter := &recordingPrompter{out: &out}
+	sess.Prompter = prompter
+
+	h := NewPermissionHandler(sess.WorkingDir, &DisplayConfig{})
+	h.out = &out
+
+	matches := detect.DetectMatches("please list the files", detect.FSReadRules)
+	if !h.HandleDetected(matches, sess, "test") {
+		t.Fatal("expected the request to be granted")
+	}
+
+	if len(prompter.shown) != 1 {
+		t.Fatalf("expected one prompt, got %d", len(prompter.shown))
+	}
+	shown := prompter.shown[0]
+	if !strings.Contains(shown, "Requesting FS_READ") || !strings.Contains(shown, `"list the files"`) ||
+		!strings.Contains(shown, `verb "list"`) {
+		t.Errorf("expected the triggering rule before the prompt, got %q", shown)
+	}
+	if !sess.HasPermission("FS_READ") {
+		t.Error("expected FS_READ to be granted")
+	}
+}
+
+func TestHandleDetected_ExplanationCanBeDisabled(t *testing.T) {
+	sess := newPermissionTestSession(t, "safety:\n  explain_detection: false\n")
+	var out bytes.Buffer
+	prompter := &recordingPrompter{out: &out}
+	sess.Prompter = prompter
+
+	h := NewPermissionHandler(sess.WorkingDir, &DisplayConfig{})
+	h.out = &out
+
+	h.HandleDetected(detect.DetectMatches("read the file", detect.FSReadRules), sess, "test")
+	if len(prompter.shown) != 1 || prompter.shown[0] != "" {
+		t.Errorf("expected no explanation when disabled, got %q", prompter.shown)
+	}
+}
+
+// TestHandleDetected_AutoApprovesReadOnly tests that auto_approve_read_only
+// grants a detected read without prompting, but still prompts for writes
+func TestHandleDetected_AutoApprovesReadOnly(t *testing.T) {
+	sess := newPermissionTestSession(t, "safety:\n  auto_approve_read_only: true\n")
+	var out bytes.Buffer
+	prompter := &recordingPrompter{out: &out}
+	sess.Prompter = prompter
+
+	h := NewPermissionHandler(sess.WorkingDir, &DisplayConfig{})
+	h.out = &out
+
+	if !h.HandleDetected(detect.DetectMatches("please list the files", detect.FSReadRules), sess, "test") {
+		t.Fatal("expected the read to be granted")
+	}
+	if len(prompter.shown) != 0 {
+		t.Errorf("expected no prompt for a read-only capability, got %d", len(prompter.shown))
+	}
+	if !sess.HasPermission("FS_READ") {
+		t.Error("expected FS_READ granted")
+	}
+
+	h.HandleDetected(detect.DetectMatches("write the file", detect.FSWriteRules), sess, "test")
+	if len(prompter.shown) != 1 {
+		t.Errorf("expected a prompt for FS_WRITE, got %d", len(prompter.shown))
+	}
+}
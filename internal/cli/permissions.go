@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cshaiku/goshi/internal/audit"
+	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func newPermissionsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "permissions",
+		Short: "Review permission decisions",
+		Long: `Review the capability grants and denials recorded for a session.
+
+SUBCOMMANDS:
+  goshi permissions log     - Show a session's grant/deny history
+  goshi permissions status  - Show which capabilities a session holds`,
+	}
+
+	cmd.AddCommand(newPermissionsLogCommand())
+	cmd.AddCommand(newPermissionsStatusCommand())
+	return cmd
+}
+
+func newPermissionsLogCommand() *cobra.Command {
+	var format string
+	var sessionID string
+
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show a session's grant/deny history",
+		Long: `Show the permission decisions recorded in a session's audit log, in the
+order they were made. Defaults to the latest session.
+
+EXAMPLES:
+  goshi permissions log
+  goshi permissions log --format=json
+  goshi permissions log --session=session-20260210-153000.000-1234`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			auditDir, err := resolveAuditDir(config.Load())
+			if err != nil {
+				return err
+			}
+			filePath, err := resolveSessionFile(auditDir, sessionID)
+			if err != nil {
+				return err
+			}
+
+			perms, err := readSessionPermissions(filePath)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "json":
+				data, err := perms.ExportJSON()
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(out, string(data))
+				return nil
+			case "human", "":
+				if len(perms.AuditLog) == 0 {
+					fmt.Fprintln(out, "No permission decisions recorded.")
+					return nil
+				}
+				fmt.Fprint(out, perms.GetAuditTrail())
+				return nil
+			default:
+				return fmt.Errorf("unknown format: %s (use human or json)", format)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "human", "Output format: human or json")
+	cmd.Flags().StringVar(&sessionID, "session", "", "Session ID or filename (default: latest)")
+	return cmd
+}
+
+func newPermissionsStatusCommand() *cobra.Command {
+	var format string
+	var sessionID string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show which capabilities a session holds",
+		Long: `Show each capability's current state in a session: granted, denied or not
+requested, with the directory the decision was made in and why. Decisions
+do not expire; they last until the session ends. Defaults to the latest
+session.
+
+EXAMPLES:
+  goshi permissions status
+  goshi permissions status --format=json
+  goshi permissions status --session=session-20260210-153000.000-1234`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			auditDir, err := resolveAuditDir(config.Load())
+			if err != nil {
+				return err
+			}
+			filePath, err := resolveSessionFile(auditDir, sessionID)
+			if err != nil {
+				return err
+			}
+
+			perms, err := readSessionPermissions(filePath)
+			if err != nil {
+				return err
+			}
+			statuses := perms.Status()
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "json":
+				data, err := json.MarshalIndent(statuses, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(out, string(data))
+				return nil
+			case "human", "":
+				fmt.Fprint(out, session.FormatStatus(statuses))
+				return nil
+			default:
+				return fmt.Errorf("unknown format: %s (use human or json)", format)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "human", "Output format: human or json")
+	cmd.Flags().StringVar(&sessionID, "session", "", "Session ID or filename (default: latest)")
+	return cmd
+}
+
+// readSessionPermissions rebuilds a session's permissions from its audit log
+func readSessionPermissions(filePath string) (*session.Permissions, error) {
+	events, err := audit.ReadEvents(filePath, audit.Filter{
+		Types: map[audit.EventType]bool{audit.EventTypePermission: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session.PermissionsFromEvents(events), nil
+}
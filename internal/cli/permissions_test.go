@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/audit"
+	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/session"
+)
+
+func TestPermissionsLogCommand(t *testing.T) {
+	tmp := t.TempDir()
+	auditDir := filepath.Join(tmp, "audit")
+
+	logger, err := audit.NewLogger(audit.Config{Enabled: true, Dir: auditDir}, tmp)
+	if err != nil {
+		t.Fatalf("failed to create audit logger: %v", err)
+	}
+	perms := &session.Permissions{Logger: logger}
+	perms.Grant("FS_READ", tmp)
+	perms.Deny("FS_WRITE", tmp)
+	logger.Close()
+
+	cfgPath := filepath.Join(tmp, "goshi.yaml")
+	if err := os.WriteFile(cfgPath, []byte("audit:\n  dir: "+auditDir+"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	cmd := newPermissionsLogCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--format=json", "--session=" + filepath.Base(logger.FilePath())})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("permissions log failed: %v", err)
+	}
+
+	var entries []session.PermissionEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(entries) != 2 || entries[0].Action != "GRANT" || entries[1].Action != "DENY" {
+		t.Errorf("expected GRANT then DENY, got %+v", entries)
+	}
+}
+
+func TestPermissionsStatusCommand(t *testing.T) {
+	tmp := t.TempDir()
+	auditDir := filepath.Join(tmp, "audit")
+	scope := filepath.Join(tmp, "project")
+
+	logger, err := audit.NewLogger(audit.Config{Enabled: true, Dir: auditDir}, tmp)
+	if err != nil {
+		t.Fatalf("failed to create audit logger: %v", err)
+	}
+	perms := &session.Permissions{Logger: logger}
+	perms.StartupGrant("FS_READ", scope)
+	perms.Deny("FS_WRITE", scope)
+	logger.Close()
+
+	cfgPath := filepath.Join(tmp, "goshi.yaml")
+	if err := os.WriteFile(cfgPath, []byte("audit:\n  dir: "+auditDir+"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	cmd := newPermissionsStatusCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--format=json", "--session=" + filepath.Base(logger.FilePath())})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("permissions status failed: %v", err)
+	}
+
+	var statuses []session.CapabilityStatus
+	if err := json.Unmarshal(out.Bytes(), &statuses); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected a status per capability, got %+v", statuses)
+	}
+	if read := statuses[0]; read.State != "granted" || read.Scope != scope || read.Reason != "config-default-grant" {
+		t.Errorf("expected FS_READ granted by config in %s, got %+v", scope, read)
+	}
+	if write := statuses[1]; write.State != "denied" || write.Scope != scope {
+		t.Errorf("expected FS_WRITE denied in %s, got %+v", scope, write)
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// progressFrames animate the spinner shown while a tool runs
+var progressFrames = []string{"|", "/", "-", "\\"}
+
+// progressInterval is how often the spinner line is redrawn
+const progressInterval = 200 * time.Millisecond
+
+// clearLine returns the cursor to the start of the line and erases it
+const clearLine = "\r\033[K"
+
+// runWithProgress runs fn and returns its result. Once fn has run longer
+// than after, a spinner line with the label and elapsed time is drawn on
+// out every interval; it is cleared before runWithProgress returns. An
+// after of 0 or less disables the spinner.
+func runWithProgress(out io.Writer, label string, after, interval time.Duration, fn func() any) any {
+	if after <= 0 {
+		return fn()
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		start := time.Now()
+		select {
+		case <-done:
+			return
+		case <-time.After(after):
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			fmt.Fprintf(out, "%s%s %s (%s)", clearLine, progressFrames[frame%len(progressFrames)], label,
+				time.Since(start).Round(time.Second))
+			select {
+			case <-done:
+				fmt.Fprint(out, clearLine)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	result := fn()
+	close(done)
+	wg.Wait()
+	return result
+}
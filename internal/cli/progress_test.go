@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunWithProgress_ShowsAndClearsSpinnerForSlowTool(t *testing.T) {
+	var out bytes.Buffer
+	result := runWithProgress(&out, "Running slow.tool", 10*time.Millisecond, 10*time.Millisecond, func() any {
+		time.Sleep(80 * time.Millisecond)
+		return "done"
+	})
+
+	if result != "done" {
+		t.Errorf("expected the tool result to be returned, got %v", result)
+	}
+	got := out.String()
+	if !strings.Contains(got, "Running slow.tool") {
+		t.Errorf("expected the progress indicator, got %q", got)
+	}
+	if !strings.HasSuffix(got, clearLine) {
+		t.Errorf("expected the indicator to be cleared on completion, got %q", got)
+	}
+}
+
+func TestRunWithProgress_SilentForFastTool(t *testing.T) {
+	var out bytes.Buffer
+	runWithProgress(&out, "Running fast.tool", time.Second, 10*time.Millisecond, func() any { return nil })
+	if out.Len() != 0 {
+		t.Errorf("expected no indicator for a fast tool, got %q", out.String())
+	}
+
+	runWithProgress(&out, "Running slow.tool", 0, 10*time.Millisecond, func() any {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	if out.Len() != 0 {
+		t.Errorf("expected no indicator when disabled, got %q", out.String())
+	}
+}
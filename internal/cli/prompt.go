@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newPromptCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Inspect the system prompt sent to the model",
+		Long: `Inspect the system prompt goshi sends to the model.
+
+SEE ALSO:
+  goshi help prompt show  - Print the merged system prompt`,
+	}
+
+	cmd.AddCommand(newPromptShowCommand())
+	return cmd
+}
+
+func newPromptShowCommand() *cobra.Command {
+	var provider string
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the exact system prompt that would be sent",
+		Long: `Print the merged system prompt exactly as the backend sends it:
+the self-model laws, the persona (if any), and the tool-usage instructions.
+
+No request is made and no credentials are needed.
+
+EXAMPLES:
+  $ goshi prompt show
+
+  $ goshi prompt show --persona="Terse and dry."
+
+  $ goshi prompt show --provider=openai`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if provider == "" {
+				provider = GetConfig().LLMProvider
+			}
+			prompt, err := renderSystemPrompt(provider)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), prompt)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&provider, "provider", "", "Provider whose prompt to show (default: configured provider)")
+	return cmd
+}
+
+// renderSystemPrompt assembles the combined system prompt for a provider
+func renderSystemPrompt(provider string) (string, error) {
+	if runtime == nil || runtime.SystemPrompt == nil {
+		return "", fmt.Errorf("system prompt not initialized")
+	}
+
+	prompt, err := resolveSystemPrompt()
+	if err != nil {
+		return "", err
+	}
+	cfg := GetConfig()
+	return NewBackendFactory(provider, "").
+		WithToolMode(cfg.LLM.ToolMode).
+		WithToolFilter(cfg.Safety.AllowedTools, cfg.Safety.DisabledTools).
+		BuildSystemPrompt(prompt.Raw())
+}
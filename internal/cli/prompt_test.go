@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+func withTestRuntime(t *testing.T, selfModel string) {
+	t.Helper()
+	sp, err := llm.NewSystemPrompt(selfModel)
+	if err != nil {
+		t.Fatalf("NewSystemPrompt failed: %v", err)
+	}
+	previous := runtime
+	runtime = &Runtime{SystemPrompt: sp}
+	t.Cleanup(func() { runtime = previous })
+}
+
+func TestRenderSystemPrompt(t *testing.T) {
+	withTestRuntime(t, "primary_laws:\n  - Restraint\n  - Safety\n  - Truth\n")
+
+	for _, provider := range []string{"ollama", "openai"} {
+		prompt, err := renderSystemPrompt(provider)
+		if err != nil {
+			t.Fatalf("%s: renderSystemPrompt failed: %v", provider, err)
+		}
+		if !strings.Contains(prompt, "primary_laws") || !strings.Contains(prompt, "Restraint") {
+			t.Errorf("%s: expected the self-model laws in the prompt", provider)
+		}
+		if !strings.Contains(prompt, "Tool Usage Instructions") {
+			t.Errorf("%s: expected the tool-usage instructions in the prompt", provider)
+		}
+		if strings.Index(prompt, "Truth") > strings.Index(prompt, "Tool Usage Instructions") {
+			t.Errorf("%s: expected the laws before the tool instructions", provider)
+		}
+	}
+}
+
+func TestRenderSystemPromptIncludesPersona(t *testing.T) {
+	withTestRuntime(t, "primary_laws:\n  - Truth\n")
+	personaFlag = "Terse and dry."
+	t.Cleanup(func() { personaFlag = "" })
+
+	prompt, err := renderSystemPrompt("ollama")
+	if err != nil {
+		t.Fatalf("renderSystemPrompt failed: %v", err)
+	}
+	if !strings.Contains(prompt, "Terse and dry.") {
+		t.Error("expected the persona in the prompt")
+	}
+}
+
+func TestRenderSystemPromptUnknownProvider(t *testing.T) {
+	withTestRuntime(t, "primary_laws:\n  - Truth\n")
+
+	if _, err := renderSystemPrompt("nope"); err == nil {
+		t.Error("expected an unknown provider to fail")
+	}
+}
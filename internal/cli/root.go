@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/diagnostics/integrity"
+	"github.com/cshaiku/goshi/internal/llm"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +18,10 @@ var globalConfig *config.Config
 // Mode flags
 var (
 	headlessMode bool
+	logprobsMode bool
+	strictMode   bool
+	compactMode  bool
+	personaFlag  string
 )
 
 var rootCmd = &cobra.Command{
@@ -81,7 +87,9 @@ INTERACTIVE MODES:
     Traditional command-line interface suitable for piping and automation.
 
 ENVIRONMENT VARIABLES:
-  GOSHI_CONFIG        - Path to configuration file (overrides file search)
+  GOSHI_CONFIG        - Path or https URL of the configuration file (overrides file search;
+                        goshi refuses to start if the URL cannot be loaded)
+  GOSHI_CONFIG_INSECURE - Set to true to allow an http:// GOSHI_CONFIG URL
   GOSHI_MODEL         - LLM model to use (overrides config file)
   GOSHI_LLM_PROVIDER  - LLM provider: ollama, openai, etc.
   GOSHI_OLLAMA_URL    - Ollama server URL
@@ -99,15 +107,39 @@ ENVIRONMENT VARIABLES:
 			os.Exit(1)
 		}
 
+		// Strict mode turns the integrity and law checks into a startup gate
+		if strictMode || GetConfig().Safety.Strict {
+			if err := strictStartupCheck(runtime.SystemPrompt.Raw(), integrity.NewIntegrityDiagnostic()); err != nil {
+				fmt.Fprintf(os.Stderr, "startup aborted: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		prompt, err := resolveSystemPrompt()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(1)
+		}
+
 		// Check if we should run in TUI or headless/CLI mode
 		if headlessMode {
-			runChat(runtime.SystemPrompt.Raw())
+			runChat(prompt.Raw())
 		} else {
-			runTUIMode(runtime.SystemPrompt.Raw())
+			runTUIMode(prompt.Raw())
 		}
 	},
 }
 
+// resolveSystemPrompt layers the configured persona (tone/style) after the
+// self-model laws
+func resolveSystemPrompt() (*llm.SystemPrompt, error) {
+	persona := GetConfig().LLM.Persona
+	if personaFlag != "" {
+		persona = personaFlag
+	}
+	return runtime.SystemPrompt.WithPersona(persona)
+}
+
 // GetConfig returns the globally loaded config
 func GetConfig() *config.Config {
 	if globalConfig == nil {
@@ -122,16 +154,32 @@ func Execute(rt *Runtime) {
 	cfg := config.Load()
 	globalConfig = &cfg
 
+	// A centrally managed config that cannot be fetched fails closed
+	if err := config.RemoteConfigError(); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Add mode flags
 	rootCmd.PersistentFlags().BoolVar(&headlessMode, "headless", false, "Run in headless/CLI mode (no TUI)")
+	rootCmd.PersistentFlags().BoolVar(&logprobsMode, "logprobs", false, "Request token logprobs and show response confidence (OpenAI only)")
+	rootCmd.PersistentFlags().StringVar(&personaFlag, "persona", "", "Tone/style persona layered after the self-model laws (overrides llm.persona)")
+	rootCmd.PersistentFlags().BoolVar(&compactMode, "compact", false, "Print JSON output on a single line (or set logging.compact_json)")
+	rootCmd.PersistentFlags().BoolVar(&strictMode, "strict", false, "Refuse to start chat on any integrity or self-model law anomaly")
 
 	// Register all subcommands
 	rootCmd.AddCommand(
 		newFSCommand(),
 		newAuditCommand(),
+		newPermissionsCommand(),
 		newDoctorCmd(&cfg),
 		newHealCmd(&cfg),
 		newConfigCommand(),
+		newToolsCommand(),
+		newPromptCommand(),
+		newServeCommand(),
+		newBenchCommand(),
+		newSelftestCommand(),
 		newVersionCmd(),
 	)
 
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func newSelftestCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Exercise every tool end-to-end in a temporary workspace",
+		Long: `Run each registered tool through a canned call against a temporary
+directory, without contacting a model: a mock backend requests each call,
+which is parsed like a real model's. For every tool the self-test checks
+that the canned call matches its schema, that the call is refused until the
+required capability is granted, and that the granted call returns the
+expected result shape.
+
+EXAMPLES:
+  $ goshi selftest
+
+  $ goshi selftest --format=json
+
+EXIT CODES:
+  0   - Every tool passed
+  1   - At least one tool failed`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := app.RunSelfTest(app.NewDefaultToolRegistry(), app.DefaultSelfTestCases())
+			if err != nil {
+				return err
+			}
+
+			switch format {
+			case "json":
+				if err := printJSON(results); err != nil {
+					return err
+				}
+			case "", "human":
+				printSelfTestResults(os.Stdout, results, DefaultDisplayConfig())
+			default:
+				return fmt.Errorf("unknown format: %s (use 'json' or 'human')", format)
+			}
+
+			for _, r := range results {
+				if !r.Passed {
+					os.Exit(1)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "human", "Output format (human or json)")
+	return cmd
+}
+
+// printSelfTestResults renders one pass/fail line per tool and a summary
+func printSelfTestResults(w io.Writer, results []app.SelfTestResult, display *DisplayConfig) {
+	failed := 0
+	for _, r := range results {
+		if r.Passed {
+			fmt.Fprintf(w, "%s %s\n", display.Colorize("✓", ColorGreen), r.Tool)
+			continue
+		}
+		failed++
+		fmt.Fprintf(w, "%s %s: %s\n", display.Colorize("✗", ColorRed), r.Tool, r.Error)
+	}
+	fmt.Fprintf(w, "\n%d/%d tools passed\n", len(results)-failed, len(results))
+}
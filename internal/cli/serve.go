@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/diagnostics/integrity"
+	"github.com/cshaiku/goshi/internal/metrics"
+	"github.com/cshaiku/goshi/internal/rpc"
+	"github.com/cshaiku/goshi/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func newServeCommand() *cobra.Command {
+	var stdio bool
+	var metricsAddr string
+
+	cmd := &cobra.Command{
+		Use:   "serve --stdio",
+		Short: "Serve chat and tools over JSON-RPC for editor integrations",
+		Long: `Expose the chat session over JSON-RPC 2.0 so editors and plugins can drive
+goshi programmatically. Each request and response is one JSON object per line.
+
+METHODS:
+  chat.send      {"message": "..."}  Run one user turn; returns the final text
+  tools.list                         List the tools available to the model
+  session.reset                      Start a fresh session
+
+While chat.send runs, response chunks stream as "chat.chunk" notifications
+and each tool the model runs is reported as a "chat.step" notification.
+Permissions come from safety.default_grants and the auto-approve settings;
+there is no interactive prompt, so a tool needing any other capability is
+refused. chat.send fails once behavior.max_turns is used up, and --strict
+refuses to serve on any integrity or self-model law anomaly.
+
+With --metrics-addr, request counts, latency percentiles, token usage, cost
+and circuit-breaker state are served at /metrics in the Prometheus text
+format.
+
+EXAMPLES:
+  $ echo '{"jsonrpc":"2.0","id":1,"method":"tools.list"}' | goshi serve --stdio
+  $ goshi serve --stdio --metrics-addr 127.0.0.1:9464`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !stdio {
+				return fmt.Errorf("only --stdio transport is supported")
+			}
+
+			// Strict mode gates serving like it gates the chat
+			if strictMode || GetConfig().Safety.Strict {
+				if err := strictStartupCheck(runtime.SystemPrompt.Raw(), integrity.NewIntegrityDiagnostic()); err != nil {
+					return fmt.Errorf("serve aborted: %w", err)
+				}
+			}
+
+			prompt, err := resolveSystemPrompt()
+			if err != nil {
+				return err
+			}
+
+			collector := metrics.NewCollector()
+			if metricsAddr != "" {
+				if err := serveMetrics(metricsAddr, collector); err != nil {
+					return err
+				}
+			}
+
+			srv, err := rpc.NewServer(func() (*session.ChatSession, error) {
+				return newServeSession(prompt.Raw(), collector)
+			})
+			if err != nil {
+				return err
+			}
+			return srv.Serve(cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().BoolVar(&stdio, "stdio", false, "Serve JSON-RPC on stdin/stdout")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics at /metrics on this address")
+	return cmd
+}
+
+// serveMetrics serves the collector's metrics over HTTP in the background.
+// Listening happens up front so a bad address fails the command.
+func serveMetrics(addr string, collector *metrics.Collector) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to serve metrics on %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", collector)
+	go func() {
+		_ = http.Serve(listener, mux)
+	}()
+	return nil
+}
+
+// newServeSession creates a chat session for the JSON-RPC server whose
+// requests are recorded in collector
+func newServeSession(systemPrompt string, collector *metrics.Collector) (*session.ChatSession, error) {
+	cfg := config.Load()
+	provider, model := resolveProvider(cfg)
+	factory := NewBackendFactory(provider, model).
+		WithLogprobs(cfg.LLM.Logprobs || logprobsMode).
+		WithToolMode(cfg.LLM.ToolMode).
+		WithToolFilter(cfg.Safety.AllowedTools, cfg.Safety.DisabledTools).
+		WithReuseRequestTemplate(cfg.LLM.ReuseTemplate).
+		WithUnknownModelPricing(cfg.LLM.UnknownPricing).
+		WithTimeouts(time.Duration(cfg.LLM.RequestTimeout)*time.Second, time.Duration(cfg.LLM.IdleTimeout)*time.Second)
+	backend, err := factory.Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize LLM backend (supported providers: %s): %w", strings.Join(SupportedProviders(), ", "), err)
+	}
+	backend = collector.Instrument(backend)
+
+	sess, err := session.NewChatSession(context.Background(), systemPrompt, backend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize chat session: %w", err)
+	}
+	sess.Provider, sess.Model = provider, model
+	// Stdin carries JSON-RPC, so capabilities can't be asked for there
+	sess.Prompter = nil
+	return sess, nil
+}
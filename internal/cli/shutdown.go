@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/cshaiku/goshi/internal/session"
+)
+
+// shutdownOnSignal shuts the session down and exits when SIGINT or SIGTERM
+// arrives, so an interrupted chat still saves its state and closes the
+// audit log. The turn in flight is aborted through cancel, and the session
+// is only saved once the chat loop has released turns, so it is never saved
+// while a turn is changing it. A second signal exits without waiting, e.g.
+// when the turn is blocked on a permission prompt. The returned function
+// stops listening.
+func shutdownOnSignal(sess *session.ChatSession, cancel context.CancelFunc, turns *sync.Mutex) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-signals:
+		case <-done:
+			return
+		}
+
+		fmt.Fprintln(os.Stderr, "\ninterrupted, shutting down")
+		cancel()
+		idle := make(chan struct{})
+		go func() {
+			turns.Lock()
+			close(idle)
+		}()
+		select {
+		case <-idle:
+			shutdownSession(sess)
+		case <-signals:
+		}
+		os.Exit(signalExitCode(sig))
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// signalExitCode is the shell convention for a process killed by sig:
+// 128 plus the signal number, so 130 for SIGINT and 143 for SIGTERM
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// shutdownSession runs the session's shutdown, reporting failures
+func shutdownSession(sess *session.ChatSession) {
+	if err := sess.Shutdown(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: shutdown: %v\n", err)
+	}
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestSignalExitCode(t *testing.T) {
+	cases := []struct {
+		sig  os.Signal
+		want int
+	}{
+		{os.Interrupt, 130},
+		{syscall.SIGTERM, 143},
+	}
+	for _, tc := range cases {
+		if got := signalExitCode(tc.sig); got != tc.want {
+			t.Errorf("signalExitCode(%v) = %d, want %d", tc.sig, got, tc.want)
+		}
+	}
+}
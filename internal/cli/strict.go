@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cshaiku/goshi/internal/diagnose"
+	"github.com/cshaiku/goshi/internal/diagnostics/integrity"
+	"github.com/cshaiku/goshi/internal/selfmodel"
+)
+
+// strictStartupCheck gates startup in strict mode: any integrity anomaly
+// (including a missing manifest) or a self-model without primary laws
+// refuses the chat
+func strictStartupCheck(systemPrompt string, integrityDiag *integrity.IntegrityDiagnostic) error {
+	var problems []string
+
+	for _, issue := range integrityDiag.Run() {
+		if issue.Severity != diagnose.SeverityOK {
+			problems = append(problems, fmt.Sprintf("[%s] %s", issue.Code, firstLine(issue.Message)))
+		}
+	}
+
+	if len(selfmodel.ExtractPrimaryLaws(systemPrompt)) == 0 {
+		problems = append(problems, "[SELFMODEL_NO_PRIMARY_LAWS] self-model declares no primary laws")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("strict mode refused to start:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// firstLine returns the first line of a possibly multi-line message
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/diagnostics/integrity"
+)
+
+const strictTestPrompt = "primary_laws:\n  - Restraint\n  - Safety\n  - Truth\n"
+
+// writeStrictManifest builds a repo with one tracked file and a manifest
+// recording fileHash for it
+func writeStrictManifest(t *testing.T, fileHash func(actual string) string) *integrity.IntegrityDiagnostic {
+	t.Helper()
+	root := t.TempDir()
+	sum := func(data []byte) string {
+		h := sha256.Sum256(data)
+		return hex.EncodeToString(h[:])
+	}
+
+	tarball := []byte("tarball")
+	source := []byte("package main\n")
+	if err := os.MkdirAll(filepath.Join(root, ".goshi"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".goshi", "goshi.source.tar.gz"), tarball, 0644); err != nil {
+		t.Fatalf("write tarball: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.go"), source, 0644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+
+	manifest := fmt.Sprintf("VERSION 2\nTARBALL %s %d .goshi/goshi.source.tar.gz\nFILE %s %d 0644 2026-02-10T00:00:00Z main.go\n",
+		sum(tarball), len(tarball), fileHash(sum(source)), len(source))
+	manifestPath := filepath.Join(root, ".goshi", "goshi.manifest")
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+
+	return &integrity.IntegrityDiagnostic{ManifestPath: manifestPath, RepoRoot: root}
+}
+
+func TestStrictStartupCheck_CleanProceeds(t *testing.T) {
+	diag := writeStrictManifest(t, func(actual string) string { return actual })
+
+	if err := strictStartupCheck(strictTestPrompt, diag); err != nil {
+		t.Errorf("expected clean startup, got %v", err)
+	}
+}
+
+func TestStrictStartupCheck_TamperedManifestAborts(t *testing.T) {
+	diag := writeStrictManifest(t, func(string) string { return strings.Repeat("0", 64) })
+
+	err := strictStartupCheck(strictTestPrompt, diag)
+	if err == nil || !strings.Contains(err.Error(), "INTEGRITY_HASH_MISMATCH") {
+		t.Errorf("expected tampering to abort startup, got %v", err)
+	}
+}
+
+func TestStrictStartupCheck_MissingLawsAborts(t *testing.T) {
+	diag := writeStrictManifest(t, func(actual string) string { return actual })
+
+	err := strictStartupCheck("human_greeting: hi\n", diag)
+	if err == nil || !strings.Contains(err.Error(), "SELFMODEL_NO_PRIMARY_LAWS") {
+		t.Errorf("expected missing primary laws to abort startup, got %v", err)
+	}
+}
+
+func TestStrictStartupCheck_MissingManifestAborts(t *testing.T) {
+	diag := &integrity.IntegrityDiagnostic{
+		ManifestPath: filepath.Join(t.TempDir(), "missing.manifest"),
+		RepoRoot:     t.TempDir(),
+	}
+
+	if err := strictStartupCheck(strictTestPrompt, diag); err == nil {
+		t.Error("expected a missing manifest to abort startup in strict mode")
+	}
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/fs"
+	"github.com/cshaiku/goshi/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+func newToolsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tools",
+		Short: "Inspect the tools available to the model",
+		Long: `Inspect the tools the model can call during a chat session.
+
+SEE ALSO:
+  goshi help tools explain   - Describe what a tool call would do
+  goshi help tools validate  - Check a model response against the tool schemas`,
+	}
+
+	cmd.AddCommand(newToolsExplainCommand())
+	cmd.AddCommand(newToolsValidateCommand())
+	return cmd
+}
+
+func newToolsExplainCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "explain <tool> '<json-args>'",
+		Short: "Describe what a tool call would do without executing it",
+		Long: `Validate a tool call and describe its effect without executing it.
+
+Arguments are checked against the tool's schema, path arguments are resolved
+to absolute paths inside the repository jail, and the capability the call
+requires is shown. Protected path policy refusals are reported too.
+
+EXAMPLES:
+  $ goshi tools explain fs.read '{"path": "README.md"}'
+
+  $ goshi tools explain fs.write '{"path": "notes.txt", "content": "hi"}'
+
+  $ goshi tools explain fs.list '{"path": ".", "detail": true}' --format=json
+
+EXIT CODES:
+  0   - Call is valid; explanation printed
+  1   - Unknown tool, invalid JSON, or arguments fail validation`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exp, err := explainToolCall(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			switch format {
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(exp)
+			case "", "human":
+				fmt.Print(formatToolExplanation(exp))
+				return nil
+			default:
+				return fmt.Errorf("unknown format: %s (use 'json' or 'human')", format)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "human", "Output format (human or json)")
+	return cmd
+}
+
+// explainToolCall parses the JSON arguments and explains the call relative
+// to the configured repository root and protected paths
+func explainToolCall(tool string, rawArgs string) (*app.ToolExplanation, error) {
+	var callArgs map[string]any
+	if err := json.Unmarshal([]byte(rawArgs), &callArgs); err != nil {
+		return nil, fmt.Errorf("invalid JSON arguments: %w", err)
+	}
+	if callArgs == nil {
+		callArgs = map[string]any{}
+	}
+
+	cfg := GetConfig()
+	root := cfg.Behavior.RepoRoot
+	if root == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		root = cwd
+	}
+
+	guard, err := fs.NewGuard(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.ExplainToolCall(
+		app.NewDefaultToolRegistry(),
+		guard,
+		app.NewProtectedPaths(root, cfg.Safety.ProtectedPaths),
+		app.ToolCall{Name: tool, Args: callArgs},
+	)
+}
+
+// formatToolExplanation renders an explanation for terminal output
+func formatToolExplanation(exp *app.ToolExplanation) string {
+	out := fmt.Sprintf("Tool:       %s (%s)\n", exp.Tool, exp.Name)
+	out += fmt.Sprintf("Capability: %s\n", exp.Capability)
+
+	names := make([]string, 0, len(exp.Paths))
+	for name := range exp.Paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out += fmt.Sprintf("Path:       %s (%s)\n", exp.Paths[name], name)
+	}
+
+	out += fmt.Sprintf("Effect:     %s\n", exp.Effect)
+	if exp.Refusal != "" {
+		out += fmt.Sprintf("Policy:     REFUSED - %s\n", exp.Refusal)
+	} else {
+		out += "Policy:     allowed once the capability is granted\n"
+	}
+	return out
+}
+
+func newToolsValidateCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "validate '<json>'",
+		Short: "Check a structured model response against the tool schemas",
+		Long: `Parse a model response the way a chat session would and, if it is a tool
+call, validate its arguments against the tool's schema. Every schema
+violation is reported. Nothing is executed and no backend is needed, which
+makes this useful when tuning prompts.
+
+EXAMPLES:
+  $ goshi tools validate '{"type": "action", "action": {"tool": "fs.read", "args": {"path": "README.md"}}}'
+
+  $ goshi tools validate '{"type": "action", "action": {"tool": "fs.write", "args": {"path": 1}}}' --format=json
+
+EXIT CODES:
+  0   - The response is a tool call that passes validation
+  1   - The response is not a tool call, or the call fails validation`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result := validateToolCallJSON(args[0])
+
+			switch format {
+			case "json":
+				if err := printJSON(result); err != nil {
+					return err
+				}
+			case "", "human":
+				fmt.Print(formatToolValidation(result))
+			default:
+				return fmt.Errorf("unknown format: %s (use 'json' or 'human')", format)
+			}
+
+			if !result.Valid {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "human", "Output format (human or json)")
+	return cmd
+}
+
+// toolValidation is the outcome of validating a model response offline
+type toolValidation struct {
+	Type   llm.ResponseType `json:"type"`
+	Tool   string           `json:"tool,omitempty"`
+	Valid  bool             `json:"valid"`
+	Errors []string         `json:"errors,omitempty"`
+}
+
+// validateToolCallJSON parses a model response and checks any tool call
+// in it against the default registry's schemas
+func validateToolCallJSON(raw string) *toolValidation {
+	resp, err := llm.ParseStructuredResponse(raw)
+	if err != nil {
+		return &toolValidation{Type: llm.ResponseTypeError, Errors: []string{err.Error()}}
+	}
+
+	result := &toolValidation{Type: resp.Type}
+	if resp.Type != llm.ResponseTypeAction || resp.Action == nil {
+		result.Errors = []string{fmt.Sprintf("not a tool call: response parsed as %s", resp.Type)}
+		return result
+	}
+
+	result.Tool = resp.Action.Tool
+	for _, err := range app.NewDefaultToolRegistry().ValidationErrors(resp.Action.Tool, resp.Action.Args) {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// formatToolValidation renders a validation outcome for terminal output
+func formatToolValidation(v *toolValidation) string {
+	status := "FAIL"
+	if v.Valid {
+		status = "PASS"
+	}
+	out := fmt.Sprintf("%s  %s\n", status, v.Type)
+	if v.Tool != "" {
+		out = fmt.Sprintf("%s  %s\n", status, v.Tool)
+	}
+	for _, e := range v.Errors {
+		out += fmt.Sprintf("  - %s\n", e)
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplainToolCall_ShowsPlan(t *testing.T) {
+	exp, err := explainToolCall("fs.read", `{"path": "README.md"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := formatToolExplanation(exp)
+	for _, want := range []string{"fs.read", "Capability: FS_READ", "README.md (path)", "Effect:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in explanation:\n%s", want, out)
+		}
+	}
+}
+
+func TestExplainToolCall_ShowsValidationError(t *testing.T) {
+	_, err := explainToolCall("fs.read", `{"file": "README.md"}`)
+	if err == nil || !strings.Contains(err.Error(), "invalid tool call") {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	_, err = explainToolCall("fs.read", `{not json`)
+	if err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("expected JSON error, got %v", err)
+	}
+}
+
+func TestValidateToolCallJSON_ValidAction(t *testing.T) {
+	v := validateToolCallJSON(`{"type": "action", "action": {"tool": "fs.read", "args": {"path": "README.md"}}}`)
+	if !v.Valid || v.Tool != "fs.read" || len(v.Errors) != 0 {
+		t.Fatalf("expected a passing fs.read call, got %+v", v)
+	}
+	if out := formatToolValidation(v); !strings.HasPrefix(out, "PASS  fs.read") {
+		t.Errorf("expected a PASS line, got:\n%s", out)
+	}
+}
+
+func TestValidateToolCallJSON_SchemaViolations(t *testing.T) {
+	v := validateToolCallJSON(`{"type": "action", "action": {"tool": "fs.write", "args": {"path": 1, "mode": "x"}}}`)
+	if v.Valid {
+		t.Fatalf("expected the call to fail validation, got %+v", v)
+	}
+	out := formatToolValidation(v)
+	for _, want := range []string{
+		"FAIL  fs.write",
+		"missing required argument: content",
+		"unexpected argument: mode",
+		"invalid value for path: expected string, got float64",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in report:\n%s", want, out)
+		}
+	}
+
+	v = validateToolCallJSON(`{"type": "action", "action": {"tool": "fs.delete", "args": {}}}`)
+	if v.Valid || len(v.Errors) != 1 || !strings.Contains(v.Errors[0], "unknown tool: fs.delete") {
+		t.Errorf("expected an unknown tool failure, got %+v", v)
+	}
+}
+
+func TestValidateToolCallJSON_NonAction(t *testing.T) {
+	for _, raw := range []string{
+		`{"type": "text", "text": "Here is the plan."}`,
+		`just some prose`,
+		`{not json`,
+	} {
+		v := validateToolCallJSON(raw)
+		if v.Valid || v.Tool != "" {
+			t.Errorf("%q: expected a non-action failure, got %+v", raw, v)
+		}
+		if len(v.Errors) != 1 || !strings.Contains(v.Errors[0], "not a tool call: response parsed as text") {
+			t.Errorf("%q: expected a not-a-tool-call error, got %v", raw, v.Errors)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -23,20 +26,43 @@ type LLMConfig struct {
 	Temperature    float32     `yaml:"temperature"`
 	MaxTokens      int         `yaml:"max_tokens"`
 	RequestTimeout int         `yaml:"request_timeout"`
+	IdleTimeout    int         `yaml:"idle_timeout"`
+	ContextTokens  int         `yaml:"context_tokens"`
+	EmptyRetries   int         `yaml:"empty_response_retries"`
+	UnknownPricing string      `yaml:"unknown_model_pricing"` // "free" or a priced model, for unpriced models
+	WarnPaid       bool        `yaml:"warn_paid_provider"`    // Warn once when starting with a paid provider
+	Logprobs       bool        `yaml:"logprobs"`
+	ReuseTemplate  bool        `yaml:"reuse_request_template"` // Reuse the pre-marshaled request body (OpenAI only)
+	Persona        string      `yaml:"persona"`
+	ShowReasoning  bool        `yaml:"show_reasoning"`
+	ToolMode       string      `yaml:"tool_mode"`
+	PostProcessors []string    `yaml:"post_processors"`
 	Local          LocalConfig `yaml:"local"`
 }
 
 // SafetyConfig holds safety and permission settings
 type SafetyConfig struct {
-	DryRunByDefault        bool `yaml:"dry_run_by_default"`
-	AutoConfirmPermissions bool `yaml:"auto_confirm_permissions"`
-	AutoBackupOnWrite      bool `yaml:"auto_backup_on_write"`
+	DryRunByDefault        bool     `yaml:"dry_run_by_default"`
+	AutoConfirmPermissions bool     `yaml:"auto_confirm_permissions"`
+	AutoApproveReadOnly    bool     `yaml:"auto_approve_read_only"`
+	ExplainDetection       bool     `yaml:"explain_detection"`
+	ContinueAfterDenial    bool     `yaml:"continue_after_denial"`
+	AutoBackupOnWrite      bool     `yaml:"auto_backup_on_write"`
+	BackupRetentionDays    int      `yaml:"backup_retention_days"`
+	ProtectedPaths         []string `yaml:"protected_paths"`
+	AllowedTools           []string `yaml:"allowed_tools"`
+	DisabledTools          []string `yaml:"disabled_tools"`
+	DefaultGrants          []string `yaml:"default_grants"`
+	MaxToolArgsBytes       int      `yaml:"max_tool_args_bytes"`
+	MaxRecursiveReadBytes  int      `yaml:"max_recursive_read_bytes"`
+	Strict                 bool     `yaml:"strict"`
 }
 
 // LoggingConfig holds logging settings
 type LoggingConfig struct {
 	Level        string `yaml:"level"`
 	OutputFormat string `yaml:"output_format"`
+	CompactJSON  bool   `yaml:"compact_json"`
 }
 
 // AuditConfig holds audit log settings
@@ -47,14 +73,63 @@ type AuditConfig struct {
 	MaxSessions        int    `yaml:"max_sessions"`
 	Redact             bool   `yaml:"redact"`
 	ToolArgumentsStyle string `yaml:"tool_arguments_style"`
+	Format             string `yaml:"format"`
+	// MaxToolEventsPerTurn caps tool events logged per turn (0 = unlimited)
+	MaxToolEventsPerTurn int `yaml:"max_tool_events_per_turn"`
 }
 
 // BehaviorConfig holds behavioral settings
 type BehaviorConfig struct {
-	RepoRoot string `yaml:"repo_root"`
-	CacheDir string `yaml:"cache_dir"`
+	RepoRoot        string `yaml:"repo_root"`
+	CacheDir        string `yaml:"cache_dir"`
+	MaxTurns        int    `yaml:"max_turns"`
+	MaxStepsPerTurn int    `yaml:"max_steps_per_turn"`
+	// SaveSessionOnExit writes the chat history to SessionDir on quit
+	SaveSessionOnExit bool   `yaml:"save_session_on_exit"`
+	SessionDir        string `yaml:"session_dir"`
+	// AutoTitle names the conversation after the first exchange: off, heuristic or model
+	AutoTitle string `yaml:"auto_title"`
+	// ToolProgressAfterMs shows a CLI spinner once a tool has run this long (0 = never)
+	ToolProgressAfterMs int `yaml:"tool_progress_after_ms"`
+	// ToolResultMaxLines truncates each text field of a tool result sent back
+	// to the model to this many lines (0 = send results in full)
+	ToolResultMaxLines int `yaml:"tool_result_max_lines"`
+	// ExplainBeforeAct makes the agent explain its tool calls in text, shown
+	// to the user, before making them in reply to a message
+	ExplainBeforeAct bool `yaml:"explain_before_act"`
 }
 
+// TUIConfig holds the initial state of new TUI sessions
+type TUIConfig struct {
+	Mode          string      `yaml:"mode"`
+	DryRun        bool        `yaml:"dry_run"`
+	Deterministic bool        `yaml:"deterministic"`
+	Theme         ThemeConfig `yaml:"theme"`
+	// InputCharLimit caps the characters the input box accepts (0 = unlimited)
+	InputCharLimit int `yaml:"input_char_limit"`
+	// Prompts is the input line prefix for each mode: chat, command, diff
+	Prompts map[string]string `yaml:"prompts"`
+	// MemoryMax is the history size the inspect panel's memory bar measures
+	// against, in entries (0 = derive from llm.context_tokens)
+	MemoryMax int `yaml:"memory_max"`
+}
+
+// ThemeConfig customizes how the TUI renders messages
+type ThemeConfig struct {
+	// Cursor is the glyph shown after streaming text ("" hides it)
+	Cursor      string `yaml:"cursor"`
+	CursorColor string `yaml:"cursor_color"`
+	// RoleColors overrides role label colors, keyed by role
+	RoleColors map[string]string `yaml:"role_colors"`
+}
+
+// ThemeRoles lists the message roles whose label color can be themed
+var ThemeRoles = []string{"user", "assistant", "system", "tool", "clarification", "reasoning"}
+
+// ToolIDs lists the tools safety.allowed_tools and safety.disabled_tools
+// can name, as registered in the default tool registry
+var ToolIDs = []string{"fs.read", "fs.list", "fs.write", "fs.write-many", "audit.query"}
+
 // Config is the complete goshi configuration
 type Config struct {
 	LLM      LLMConfig      `yaml:"llm"`
@@ -62,6 +137,7 @@ type Config struct {
 	Logging  LoggingConfig  `yaml:"logging"`
 	Audit    AuditConfig    `yaml:"audit"`
 	Behavior BehaviorConfig `yaml:"behavior"`
+	TUI      TUIConfig      `yaml:"tui"`
 
 	// Legacy CLI flags (for backward compatibility)
 	Model       string
@@ -73,6 +149,52 @@ type Config struct {
 
 var cachedConfig *Config
 
+// loadedConfigPath is the config file the last LoadYAML read ("" if none),
+// and shadowedConfigPaths the lower-priority files it ignored
+var (
+	loadedConfigPath    string
+	shadowedConfigPaths []string
+)
+
+// remoteConfigErr is why the last Load could not use a remote config
+var remoteConfigErr error
+
+// DefaultModels maps each provider to the model used when switching to it
+// without naming a model
+var DefaultModels = map[string]string{
+	"ollama": "llama3.1:8b",
+	"openai": "gpt-4o-mini",
+}
+
+// DefaultModelFor returns the default model for a provider ("" if unknown)
+func DefaultModelFor(provider string) string {
+	return DefaultModels[provider]
+}
+
+// applyProviderDefaultModel sets the provider's default model when no model
+// was named for it: the model is empty or still another provider's default
+func applyProviderDefaultModel(cfg *Config) {
+	model := DefaultModelFor(cfg.LLM.Provider)
+	if model == "" || cfg.LLM.Model == model {
+		return
+	}
+	if cfg.LLM.Model != "" && !isProviderDefaultModel(cfg.LLM.Model) {
+		return
+	}
+	cfg.Model = model
+	cfg.LLM.Model = model
+}
+
+// isProviderDefaultModel reports whether model is some provider's default
+func isProviderDefaultModel(model string) bool {
+	for _, m := range DefaultModels {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
 // LoadDefaults returns a Config with safe defaults
 // Available Ollama models (performance ranked for TUI):
 //   - llama3.1:8b (RECOMMENDED for TUI - 4.9GB, ~0.19s cached response)
@@ -82,11 +204,16 @@ var cachedConfig *Config
 func LoadDefaults() Config {
 	return Config{
 		LLM: LLMConfig{
-			Model:          "llama3.1:8b",
+			Model:          DefaultModels["ollama"],
 			Provider:       "ollama",
 			Temperature:    0,
 			MaxTokens:      4096,
 			RequestTimeout: 60,
+			IdleTimeout:    30,
+			ContextTokens:  0,
+			ToolMode:       "instructions",
+			UnknownPricing: "gpt-4o",
+			WarnPaid:       true,
 			Local: LocalConfig{
 				URL:  "http://localhost",
 				Port: 11434,
@@ -95,23 +222,46 @@ func LoadDefaults() Config {
 		Safety: SafetyConfig{
 			DryRunByDefault:        true,
 			AutoConfirmPermissions: false,
+			AutoApproveReadOnly:    false,
+			ExplainDetection:       true,
+			ContinueAfterDenial:    false,
 			AutoBackupOnWrite:      true,
+			BackupRetentionDays:    14,
+			ProtectedPaths:         []string{".git/**", ".goshi/**", "*.key"},
+			DefaultGrants:          []string{},
+			MaxToolArgsBytes:       1 << 20,
+			MaxRecursiveReadBytes:  1 << 20,
 		},
 		Logging: LoggingConfig{
 			Level:        "info",
 			OutputFormat: "json",
 		},
 		Audit: AuditConfig{
-			Enabled:            true,
-			Dir:                ".goshi/audit",
-			RetentionDays:      14,
-			MaxSessions:        50,
-			Redact:             true,
-			ToolArgumentsStyle: "summaries",
+			Enabled:              true,
+			Dir:                  ".goshi/audit",
+			RetentionDays:        14,
+			MaxSessions:          50,
+			Redact:               true,
+			ToolArgumentsStyle:   "summaries",
+			Format:               "jsonl",
+			MaxToolEventsPerTurn: 50,
 		},
 		Behavior: BehaviorConfig{
-			RepoRoot: "",
-			CacheDir: "",
+			RepoRoot:            "",
+			CacheDir:            "",
+			MaxTurns:            0,
+			MaxStepsPerTurn:     8,
+			SessionDir:          ".goshi/sessions",
+			AutoTitle:           "heuristic",
+			ToolProgressAfterMs: 1000,
+		},
+		TUI: TUIConfig{
+			Mode: "chat",
+			Theme: ThemeConfig{
+				Cursor: "▊",
+			},
+			InputCharLimit: 4000,
+			Prompts:        map[string]string{"chat": "chat> ", "command": "cmd> ", "diff": "diff> "},
 		},
 		DryRun: true,
 		Yes:    false,
@@ -123,7 +273,7 @@ func LoadDefaults() Config {
 func configPaths() []string {
 	paths := []string{}
 
-	// 1. Environment variable override
+	// 1. Environment variable override (a path or an http(s) URL)
 	if envPath := os.Getenv("GOSHI_CONFIG"); envPath != "" {
 		paths = append(paths, envPath)
 	}
@@ -148,9 +298,10 @@ func configPaths() []string {
 func LoadYAML() (Config, error) {
 	cfg := LoadDefaults()
 	paths := configPaths()
+	loadedConfigPath, shadowedConfigPaths = "", nil
 
-	for _, path := range paths {
-		data, err := os.ReadFile(path)
+	for i, path := range paths {
+		data, err := readConfigSource(path)
 		if err != nil {
 			if os.IsNotExist(err) {
 				continue
@@ -158,11 +309,20 @@ func LoadYAML() (Config, error) {
 			return cfg, fmt.Errorf("failed to read config at %s: %w", path, err)
 		}
 
-		if err := yaml.Unmarshal(data, &cfg); err != nil {
+		if err := yaml.Unmarshal(normalizeText(data), &cfg); err != nil {
 			return cfg, fmt.Errorf("failed to parse config at %s: %w", path, err)
 		}
 
-		// Found and loaded config
+		// Found and loaded config; only the first one found is used
+		loadedConfigPath = path
+		for _, other := range paths[i+1:] {
+			if isRemoteConfig(other) {
+				continue
+			}
+			if _, err := os.Stat(other); err == nil {
+				shadowedConfigPaths = append(shadowedConfigPaths, other)
+			}
+		}
 		return cfg, nil
 	}
 
@@ -170,6 +330,33 @@ func LoadYAML() (Config, error) {
 	return cfg, nil
 }
 
+// LoadedConfigPath returns the config file (or URL) the configuration was
+// last loaded from, or "" when no file was found and only defaults apply
+func LoadedConfigPath() string {
+	return loadedConfigPath
+}
+
+// ShadowedConfigPaths returns the config files that also exist but were
+// ignored because LoadedConfigPath takes precedence over them
+func ShadowedConfigPaths() []string {
+	return append([]string(nil), shadowedConfigPaths...)
+}
+
+// LoadFile loads a single config file over the defaults, without the
+// search path, environment overrides, or caching
+func LoadFile(path string) (Config, error) {
+	cfg := LoadDefaults()
+	data, err := readConfigSource(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config at %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(normalizeText(data), &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config at %s: %w", path, err)
+	}
+	applyProviderDefaultModel(&cfg)
+	return cfg, nil
+}
+
 // Load loads configuration with environment variable overrides
 // This is the main entry point and uses caching
 func Load() Config {
@@ -177,29 +364,36 @@ func Load() Config {
 		return *cachedConfig
 	}
 
-	cfg, _ := LoadYAML()
-
-	// Apply environment variable overrides
-	if model := os.Getenv("GOSHI_MODEL"); model != "" {
-		cfg.Model = model
-		cfg.LLM.Model = model
-	}
-
-	if provider := os.Getenv("GOSHI_LLM_PROVIDER"); provider != "" {
-		cfg.LLMProvider = provider
-		cfg.LLM.Provider = provider
-	}
-
-	if ollamaURL := os.Getenv("GOSHI_OLLAMA_URL"); ollamaURL != "" {
-		cfg.LLM.Local.URL = ollamaURL
+	// An unreadable local config falls back to the defaults, but say so
+	// rather than silently ignoring it. A remote config is meant to be
+	// enforced centrally, so its failure is kept for RemoteConfigError.
+	cfg, err := LoadYAML()
+	remoteConfigErr = nil
+	if err != nil {
+		if isRemoteConfig(os.Getenv("GOSHI_CONFIG")) {
+			remoteConfigErr = err
+		} else {
+			fmt.Fprintf(os.Stderr, "warning: %v; using defaults\n", err)
+		}
 	}
+	fileProvider := cfg.LLM.Provider
 
-	if ollamaPort := os.Getenv("GOSHI_OLLAMA_PORT"); ollamaPort != "" {
-		fmt.Sscanf(ollamaPort, "%d", &cfg.LLM.Local.Port)
+	// Apply environment variable overrides
+	for _, override := range envOverrides {
+		if value := os.Getenv(override.env); value != "" {
+			override.apply(&cfg, value)
+		}
 	}
 
-	if auditEnabled := os.Getenv("GOSHI_AUDIT_ENABLED"); auditEnabled != "" {
-		cfg.Audit.Enabled = parseBool(auditEnabled)
+	// Choosing a provider, in the file or by env, without naming a model
+	// picks that provider's default rather than keeping another's. A model
+	// named in the file is meant for the file's provider, so switching
+	// provider by env replaces it too.
+	if os.Getenv("GOSHI_MODEL") == "" {
+		if cfg.LLM.Provider != fileProvider && DefaultModelFor(cfg.LLM.Provider) != "" {
+			cfg.LLM.Model = ""
+		}
+		applyProviderDefaultModel(&cfg)
 	}
 
 	// Set defaults for legacy fields if not already set
@@ -210,10 +404,101 @@ func Load() Config {
 		cfg.LLMProvider = cfg.LLM.Provider
 	}
 
+	// At debug level, say which file won so a shadowing config is no surprise
+	if cfg.Logging.Level == "debug" {
+		for _, line := range configSourceLines() {
+			fmt.Fprintln(os.Stderr, line)
+		}
+	}
+
 	cachedConfig = &cfg
 	return cfg
 }
 
+// envOverride maps an environment variable onto a config field
+type envOverride struct {
+	env   string
+	field string
+	get   func(*Config) string
+	apply func(*Config, string)
+}
+
+// envOverrides lists the environment variables that override config values
+var envOverrides = []envOverride{
+	{
+		env: "GOSHI_MODEL", field: "llm.model",
+		get: func(c *Config) string { return c.LLM.Model },
+		apply: func(c *Config, v string) {
+			c.Model = v
+			c.LLM.Model = v
+		},
+	},
+	{
+		env: "GOSHI_LLM_PROVIDER", field: "llm.provider",
+		get: func(c *Config) string { return c.LLM.Provider },
+		apply: func(c *Config, v string) {
+			c.LLMProvider = v
+			c.LLM.Provider = v
+		},
+	},
+	{
+		env: "GOSHI_OLLAMA_URL", field: "llm.local.url",
+		get:   func(c *Config) string { return c.LLM.Local.URL },
+		apply: func(c *Config, v string) { c.LLM.Local.URL = v },
+	},
+	{
+		env: "GOSHI_OLLAMA_PORT", field: "llm.local.port",
+		get:   func(c *Config) string { return strconv.Itoa(c.LLM.Local.Port) },
+		apply: func(c *Config, v string) { fmt.Sscanf(v, "%d", &c.LLM.Local.Port) },
+	},
+	{
+		env: "GOSHI_AUDIT_ENABLED", field: "audit.enabled",
+		get:   func(c *Config) string { return strconv.FormatBool(c.Audit.Enabled) },
+		apply: func(c *Config, v string) { c.Audit.Enabled = parseBool(v) },
+	},
+}
+
+// Override describes a config value replaced by an environment variable
+type Override struct {
+	Field     string `json:"field"`
+	Env       string `json:"env"`
+	FileValue string `json:"file_value"`
+	EnvValue  string `json:"env_value"`
+}
+
+// EnvOverrides reports the fields whose file (or default) value is changed
+// by an environment variable. Variables that agree with the file are omitted.
+func EnvOverrides() []Override {
+	fileCfg, _ := LoadYAML()
+
+	var overrides []Override
+	for _, override := range envOverrides {
+		value := os.Getenv(override.env)
+		if value == "" {
+			continue
+		}
+		applied := fileCfg
+		override.apply(&applied, value)
+		before, after := override.get(&fileCfg), override.get(&applied)
+		if before != after {
+			overrides = append(overrides, Override{
+				Field:     override.field,
+				Env:       override.env,
+				FileValue: before,
+				EnvValue:  after,
+			})
+		}
+	}
+	return overrides
+}
+
+// normalizeText strips a UTF-8 byte order mark and converts CRLF line
+// endings to LF, so config files authored on Windows parse cleanly
+func normalizeText(b []byte) []byte {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	return bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+}
+
 func parseBool(value string) bool {
 	switch strings.ToLower(value) {
 	case "1", "true", "yes", "y", "on":
@@ -245,6 +530,34 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("llm.request_timeout must be positive, got %d", c.LLM.RequestTimeout)
 	}
 
+	if c.LLM.IdleTimeout < 0 {
+		return fmt.Errorf("llm.idle_timeout must be >= 0, got %d", c.LLM.IdleTimeout)
+	}
+
+	if c.LLM.ContextTokens < 0 {
+		return fmt.Errorf("llm.context_tokens must be >= 0, got %d", c.LLM.ContextTokens)
+	}
+
+	if c.LLM.EmptyRetries < 0 || c.LLM.EmptyRetries > 5 {
+		return fmt.Errorf("llm.empty_response_retries must be between 0 and 5, got %d", c.LLM.EmptyRetries)
+	}
+
+	switch c.LLM.ToolMode {
+	case "", "instructions", "native", "none":
+		// valid; empty falls back to instructions
+	default:
+		return fmt.Errorf("llm.tool_mode must be instructions, native, or none, got %s", c.LLM.ToolMode)
+	}
+
+	for _, name := range c.LLM.PostProcessors {
+		switch name {
+		case "strip_thinking", "normalize_whitespace", "redact_secrets":
+			// valid
+		default:
+			return fmt.Errorf("llm.post_processors entries must be strip_thinking, normalize_whitespace, or redact_secrets, got %s", name)
+		}
+	}
+
 	if c.LLM.Provider == "ollama" {
 		if c.LLM.Local.URL == "" {
 			return errors.New("llm.local.url is required for ollama provider")
@@ -254,6 +567,37 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Safety.MaxToolArgsBytes < 0 {
+		return fmt.Errorf("safety.max_tool_args_bytes must be >= 0, got %d", c.Safety.MaxToolArgsBytes)
+	}
+
+	if c.Safety.BackupRetentionDays < 0 {
+		return fmt.Errorf("safety.backup_retention_days must be >= 0, got %d", c.Safety.BackupRetentionDays)
+	}
+	if c.Safety.MaxRecursiveReadBytes < 0 {
+		return fmt.Errorf("safety.max_recursive_read_bytes must be >= 0, got %d", c.Safety.MaxRecursiveReadBytes)
+	}
+
+	for _, capability := range c.Safety.DefaultGrants {
+		if capability != "FS_READ" && capability != "FS_WRITE" {
+			return fmt.Errorf("safety.default_grants entries must be FS_READ or FS_WRITE, got %s", capability)
+		}
+	}
+
+	for _, list := range []struct {
+		key string
+		ids []string
+	}{
+		{"safety.allowed_tools", c.Safety.AllowedTools},
+		{"safety.disabled_tools", c.Safety.DisabledTools},
+	} {
+		for _, id := range list.ids {
+			if !slices.Contains(ToolIDs, id) {
+				return fmt.Errorf("%s has unknown tool %q (valid: %s)", list.key, id, strings.Join(ToolIDs, ", "))
+			}
+		}
+	}
+
 	if c.Logging.Level == "" ||
 		(c.Logging.Level != "debug" &&
 			c.Logging.Level != "info" &&
@@ -273,6 +617,13 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("audit.tool_arguments_style must be full, long, short, or summaries, got %s", c.Audit.ToolArgumentsStyle)
 	}
 
+	switch c.Audit.Format {
+	case "", "jsonl", "text", "both":
+		// valid; empty falls back to jsonl
+	default:
+		return fmt.Errorf("audit.format must be jsonl, text, or both, got %s", c.Audit.Format)
+	}
+
 	if c.Audit.RetentionDays < 0 {
 		return fmt.Errorf("audit.retention_days must be >= 0, got %d", c.Audit.RetentionDays)
 	}
@@ -281,10 +632,88 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("audit.max_sessions must be >= 0, got %d", c.Audit.MaxSessions)
 	}
 
+	if c.Audit.MaxToolEventsPerTurn < 0 {
+		return fmt.Errorf("audit.max_tool_events_per_turn must be >= 0, got %d", c.Audit.MaxToolEventsPerTurn)
+	}
+
+	if c.Behavior.MaxTurns < 0 {
+		return fmt.Errorf("behavior.max_turns must be >= 0, got %d", c.Behavior.MaxTurns)
+	}
+
+	if c.Behavior.MaxStepsPerTurn <= 0 {
+		return fmt.Errorf("behavior.max_steps_per_turn must be positive, got %d", c.Behavior.MaxStepsPerTurn)
+	}
+
+	switch c.Behavior.AutoTitle {
+	case "", "off", "heuristic", "model":
+		// valid; empty means off
+	default:
+		return fmt.Errorf("behavior.auto_title must be off, heuristic, or model, got %s", c.Behavior.AutoTitle)
+	}
+
+	if c.Behavior.ToolProgressAfterMs < 0 {
+		return fmt.Errorf("behavior.tool_progress_after_ms must be >= 0, got %d", c.Behavior.ToolProgressAfterMs)
+	}
+
+	if c.Behavior.ToolResultMaxLines < 0 {
+		return fmt.Errorf("behavior.tool_result_max_lines must be >= 0, got %d", c.Behavior.ToolResultMaxLines)
+	}
+
+	switch c.TUI.Mode {
+	case "", "chat", "command", "diff":
+		// valid; empty falls back to chat
+	default:
+		return fmt.Errorf("tui.mode must be chat, command, or diff, got %s", c.TUI.Mode)
+	}
+
+	if c.TUI.InputCharLimit < 0 {
+		return fmt.Errorf("tui.input_char_limit must be >= 0, got %d", c.TUI.InputCharLimit)
+	}
+
+	if c.TUI.MemoryMax < 0 {
+		return fmt.Errorf("tui.memory_max must be >= 0, got %d", c.TUI.MemoryMax)
+	}
+
+	for mode := range c.TUI.Prompts {
+		switch mode {
+		case "chat", "command", "diff":
+		default:
+			return fmt.Errorf("tui.prompts has unknown mode %s (valid: chat, command, diff)", mode)
+		}
+	}
+
+	for role := range c.TUI.Theme.RoleColors {
+		if !slices.Contains(ThemeRoles, role) {
+			return fmt.Errorf("tui.theme.role_colors has unknown role %s (valid: %s)", role, strings.Join(ThemeRoles, ", "))
+		}
+	}
+
 	return nil
 }
 
+// configSourceLines describes which config file was loaded and which
+// files it shadowed
+func configSourceLines() []string {
+	if loadedConfigPath == "" {
+		return []string{"config: no config file found, using defaults"}
+	}
+	lines := []string{fmt.Sprintf("config: loaded %s", loadedConfigPath)}
+	for _, path := range shadowedConfigPaths {
+		lines = append(lines, fmt.Sprintf("config: ignoring %s (shadowed by %s)", path, loadedConfigPath))
+	}
+	return lines
+}
+
 // Reset clears the cached config (useful for testing)
 func Reset() {
 	cachedConfig = nil
+	loadedConfigPath, shadowedConfigPaths = "", nil
+	remoteConfigErr = nil
+}
+
+// RemoteConfigError returns why the last Load could not fetch or parse the
+// remote config named by GOSHI_CONFIG, or nil. Goshi refuses to start on
+// it rather than running with the defaults.
+func RemoteConfigError() error {
+	return remoteConfigErr
 }
@@ -2,6 +2,10 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -29,6 +33,10 @@ func TestLoadDefaults(t *testing.T) {
 		t.Errorf("expected default ollama port 11434, got %d", cfg.LLM.Local.Port)
 	}
 
+	if !cfg.LLM.WarnPaid {
+		t.Errorf("expected warn_paid_provider to default to true")
+	}
+
 	if cfg.Safety.DryRunByDefault != true {
 		t.Errorf("expected dry_run_by_default to be true")
 	}
@@ -37,6 +45,10 @@ func TestLoadDefaults(t *testing.T) {
 		t.Errorf("expected auto_confirm_permissions to be false")
 	}
 
+	if len(cfg.Safety.ProtectedPaths) == 0 {
+		t.Errorf("expected default safety.protected_paths to be set")
+	}
+
 	if cfg.Audit.Enabled != true {
 		t.Errorf("expected audit.enabled to be true")
 	}
@@ -259,6 +271,739 @@ func TestValidateTimeoutSeconds(t *testing.T) {
 	}
 }
 
+// TestValidateMaxTurns tests that validation rejects negative turn limits
+func TestValidateMaxTurns(t *testing.T) {
+	if cfg := LoadDefaults(); cfg.Behavior.MaxTurns != 0 {
+		t.Errorf("expected sessions to be unlimited by default, got max_turns %d", cfg.Behavior.MaxTurns)
+	}
+
+	tests := []struct {
+		name       string
+		maxTurns   int
+		shouldFail bool
+	}{
+		{"positive limit", 20, false},
+		{"zero disables limit", 0, false},
+		{"negative limit", -1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := LoadDefaults()
+			cfg.Behavior.MaxTurns = test.maxTurns
+			err := cfg.Validate()
+
+			if test.shouldFail && err == nil {
+				t.Errorf("expected validation to fail for max_turns %d", test.maxTurns)
+			}
+			if !test.shouldFail && err != nil {
+				t.Errorf("expected validation to pass for max_turns %d, got error: %v", test.maxTurns, err)
+			}
+		})
+	}
+}
+
+// TestValidateMaxStepsPerTurn tests that validation requires a positive step limit
+func TestValidateMaxStepsPerTurn(t *testing.T) {
+	cfg := LoadDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected defaults to validate, got %v", err)
+	}
+
+	cfg.Behavior.MaxStepsPerTurn = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation to fail for max_steps_per_turn 0")
+	}
+}
+
+// TestValidateContextTokens tests that the context budget is off by
+// default and may be disabled but not negative
+func TestValidateContextTokens(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.LLM.ContextTokens != 0 {
+		t.Errorf("expected context trimming off by default, got %d", cfg.LLM.ContextTokens)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected context_tokens 0 to be valid, got %v", err)
+	}
+
+	cfg.LLM.ContextTokens = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation to fail for negative context_tokens")
+	}
+}
+
+// TestValidateAuditFormat tests that only known audit log formats are accepted
+func TestValidateAuditFormat(t *testing.T) {
+	tests := []struct {
+		format     string
+		shouldFail bool
+	}{
+		{"jsonl", false},
+		{"text", false},
+		{"both", false},
+		{"xml", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			cfg := LoadDefaults()
+			cfg.Audit.Format = test.format
+			err := cfg.Validate()
+			if test.shouldFail && err == nil {
+				t.Errorf("expected validation to fail for audit.format %q", test.format)
+			}
+			if !test.shouldFail && err != nil {
+				t.Errorf("expected validation to pass for audit.format %q, got error: %v", test.format, err)
+			}
+		})
+	}
+}
+
+// TestValidateIdleTimeout tests that the idle timeout may be disabled but not negative
+func TestValidateIdleTimeout(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.LLM.IdleTimeout != 30 {
+		t.Errorf("expected default idle_timeout 30, got %d", cfg.LLM.IdleTimeout)
+	}
+
+	cfg.LLM.IdleTimeout = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected idle_timeout 0 to be valid, got %v", err)
+	}
+
+	cfg.LLM.IdleTimeout = -5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation to fail for negative idle_timeout")
+	}
+}
+
+// TestValidateDefaultGrants tests that only known capabilities may be granted at startup
+func TestValidateDefaultGrants(t *testing.T) {
+	cfg := LoadDefaults()
+	if len(cfg.Safety.DefaultGrants) != 0 {
+		t.Errorf("expected no default grants, got %v", cfg.Safety.DefaultGrants)
+	}
+
+	cfg.Safety.DefaultGrants = []string{"FS_READ"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected FS_READ to be valid, got %v", err)
+	}
+
+	cfg.Safety.DefaultGrants = []string{"NET_ACCESS"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation to fail for unknown capability")
+	}
+}
+
+// TestEnvOverridesReportsConflicts tests that env values differing from the file are reported
+func TestEnvOverridesReportsConflicts(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "goshi.yaml")
+	content := "llm:\n  model: file-model\n  provider: ollama\n  local:\n    port: 11434\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	t.Setenv("GOSHI_CONFIG", configPath)
+	t.Setenv("GOSHI_MODEL", "env-model")
+	t.Setenv("GOSHI_LLM_PROVIDER", "ollama") // agrees with the file
+	t.Setenv("GOSHI_OLLAMA_PORT", "9999")
+
+	overrides := EnvOverrides()
+	fields := map[string]Override{}
+	for _, o := range overrides {
+		fields[o.Field] = o
+	}
+
+	if len(overrides) != 2 {
+		t.Fatalf("expected 2 overrides, got %+v", overrides)
+	}
+	if o := fields["llm.model"]; o.Env != "GOSHI_MODEL" || o.FileValue != "file-model" || o.EnvValue != "env-model" {
+		t.Errorf("unexpected llm.model override: %+v", o)
+	}
+	if o := fields["llm.local.port"]; o.FileValue != "11434" || o.EnvValue != "9999" {
+		t.Errorf("unexpected llm.local.port override: %+v", o)
+	}
+	if _, ok := fields["llm.provider"]; ok {
+		t.Error("expected agreeing env value not to be reported")
+	}
+}
+
+// TestValidateMaxToolArgsBytes tests the tool argument size cap bounds
+func TestValidateMaxToolArgsBytes(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.Safety.MaxToolArgsBytes != 1<<20 {
+		t.Errorf("expected default cap of 1 MiB, got %d", cfg.Safety.MaxToolArgsBytes)
+	}
+
+	cfg.Safety.MaxToolArgsBytes = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected 0 (unlimited) to be valid, got %v", err)
+	}
+
+	cfg.Safety.MaxToolArgsBytes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected negative cap to fail validation")
+	}
+}
+
+// TestProviderSwitchUsesProviderDefaultModel tests that changing only the provider picks its default model
+func TestProviderSwitchUsesProviderDefaultModel(t *testing.T) {
+	t.Setenv("GOSHI_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	t.Setenv("GOSHI_MODEL", "")
+	t.Cleanup(Reset)
+
+	tests := []struct {
+		name     string
+		provider string
+		model    string
+		want     string
+	}{
+		{"same provider keeps model", "ollama", "", "llama3.1:8b"},
+		{"switch to openai", "openai", "", "gpt-4o-mini"},
+		{"explicit model wins", "openai", "gpt-4o", "gpt-4o"},
+		{"unknown provider keeps model", "custom", "", "llama3.1:8b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOSHI_LLM_PROVIDER", tt.provider)
+			t.Setenv("GOSHI_MODEL", tt.model)
+			Reset()
+
+			cfg := Load()
+			if cfg.LLM.Model != tt.want || cfg.Model != tt.want {
+				t.Errorf("expected model %q, got llm.model=%q model=%q", tt.want, cfg.LLM.Model, cfg.Model)
+			}
+		})
+	}
+}
+
+// TestValidateToolMode tests that only known tool modes are accepted
+func TestValidateToolMode(t *testing.T) {
+	for _, mode := range []string{"", "instructions", "native", "none"} {
+		cfg := LoadDefaults()
+		cfg.LLM.ToolMode = mode
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("tool_mode %q should be valid, got %v", mode, err)
+		}
+	}
+
+	cfg := LoadDefaults()
+	cfg.LLM.ToolMode = "magic"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown tool_mode")
+	}
+}
+
+// TestValidateTUIMode tests that only known initial TUI modes are accepted
+func TestValidateTUIMode(t *testing.T) {
+	for _, mode := range []string{"", "chat", "command", "diff"} {
+		cfg := LoadDefaults()
+		cfg.TUI.Mode = mode
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("tui.mode %q should be valid, got %v", mode, err)
+		}
+	}
+
+	cfg := LoadDefaults()
+	cfg.TUI.Mode = "fullscreen"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown tui.mode")
+	}
+}
+
+// TestLoadedConfigPathReportsHighestPrecedence tests that with several
+// config files present, the one actually loaded is reported and the rest
+// are listed as shadowed
+func TestLoadedConfigPathReportsHighestPrecedence(t *testing.T) {
+	t.Cleanup(Reset)
+	write := func(path, model string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("llm:\n  model: "+model+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	envPath := filepath.Join(t.TempDir(), "env.yaml")
+	repo := t.TempDir()
+	home := t.TempDir()
+	write(envPath, "env-model")
+	write(filepath.Join(repo, "goshi.yaml"), "repo-model")
+	write(filepath.Join(home, ".goshi", "config.yaml"), "home-model")
+	t.Chdir(repo)
+	t.Setenv("HOME", home)
+
+	// GOSHI_CONFIG wins over the repository and home configs
+	t.Setenv("GOSHI_CONFIG", envPath)
+	cfg, err := LoadYAML()
+	if err != nil {
+		t.Fatalf("LoadYAML failed: %v", err)
+	}
+	if cfg.LLM.Model != "env-model" || LoadedConfigPath() != envPath {
+		t.Errorf("expected %s loaded, got %q from %q", envPath, cfg.LLM.Model, LoadedConfigPath())
+	}
+	want := []string{filepath.Join(repo, "goshi.yaml"), filepath.Join(home, ".goshi", "config.yaml")}
+	if got := ShadowedConfigPaths(); !slices.Equal(got, want) {
+		t.Errorf("expected shadowed %v, got %v", want, got)
+	}
+
+	// Without it, the repository config wins over the home config
+	t.Setenv("GOSHI_CONFIG", "")
+	cfg, err = LoadYAML()
+	if err != nil {
+		t.Fatalf("LoadYAML failed: %v", err)
+	}
+	if cfg.LLM.Model != "repo-model" || LoadedConfigPath() != want[0] {
+		t.Errorf("expected %s loaded, got %q from %q", want[0], cfg.LLM.Model, LoadedConfigPath())
+	}
+	if got := ShadowedConfigPaths(); !slices.Equal(got, want[1:]) {
+		t.Errorf("expected shadowed %v, got %v", want[1:], got)
+	}
+	lines := configSourceLines()
+	if len(lines) != 2 || !strings.Contains(lines[0], want[0]) || !strings.Contains(lines[1], "shadowed") {
+		t.Errorf("unexpected source log lines: %v", lines)
+	}
+}
+
+// TestLoadedConfigPathEmptyWithoutFile tests that defaults-only loads
+// report no config file
+func TestLoadedConfigPathEmptyWithoutFile(t *testing.T) {
+	t.Cleanup(Reset)
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GOSHI_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+
+	if _, err := os.Stat("/etc/goshi/config.yaml"); err == nil {
+		t.Skip("system-wide config present")
+	}
+	if _, err := LoadYAML(); err != nil {
+		t.Fatalf("LoadYAML failed: %v", err)
+	}
+	if LoadedConfigPath() != "" || len(ShadowedConfigPaths()) != 0 {
+		t.Errorf("expected no config file reported, got %q (shadowed %v)", LoadedConfigPath(), ShadowedConfigPaths())
+	}
+}
+
+// TestLoadYAMLNormalizesBOMAndCRLF tests that Windows-authored config files
+// parse identically to clean ones
+func TestLoadYAMLNormalizesBOMAndCRLF(t *testing.T) {
+	clean := "llm:\n  model: \"gpt-4o\"\n  provider: \"openai\"\n  persona: |\n    Terse.\n    Dry.\nsafety:\n  protected_paths:\n    - \"*.pem\"\n"
+	load := func(content string) Config {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "goshi.yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		t.Setenv("GOSHI_CONFIG", path)
+		cfg, err := LoadYAML()
+		if err != nil {
+			t.Fatalf("LoadYAML failed: %v", err)
+		}
+		return cfg
+	}
+
+	want := load(clean)
+	got := load("\xef\xbb\xbf" + strings.ReplaceAll(clean, "\n", "\r\n"))
+
+	if got.LLM.Model != "gpt-4o" || got.LLM.Provider != "openai" {
+		t.Errorf("expected llm settings from BOM/CRLF file, got %+v", got.LLM)
+	}
+	if got.LLM.Persona != want.LLM.Persona {
+		t.Errorf("persona = %q, want %q", got.LLM.Persona, want.LLM.Persona)
+	}
+	if !reflect.DeepEqual(got.Safety.ProtectedPaths, want.Safety.ProtectedPaths) {
+		t.Errorf("protected paths = %q, want %q", got.Safety.ProtectedPaths, want.Safety.ProtectedPaths)
+	}
+}
+
+// TestDiffReportsOnlyChangedFields tests that Diff reports exactly the
+// fields that differ between two config files, respecting defaults
+func TestDiffReportsOnlyChangedFields(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+
+	// a.yaml spells out a default (max_turns: 0) that b.yaml omits
+	a, err := LoadFile(write("a.yaml", "llm:\n  model: \"llama3.1:8b\"\nbehavior:\n  max_turns: 0\naudit:\n  redact: true\n"))
+	if err != nil {
+		t.Fatalf("load a: %v", err)
+	}
+	b, err := LoadFile(write("b.yaml", "llm:\n  model: \"gpt-4o\"\n  provider: \"openai\"\naudit:\n  redact: false\nsafety:\n  default_grants: [\"FS_READ\"]\n"))
+	if err != nil {
+		t.Fatalf("load b: %v", err)
+	}
+
+	want := []FieldDiff{
+		{Field: "llm.model", A: `"llama3.1:8b"`, B: `"gpt-4o"`},
+		{Field: "llm.provider", A: `"ollama"`, B: `"openai"`},
+		{Field: "safety.default_grants", A: `[]`, B: `["FS_READ"]`},
+		{Field: "audit.redact", A: `true`, B: `false`},
+	}
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := Diff(a, a); len(got) != 0 {
+		t.Errorf("expected no differences comparing a config with itself, got %+v", got)
+	}
+}
+
+// TestValidateMaxRecursiveReadBytes tests that a negative recursive read cap is rejected
+func TestValidateMaxRecursiveReadBytes(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.Safety.MaxRecursiveReadBytes != 1<<20 {
+		t.Errorf("expected default cap of 1 MiB, got %d", cfg.Safety.MaxRecursiveReadBytes)
+	}
+
+	cfg.Safety.MaxRecursiveReadBytes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative safety.max_recursive_read_bytes")
+	}
+}
+
+// TestValidateBackupRetentionDays tests that a negative backup retention is rejected
+func TestValidateBackupRetentionDays(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.Safety.BackupRetentionDays != 14 {
+		t.Errorf("expected default backup retention of 14 days, got %d", cfg.Safety.BackupRetentionDays)
+	}
+
+	cfg.Safety.BackupRetentionDays = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative safety.backup_retention_days")
+	}
+}
+
+// TestFileProviderUsesProviderDefaultModel tests that a config file choosing
+// a provider without a model gets that provider's default model
+func TestFileProviderUsesProviderDefaultModel(t *testing.T) {
+	t.Setenv("GOSHI_LLM_PROVIDER", "")
+	t.Setenv("GOSHI_MODEL", "")
+	t.Cleanup(Reset)
+
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"provider only", "llm:\n  provider: openai\n", "gpt-4o-mini"},
+		{"another provider's default", "llm:\n  provider: openai\n  model: llama3.1:8b\n", "gpt-4o-mini"},
+		{"explicit model wins", "llm:\n  provider: openai\n  model: gpt-4o\n", "gpt-4o"},
+		{"unknown provider keeps model", "llm:\n  provider: custom\n", "llama3.1:8b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "goshi.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0644); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+			t.Setenv("GOSHI_CONFIG", path)
+			Reset()
+
+			cfg := Load()
+			if cfg.LLM.Model != tt.want || cfg.Model != tt.want {
+				t.Errorf("expected model %q, got llm.model=%q model=%q", tt.want, cfg.LLM.Model, cfg.Model)
+			}
+			fileCfg, err := LoadFile(path)
+			if err != nil {
+				t.Fatalf("LoadFile failed: %v", err)
+			}
+			if fileCfg.LLM.Model != tt.want {
+				t.Errorf("expected LoadFile model %q, got %q", tt.want, fileCfg.LLM.Model)
+			}
+		})
+	}
+}
+
+// TestLoadFileAutoApproveReadOnly tests that the read-only auto-approve mode
+// is off by default and loads from safety.auto_approve_read_only
+func TestLoadFileAutoApproveReadOnly(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.Safety.AutoApproveReadOnly {
+		t.Error("expected auto_approve_read_only to be off by default")
+	}
+
+	path := filepath.Join(t.TempDir(), "goshi.yaml")
+	if err := os.WriteFile(path, []byte("safety:\n  auto_approve_read_only: true\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if !cfg.Safety.AutoApproveReadOnly || cfg.Safety.AutoConfirmPermissions {
+		t.Errorf("expected only read-only auto-approve, got %+v", cfg.Safety)
+	}
+}
+
+// TestLoadFileExplainDetection tests that detection reasons are shown by
+// default and can be turned off
+func TestLoadFileExplainDetection(t *testing.T) {
+	if !LoadDefaults().Safety.ExplainDetection {
+		t.Error("expected explain_detection to be on by default")
+	}
+
+	path := filepath.Join(t.TempDir(), "goshi.yaml")
+	if err := os.WriteFile(path, []byte("safety:\n  explain_detection: false\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if cfg.Safety.ExplainDetection {
+		t.Error("expected explain_detection to load as false")
+	}
+}
+
+// TestValidatePostProcessors tests that only built-in post-processors are accepted
+func TestValidatePostProcessors(t *testing.T) {
+	cfg := LoadDefaults()
+	cfg.LLM.PostProcessors = []string{"strip_thinking", "normalize_whitespace", "redact_secrets"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected built-in post-processors to validate, got %v", err)
+	}
+
+	cfg.LLM.PostProcessors = []string{"summarize"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "llm.post_processors") {
+		t.Errorf("expected unknown post-processor to be rejected, got %v", err)
+	}
+}
+
+// TestLoadFileCompactJSON tests that compact JSON output is off by default
+// and loads from logging.compact_json
+func TestLoadFileCompactJSON(t *testing.T) {
+	if LoadDefaults().Logging.CompactJSON {
+		t.Error("expected compact_json to be off by default")
+	}
+
+	path := filepath.Join(t.TempDir(), "goshi.yaml")
+	if err := os.WriteFile(path, []byte("logging:\n  compact_json: true\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if !cfg.Logging.CompactJSON {
+		t.Error("expected compact_json to load from the config file")
+	}
+}
+
+func TestLoadFileReuseRequestTemplate(t *testing.T) {
+	if LoadDefaults().LLM.ReuseTemplate {
+		t.Error("expected reuse_request_template to be off by default")
+	}
+
+	path := filepath.Join(t.TempDir(), "goshi.yaml")
+	if err := os.WriteFile(path, []byte("llm:\n  reuse_request_template: true\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if !cfg.LLM.ReuseTemplate {
+		t.Error("expected reuse_request_template to load from the config file")
+	}
+}
+
+// TestValidateMaxToolEventsPerTurn tests the per-turn audit cap bounds
+func TestValidateMaxToolEventsPerTurn(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.Audit.MaxToolEventsPerTurn != 50 {
+		t.Errorf("expected default cap 50, got %d", cfg.Audit.MaxToolEventsPerTurn)
+	}
+	cfg.Audit.MaxToolEventsPerTurn = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected 0 (unlimited) to be valid, got %v", err)
+	}
+	cfg.Audit.MaxToolEventsPerTurn = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a negative cap to be rejected")
+	}
+}
+
+// TestValidateToolProgressAfterMs tests the CLI tool spinner threshold bounds
+func TestValidateToolProgressAfterMs(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.Behavior.ToolProgressAfterMs != 1000 {
+		t.Errorf("expected default threshold 1000ms, got %d", cfg.Behavior.ToolProgressAfterMs)
+	}
+	cfg.Behavior.ToolProgressAfterMs = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected 0 (disabled) to be valid, got %v", err)
+	}
+	cfg.Behavior.ToolProgressAfterMs = -5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a negative threshold to be rejected")
+	}
+}
+
+// TestValidateEmptyResponseRetries tests the empty response retry bounds
+func TestValidateEmptyResponseRetries(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.LLM.EmptyRetries != 0 {
+		t.Errorf("expected empty response retries to be disabled by default, got %d", cfg.LLM.EmptyRetries)
+	}
+	cfg.LLM.EmptyRetries = 5
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected 5 retries to be valid, got %v", err)
+	}
+	for _, retries := range []int{-1, 6} {
+		cfg.LLM.EmptyRetries = retries
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %d retries to be rejected", retries)
+		}
+	}
+}
+
+// TestLoadFileToolFilter tests loading and validating the tool allow/deny lists
+func TestLoadFileToolFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goshi.yaml")
+	data := "safety:\n  allowed_tools: [\"fs.read\", \"fs.list\"]\n  disabled_tools: [\"fs.write\"]\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Safety.AllowedTools, []string{"fs.read", "fs.list"}) {
+		t.Errorf("unexpected allowed tools: %v", cfg.Safety.AllowedTools)
+	}
+	if !reflect.DeepEqual(cfg.Safety.DisabledTools, []string{"fs.write"}) {
+		t.Errorf("unexpected disabled tools: %v", cfg.Safety.DisabledTools)
+	}
+
+	cfg.Safety.DisabledTools = []string{""}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an empty tool ID to be rejected")
+	}
+	// A typo must not silently leave the tool enabled
+	cfg.Safety.DisabledTools = []string{"fs.wirte"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "fs.wirte") {
+		t.Errorf("expected an unknown tool ID to be rejected, got %v", err)
+	}
+	cfg.Safety.DisabledTools = nil
+	cfg.Safety.AllowedTools = []string{"fs.read", "shell.exec"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "safety.allowed_tools") {
+		t.Errorf("expected an unknown allowed tool to be rejected, got %v", err)
+	}
+}
+
+// TestValidateAutoTitle tests the conversation title modes
+func TestValidateAutoTitle(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.Behavior.AutoTitle != "heuristic" {
+		t.Errorf("expected heuristic titles by default, got %q", cfg.Behavior.AutoTitle)
+	}
+	for _, mode := range []string{"off", "heuristic", "model"} {
+		cfg.Behavior.AutoTitle = mode
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected %q to be valid, got %v", mode, err)
+		}
+	}
+	cfg.Behavior.AutoTitle = "llm"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unknown title mode to be rejected")
+	}
+}
+
+// TestValidateToolResultMaxLines tests the tool result truncation bounds
+func TestValidateToolResultMaxLines(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.Behavior.ToolResultMaxLines != 0 {
+		t.Errorf("expected tool results sent in full by default, got %d lines", cfg.Behavior.ToolResultMaxLines)
+	}
+	cfg.Behavior.ToolResultMaxLines = 200
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected 200 lines to be valid, got %v", err)
+	}
+	cfg.Behavior.ToolResultMaxLines = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a negative line limit to be rejected")
+	}
+}
+
+// TestValidateTheme tests the TUI theme defaults and role names
+func TestValidateTheme(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.TUI.Theme.Cursor != "▊" {
+		t.Errorf("expected the default streaming cursor, got %q", cfg.TUI.Theme.Cursor)
+	}
+	cfg.TUI.Theme.Cursor = ""
+	cfg.TUI.Theme.RoleColors = map[string]string{"user": "39", "reasoning": "#888888"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected a hidden cursor and known roles to be valid, got %v", err)
+	}
+	cfg.TUI.Theme.RoleColors = map[string]string{"narrator": "39"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unknown role to be rejected")
+	}
+}
+
+// TestValidateInputCharLimit tests the TUI input limit bounds
+func TestValidateInputCharLimit(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.TUI.InputCharLimit != 4000 {
+		t.Errorf("expected default input limit 4000, got %d", cfg.TUI.InputCharLimit)
+	}
+	cfg.TUI.InputCharLimit = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected 0 (unlimited) to be valid, got %v", err)
+	}
+	cfg.TUI.InputCharLimit = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a negative input limit to be rejected")
+	}
+}
+
+// TestValidateMemoryMax tests the inspect panel memory capacity bounds
+func TestValidateMemoryMax(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.TUI.MemoryMax != 0 {
+		t.Errorf("expected the memory capacity derived by default, got %d", cfg.TUI.MemoryMax)
+	}
+	cfg.TUI.MemoryMax = 64
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected a positive capacity to be valid, got %v", err)
+	}
+	cfg.TUI.MemoryMax = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a negative memory capacity to be rejected")
+	}
+}
+
+// TestValidatePrompts tests the per-mode input prompt keys
+func TestValidatePrompts(t *testing.T) {
+	cfg := LoadDefaults()
+	if cfg.TUI.Prompts["command"] != "cmd> " {
+		t.Errorf("expected the default command prompt, got %q", cfg.TUI.Prompts["command"])
+	}
+	cfg.TUI.Prompts = map[string]string{"chat": "> ", "diff": ""}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected known modes to be valid, got %v", err)
+	}
+	cfg.TUI.Prompts = map[string]string{"shell": "$ "}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+}
+
 // TestEnvironmentVariableOverrides tests that environment variables properly override config
 func TestEnvironmentVariableOverrides(t *testing.T) {
 	// Save original env vars
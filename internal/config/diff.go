@@ -0,0 +1,69 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// FieldDiff is a config field whose value differs between two configs
+type FieldDiff struct {
+	Field string `json:"field"` // Dotted YAML path, e.g. "llm.model"
+	A     string `json:"a"`     // JSON-encoded value in the first config
+	B     string `json:"b"`     // JSON-encoded value in the second config
+}
+
+// Diff compares two configs field by field and returns the differences in
+// declaration order. Only YAML-backed fields are compared; legacy CLI
+// fields are ignored.
+func Diff(a, b Config) []FieldDiff {
+	var diffs []FieldDiff
+	diffValues("", reflect.ValueOf(a), reflect.ValueOf(b), &diffs)
+	return diffs
+}
+
+// diffValues walks two struct values, appending differing leaf fields
+func diffValues(prefix string, a, b reflect.Value, diffs *[]FieldDiff) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		av, bv := a.Field(i), b.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			diffValues(name, av, bv, diffs)
+			continue
+		}
+		if reflect.DeepEqual(normalizeEmpty(av), normalizeEmpty(bv)) {
+			continue
+		}
+		*diffs = append(*diffs, FieldDiff{
+			Field: name,
+			A:     encodeValue(av),
+			B:     encodeValue(bv),
+		})
+	}
+}
+
+// normalizeEmpty treats nil and empty slices as equal
+func normalizeEmpty(v reflect.Value) any {
+	if v.Kind() == reflect.Slice && v.Len() == 0 {
+		return nil
+	}
+	return v.Interface()
+}
+
+// encodeValue renders a field value as JSON for display
+func encodeValue(v reflect.Value) string {
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "?"
+	}
+	return string(b)
+}
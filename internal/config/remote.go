@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Limits for configs fetched from a URL
+const (
+	remoteConfigTimeout  = 10 * time.Second
+	maxRemoteConfigBytes = 1 << 20
+)
+
+// insecureConfigEnv allows fetching a config over plain http. A remote
+// config can change permissions and providers, so it must otherwise come
+// over https.
+const insecureConfigEnv = "GOSHI_CONFIG_INSECURE"
+
+// isRemoteConfig reports whether a config location is an http(s) URL
+func isRemoteConfig(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// readConfigSource reads a config from a local path or, for http(s) URLs,
+// fetches it with a timeout and size cap
+func readConfigSource(path string) ([]byte, error) {
+	if !isRemoteConfig(path) {
+		return os.ReadFile(path)
+	}
+	if strings.HasPrefix(path, "http://") && !parseBool(os.Getenv(insecureConfigEnv)) {
+		return nil, fmt.Errorf("refusing to fetch remote config over plain http; use https or set %s=true", insecureConfigEnv)
+	}
+	return fetchRemoteConfig(path, remoteConfigTimeout, maxRemoteConfigBytes)
+}
+
+// fetchRemoteConfig downloads a config, refusing bodies over maxBytes
+func fetchRemoteConfig(url string, timeout time.Duration, maxBytes int64) ([]byte, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remote config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch remote config: HTTP %d", resp.StatusCode)
+	}
+
+	// Read one byte past the cap to tell "exactly at the limit" from "over"
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote config: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("remote config exceeds the %d byte limit", maxBytes)
+	}
+	return data, nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestLoadYAMLFromURL tests that GOSHI_CONFIG may point at an http(s) URL
+func TestLoadYAMLFromURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("llm:\n  provider: openai\n  model: gpt-4o\n"))
+	}))
+	defer server.Close()
+	t.Setenv("GOSHI_CONFIG", server.URL+"/goshi.yaml")
+	t.Setenv(insecureConfigEnv, "true")
+
+	cfg, err := LoadYAML()
+	if err != nil {
+		t.Fatalf("LoadYAML failed: %v", err)
+	}
+	if cfg.LLM.Provider != "openai" || cfg.LLM.Model != "gpt-4o" {
+		t.Errorf("expected the remote config to load, got provider=%s model=%s", cfg.LLM.Provider, cfg.LLM.Model)
+	}
+}
+
+// TestLoadYAMLFromURLErrors tests that oversized, failing and unreachable
+// remote configs error clearly
+func TestLoadYAMLFromURLErrors(t *testing.T) {
+	large := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# " + strings.Repeat("x", maxRemoteConfigBytes) + "\n"))
+	}))
+	defer large.Close()
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachableURL := unreachable.URL
+	unreachable.Close()
+
+	cases := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"too large", large.URL, "exceeds the 1048576 byte limit"},
+		{"not found", missing.URL, "HTTP 404"},
+		{"unreachable", unreachableURL, "failed to fetch remote config"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GOSHI_CONFIG", tc.url)
+			t.Setenv(insecureConfigEnv, "true")
+			_, err := LoadYAML()
+			if err == nil || !strings.Contains(err.Error(), tc.want) || !strings.Contains(err.Error(), tc.url) {
+				t.Errorf("expected an error naming %s containing %q, got %v", tc.url, tc.want, err)
+			}
+		})
+	}
+}
+
+// TestLoadYAMLFromURLRequiresHTTPS tests that a plain http config is only
+// fetched when explicitly allowed
+func TestLoadYAMLFromURLRequiresHTTPS(t *testing.T) {
+	fetched := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = true
+		w.Write([]byte("llm:\n  provider: openai\n"))
+	}))
+	defer server.Close()
+	t.Setenv("GOSHI_CONFIG", server.URL)
+	t.Setenv(insecureConfigEnv, "")
+
+	_, err := LoadYAML()
+	if err == nil || !strings.Contains(err.Error(), "plain http") || !strings.Contains(err.Error(), insecureConfigEnv) {
+		t.Errorf("expected plain http to be refused, got %v", err)
+	}
+	if fetched {
+		t.Error("expected no request over plain http")
+	}
+}
+
+// TestLoadFailsClosedOnRemoteConfigError tests that an unusable remote
+// config is reported for the caller to refuse to start, not replaced by
+// the defaults with a warning
+func TestLoadFailsClosedOnRemoteConfigError(t *testing.T) {
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	t.Setenv("GOSHI_CONFIG", missing.URL)
+	t.Setenv(insecureConfigEnv, "true")
+	Reset()
+	defer Reset()
+
+	Load()
+	if err := RemoteConfigError(); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("expected the remote config error kept, got %v", err)
+	}
+
+	Reset()
+	if err := RemoteConfigError(); err != nil {
+		t.Errorf("expected Reset to clear the error, got %v", err)
+	}
+}
+
+// TestIsRemoteConfig tests that only http(s) URLs are fetched
+func TestIsRemoteConfig(t *testing.T) {
+	for path, want := range map[string]bool{
+		"https://config.example.com/goshi.yaml": true,
+		"http://localhost:8080/goshi.yaml":      true,
+		"/etc/goshi/config.yaml":                false,
+		"goshi.yaml":                            false,
+	} {
+		if got := isRemoteConfig(path); got != want {
+			t.Errorf("isRemoteConfig(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package detect
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Capability string
 
 const (
@@ -14,22 +19,47 @@ type Rule struct {
 	Window     int
 }
 
+// Match records the words that made a rule detect its capability
+type Match struct {
+	Capability Capability
+	Verb       string
+	Noun       string
+	Window     int
+	Phrase     string // Input tokens from the verb to the noun
+}
+
+// String describes the match for display, e.g.
+// fs_read: "list the files" (verb "list" within 3 words of "files")
+func (m Match) String() string {
+	return fmt.Sprintf("%s: %q (verb %q within %d words of %q)", m.Capability, m.Phrase, m.Verb, m.Window, m.Noun)
+}
+
 // DetectCapabilities evaluates rules against tokenized input.
 func DetectCapabilities(prompt string, rules []Rule) []Capability {
+	var detected []Capability
+	for _, match := range DetectMatches(prompt, rules) {
+		detected = append(detected, match.Capability)
+	}
+	return detected
+}
+
+// DetectMatches evaluates rules against tokenized input, returning the
+// first match of each rule that fired.
+func DetectMatches(prompt string, rules []Rule) []Match {
 	tokens := Tokenize(prompt)
 
-	var detected []Capability
+	var matches []Match
 
 	for _, rule := range rules {
-		if matchRule(tokens, rule) {
-			detected = append(detected, rule.Capability)
+		if match, ok := matchRule(tokens, rule); ok {
+			matches = append(matches, match)
 		}
 	}
 
-	return detected
+	return matches
 }
 
-func matchRule(tokens []string, rule Rule) bool {
+func matchRule(tokens []string, rule Rule) (Match, bool) {
 	for i, tok := range tokens {
 		if !contains(rule.Verbs, tok) {
 			continue
@@ -47,12 +77,22 @@ func matchRule(tokens []string, rule Rule) bool {
 
 		for j := start; j <= end; j++ {
 			if contains(rule.Nouns, tokens[j]) {
-				return true
+				from, to := i, j
+				if from > to {
+					from, to = to, from
+				}
+				return Match{
+					Capability: rule.Capability,
+					Verb:       tok,
+					Noun:       tokens[j],
+					Window:     rule.Window,
+					Phrase:     strings.Join(tokens[from:to+1], " "),
+				}, true
 			}
 		}
 	}
 
-	return false
+	return Match{}, false
 }
 
 func contains(list []string, v string) bool {
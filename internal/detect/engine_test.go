@@ -0,0 +1,34 @@
+package detect
+
+import "testing"
+
+// TestDetectMatchesReportsTriggeringWords tests that matches carry the rule's trigger
+func TestDetectMatchesReportsTriggeringWords(t *testing.T) {
+	matches := DetectMatches("Please list all the files here", FSReadRules)
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+
+	m := matches[0]
+	if m.Capability != CapabilityFSRead || m.Verb != "list" || m.Noun != "files" {
+		t.Errorf("unexpected match: %+v", m)
+	}
+	if m.Phrase != "list all the files" {
+		t.Errorf("expected phrase %q, got %q", "list all the files", m.Phrase)
+	}
+	want := `fs_read: "list all the files" (verb "list" within 3 words of "files")`
+	if m.String() != want {
+		t.Errorf("String() = %q, want %q", m.String(), want)
+	}
+}
+
+// TestDetectMatchesNounBeforeVerb tests phrases where the noun precedes the verb
+func TestDetectMatchesNounBeforeVerb(t *testing.T) {
+	matches := DetectMatches("the file, please update it", FSWriteRules)
+	if len(matches) != 1 || matches[0].Phrase != "file please update" {
+		t.Errorf("expected the phrase to run from noun to verb, got %+v", matches)
+	}
+	if caps := DetectCapabilities("hello there", FSReadRules); len(caps) != 0 {
+		t.Errorf("expected no capabilities, got %v", caps)
+	}
+}
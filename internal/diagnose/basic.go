@@ -12,6 +12,7 @@ func (d *BasicDiagnoser) Diagnose(res detect.Result) (Result, error) {
 	for _, bin := range res.MissingBinaries {
 		out.Issues = append(out.Issues, Issue{
 			Code:     "missing_binary",
+			Category: CategoryBinary,
 			Message:  "binary not found: " + bin,
 			Strategy: "install_" + bin,
 			Severity: SeverityError,
@@ -21,6 +22,7 @@ func (d *BasicDiagnoser) Diagnose(res detect.Result) (Result, error) {
 	for _, w := range res.Warnings {
 		out.Issues = append(out.Issues, Issue{
 			Code:     "warning",
+			Category: CategoryEnvironment,
 			Message:  w,
 			Strategy: "manual_review",
 			Severity: SeverityWarn,
@@ -181,6 +181,76 @@ func TestBasicDiagnoserResultStructure(t *testing.T) {
 	}
 }
 
+// TestBasicDiagnoserCategories tests that issues carry their subsystem category
+func TestBasicDiagnoserCategories(t *testing.T) {
+	diagnoser := &BasicDiagnoser{}
+	detectResult := detect.Result{
+		MissingBinaries: []string{"git"},
+		Warnings:        []string{"PATH is empty"},
+	}
+
+	result, err := diagnoser.Diagnose(detectResult)
+	if err != nil {
+		t.Fatalf("expected diagnosis to succeed, got error: %v", err)
+	}
+
+	for _, issue := range result.Issues {
+		switch issue.Code {
+		case "missing_binary":
+			if issue.Category != CategoryBinary {
+				t.Errorf("expected category %q for missing binary, got %q", CategoryBinary, issue.Category)
+			}
+		case "warning":
+			if issue.Category != CategoryEnvironment {
+				t.Errorf("expected category %q for warning, got %q", CategoryEnvironment, issue.Category)
+			}
+		}
+	}
+}
+
+// TestGroupByCategory tests grouping keeps first-seen order and buckets uncategorized issues
+func TestGroupByCategory(t *testing.T) {
+	issues := []Issue{
+		{Code: "missing_binary", Category: CategoryBinary},
+		{Code: "INTEGRITY_NO_MANIFEST", Category: CategoryIntegrity},
+		{Code: "missing_binary", Category: CategoryBinary},
+		{Code: "mystery"},
+	}
+
+	groups := GroupByCategory(issues)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	if groups[0].Category != CategoryBinary || len(groups[0].Issues) != 2 {
+		t.Errorf("expected 2 binary issues first, got %+v", groups[0])
+	}
+	if groups[1].Category != CategoryIntegrity {
+		t.Errorf("expected integrity group second, got %q", groups[1].Category)
+	}
+	if groups[2].Category != CategoryOther {
+		t.Errorf("expected uncategorized issues under %q, got %q", CategoryOther, groups[2].Category)
+	}
+}
+
+// TestFormatHumanGroupsIssues tests human output lists issues under category headings
+func TestFormatHumanGroupsIssues(t *testing.T) {
+	issues := []Issue{
+		{Code: "missing_binary", Category: CategoryBinary, Message: "binary not found: git", Strategy: "install_git", Severity: SeverityError},
+		{Code: "MOD_NOT_TIDY", Category: CategoryModules, Message: "not tidy", Strategy: "go mod tidy", Severity: SeverityWarn},
+		{Code: "missing_binary", Category: CategoryBinary, Message: "binary not found: jq", Strategy: "install_jq", Severity: SeverityError},
+	}
+
+	out := FormatHuman(issues)
+	expected := "binary:\n" +
+		" - [error][missing_binary] binary not found: git (suggested: install_git)\n" +
+		" - [error][missing_binary] binary not found: jq (suggested: install_jq)\n" +
+		"modules:\n" +
+		" - [warn][MOD_NOT_TIDY] not tidy (suggested: go mod tidy)\n"
+	if out != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substring string) bool {
 	for i := 0; i <= len(s)-len(substring); i++ {
@@ -190,3 +260,28 @@ func contains(s, substring string) bool {
 	}
 	return false
 }
+
+// TestFilterBySeverity tests that only issues at or above the threshold are kept
+func TestFilterBySeverity(t *testing.T) {
+	issues := []Issue{
+		{Code: "a", Severity: SeverityOK},
+		{Code: "b", Severity: SeverityWarn},
+		{Code: "c", Severity: SeverityError},
+		{Code: "d", Severity: SeverityFatal},
+	}
+
+	tests := map[Severity]string{
+		SeverityWarn:  "bcd",
+		SeverityError: "cd",
+		SeverityFatal: "d",
+	}
+	for min, want := range tests {
+		got := ""
+		for _, issue := range FilterBySeverity(issues, min) {
+			got += issue.Code
+		}
+		if got != want {
+			t.Errorf("FilterBySeverity(%s) kept %q, want %q", min, got, want)
+		}
+	}
+}
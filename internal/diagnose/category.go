@@ -0,0 +1,65 @@
+package diagnose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Category groups issues by the subsystem they concern.
+type Category string
+
+const (
+	CategoryBinary      Category = "binary"
+	CategoryEnvironment Category = "environment"
+	CategoryModules     Category = "modules"
+	CategoryIntegrity   Category = "integrity"
+	CategoryLLM         Category = "llm"
+	CategoryConfig      Category = "config"
+	CategoryOther       Category = "other"
+)
+
+// IssueGroup is the set of issues belonging to one category.
+type IssueGroup struct {
+	Category Category
+	Issues   []Issue
+}
+
+// GroupByCategory groups issues by category, keeping categories in the
+// order they first appear. Uncategorized issues are grouped under "other".
+func GroupByCategory(issues []Issue) []IssueGroup {
+	var groups []IssueGroup
+	index := map[Category]int{}
+
+	for _, issue := range issues {
+		cat := issue.Category
+		if cat == "" {
+			cat = CategoryOther
+		}
+		i, ok := index[cat]
+		if !ok {
+			i = len(groups)
+			index[cat] = i
+			groups = append(groups, IssueGroup{Category: cat})
+		}
+		groups[i].Issues = append(groups[i].Issues, issue)
+	}
+
+	return groups
+}
+
+// FormatHuman renders issues grouped by category for terminal output.
+func FormatHuman(issues []Issue) string {
+	var sb strings.Builder
+	for _, group := range GroupByCategory(issues) {
+		fmt.Fprintf(&sb, "%s:\n", group.Category)
+		for _, issue := range group.Issues {
+			fmt.Fprintf(&sb, " - [%s][%s] %s (suggested: %s)\n",
+				issue.Severity,
+				issue.Code,
+				issue.Message,
+				issue.Strategy,
+			)
+		}
+	}
+	return sb.String()
+}
@@ -2,6 +2,7 @@ package diagnose
 
 type Issue struct {
 	Code     string
+	Category Category
 	Message  string
 	Strategy string
 	Severity Severity
@@ -0,0 +1,42 @@
+package diagnose
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cshaiku/goshi/internal/selfmodel"
+)
+
+// CheckSelfModelVersion reports an issue when the self-model's version is
+// missing or its major.minor differs from the version goshi expects
+func CheckSelfModelVersion(raw, expected string) []Issue {
+	got := selfmodel.Version(raw)
+	if got == "" {
+		return []Issue{{
+			Code:     "SELFMODEL_VERSION_MISSING",
+			Category: CategoryConfig,
+			Message:  fmt.Sprintf("self-model declares no model.model_version (expected %s)", expected),
+			Strategy: "Add model.model_version to goshi.self.model.yaml",
+			Severity: SeverityWarn,
+		}}
+	}
+	if majorMinor(got) != majorMinor(expected) {
+		return []Issue{{
+			Code:     "SELFMODEL_VERSION_MISMATCH",
+			Category: CategoryConfig,
+			Message:  fmt.Sprintf("self-model version %s does not match the version goshi expects (%s)", got, expected),
+			Strategy: "Update goshi.self.model.yaml or the goshi binary so their versions agree",
+			Severity: SeverityWarn,
+		}}
+	}
+	return nil
+}
+
+// majorMinor trims a version to its major.minor prefix
+func majorMinor(v string) string {
+	parts := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(v), "v"), ".", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,35 @@
+package diagnose
+
+import "testing"
+
+func TestCheckSelfModelVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		wantCode string
+	}{
+		{"matching", "model:\n  model_version: \"1.0.1\"\n", ""},
+		{"hotfix differs", "model:\n  model_version: \"1.0.4\"\n", ""},
+		{"minor differs", "model:\n  model_version: \"1.1.0\"\n", "SELFMODEL_VERSION_MISMATCH"},
+		{"major differs", "model:\n  model_version: \"2.0.1\"\n", "SELFMODEL_VERSION_MISMATCH"},
+		{"missing", "primary_laws:\n  - \"Ask before writing\"\n", "SELFMODEL_VERSION_MISSING"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := CheckSelfModelVersion(tt.raw, "1.0.1")
+			if tt.wantCode == "" {
+				if len(issues) != 0 {
+					t.Errorf("expected no issues, got %+v", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || issues[0].Code != tt.wantCode {
+				t.Fatalf("expected a %s issue, got %+v", tt.wantCode, issues)
+			}
+			if issues[0].Severity != SeverityWarn || issues[0].Category != CategoryConfig {
+				t.Errorf("expected a config warning, got %+v", issues[0])
+			}
+		})
+	}
+}
@@ -8,3 +8,27 @@ const (
 	SeverityError Severity = "error"
 	SeverityFatal Severity = "fatal"
 )
+
+// severityRank orders severities from least to most severe
+var severityRank = map[Severity]int{
+	SeverityOK:    0,
+	SeverityWarn:  1,
+	SeverityError: 2,
+	SeverityFatal: 3,
+}
+
+// AtLeast reports whether s is as severe as min or more
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRank[s] >= severityRank[min]
+}
+
+// FilterBySeverity returns the issues at or above min
+func FilterBySeverity(issues []Issue, min Severity) []Issue {
+	out := make([]Issue, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Severity.AtLeast(min) {
+			out = append(out, issue)
+		}
+	}
+	return out
+}
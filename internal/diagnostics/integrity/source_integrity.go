@@ -80,6 +80,7 @@ func (d *IntegrityDiagnostic) Run() []diagnose.Issue {
 	if _, err := os.Stat(d.ManifestPath); os.IsNotExist(err) {
 		issues = append(issues, diagnose.Issue{
 			Code:     "INTEGRITY_NO_MANIFEST",
+			Category: diagnose.CategoryIntegrity,
 			Message:  fmt.Sprintf("No integrity manifest found at %s", d.ManifestPath),
 			Strategy: "Run 'scripts/generate_goshi_manifest.sh' to create the reference bundle",
 			Severity: diagnose.SeverityWarn,
@@ -92,6 +93,7 @@ func (d *IntegrityDiagnostic) Run() []diagnose.Issue {
 	if err != nil {
 		issues = append(issues, diagnose.Issue{
 			Code:     "INTEGRITY_PARSE_ERROR",
+			Category: diagnose.CategoryIntegrity,
 			Message:  fmt.Sprintf("Failed to parse integrity manifest: %v", err),
 			Severity: diagnose.SeverityError,
 		})
@@ -102,6 +104,7 @@ func (d *IntegrityDiagnostic) Run() []diagnose.Issue {
 	if manifest.Tarball.Path == "" {
 		issues = append(issues, diagnose.Issue{
 			Code:     "INTEGRITY_TARBALL_NOT_DECLARED",
+			Category: diagnose.CategoryIntegrity,
 			Message:  "Integrity manifest is missing tarball metadata",
 			Strategy: "Regenerate the reference bundle with 'scripts/generate_goshi_manifest.sh'",
 			Severity: diagnose.SeverityError,
@@ -113,6 +116,7 @@ func (d *IntegrityDiagnostic) Run() []diagnose.Issue {
 	if _, err := os.Stat(tarballPath); os.IsNotExist(err) {
 		issues = append(issues, diagnose.Issue{
 			Code:     "INTEGRITY_TARBALL_MISSING",
+			Category: diagnose.CategoryIntegrity,
 			Message:  fmt.Sprintf("Source tarball missing at %s", tarballPath),
 			Strategy: "Regenerate the reference bundle with 'scripts/generate_goshi_manifest.sh'",
 			Severity: diagnose.SeverityError,
@@ -124,6 +128,7 @@ func (d *IntegrityDiagnostic) Run() []diagnose.Issue {
 	if err != nil {
 		issues = append(issues, diagnose.Issue{
 			Code:     "INTEGRITY_TARBALL_UNREADABLE",
+			Category: diagnose.CategoryIntegrity,
 			Message:  fmt.Sprintf("Failed to read tarball: %v", err),
 			Severity: diagnose.SeverityError,
 		})
@@ -133,6 +138,7 @@ func (d *IntegrityDiagnostic) Run() []diagnose.Issue {
 	if tarballHash != manifest.Tarball.Hash {
 		issues = append(issues, diagnose.Issue{
 			Code:     "INTEGRITY_TARBALL_HASH_MISMATCH",
+			Category: diagnose.CategoryIntegrity,
 			Message:  "Source tarball hash does not match manifest",
 			Strategy: "Regenerate the reference bundle with 'scripts/generate_goshi_manifest.sh'",
 			Severity: diagnose.SeverityError,
@@ -147,6 +153,7 @@ func (d *IntegrityDiagnostic) Run() []diagnose.Issue {
 	if len(result.MissingFiles) > 0 {
 		issues = append(issues, diagnose.Issue{
 			Code:     "INTEGRITY_MISSING_FILES",
+			Category: diagnose.CategoryIntegrity,
 			Message:  fmt.Sprintf("%d tracked files are missing:\n%s", len(result.MissingFiles), strings.Join(result.MissingFiles, "\n")),
 			Strategy: "Files may have been deleted or moved. Regenerate the reference bundle if this is intentional.",
 			Severity: diagnose.SeverityError,
@@ -162,6 +169,7 @@ func (d *IntegrityDiagnostic) Run() []diagnose.Issue {
 		}
 		issues = append(issues, diagnose.Issue{
 			Code:     "INTEGRITY_HASH_MISMATCH",
+			Category: diagnose.CategoryIntegrity,
 			Message:  fmt.Sprintf("%d files have been modified:\n%s", len(result.ModifiedFiles), strings.Join(modifiedList, "\n")),
 			Strategy: "Review changes and regenerate the reference bundle after committing valid changes.",
 			Severity: diagnose.SeverityError,
@@ -172,6 +180,7 @@ func (d *IntegrityDiagnostic) Run() []diagnose.Issue {
 	if len(issues) == 0 {
 		issues = append(issues, diagnose.Issue{
 			Code:     "INTEGRITY_OK",
+			Category: diagnose.CategoryIntegrity,
 			Message:  fmt.Sprintf("All %d files verified successfully. Source file integrity check passed.", result.VerifiedFiles),
 			Severity: diagnose.SeverityOK,
 		})
@@ -27,6 +27,7 @@ func (m *ModuleDiagnostic) Run() []diagnose.Issue {
 			issues = append(issues, diagnose.Issue{
 				Severity: diagnose.SeverityError,
 				Code:     "MOD_VERIFY_FAILED",
+				Category: diagnose.CategoryModules,
 				Message:  fmt.Sprintf("Module integrity check failed: %v", err),
 				Strategy: "Run 'go mod verify' and 'go mod tidy' to fix",
 			})
@@ -39,6 +40,7 @@ func (m *ModuleDiagnostic) Run() []diagnose.Issue {
 			issues = append(issues, diagnose.Issue{
 				Severity: diagnose.SeverityWarn,
 				Code:     "MOD_NOT_TIDY",
+				Category: diagnose.CategoryModules,
 				Message:  "go.mod/go.sum may have unused or missing dependencies",
 				Strategy: "Run 'go mod tidy' to clean up",
 			})
@@ -52,6 +54,7 @@ func (m *ModuleDiagnostic) Run() []diagnose.Issue {
 			issues = append(issues, diagnose.Issue{
 				Severity: diagnose.SeverityWarn,
 				Code:     "MOD_UPDATE_CHECK_FAILED",
+				Category: diagnose.CategoryModules,
 				Message:  fmt.Sprintf("Unable to check for updates: %v", err),
 				Strategy: "Ensure network connectivity and try 'go list -u -m all'",
 			})
@@ -59,6 +62,7 @@ func (m *ModuleDiagnostic) Run() []diagnose.Issue {
 			issues = append(issues, diagnose.Issue{
 				Severity: diagnose.SeverityWarn,
 				Code:     "MOD_OUTDATED",
+				Category: diagnose.CategoryModules,
 				Message:  fmt.Sprintf("%d outdated dependencies found", len(outdated)),
 				Strategy: "Run 'go get -u ./...' to update, or 'go list -u -m all' to review",
 			})
@@ -73,6 +77,7 @@ func (m *ModuleDiagnostic) Run() []diagnose.Issue {
 			issues = append(issues, diagnose.Issue{
 				Severity: diagnose.SeverityWarn,
 				Code:     "VULN_CHECK_UNAVAILABLE",
+				Category: diagnose.CategoryModules,
 				Message:  "govulncheck not available for vulnerability scanning",
 				Strategy: "Install with 'go install golang.org/x/vuln/cmd/govulncheck@latest'",
 			})
@@ -80,6 +85,7 @@ func (m *ModuleDiagnostic) Run() []diagnose.Issue {
 			issues = append(issues, diagnose.Issue{
 				Severity: diagnose.SeverityError,
 				Code:     "VULN_DETECTED",
+				Category: diagnose.CategoryModules,
 				Message:  fmt.Sprintf("Known vulnerabilities detected in dependencies"),
 				Strategy: "Run 'govulncheck ./...' for details and update affected packages",
 			})
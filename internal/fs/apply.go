@@ -13,6 +13,10 @@ func ApplyWriteProposal(id string) error {
 		return err
 	}
 
+	if len(p.Writes) > 0 {
+		return applyBatch(p.Writes)
+	}
+
 	if !p.IsNewFile {
 		current, err := os.ReadFile(p.Path)
 		if err != nil {
@@ -0,0 +1,74 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BackupDir returns the directory backups are kept in under a repo root
+func BackupDir(root string) string {
+	return filepath.Join(root, ".goshi", "backups")
+}
+
+// BackupPath returns the location of the backup taken for a proposal
+func BackupPath(root, id string) string {
+	return filepath.Join(BackupDir(root), id+".bak")
+}
+
+// SaveBackup stores the pre-write content of a file for a proposal under
+// the repo root's .goshi/backups directory. Backups may hold secrets, so
+// they are readable only by the owner.
+func SaveBackup(root, id string, data []byte) (string, error) {
+	path := BackupPath(root, id)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// LoadBackup reads a backup previously saved with SaveBackup
+func LoadBackup(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// PruneBackups removes backups under root last modified more than olderThan
+// ago, returning the names of the files removed. A missing backup directory
+// is not an error, and olderThan <= 0 keeps every backup.
+func PruneBackups(root string, olderThan time.Duration) ([]string, error) {
+	if olderThan <= 0 {
+		return nil, nil
+	}
+
+	dir := BackupDir(root)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup dir: %w", err)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var removed []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".bak") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err == nil {
+			removed = append(removed, entry.Name())
+		}
+	}
+	return removed, nil
+}
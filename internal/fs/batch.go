@@ -0,0 +1,110 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// applyBatch writes every file of a multi-file proposal or none of them.
+// All targets are checked for drift and the new content is staged next to
+// each target before any file is replaced. Existing files keep their mode,
+// as with a single-file write. If a replacement fails, the files already
+// replaced are restored.
+func applyBatch(writes []WriteProposal) error {
+	originals := make([][]byte, len(writes))
+	modes := make([]os.FileMode, len(writes))
+	for i, w := range writes {
+		modes[i] = 0644
+		if info, err := os.Stat(w.Path); err == nil {
+			modes[i] = info.Mode().Perm()
+		}
+		current, err := os.ReadFile(w.Path)
+		switch {
+		case w.IsNewFile && err == nil:
+			return fmt.Errorf("%w: %s", ErrDriftDetected, w.Path)
+		case w.IsNewFile && os.IsNotExist(err):
+			continue
+		case err != nil:
+			return err
+		case hashBytes(current) != w.BaseHash:
+			return fmt.Errorf("%w: %s", ErrDriftDetected, w.Path)
+		}
+		originals[i] = current
+	}
+
+	staged := make([]string, 0, len(writes))
+	defer func() {
+		// Replaced files were renamed away; this only cleans up leftovers
+		for _, tmp := range staged {
+			os.Remove(tmp)
+		}
+	}()
+	for i, w := range writes {
+		tmp, err := stageFile(w.Path, w.Content, modes[i])
+		if err != nil {
+			return fmt.Errorf("failed to stage %s: %w", w.Path, err)
+		}
+		staged = append(staged, tmp)
+	}
+
+	for i, w := range writes {
+		if err := os.Rename(staged[i], w.Path); err != nil {
+			err = fmt.Errorf("failed to write %s: %w", w.Path, err)
+			if restoreErr := restoreBatch(writes[:i], originals, modes); restoreErr != nil {
+				return errors.Join(err, restoreErr)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stageFile writes content to a temporary file in the target's directory,
+// so replacing the target is a rename on the same filesystem. The staged
+// file gets the mode the target should have.
+func stageFile(path string, content []byte, mode os.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), ".goshi-apply-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := os.Chmod(f.Name(), mode); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// restoreBatch undoes the writes already applied from a failed batch,
+// putting back each original with its mode. It returns an error for every
+// file it could not restore, since the tree is then left half-applied.
+func restoreBatch(applied []WriteProposal, originals [][]byte, modes []os.FileMode) error {
+	var errs []error
+	for i, w := range applied {
+		if w.IsNewFile {
+			if err := os.Remove(w.Path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("failed to remove %s: %w", w.Path, err))
+			}
+			continue
+		}
+		if err := os.WriteFile(w.Path, originals[i], modes[i]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", w.Path, err))
+			continue
+		}
+		if err := os.Chmod(w.Path, modes[i]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore the mode of %s: %w", w.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
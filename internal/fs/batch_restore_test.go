@@ -0,0 +1,56 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRestoreBatchRestoresModes(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.env")
+	if err := os.WriteFile(secret, []byte("replaced"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	applied := []WriteProposal{{Path: secret}}
+	if err := restoreBatch(applied, [][]byte{[]byte("TOKEN=1")}, []os.FileMode{0600}); err != nil {
+		t.Fatalf("restoreBatch failed: %v", err)
+	}
+
+	data, err := os.ReadFile(secret)
+	if err != nil || string(data) != "TOKEN=1" {
+		t.Fatalf("expected the original content back, got %q (%v)", data, err)
+	}
+	info, err := os.Stat(secret)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("expected the original mode 0600 back, got %o", mode)
+	}
+}
+
+func TestRestoreBatchReportsEveryFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	// A new file that turned into a non-empty directory cannot be removed,
+	// and an original cannot be written back where its directory is gone
+	created := filepath.Join(dir, "created")
+	if err := os.MkdirAll(filepath.Join(created, "child"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	gone := filepath.Join(dir, "gone", "a.txt")
+
+	applied := []WriteProposal{{Path: created, IsNewFile: true}, {Path: gone}}
+	err := restoreBatch(applied, [][]byte{nil, []byte("old")}, []os.FileMode{0644, 0644})
+	if err == nil {
+		t.Fatal("expected the restore failures reported")
+	}
+	for _, want := range []string{"failed to remove " + created, "failed to restore " + gone} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in the error, got %v", want, err)
+		}
+	}
+}
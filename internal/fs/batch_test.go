@@ -0,0 +1,152 @@
+package fs_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/fs"
+)
+
+// newBatch saves a multi-file proposal writing each path's content
+func newBatch(t *testing.T, workspace string, files map[string]string, order ...string) fs.Proposal {
+	t.Helper()
+
+	var writes []fs.Proposal
+	for _, name := range order {
+		path := filepath.Join(workspace, name)
+		content := []byte(files[name])
+		w := fs.Proposal{
+			Path:        path,
+			IsNewFile:   true,
+			ContentHash: fs.ComputeHash(content),
+			Content:     content,
+		}
+		if before, err := os.ReadFile(path); err == nil {
+			w.IsNewFile = false
+			w.BaseHash = fs.ComputeHash(before)
+		}
+		w.ID = fs.ProposalID(path, w.IsNewFile, w.BaseHash, w.ContentHash)
+		writes = append(writes, w)
+	}
+
+	p := fs.Proposal{ID: fs.BatchProposalID(writes), Writes: writes}
+	if err := fs.SaveProposal(p); err != nil {
+		t.Fatalf("SaveProposal failed: %v", err)
+	}
+	return p
+}
+
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	workspace := t.TempDir()
+	oldwd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(oldwd) })
+	if err := os.Chdir(workspace); err != nil {
+		t.Fatalf("chdir failed: %v", err)
+	}
+	return workspace
+}
+
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if string(got) != want {
+		t.Errorf("expected %s to contain %q, got %q", filepath.Base(path), want, got)
+	}
+}
+
+func TestApplyBatchWritesAllFiles(t *testing.T) {
+	workspace := chdirTemp(t)
+	existing := filepath.Join(workspace, "a.txt")
+	if err := os.WriteFile(existing, []byte("old"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	p := newBatch(t, workspace, map[string]string{"a.txt": "new a", "b.txt": "new b"}, "a.txt", "b.txt")
+	if err := fs.ApplyWriteProposal(p.ID); err != nil {
+		t.Fatalf("ApplyWriteProposal failed: %v", err)
+	}
+
+	assertContent(t, existing, "new a")
+	assertContent(t, filepath.Join(workspace, "b.txt"), "new b")
+
+	entries, _ := os.ReadDir(workspace)
+	for _, e := range entries {
+		if e.Name() != "a.txt" && e.Name() != "b.txt" && e.Name() != ".goshi" {
+			t.Errorf("unexpected leftover file %s", e.Name())
+		}
+	}
+}
+
+func TestApplyBatchKeepsFileModes(t *testing.T) {
+	workspace := chdirTemp(t)
+	script := filepath.Join(workspace, "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Chmod(script, 0755); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+
+	p := newBatch(t, workspace, map[string]string{"run.sh": "#!/bin/sh\necho hi\n", "new.txt": "new"}, "run.sh", "new.txt")
+	if err := fs.ApplyWriteProposal(p.ID); err != nil {
+		t.Fatalf("ApplyWriteProposal failed: %v", err)
+	}
+
+	assertContent(t, script, "#!/bin/sh\necho hi\n")
+	for path, want := range map[string]os.FileMode{script: 0755, filepath.Join(workspace, "new.txt"): 0644} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat %s: %v", path, err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("expected %s to have mode %o, got %o", filepath.Base(path), want, got)
+		}
+	}
+}
+
+func TestApplyBatchIsAllOrNothing(t *testing.T) {
+	workspace := chdirTemp(t)
+	existing := filepath.Join(workspace, "a.txt")
+	if err := os.WriteFile(existing, []byte("old"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	// The second target's directory does not exist, so it cannot be written
+	p := newBatch(t, workspace, map[string]string{"a.txt": "new a", "missing/b.txt": "new b"}, "a.txt", "missing/b.txt")
+	if err := fs.ApplyWriteProposal(p.ID); err == nil {
+		t.Fatal("expected the batch to fail")
+	}
+
+	assertContent(t, existing, "old")
+	if _, err := os.Stat(filepath.Join(workspace, "missing", "b.txt")); !os.IsNotExist(err) {
+		t.Error("expected b.txt not to be written")
+	}
+}
+
+func TestApplyBatchRefusesDrift(t *testing.T) {
+	workspace := chdirTemp(t)
+	a := filepath.Join(workspace, "a.txt")
+	b := filepath.Join(workspace, "b.txt")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	p := newBatch(t, workspace, map[string]string{"a.txt": "new a", "b.txt": "new b"}, "a.txt", "b.txt")
+	if err := os.WriteFile(b, []byte("changed"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if err := fs.ApplyWriteProposal(p.ID); !errors.Is(err, fs.ErrDriftDetected) {
+		t.Fatalf("expected drift error, got %v", err)
+	}
+	assertContent(t, a, "old")
+	assertContent(t, b, "changed")
+}
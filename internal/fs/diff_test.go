@@ -0,0 +1,113 @@
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/fs"
+)
+
+func TestUnifiedDiffIdentical(t *testing.T) {
+	if diff := fs.UnifiedDiff("a.txt", []byte("same\n"), []byte("same\n")); diff != "" {
+		t.Fatalf("expected empty diff, got %q", diff)
+	}
+}
+
+func TestUnifiedDiffChangedLine(t *testing.T) {
+	before := []byte("one\ntwo\nthree\n")
+	after := []byte("one\n2\nthree\n")
+
+	diff := fs.UnifiedDiff("a.txt", before, after)
+
+	for _, want := range []string{"--- a/a.txt", "+++ b/a.txt", "@@ -1,3 +1,3 @@", "-two", "+2", " one", " three"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected diff to contain %q, got:\n%s", want, diff)
+		}
+	}
+}
+
+func TestUnifiedDiffNewFile(t *testing.T) {
+	diff := fs.UnifiedDiff("new.txt", nil, []byte("hello\nworld\n"))
+
+	if !strings.Contains(diff, "@@ -1,0 +1,2 @@") {
+		t.Errorf("expected hunk header for new file, got:\n%s", diff)
+	}
+	if !strings.Contains(diff, "+hello") || !strings.Contains(diff, "+world") {
+		t.Errorf("expected added lines, got:\n%s", diff)
+	}
+}
+
+func TestSaveAndLoadBackup(t *testing.T) {
+	root := t.TempDir()
+
+	path, err := fs.SaveBackup(root, "abc", []byte("original"))
+	if err != nil {
+		t.Fatalf("SaveBackup failed: %v", err)
+	}
+	if want := filepath.Join(root, ".goshi", "backups", "abc.bak"); path != want {
+		t.Errorf("expected backup at %s, got %s", want, path)
+	}
+
+	data, err := fs.LoadBackup(path)
+	if err != nil {
+		t.Fatalf("LoadBackup failed: %v", err)
+	}
+	if string(data) != "original" {
+		t.Errorf("expected backup content 'original', got %q", data)
+	}
+
+	// Backups may copy secrets, so only the owner can read them
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat backup: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("expected backup mode 0600, got %o", mode)
+	}
+	info, err = os.Stat(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("stat backup dir: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0700 {
+		t.Errorf("expected backup dir mode 0700, got %o", mode)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	root := t.TempDir()
+
+	oldPath, err := fs.SaveBackup(root, "old", []byte("old"))
+	if err != nil {
+		t.Fatalf("SaveBackup failed: %v", err)
+	}
+	newPath, err := fs.SaveBackup(root, "new", []byte("new"))
+	if err != nil {
+		t.Fatalf("SaveBackup failed: %v", err)
+	}
+	stale := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(oldPath, stale, stale); err != nil {
+		t.Fatalf("chtimes failed: %v", err)
+	}
+
+	removed, err := fs.PruneBackups(root, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PruneBackups failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "old.bak" {
+		t.Errorf("expected only old.bak removed, got %v", removed)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Error("expected stale backup to be removed")
+	}
+	if _, err := os.Stat(newPath); err != nil {
+		t.Errorf("expected recent backup to be kept: %v", err)
+	}
+
+	// A retention of 0 keeps everything
+	if removed, _ := fs.PruneBackups(root, 0); len(removed) != 0 {
+		t.Errorf("expected nothing removed with retention 0, got %v", removed)
+	}
+}
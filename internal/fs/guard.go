@@ -34,6 +34,11 @@ func NewGuard(root string) (*Guard, error) {
 	return &Guard{root: real}, nil
 }
 
+// Root returns the absolute, resolved directory the guard is rooted at.
+func (g *Guard) Root() string {
+	return g.root
+}
+
 // Resolve validates a user-supplied path and returns a safe absolute target path.
 // It allows new files while preventing traversal or symlink escape.
 func (g *Guard) Resolve(p string) (string, error) {
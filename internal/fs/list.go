@@ -3,14 +3,17 @@ package fs
 import (
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // ListEntry describes a single directory entry.
 type ListEntry struct {
-	Name  string // base name
-	Path  string // absolute resolved path
-	IsDir bool
-	Size  int64 // bytes (0 for directories)
+	Name    string // base name
+	Path    string // absolute resolved path
+	IsDir   bool
+	Size    int64 // bytes (0 for directories)
+	Mode    os.FileMode
+	ModTime time.Time
 }
 
 // ListResult is the structured result of a directory listing.
@@ -59,10 +62,12 @@ func List(g *Guard, path string) (*ListResult, error) {
 		}
 
 		result.Entries = append(result.Entries, ListEntry{
-			Name:  e.Name(),
-			Path:  entryPath,
-			IsDir: info.IsDir(),
-			Size:  info.Size(),
+			Name:    e.Name(),
+			Path:    entryPath,
+			IsDir:   info.IsDir(),
+			Size:    info.Size(),
+			Mode:    info.Mode(),
+			ModTime: info.ModTime(),
 		})
 	}
 
@@ -71,9 +76,10 @@ func List(g *Guard, path string) (*ListResult, error) {
 
 // RecursiveListResult is the structured result of a recursive directory listing.
 type RecursiveListResult struct {
-	Path  string   // absolute resolved root directory path
-	Files []string // relative paths to all files found
-	Count int      // total number of files
+	Path      string   // absolute resolved root directory path
+	Files     []string // relative paths to all files found
+	Count     int      // total number of files
+	Truncated bool     // true if the listing stopped at the byte limit
 }
 
 // ListRecursive recursively lists all files in a directory tree safely within the Guard root.
@@ -84,6 +90,13 @@ type RecursiveListResult struct {
 // - returns only regular files (no directories)
 // - returns relative paths from the root directory
 func ListRecursive(g *Guard, path string) (*RecursiveListResult, error) {
+	return ListRecursiveLimit(g, path, 0)
+}
+
+// ListRecursiveLimit is ListRecursive with a cap on the total bytes of the
+// listing, measured as the JSON-encoded file list. A tree of many small
+// files stops at the cap with Truncated set. maxBytes <= 0 disables the cap.
+func ListRecursiveLimit(g *Guard, path string, maxBytes int) (*RecursiveListResult, error) {
 	resolved, err := g.Resolve(path)
 	if err != nil {
 		return nil, err
@@ -104,6 +117,7 @@ func ListRecursive(g *Guard, path string) (*RecursiveListResult, error) {
 	}
 
 	// Walk the directory tree
+	total := 2 // enclosing brackets of the JSON array
 	err = filepath.Walk(resolved, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			// Skip files we can't access
@@ -118,6 +132,14 @@ func ListRecursive(g *Guard, path string) (*RecursiveListResult, error) {
 				return nil
 			}
 
+			// Each entry costs its quoted path plus a separating comma
+			entryBytes := len(relPath) + 3
+			if maxBytes > 0 && total+entryBytes > maxBytes {
+				result.Truncated = true
+				return filepath.SkipAll
+			}
+			total += entryBytes
+
 			result.Files = append(result.Files, relPath)
 			result.Count++
 		}
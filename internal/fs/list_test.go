@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeTree creates n small files spread over a few nested directories
+func makeTree(t *testing.T, n int) string {
+	t.Helper()
+	root := t.TempDir()
+	for i := 0; i < n; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("d%d", i%4), "sub")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%03d.txt", i)), []byte("x"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	return root
+}
+
+// TestListRecursiveLimitTruncates tests that the byte cap stops the listing
+// and flags the result
+func TestListRecursiveLimitTruncates(t *testing.T) {
+	root := makeTree(t, 200)
+	guard, _ := NewGuard(root)
+
+	const maxBytes = 1024
+	res, err := ListRecursiveLimit(guard, ".", maxBytes)
+	if err != nil {
+		t.Fatalf("ListRecursiveLimit failed: %v", err)
+	}
+
+	if !res.Truncated {
+		t.Error("expected result to be flagged as truncated")
+	}
+	if res.Count == 0 || res.Count >= 200 || res.Count != len(res.Files) {
+		t.Errorf("expected a partial listing, got count=%d files=%d", res.Count, len(res.Files))
+	}
+	encoded, _ := json.Marshal(res.Files)
+	if len(encoded) > maxBytes {
+		t.Errorf("encoded file list is %d bytes, exceeds cap %d", len(encoded), maxBytes)
+	}
+}
+
+// TestListRecursiveLimitUnderCap tests that small trees are listed in full
+func TestListRecursiveLimitUnderCap(t *testing.T) {
+	root := makeTree(t, 10)
+	guard, _ := NewGuard(root)
+
+	for _, maxBytes := range []int{0, 1 << 20} {
+		res, err := ListRecursiveLimit(guard, ".", maxBytes)
+		if err != nil {
+			t.Fatalf("ListRecursiveLimit(%d) failed: %v", maxBytes, err)
+		}
+		if res.Truncated || res.Count != 10 {
+			t.Errorf("maxBytes=%d: expected all 10 files untruncated, got count=%d truncated=%v", maxBytes, res.Count, res.Truncated)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package fs
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// NameEncodingPercent marks a file name whose invalid UTF-8 bytes (and any
+// literal '%') were percent-escaped so it survives JSON encoding intact.
+const NameEncodingPercent = "percent"
+
+// EncodeName returns a JSON-safe form of a file name and the encoding used.
+// Valid UTF-8 names are returned unchanged with an empty encoding.
+func EncodeName(name string) (string, string) {
+	if utf8.ValidString(name) {
+		return name, ""
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if (r == utf8.RuneError && size == 1) || name[i] == '%' {
+			fmt.Fprintf(&b, "%%%02X", name[i])
+			i++
+			continue
+		}
+		b.WriteString(name[i : i+size])
+		i += size
+	}
+	return b.String(), NameEncodingPercent
+}
+
+// DecodeName reverses EncodeName, returning the original file name bytes.
+func DecodeName(encoded, encoding string) (string, error) {
+	switch encoding {
+	case "":
+		return encoded, nil
+	case NameEncodingPercent:
+		return url.PathUnescape(encoded)
+	default:
+		return "", fmt.Errorf("unknown name encoding %q", encoding)
+	}
+}
@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestEncodeNameRoundTrip tests that names encode to valid UTF-8 and decode
+// back to the original bytes
+func TestEncodeNameRoundTrip(t *testing.T) {
+	cases := []struct {
+		name     string
+		encoding string
+	}{
+		{"plain.txt", ""},
+		{"héllo 100%.txt", ""},
+		{"bad\xff\xfename.txt", NameEncodingPercent},
+		{"50%\x80.txt", NameEncodingPercent},
+	}
+
+	for _, tc := range cases {
+		encoded, encoding := EncodeName(tc.name)
+		if encoding != tc.encoding {
+			t.Errorf("EncodeName(%q) encoding = %q, want %q", tc.name, encoding, tc.encoding)
+		}
+		if !utf8.ValidString(encoded) {
+			t.Errorf("EncodeName(%q) = %q is not valid UTF-8", tc.name, encoded)
+		}
+		decoded, err := DecodeName(encoded, encoding)
+		if err != nil || decoded != tc.name {
+			t.Errorf("DecodeName(%q) = %q, %v; want %q", encoded, decoded, err, tc.name)
+		}
+	}
+
+	if got, _ := EncodeName("bad\xff%.txt"); got != "bad%FF%25.txt" {
+		t.Errorf("unexpected encoding: %q", got)
+	}
+}
+
+// TestListKeepsNonUTF8Names tests that a file with an invalid UTF-8 name is
+// listed and its encoded name maps back to the file on disk
+func TestListKeepsNonUTF8Names(t *testing.T) {
+	root := t.TempDir()
+	raw := "report\xff.txt"
+	if err := os.WriteFile(filepath.Join(root, raw), []byte("x"), 0644); err != nil {
+		t.Skipf("filesystem rejects non-UTF-8 names: %v", err)
+	}
+	guard, _ := NewGuard(root)
+
+	res, err := List(guard, ".")
+	if err != nil || len(res.Entries) != 1 {
+		t.Fatalf("List = %v, %v; want one entry", res, err)
+	}
+
+	encoded, encoding := EncodeName(res.Entries[0].Name)
+	if encoding != NameEncodingPercent || encoded != "report%FF.txt" {
+		t.Fatalf("encoded = %q (%q)", encoded, encoding)
+	}
+	decoded, _ := DecodeName(encoded, encoding)
+	if _, err := os.Stat(filepath.Join(root, decoded)); err != nil {
+		t.Errorf("decoded name does not refer to the file: %v", err)
+	}
+}
@@ -10,13 +10,15 @@ import (
 )
 
 type Proposal struct {
-	ID          string    `json:"id"`
-	Path        string    `json:"path"`
-	IsNewFile   bool      `json:"is_new_file"`
-	BaseHash    string    `json:"base_hash"`
-	ContentHash string    `json:"content_hash"`
-	Diff        string    `json:"diff"`
-	GeneratedAt time.Time `json:"generated_at"`
+	ID          string     `json:"id"`
+	Path        string     `json:"path"`
+	IsNewFile   bool       `json:"is_new_file"`
+	BaseHash    string     `json:"base_hash"`
+	ContentHash string     `json:"content_hash"`
+	Content     []byte     `json:"content,omitempty"`
+	Diff        string     `json:"diff"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	Writes      []Proposal `json:"writes,omitempty"` // Set for a multi-file proposal
 }
 
 func ComputeHash(data []byte) string {
@@ -30,8 +32,24 @@ func ProposalID(path string, isNew bool, baseHash, contentHash string) string {
 	return hex.EncodeToString(sum[:])
 }
 
+// BatchProposalID derives the ID of a multi-file proposal from its writes
+func BatchProposalID(writes []Proposal) string {
+	h := sha256.New()
+	for _, w := range writes {
+		h.Write([]byte(w.ID + "|"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SaveProposal stores a proposal under the working directory's .goshi
 func SaveProposal(p Proposal) error {
-	dir := filepath.Join(".goshi", "proposals")
+	return SaveProposalIn("", p)
+}
+
+// SaveProposalIn stores a proposal under root's .goshi/proposals, or the
+// working directory's when root is empty
+func SaveProposalIn(root string, p Proposal) error {
+	dir := filepath.Join(root, ".goshi", "proposals")
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
@@ -9,13 +9,14 @@ import (
 )
 
 type WriteProposal struct {
-	ID          string `json:"id"`
-	Path        string `json:"path"`
-	IsNewFile   bool   `json:"is_new_file"`
-	BaseHash    string `json:"base_hash"`
-	Content     []byte `json:"content"`
-	Diff        string `json:"diff"`
-	GeneratedAt string `json:"generated_at"`
+	ID          string          `json:"id"`
+	Path        string          `json:"path"`
+	IsNewFile   bool            `json:"is_new_file"`
+	BaseHash    string          `json:"base_hash"`
+	Content     []byte          `json:"content"`
+	Diff        string          `json:"diff"`
+	GeneratedAt string          `json:"generated_at"`
+	Writes      []WriteProposal `json:"writes,omitempty"`
 }
 
 func hashBytes(b []byte) string {
@@ -114,11 +114,17 @@ func (sr *StreamingResponse) ParseCollectedResponse() (*ParseResult, error) {
 	return sr.parser.ParseWithRetryAdvice(fullResponse), nil
 }
 
+// minDedupChunkLen is the shortest chunk considered for de-duplication;
+// short repeats ("ha", "\n", "  ") are usually legitimate
+const minDedupChunkLen = 8
+
 // ResponseCollector collects streamed chunks and provides full response for parsing
 type ResponseCollector struct {
 	chunks   []string
 	fullText strings.Builder
 	parser   *StructuredParser
+	dedup    bool
+	dropped  int
 }
 
 // NewResponseCollector creates a response collector
@@ -129,10 +135,37 @@ func NewResponseCollector(parser *StructuredParser) *ResponseCollector {
 	}
 }
 
-// AddChunk adds a streamed chunk to the collector
-func (rc *ResponseCollector) AddChunk(chunk string) {
+// EnableDedup drops a chunk that exactly repeats the one before it, as
+// some backends re-send the last delta after reconnecting. Only chunks of
+// at least minDedupChunkLen bytes with non-space content are considered.
+func (rc *ResponseCollector) EnableDedup() *ResponseCollector {
+	rc.dedup = true
+	return rc
+}
+
+// AddChunk adds a streamed chunk to the collector. It reports false if
+// the chunk was dropped as a duplicate.
+func (rc *ResponseCollector) AddChunk(chunk string) bool {
+	if rc.dedup && rc.isRepeat(chunk) {
+		rc.dropped++
+		return false
+	}
 	rc.chunks = append(rc.chunks, chunk)
 	rc.fullText.WriteString(chunk)
+	return true
+}
+
+// isRepeat reports whether chunk exactly repeats the previous chunk
+func (rc *ResponseCollector) isRepeat(chunk string) bool {
+	if len(chunk) < minDedupChunkLen || strings.TrimSpace(chunk) == "" || len(rc.chunks) == 0 {
+		return false
+	}
+	return rc.chunks[len(rc.chunks)-1] == chunk
+}
+
+// Dropped returns how many duplicate chunks were dropped
+func (rc *ResponseCollector) Dropped() int {
+	return rc.dropped
 }
 
 // GetFullResponse returns the complete collected response
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -61,6 +62,50 @@ func TestResponseCollector_ParseEmpty(t *testing.T) {
 	}
 }
 
+func TestResponseCollector_DedupDropsRepeatedDelta(t *testing.T) {
+	collector := NewResponseCollector(NewStructuredParser()).EnableDedup()
+
+	collector.AddChunk("The quick brown fox ")
+	if collector.AddChunk("The quick brown fox ") {
+		t.Error("expected the re-sent delta to be dropped")
+	}
+	collector.AddChunk("jumps over the lazy dog")
+
+	if got := collector.GetFullResponse(); got != "The quick brown fox jumps over the lazy dog" {
+		t.Errorf("expected content not to be doubled, got %q", got)
+	}
+	if collector.Dropped() != 1 {
+		t.Errorf("expected 1 dropped chunk, got %d", collector.Dropped())
+	}
+}
+
+func TestResponseCollector_DedupKeepsShortAndNonAdjacentRepeats(t *testing.T) {
+	collector := NewResponseCollector(NewStructuredParser()).EnableDedup()
+
+	for _, chunk := range []string{"ha", "ha", "\n\n\n\n\n\n\n\n", "\n\n\n\n\n\n\n\n", "repeated line\n", "other\n", "repeated line\n"} {
+		collector.AddChunk(chunk)
+	}
+
+	want := "haha" + strings.Repeat("\n", 16) + "repeated line\nother\nrepeated line\n"
+	if got := collector.GetFullResponse(); got != want {
+		t.Errorf("expected legitimate repeats to be kept, got %q", got)
+	}
+	if collector.Dropped() != 0 {
+		t.Errorf("expected no dropped chunks, got %d", collector.Dropped())
+	}
+}
+
+func TestResponseCollector_NoDedupByDefault(t *testing.T) {
+	collector := NewResponseCollector(NewStructuredParser())
+
+	collector.AddChunk("same chunk text")
+	collector.AddChunk("same chunk text")
+
+	if got := collector.GetFullResponse(); got != "same chunk textsame chunk text" {
+		t.Errorf("expected dedup to be off by default, got %q", got)
+	}
+}
+
 func TestClientWithTools_SetToolValidator(t *testing.T) {
 	sp, _ := NewSystemPrompt("test")
 	client := NewClientWithTools(sp, nil)
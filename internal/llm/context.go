@@ -0,0 +1,59 @@
+package llm
+
+// EstimateTokens approximates the token count of text (~4 characters per token)
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// TrimToTokenBudget drops the oldest unpinned messages until the estimated
+// token count fits within budget. A message and the paired messages after it
+// (a tool call and its result) are dropped together, never one without the
+// other. Pinned messages and the latest message are always kept, along with
+// anything paired with them, even if they alone exceed the budget. A budget
+// <= 0 disables trimming.
+func TrimToTokenBudget(messages []Message, budget int) []Message {
+	if budget <= 0 {
+		return messages
+	}
+
+	total := 0
+	for _, m := range messages {
+		total += EstimateTokens(m.Content)
+	}
+	if total <= budget {
+		return messages
+	}
+
+	drop := make([]bool, len(messages))
+	for i := 0; i < len(messages) && total > budget; {
+		end := i + 1
+		for end < len(messages) && messages[end].Paired {
+			end++
+		}
+		if end == len(messages) {
+			break // The latest message's group is always kept
+		}
+
+		pinned := false
+		tokens := 0
+		for j := i; j < end; j++ {
+			pinned = pinned || messages[j].Pinned
+			tokens += EstimateTokens(messages[j].Content)
+		}
+		if !pinned {
+			for j := i; j < end; j++ {
+				drop[j] = true
+			}
+			total -= tokens
+		}
+		i = end
+	}
+
+	trimmed := make([]Message, 0, len(messages))
+	for i, m := range messages {
+		if !drop[i] {
+			trimmed = append(trimmed, m)
+		}
+	}
+	return trimmed
+}
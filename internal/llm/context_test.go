@@ -0,0 +1,70 @@
+package llm
+
+import "testing"
+
+func TestTrimToTokenBudget_KeepsPinned(t *testing.T) {
+	long := string(make([]byte, 400)) // ~100 tokens each
+	messages := []Message{
+		{Role: "user", Content: "pinned: " + long, Pinned: true},
+		{Role: "assistant", Content: long},
+		{Role: "user", Content: long},
+		{Role: "assistant", Content: long},
+		{Role: "user", Content: "latest"},
+	}
+
+	trimmed := TrimToTokenBudget(messages, 250)
+
+	if !trimmed[0].Pinned {
+		t.Fatalf("expected pinned message to survive trimming, got %+v", trimmed[0])
+	}
+	if trimmed[len(trimmed)-1].Content != "latest" {
+		t.Errorf("expected latest message to be kept, got %q", trimmed[len(trimmed)-1].Content)
+	}
+	if len(trimmed) != 3 {
+		t.Errorf("expected oldest unpinned messages to be dropped (3 left), got %d", len(trimmed))
+	}
+}
+
+func TestTrimToTokenBudget_Disabled(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "a"}, {Role: "assistant", Content: "b"}}
+	if got := TrimToTokenBudget(messages, 0); len(got) != 2 {
+		t.Errorf("expected no trimming with budget 0, got %d messages", len(got))
+	}
+}
+
+func TestTrimToTokenBudget_PinnedExceedsBudget(t *testing.T) {
+	long := string(make([]byte, 400))
+	messages := []Message{
+		{Role: "user", Content: long, Pinned: true},
+		{Role: "user", Content: long, Pinned: true},
+		{Role: "user", Content: "latest"},
+	}
+
+	if got := TrimToTokenBudget(messages, 10); len(got) != 3 {
+		t.Errorf("expected pinned messages to be kept even over budget, got %d", len(got))
+	}
+}
+
+func TestTrimToTokenBudget_KeepsToolPairsTogether(t *testing.T) {
+	long := string(make([]byte, 400))
+	messages := []Message{
+		{Role: "assistant", Content: "call: " + long},
+		{Role: "user", Content: "result", Paired: true},
+		{Role: "assistant", Content: "call"},
+		{Role: "user", Content: "latest result", Paired: true},
+	}
+
+	trimmed := TrimToTokenBudget(messages, 50)
+	if len(trimmed) != 2 {
+		t.Fatalf("expected the oldest call and its result dropped together, got %+v", trimmed)
+	}
+	if trimmed[0].Content != "call" || trimmed[1].Content != "latest result" {
+		t.Errorf("expected the latest call and result kept, got %+v", trimmed)
+	}
+
+	// A result is never kept without its call, even when only the call
+	// would have to go to fit the budget
+	if got := TrimToTokenBudget(messages[2:], 1); len(got) != 2 {
+		t.Errorf("expected the latest call kept with its result, got %+v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithEmptyResponseRetry wraps a backend so that a response which ends
+// without any non-whitespace content is re-requested, up to retries times.
+// Nothing is passed on until real content arrives, so a retry is invisible
+// to the caller. retries <= 0 returns the backend unchanged.
+func WithEmptyResponseRetry(backend Backend, retries int) Backend {
+	if retries <= 0 {
+		return backend
+	}
+	return &emptyRetryBackend{backend: backend, retries: retries}
+}
+
+type emptyRetryBackend struct {
+	backend Backend
+	retries int
+}
+
+func (b *emptyRetryBackend) Stream(ctx context.Context, system string, messages []Message) (Stream, error) {
+	stream, err := b.backend.Stream(ctx, system, messages)
+	if err != nil {
+		return nil, err
+	}
+	return &emptyRetryStream{
+		backend:  b,
+		ctx:      ctx,
+		system:   system,
+		messages: messages,
+		current:  stream,
+	}, nil
+}
+
+// RateLimits reports the wrapped backend's rate limits, if it tracks them
+func (b *emptyRetryBackend) RateLimits() RateLimits {
+	if reporter, ok := b.backend.(RateLimitReporter); ok {
+		return reporter.RateLimits()
+	}
+	return RateLimits{}
+}
+
+// emptyRetryStream holds back leading whitespace until real content
+// arrives, and re-requests the response if none ever does
+type emptyRetryStream struct {
+	backend  *emptyRetryBackend
+	ctx      context.Context
+	system   string
+	messages []Message
+	current  Stream
+	attempts int
+
+	pending strings.Builder // Leading whitespace not yet passed on
+	started bool            // Non-whitespace content has been returned
+	ended   bool
+}
+
+func (s *emptyRetryStream) Recv() (string, error) {
+	for {
+		if s.ended {
+			return "", io.EOF
+		}
+
+		chunk, err := s.current.Recv()
+		if err == nil {
+			if s.started {
+				return chunk, nil
+			}
+			if strings.TrimSpace(chunk) == "" {
+				s.pending.WriteString(chunk)
+				continue
+			}
+			s.started = true
+			chunk = s.pending.String() + chunk
+			s.pending.Reset()
+			return chunk, nil
+		}
+		if err != io.EOF || s.started {
+			return "", err
+		}
+
+		// The response ended empty: ask again while attempts remain
+		if s.attempts >= s.backend.retries {
+			s.ended = true
+			if s.pending.Len() > 0 {
+				return s.pending.String(), nil
+			}
+			return "", io.EOF
+		}
+		s.attempts++
+
+		next, streamErr := s.backend.backend.Stream(s.ctx, s.system, s.messages)
+		if streamErr != nil {
+			return "", fmt.Errorf("failed to retry empty response: %w", streamErr)
+		}
+		s.current.Close()
+		s.current = next
+		s.pending.Reset()
+	}
+}
+
+// Confidence reports the confidence of the stream that produced the response
+func (s *emptyRetryStream) Confidence() (float64, bool) {
+	if reporter, ok := s.current.(ConfidenceReporter); ok {
+		return reporter.Confidence()
+	}
+	return 0, false
+}
+
+// Reasoning reports the reasoning of the stream that produced the response
+func (s *emptyRetryStream) Reasoning() string {
+	if reporter, ok := s.current.(ReasoningReporter); ok {
+		return reporter.Reasoning()
+	}
+	return ""
+}
+
+// Usage reports the token usage of the stream that produced the response
+func (s *emptyRetryStream) Usage() (TokenUsage, bool) {
+	if reporter, ok := s.current.(UsageReporter); ok {
+		return reporter.Usage()
+	}
+	return TokenUsage{}, false
+}
+
+// FinishReason reports why the stream that produced the response stopped
+func (s *emptyRetryStream) FinishReason() string {
+	if reporter, ok := s.current.(FinishReasonReporter); ok {
+		return reporter.FinishReason()
+	}
+	return ""
+}
+
+func (s *emptyRetryStream) Close() error {
+	return s.current.Close()
+}
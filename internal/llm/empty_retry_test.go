@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// scriptedBackend answers each request with the next scripted response,
+// streamed as the given chunks
+type scriptedBackend struct {
+	responses [][]string
+	calls     int
+}
+
+func (b *scriptedBackend) Stream(ctx context.Context, system string, messages []Message) (Stream, error) {
+	chunks := []string{}
+	if b.calls < len(b.responses) {
+		chunks = b.responses[b.calls]
+	}
+	b.calls++
+	return &chunkStream{chunks: chunks}, nil
+}
+
+type chunkStream struct {
+	chunks []string
+	closed bool
+}
+
+func (s *chunkStream) Recv() (string, error) {
+	if len(s.chunks) == 0 {
+		return "", io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *chunkStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func readAll(t *testing.T, backend Backend) string {
+	t.Helper()
+	stream, err := backend.Stream(context.Background(), "system", nil)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var out strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return out.String()
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		out.WriteString(chunk)
+	}
+}
+
+func TestEmptyResponseRetry_RetriesUntilContent(t *testing.T) {
+	inner := &scriptedBackend{responses: [][]string{
+		{},
+		{"  ", "\n"},
+		{" ", "hello", " world"},
+	}}
+
+	got := readAll(t, WithEmptyResponseRetry(inner, 2))
+	if got != " hello world" {
+		t.Errorf("expected the non-empty response, got %q", got)
+	}
+	if inner.calls != 3 {
+		t.Errorf("expected 3 requests, got %d", inner.calls)
+	}
+}
+
+func TestEmptyResponseRetry_IsBounded(t *testing.T) {
+	inner := &scriptedBackend{responses: [][]string{{}, {}, {}, {"too late"}}}
+
+	got := readAll(t, WithEmptyResponseRetry(inner, 2))
+	if got != "" {
+		t.Errorf("expected an empty response once retries run out, got %q", got)
+	}
+	if inner.calls != 3 {
+		t.Errorf("expected 1 request plus 2 retries, got %d", inner.calls)
+	}
+}
+
+func TestEmptyResponseRetry_DoesNotRetryContent(t *testing.T) {
+	inner := &scriptedBackend{responses: [][]string{{"first"}, {"second"}}}
+
+	got := readAll(t, WithEmptyResponseRetry(inner, 3))
+	if got != "first" || inner.calls != 1 {
+		t.Errorf("expected the first response without retrying, got %q after %d requests", got, inner.calls)
+	}
+}
+
+func TestEmptyResponseRetry_DisabledByDefault(t *testing.T) {
+	inner := &scriptedBackend{responses: [][]string{{}, {"hello"}}}
+
+	backend := WithEmptyResponseRetry(inner, 0)
+	if backend != Backend(inner) {
+		t.Fatal("expected 0 retries to return the backend unchanged")
+	}
+	if got := readAll(t, backend); got != "" || inner.calls != 1 {
+		t.Errorf("expected no retry, got %q after %d requests", got, inner.calls)
+	}
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator produces IDs for messages and conversation entries
+type IDGenerator func() string
+
+// RandomIDs generates random UUIDs; it is the default generator
+func RandomIDs() string {
+	return uuid.New().String()
+}
+
+// SequentialIDs returns a generator yielding prefix-1, prefix-2, ... so
+// tests can snapshot logs that carry IDs
+func SequentialIDs(prefix string) IDGenerator {
+	var n atomic.Int64
+	return func() string {
+		return fmt.Sprintf("%s-%d", prefix, n.Add(1))
+	}
+}
+
+var (
+	idMu  sync.RWMutex
+	newID IDGenerator = RandomIDs
+)
+
+// SetIDGenerator replaces the generator used by the message constructors
+// and new conversations, returning the previous one so callers can restore
+// it. A nil generator restores random IDs.
+func SetIDGenerator(gen IDGenerator) IDGenerator {
+	if gen == nil {
+		gen = RandomIDs
+	}
+	idMu.Lock()
+	defer idMu.Unlock()
+	prev := newID
+	newID = gen
+	return prev
+}
+
+// currentIDGenerator returns the generator set by SetIDGenerator
+func currentIDGenerator() IDGenerator {
+	idMu.RLock()
+	defer idMu.RUnlock()
+	return newID
+}
+
+// nextID returns an ID from the current generator
+func nextID() string {
+	return currentIDGenerator()()
+}
@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 // MessageType discriminates the purpose and role of a message
@@ -29,14 +27,15 @@ type LLMMessage interface {
 
 // UserMessage represents user input
 type UserMessage struct {
-	Content string
-	ID      string
+	Content       string
+	ID            string
+	Clarification bool // True if this answers a clarification request
 }
 
 func NewUserMessage(content string) *UserMessage {
 	return &UserMessage{
 		Content: content,
-		ID:      uuid.New().String(),
+		ID:      nextID(),
 	}
 }
 
@@ -45,17 +44,22 @@ func (m *UserMessage) Type() MessageType {
 }
 
 func (m *UserMessage) ToAPIFormat() map[string]string {
+	content := m.Content
+	if m.Clarification {
+		content = "[Clarification] " + content
+	}
 	return map[string]string{
 		"role":    "user",
-		"content": m.Content,
+		"content": content,
 	}
 }
 
 func (m *UserMessage) ToLog() map[string]any {
 	return map[string]any{
-		"type":    m.Type(),
-		"id":      m.ID,
-		"content": m.Content,
+		"type":          m.Type(),
+		"id":            m.ID,
+		"content":       m.Content,
+		"clarification": m.Clarification,
 	}
 }
 
@@ -69,7 +73,7 @@ type AssistantTextMessage struct {
 func NewAssistantTextMessage(content string) *AssistantTextMessage {
 	return &AssistantTextMessage{
 		Content: content,
-		ID:      uuid.New().String(),
+		ID:      nextID(),
 	}
 }
 
@@ -104,8 +108,8 @@ func NewAssistantActionMessage(toolName string, toolArgs map[string]any) *Assist
 	return &AssistantActionMessage{
 		ToolName: toolName,
 		ToolArgs: toolArgs,
-		ToolID:   uuid.New().String(),
-		ID:       uuid.New().String(),
+		ToolID:   nextID(),
+		ID:       nextID(),
 	}
 }
 
@@ -153,7 +157,7 @@ func NewToolResultMessage(toolID string, toolName string, result any) *ToolResul
 		Result:     result,
 		Error:      "",
 		ExecutedAt: time.Now(),
-		ID:         uuid.New().String(),
+		ID:         nextID(),
 	}
 }
 
@@ -165,7 +169,7 @@ func NewToolErrorMessage(toolID string, toolName string, err string) *ToolResult
 		Result:     nil,
 		Error:      err,
 		ExecutedAt: time.Now(),
-		ID:         uuid.New().String(),
+		ID:         nextID(),
 	}
 }
 
@@ -213,7 +217,7 @@ type SystemContextMessage struct {
 func NewSystemContextMessage(content string) *SystemContextMessage {
 	return &SystemContextMessage{
 		Content: content,
-		ID:      uuid.New().String(),
+		ID:      nextID(),
 	}
 }
 
@@ -249,19 +253,31 @@ type ConversationEntry struct {
 // Conversation represents a conversation history with structured entries
 type Conversation struct {
 	entries []ConversationEntry
+	newID   IDGenerator // Generates entry IDs
 }
 
-// NewConversation creates a new empty conversation
+// NewConversation creates a new empty conversation whose entry IDs come
+// from the generator set by SetIDGenerator
 func NewConversation() *Conversation {
+	return NewConversationWithIDs(currentIDGenerator())
+}
+
+// NewConversationWithIDs creates a new empty conversation with its own
+// entry ID generator, e.g. SequentialIDs for reproducible audit logs
+func NewConversationWithIDs(gen IDGenerator) *Conversation {
+	if gen == nil {
+		gen = RandomIDs
+	}
 	return &Conversation{
 		entries: []ConversationEntry{},
+		newID:   gen,
 	}
 }
 
 // Add appends a new message to the conversation with decision metadata
 func (c *Conversation) Add(msg LLMMessage, decision string, audit map[string]any) *ConversationEntry {
 	entry := ConversationEntry{
-		ID:        uuid.New().String(),
+		ID:        c.newID(),
 		Timestamp: time.Now(),
 		Message:   msg,
 		Decision:  decision,
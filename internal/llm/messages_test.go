@@ -1,6 +1,9 @@
 package llm
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestUserMessage(t *testing.T) {
 	msg := NewUserMessage("hello")
@@ -60,3 +63,64 @@ func TestConversation(t *testing.T) {
 		t.Error("expected 1 entry")
 	}
 }
+
+func TestUserMessage_ClarificationTag(t *testing.T) {
+	msg := &UserMessage{Content: "use a.txt", Clarification: true}
+
+	if got := msg.ToAPIFormat()["content"]; got != "[Clarification] use a.txt" {
+		t.Errorf("expected tagged clarification content, got %q", got)
+	}
+
+	if msg.ToLog()["clarification"] != true {
+		t.Error("expected clarification flag in log output")
+	}
+
+	plain := NewUserMessage("use a.txt")
+	if got := plain.ToAPIFormat()["content"]; got != "use a.txt" {
+		t.Errorf("expected untagged content for regular messages, got %q", got)
+	}
+}
+
+func TestSetIDGenerator_DeterministicMessageIDs(t *testing.T) {
+	prev := SetIDGenerator(SequentialIDs("msg"))
+	t.Cleanup(func() { SetIDGenerator(prev) })
+
+	user := NewUserMessage("read the file")
+	action := NewAssistantActionMessage("fs.read", map[string]any{"path": "a.txt"})
+	result := NewToolResultMessage(action.ToolID, "fs.read", "contents")
+
+	got := []any{user.ToLog()["id"], action.ToLog()["toolId"], action.ToLog()["id"], result.ToLog()["id"], result.ToLog()["toolId"]}
+	want := []any{"msg-1", "msg-2", "msg-3", "msg-4", "msg-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected IDs %v, got %v", want, got)
+	}
+
+	SetIDGenerator(nil)
+	if id := NewUserMessage("hi").ID; len(id) != 36 {
+		t.Errorf("expected a nil generator to restore random UUIDs, got %q", id)
+	}
+}
+
+func TestConversationWithIDs_DeterministicAuditLog(t *testing.T) {
+	prev := SetIDGenerator(SequentialIDs("msg"))
+	t.Cleanup(func() { SetIDGenerator(prev) })
+
+	conv := NewConversationWithIDs(SequentialIDs("entry"))
+	conv.Add(NewUserMessage("hello"), "received", nil)
+	conv.Add(NewAssistantTextMessage("hi there"), "responded", nil)
+
+	var got [][2]any
+	for _, entry := range conv.GetAuditLog() {
+		got = append(got, [2]any{entry["entryId"], entry["message"].(map[string]any)["id"]})
+	}
+	want := [][2]any{{"entry-1", "msg-1"}, {"entry-2", "msg-2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected entry/message IDs %v, got %v", want, got)
+	}
+
+	// New conversations draw entry IDs from the package generator
+	shared := NewConversation()
+	if entry := shared.Add(NewUserMessage("again"), "received", nil); entry.ID != "msg-4" {
+		t.Errorf("expected the package generator's next ID, got %q", entry.ID)
+	}
+}
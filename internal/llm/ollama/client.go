@@ -24,6 +24,7 @@ When calling a tool, respond with ONLY a valid JSON object in one of these exact
 
 **To list directory contents:**
 {"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}
+Entries are names only (directories end with /); add "detail": true for size, mode and modification time.
 
 **To read a file:**
 {"type": "action", "action": {"tool": "fs.read", "args": {"path": "README.md"}}}
@@ -31,14 +32,25 @@ When calling a tool, respond with ONLY a valid JSON object in one of these exact
 **To write to a file:**
 {"type": "action", "action": {"tool": "fs.write", "args": {"path": "file.txt", "content": "content here"}}}
 
+**To write several files as one all-or-nothing change:**
+{"type": "action", "action": {"tool": "fs.write-many", "args": {"files": [{"path": "a.txt", "content": "first"}, {"path": "b.txt", "content": "second"}]}}}
+
+**To review your own recent tool calls in this session:**
+{"type": "action", "action": {"tool": "audit.query", "args": {"limit": 10}}}
+
 **For planning/reasoning (NOT a tool call):**
 {"type": "text", "text": "I will read the README file to understand the project"}
 
+**To ask the user a clarifying question (when the request is ambiguous):**
+{"type": "clarification", "clarification": {"question": "Which config file should I update?", "hints": ["goshi.yaml", "~/.goshi/config.yaml"]}}
+
+User replies to a clarification question are prefixed with [Clarification].
+
 ### Rules
 
 1. If the user asks about file contents: ALWAYS use fs.read
 2. If the user asks to list files: ALWAYS use fs.list
-3. If the user asks to write/create/edit files: ALWAYS use fs.write
+3. If the user asks to write/create/edit files: ALWAYS use fs.write (fs.write-many for related edits to several files)
 4. NEVER guess file contents - always use the tools
 5. Respond only with JSON when using tools
 6. Respond with natural text for planning and reasoning
@@ -50,6 +62,12 @@ type Client struct {
 	toolDefs string // Tool definitions to include in prompt
 }
 
+// BuildSystemPrompt returns the exact system prompt sent to the model:
+// the self-model (and any persona) followed by the tool-usage instructions
+func BuildSystemPrompt(system string) string {
+	return system + "\n" + toolInstructions
+}
+
 // NewClient creates an Ollama backend client
 // Supported models: qwen3:8b-q8_0, llama3:latest, llama3.1:8b, qwen2.5-coder:1.5b-base
 func New(model string) *Client {
@@ -77,7 +95,7 @@ func (c *Client) Stream(
 	reqMessages := make([]map[string]string, 0, len(messages)+1)
 
 	// Combine the authoritative self-model with the tool-calling instructions [1, 2]
-	combinedSystemPrompt := system + "\n" + toolInstructions
+	combinedSystemPrompt := BuildSystemPrompt(system)
 
 	reqMessages = append(reqMessages, map[string]string{
 		"role":    "system",
@@ -4,12 +4,19 @@ import (
 	"bufio"
 	"encoding/json"
 	"io"
+
+	"github.com/cshaiku/goshi/internal/llm"
 )
 
 type stream struct {
 	scanner *bufio.Scanner
 	closer  io.Closer
 	done    bool
+
+	// Metadata from the final done:true message
+	usage      llm.TokenUsage
+	hasUsage   bool
+	doneReason string
 }
 
 func newStream(r io.ReadCloser) *stream {
@@ -35,7 +42,10 @@ func (s *stream) Recv() (string, error) {
 		Message struct {
 			Content string `json:"content"`
 		} `json:"message"`
-		Done bool `json:"done"`
+		Done            bool   `json:"done"`
+		DoneReason      string `json:"done_reason"`
+		PromptEvalCount *int   `json:"prompt_eval_count"`
+		EvalCount       *int   `json:"eval_count"`
 	}
 
 	if err := json.Unmarshal(s.scanner.Bytes(), &chunk); err != nil {
@@ -44,12 +54,43 @@ func (s *stream) Recv() (string, error) {
 
 	if chunk.Done {
 		s.done = true
+		s.doneReason = chunk.DoneReason
+		if chunk.PromptEvalCount != nil || chunk.EvalCount != nil {
+			s.hasUsage = true
+			if chunk.PromptEvalCount != nil {
+				s.usage.PromptTokens = *chunk.PromptEvalCount
+			}
+			if chunk.EvalCount != nil {
+				s.usage.CompletionTokens = *chunk.EvalCount
+			}
+		}
+		// The final message may still carry trailing content
+		if chunk.Message.Content != "" {
+			return chunk.Message.Content, nil
+		}
 		return "", io.EOF
 	}
 
 	return chunk.Message.Content, nil
 }
 
+// Usage returns the token counts from the final message: prompt_eval_count
+// and eval_count. ok is false until the final message arrives with them.
+func (s *stream) Usage() (llm.TokenUsage, bool) {
+	return s.usage, s.hasUsage
+}
+
+// DoneReason returns why generation stopped (e.g. "stop" or "length"),
+// empty until the final message arrives
+func (s *stream) DoneReason() string {
+	return s.doneReason
+}
+
+// FinishReason reports the done_reason as an llm.FinishReasonReporter
+func (s *stream) FinishReason() string {
+	return s.doneReason
+}
+
 func (s *stream) Close() error {
 	return s.closer.Close()
 }
@@ -0,0 +1,99 @@
+package ollama
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// mockChatStream is an /api/chat response ending in a done:true message
+const mockChatStream = `{"model":"qwen3","message":{"role":"assistant","content":"Hello"},"done":false}
+{"model":"qwen3","message":{"role":"assistant","content":" world"},"done":false}
+{"model":"qwen3","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","total_duration":5043500667,"prompt_eval_count":26,"eval_count":298}
+`
+
+func TestStream_CapturesUsageFromFinalMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprint(w, mockChatStream)
+	}))
+	defer server.Close()
+
+	client := New("qwen3")
+	client.baseURL = server.URL
+
+	s, err := client.Stream(context.Background(), "system", []llm.Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer s.Close()
+
+	reporter, ok := s.(llm.UsageReporter)
+	if !ok {
+		t.Fatal("expected the Ollama stream to report usage")
+	}
+	if _, ok := reporter.Usage(); ok {
+		t.Error("expected no usage before the final message")
+	}
+
+	var content strings.Builder
+	for {
+		chunk, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		content.WriteString(chunk)
+	}
+
+	if content.String() != "Hello world" {
+		t.Errorf("expected 'Hello world', got %q", content.String())
+	}
+	usage, ok := reporter.Usage()
+	if !ok || usage.PromptTokens != 26 || usage.CompletionTokens != 298 || usage.Total() != 324 {
+		t.Errorf("expected 26 prompt + 298 completion tokens, got %+v (ok=%v)", usage, ok)
+	}
+	if reason := s.(*stream).DoneReason(); reason != "stop" {
+		t.Errorf("expected done_reason stop, got %q", reason)
+	}
+	if reason := s.(llm.FinishReasonReporter).FinishReason(); reason != "stop" {
+		t.Errorf("expected finish reason stop, got %q", reason)
+	}
+}
+
+func TestStream_FinalMessageWithoutCounts(t *testing.T) {
+	s := newStream(io.NopCloser(strings.NewReader(`{"message":{"content":"hi"},"done":false}
+{"message":{"content":"!"},"done":true}
+`)))
+
+	var content strings.Builder
+	for {
+		chunk, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		content.WriteString(chunk)
+	}
+
+	if content.String() != "hi!" {
+		t.Errorf("expected trailing content in the final message to be kept, got %q", content.String())
+	}
+	if _, ok := s.Usage(); ok {
+		t.Error("expected no usage when the final message has no counts")
+	}
+}
@@ -6,16 +6,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/cshaiku/goshi/internal/app"
 	"github.com/cshaiku/goshi/internal/llm"
 )
 
-// toolInstructions defines the structured format for tool calling
+// toolInstructionsIntro opens the structured format for tool calling.
 // Similar to Ollama, but adapted for OpenAI's expectations
-const toolInstructions = `
+const toolInstructionsIntro = `
 ## IMPORTANT: Tool Usage Instructions
 
 When the user asks you to perform filesystem operations (list files, read files, write files),
@@ -25,40 +29,120 @@ you MUST call a tool. Do NOT attempt to guess or fabricate file contents.
 
 When calling a tool, respond with ONLY a valid JSON object in one of these exact formats:
 
-**To list directory contents:**
-{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}
+`
 
-**To read a file:**
+// toolExamples show the model how to call each tool
+var toolExamples = []struct {
+	tool string
+	text string
+}{
+	{"fs.list", `**To list directory contents:**
+{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}
+Entries are names only (directories end with /); add "detail": true for size, mode and modification time.
+`},
+	{"fs.read", `**To read a file:**
 {"type": "action", "action": {"tool": "fs.read", "args": {"path": "README.md"}}}
-
-**To write to a file:**
+`},
+	{"fs.write", `**To write to a file:**
 {"type": "action", "action": {"tool": "fs.write", "args": {"path": "file.txt", "content": "content here"}}}
+`},
+	{"fs.write-many", `**To write several files as one all-or-nothing change:**
+{"type": "action", "action": {"tool": "fs.write-many", "args": {"files": [{"path": "a.txt", "content": "first"}, {"path": "b.txt", "content": "second"}]}}}
+`},
+	{"audit.query", `**To review your own recent tool calls in this session:**
+{"type": "action", "action": {"tool": "audit.query", "args": {"limit": 10}}}
+`},
+}
 
-**For planning/reasoning (NOT a tool call):**
+// toolResponseFormats are the non-tool responses, always offered
+const toolResponseFormats = `**For planning/reasoning (NOT a tool call):**
 {"type": "text", "text": "I will read the README file to understand the project"}
 
+**To ask the user a clarifying question (when the request is ambiguous):**
+{"type": "clarification", "clarification": {"question": "Which config file should I update?", "hints": ["goshi.yaml", "~/.goshi/config.yaml"]}}
+
+User replies to a clarification question are prefixed with [Clarification].
+
 ### Rules
 
-1. If the user asks about file contents: ALWAYS use fs.read
-2. If the user asks to list files: ALWAYS use fs.list
-3. If the user asks to write/create/edit files: ALWAYS use fs.write
-4. NEVER guess file contents - always use the tools
-5. Respond only with JSON when using tools
-6. Respond with natural text for planning and reasoning
 `
 
+// toolRules are the numbered rules; a rule naming a tool is only given
+// while that tool is offered ("" = always)
+var toolRules = []struct {
+	tool string
+	text string
+}{
+	{"fs.read", "If the user asks about file contents: ALWAYS use fs.read"},
+	{"fs.list", "If the user asks to list files: ALWAYS use fs.list"},
+	{"fs.write", "If the user asks to write/create/edit files: ALWAYS use fs.write"},
+	{"", "NEVER guess file contents - always use the tools"},
+	{"", "Respond only with JSON when using tools"},
+	{"", "Respond with natural text for planning and reasoning"},
+}
+
+// toolInstructions renders the tool-usage instructions for the tools the
+// filter permits, so disabled tools are never described to the model
+func toolInstructions(filter app.ToolFilter) string {
+	var b strings.Builder
+	b.WriteString(toolInstructionsIntro)
+	for _, example := range toolExamples {
+		if filter.Enabled(example.tool) {
+			b.WriteString(example.text)
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString(toolResponseFormats)
+	n := 0
+	for _, rule := range toolRules {
+		if rule.tool != "" && !filter.Enabled(rule.tool) {
+			continue
+		}
+		n++
+		text := rule.text
+		if rule.tool == "fs.write" && filter.Enabled("fs.write-many") {
+			text += " (fs.write-many for related edits to several files)"
+		}
+		fmt.Fprintf(&b, "%d. %s\n", n, text)
+	}
+	return b.String()
+}
+
+// BuildSystemPrompt returns the exact system prompt sent to the model:
+// the self-model (and any persona) followed by the tool-usage instructions
+func BuildSystemPrompt(system string) string {
+	return system + "\n" + toolInstructions(app.ToolFilter{})
+}
+
 // Client implements the llm.Backend interface for OpenAI API
 type Client struct {
 	baseURL        string
 	apiKey         string
 	model          string
-	enableSSE      bool            // Phase 2: Enable streaming via SSE
-	maxRetries     int             // Phase 2: Maximum retry attempts
-	httpClient     *http.Client    // Phase 3: Shared HTTP client with connection pooling
-	costTracker    *CostTracker    // Phase 3: Track API costs
-	circuitBreaker *CircuitBreaker // Phase 3: Circuit breaker for reliability
+	enableSSE      bool             // Phase 2: Enable streaming via SSE
+	maxRetries     int              // Phase 2: Maximum retry attempts
+	httpClient     *http.Client     // Phase 3: Shared HTTP client with connection pooling
+	costTracker    *CostTracker     // Phase 3: Track API costs
+	circuitBreaker *CircuitBreaker  // Phase 3: Circuit breaker for reliability
+	logprobs       bool             // Request token logprobs for confidence display
+	requestTimeout time.Duration    // Overall deadline for a request, including the streamed body
+	idleTimeout    time.Duration    // Maximum gap between streamed chunks (0 = no limit)
+	toolMode       string           // How tools are offered to the model (see ToolMode*)
+	toolFilter     app.ToolFilter   // Tools offered to the model (zero value = all)
+	reuseTemplate  bool             // Reuse a pre-marshaled request template across calls
+	template       *requestTemplate // Cached template (guarded by templateMu)
+	templateMu     sync.Mutex
+	rateLimiter    rateLimiter                                      // Latest x-ratelimit-* headers
+	sleepFn        func(ctx context.Context, d time.Duration) error // Overrides waiting (tests)
 }
 
+// Tool modes control how tools are offered to the model
+const (
+	ToolModeInstructions = "instructions" // JSON tool-usage instructions in the system prompt (default)
+	ToolModeNative       = "native"       // Tool definitions in the request's native "tools" field
+	ToolModeNone         = "none"         // Pure chat: no tools offered
+)
+
 // New creates an OpenAI backend client
 // Loads API key from OPENAI_API_KEY environment variable
 // Phase 3: Adds connection pooling, cost tracking, and circuit breaker
@@ -102,9 +186,66 @@ func New(model string) (*Client, error) {
 		httpClient:     httpClient,
 		costTracker:    costTracker,
 		circuitBreaker: circuitBreaker,
+		requestTimeout: httpClient.Timeout,
+		idleTimeout:    30 * time.Second,
 	}, nil
 }
 
+// SetUnknownModelPricing selects the pricing used to estimate costs when
+// the model has no known pricing: "free" or the name of a priced model
+func (c *Client) SetUnknownModelPricing(name string) error {
+	return c.costTracker.SetFallbackPricing(name)
+}
+
+// SetToolMode selects how tools are offered to the model. Tool-usage
+// instructions are only injected in instructions mode, so pure-chat and
+// native tool_calls sessions don't pay for (or conflict with) them.
+func (c *Client) SetToolMode(mode string) error {
+	switch mode {
+	case "", ToolModeInstructions, ToolModeNative, ToolModeNone:
+		c.toolMode = mode
+		return nil
+	default:
+		return fmt.Errorf("unknown tool mode: %s (use instructions, native, or none)", mode)
+	}
+}
+
+// BuildSystemPromptForMode returns the system prompt sent in the given tool
+// mode: the tool-usage instructions, for the tools the filter permits, are
+// only appended in instructions mode
+func BuildSystemPromptForMode(system, mode string, filter app.ToolFilter) string {
+	if mode == "" || mode == ToolModeInstructions {
+		return system + "\n" + toolInstructions(filter)
+	}
+	return system
+}
+
+// SetToolFilter restricts the tools offered to the model, in the
+// instructions or the native tools list, to those the filter permits
+func (c *Client) SetToolFilter(filter app.ToolFilter) {
+	c.templateMu.Lock()
+	defer c.templateMu.Unlock()
+	c.toolFilter = filter
+	c.template = nil
+}
+
+// SetTimeouts configures the overall request timeout and the idle timeout
+// between streamed chunks. A non-positive request timeout keeps the current
+// value; a zero idle timeout disables the idle check.
+func (c *Client) SetTimeouts(request, idle time.Duration) {
+	if request > 0 {
+		c.requestTimeout = request
+		c.httpClient.Timeout = request
+	}
+	c.idleTimeout = idle
+}
+
+// SetLogprobs enables requesting token logprobs so streams can report a
+// confidence score. Off by default since it increases the response payload.
+func (c *Client) SetLogprobs(enabled bool) {
+	c.logprobs = enabled
+}
+
 // Stream sends a request to OpenAI and returns a streaming response
 // Phase 2: Supports SSE streaming and retry logic with exponential backoff
 // Phase 3: Integrates circuit breaker for reliability
@@ -122,6 +263,11 @@ func (c *Client) Stream(
 			stats.State, stats.Failures, stats.TimeUntilHalfOpen.Round(time.Second))
 	}
 
+	// Wait out a nearly exhausted rate-limit budget instead of hitting a 429
+	if err := c.throttle(ctx); err != nil {
+		return nil, err
+	}
+
 	// Retry loop with exponential backoff
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
@@ -156,6 +302,10 @@ func (c *Client) Stream(
 
 		// Success - record and return
 		c.circuitBreaker.RecordSuccess() // Phase 3: Track success
+		if c.enableSSE {
+			// Resume responses whose connection drops mid-stream
+			return newResumingStream(ctx, c, system, messages, stream), nil
+		}
 		return stream, nil
 	}
 
@@ -168,40 +318,27 @@ func (c *Client) doStream(
 	system string,
 	messages []llm.Message,
 ) (llm.Stream, error) {
-	// Build request messages array
-	reqMessages := make([]map[string]string, 0, len(messages)+1)
-
-	// Combine the authoritative self-model with the tool-calling instructions
-	combinedSystemPrompt := system + "\n" + toolInstructions
-
-	reqMessages = append(reqMessages, map[string]string{
-		"role":    "system",
-		"content": combinedSystemPrompt,
-	})
-
-	for _, m := range messages {
-		reqMessages = append(reqMessages, map[string]string{
-			"role":    m.Role,
-			"content": m.Content,
-		})
-	}
-
-	// Build request body
-	reqBody := map[string]any{
-		"model":       c.model,
-		"messages":    reqMessages,
-		"stream":      c.enableSSE, // Phase 2: Use SSE streaming
-		"temperature": 0.0,         // Deterministic tool calls per Goshi design
+	b, err := c.requestBody(system, messages)
+	if err != nil {
+		return nil, err
 	}
 
-	b, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	// Bound the whole request, including the streamed body, by the
+	// configured timeout. The stream releases the context when closed.
+	reqCtx, cancel := ctx, context.CancelFunc(func() {})
+	if c.requestTimeout > 0 {
+		reqCtx, cancel = context.WithTimeout(ctx, c.requestTimeout)
 	}
+	handedOff := false
+	defer func() {
+		if !handedOff {
+			cancel()
+		}
+	}()
 
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(
-		ctx,
+		reqCtx,
 		http.MethodPost,
 		c.baseURL+"/chat/completions",
 		bytes.NewReader(b),
@@ -219,6 +356,8 @@ func (c *Client) doStream(
 		return nil, fmt.Errorf("OpenAI API request failed: %w\n\nPossible causes:\n  - Network connectivity issues\n  - OpenAI API is down\n  - Firewall blocking https://api.openai.com", err)
 	}
 
+	c.rateLimiter.observe(resp.Header, time.Now())
+
 	// Handle HTTP errors
 	if resp.StatusCode != http.StatusOK {
 		defer resp.Body.Close()
@@ -228,16 +367,23 @@ func (c *Client) doStream(
 
 	// Phase 2: Return SSE stream if enabled
 	// Phase 3: Pass cost tracker and model for usage tracking
-	if c.enableSSE {
-		return newSSEStream(resp.Body, c.costTracker, c.model), nil
+	// Some gateways ignore stream:true and answer with a plain JSON body,
+	// so only parse SSE when the response actually is an event stream.
+	if c.enableSSE && isEventStream(resp) {
+		handedOff = true
+		body := newIdleTimeoutBody(resp.Body, c.idleTimeout, cancel)
+		return newSSEStream(body, c.costTracker, c.model), nil
 	}
 
 	// Fallback: Parse non-streaming response
 	var respData struct {
 		Choices []struct {
+			Index   int `json:"index"`
 			Message struct {
-				Content string `json:"content"`
+				Content   string          `json:"content"`
+				ToolCalls []toolCallDelta `json:"tool_calls"`
 			} `json:"message"`
+			Logprobs *choiceLogprobs `json:"logprobs"`
 		} `json:"choices"`
 		Usage struct {
 			PromptTokens     int `json:"prompt_tokens"`
@@ -256,11 +402,31 @@ func (c *Client) doStream(
 		return nil, fmt.Errorf("failed to parse response: %w\nResponse: %s", err, string(body))
 	}
 
-	if len(respData.Choices) == 0 {
+	// Use the first choice (index 0), wherever it appears in the list
+	primary := -1
+	for i, choice := range respData.Choices {
+		if choice.Index == 0 {
+			primary = i
+			break
+		}
+	}
+	if primary < 0 {
 		return nil, fmt.Errorf("no response choices returned from OpenAI")
 	}
 
-	content := respData.Choices[0].Message.Content
+	message := respData.Choices[primary].Message
+	content := message.Content
+	if len(message.ToolCalls) > 0 {
+		// Complete tool calls carry no index; number them in order
+		var calls toolCallAccumulator
+		for i, call := range message.ToolCalls {
+			call.Index = i
+			calls.add([]toolCallDelta{call})
+		}
+		if content, err = calls.action(); err != nil {
+			return nil, err
+		}
+	}
 
 	// Log token usage for visibility
 	fmt.Fprintf(os.Stderr, "[OpenAI] Tokens - prompt: %d, completion: %d, total: %d (model: %s)\n",
@@ -271,14 +437,36 @@ func (c *Client) doStream(
 	)
 
 	// Return a simple stream that returns the complete content once
-	return &simpleStream{content: content, done: false}, nil
+	stream := &simpleStream{content: content, done: false}
+	stream.confidence.add(respData.Choices[primary].Logprobs)
+	return stream, nil
+}
+
+// isEventStream reports whether a response carries an SSE body. A missing
+// Content-Type is treated as SSE, since the request asked for a stream.
+func isEventStream(resp *http.Response) bool {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/event-stream"
 }
 
 // simpleStream implements llm.Stream for non-streaming responses
 // This is a Phase 1 implementation; Phase 2 will add true streaming
 type simpleStream struct {
-	content string
-	done    bool
+	content    string
+	done       bool
+	confidence confidenceAccumulator
+}
+
+// Confidence returns the average top-token probability of the response
+func (s *simpleStream) Confidence() (float64, bool) {
+	return s.confidence.value()
 }
 
 func (s *simpleStream) Recv() (string, error) {
@@ -311,6 +499,17 @@ func (c *Client) GetCircuitState() CircuitBreakerStats {
 	return c.circuitBreaker.GetStats()
 }
 
+// TotalCost returns the estimated cost of this session's requests
+func (c *Client) TotalCost() float64 {
+	return c.GetCostSummary().TotalCost
+}
+
+// CircuitStats reports the circuit breaker state in backend-neutral form
+func (c *Client) CircuitStats() llm.CircuitStats {
+	stats := c.GetCircuitState()
+	return llm.CircuitStats{State: stats.State.String(), Failures: stats.Failures}
+}
+
 // ResetCostTracker resets the cost tracking for a new session
 func (c *Client) ResetCostTracker() {
 	if c.costTracker != nil {
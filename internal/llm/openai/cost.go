@@ -2,17 +2,23 @@ package openai
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"sync"
 	"time"
 )
 
+// Pricing is the cost per 1M tokens of a model, in USD
+type Pricing struct {
+	InputPer1M  float64
+	OutputPer1M float64
+}
+
 // ModelPricing defines the cost per 1M tokens for OpenAI models
 // Prices are in USD and should be updated periodically
 // Source: https://openai.com/pricing (as of Feb 2026)
-var ModelPricing = map[string]struct {
-	InputPer1M  float64
-	OutputPer1M float64
-}{
+var ModelPricing = map[string]Pricing{
 	"gpt-4o":        {InputPer1M: 2.50, OutputPer1M: 10.00},
 	"gpt-4o-mini":   {InputPer1M: 0.15, OutputPer1M: 0.60},
 	"gpt-4-turbo":   {InputPer1M: 10.00, OutputPer1M: 30.00},
@@ -20,6 +26,30 @@ var ModelPricing = map[string]struct {
 	"gpt-3.5-turbo": {InputPer1M: 0.50, OutputPer1M: 1.50},
 }
 
+// Fallback pricing choices for models missing from ModelPricing
+const (
+	FreePricing          = "free"   // Zero cost, e.g. for local OpenAI-compatible servers
+	DefaultFallbackModel = "gpt-4o" // Historical default: overestimates cheap models
+)
+
+// FallbackPricing resolves a fallback pricing choice: "free" or the name of
+// a model in ModelPricing
+func FallbackPricing(name string) (Pricing, error) {
+	if name == FreePricing {
+		return Pricing{}, nil
+	}
+	if pricing, ok := ModelPricing[name]; ok {
+		return pricing, nil
+	}
+
+	known := make([]string, 0, len(ModelPricing))
+	for model := range ModelPricing {
+		known = append(known, model)
+	}
+	sort.Strings(known)
+	return Pricing{}, fmt.Errorf("unknown fallback pricing: %s (use %s or one of %v)", name, FreePricing, known)
+}
+
 // CostTracker tracks token usage and costs for a session
 type CostTracker struct {
 	mu                    sync.Mutex
@@ -32,6 +62,10 @@ type CostTracker struct {
 	warnThreshold         float64 // Warn when cost exceeds this (USD)
 	maxCost               float64 // Fail when cost exceeds this (USD)
 	warningIssued         bool
+	fallback              Pricing   // Used for models missing from ModelPricing
+	fallbackName          string    // "free" or the model whose pricing is the fallback
+	unknownWarned         bool      // The unknown model warning has been shown
+	warnOut               io.Writer // Where the unknown model warning goes
 }
 
 // NewCostTracker creates a new cost tracker
@@ -41,20 +75,49 @@ func NewCostTracker(model string, warnThreshold, maxCost float64) *CostTracker {
 		startTime:     time.Now(),
 		warnThreshold: warnThreshold,
 		maxCost:       maxCost,
+		fallback:      ModelPricing[DefaultFallbackModel],
+		fallbackName:  DefaultFallbackModel,
+		warnOut:       os.Stderr,
 	}
 }
 
+// SetFallbackPricing selects the pricing used when the model is missing
+// from ModelPricing: "free" or the name of a priced model
+func (ct *CostTracker) SetFallbackPricing(name string) error {
+	pricing, err := FallbackPricing(name)
+	if err != nil {
+		return err
+	}
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.fallback = pricing
+	ct.fallbackName = name
+	return nil
+}
+
+// pricing returns the model's pricing, falling back (with a one-time
+// warning naming the model) when it has none. Callers hold ct.mu.
+func (ct *CostTracker) pricing() Pricing {
+	if pricing, ok := ModelPricing[ct.model]; ok {
+		return pricing
+	}
+
+	if !ct.unknownWarned {
+		ct.unknownWarned = true
+		fmt.Fprintf(ct.warnOut, "[OpenAI] ⚠️  No pricing known for model %q; estimating costs with %s pricing (llm.unknown_model_pricing)\n",
+			ct.model, ct.fallbackName)
+	}
+	return ct.fallback
+}
+
 // RecordUsage records token usage and calculates cost
 func (ct *CostTracker) RecordUsage(promptTokens, completionTokens int) (cost float64, warning string, err error) {
 	ct.mu.Lock()
 	defer ct.mu.Unlock()
 
 	// Calculate cost for this request
-	pricing, ok := ModelPricing[ct.model]
-	if !ok {
-		// Unknown model - use default pricing (gpt-4o)
-		pricing = ModelPricing["gpt-4o"]
-	}
+	pricing := ct.pricing()
 
 	requestCost := (float64(promptTokens)/1_000_000)*pricing.InputPer1M +
 		(float64(completionTokens)/1_000_000)*pricing.OutputPer1M
@@ -85,10 +148,7 @@ func (ct *CostTracker) EstimateCost(promptTokens, completionTokens int) float64
 	ct.mu.Lock()
 	defer ct.mu.Unlock()
 
-	pricing, ok := ModelPricing[ct.model]
-	if !ok {
-		pricing = ModelPricing["gpt-4o"]
-	}
+	pricing := ct.pricing()
 
 	return (float64(promptTokens)/1_000_000)*pricing.InputPer1M +
 		(float64(completionTokens)/1_000_000)*pricing.OutputPer1M
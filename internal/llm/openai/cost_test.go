@@ -1,6 +1,8 @@
 package openai
 
 import (
+	"bytes"
+	"io"
 	"strings"
 	"testing"
 	"time"
@@ -238,3 +240,57 @@ func TestModelPricing_AllModels(t *testing.T) {
 		}
 	}
 }
+
+func TestCostTracker_UnknownModelFreePricing(t *testing.T) {
+	ct := NewCostTracker("llama3.1:8b", 0, 0)
+	var warnings bytes.Buffer
+	ct.warnOut = &warnings
+
+	if err := ct.SetFallbackPricing(FreePricing); err != nil {
+		t.Fatalf("SetFallbackPricing failed: %v", err)
+	}
+
+	cost, _, err := ct.RecordUsage(1000, 500)
+	if err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	if cost != 0 || ct.EstimateCost(1_000_000, 1_000_000) != 0 {
+		t.Errorf("expected zero cost with free pricing, got %.4f", cost)
+	}
+	ct.RecordUsage(1000, 500)
+
+	if n := strings.Count(warnings.String(), "No pricing known"); n != 1 {
+		t.Errorf("expected the unknown model warning once, got %d:\n%s", n, warnings.String())
+	}
+	if !strings.Contains(warnings.String(), `"llama3.1:8b"`) || !strings.Contains(warnings.String(), "free pricing") {
+		t.Errorf("expected the warning to name the model and fallback, got %q", warnings.String())
+	}
+}
+
+func TestCostTracker_KnownModelDoesNotWarn(t *testing.T) {
+	ct := NewCostTracker("gpt-4o-mini", 0, 0)
+	var warnings bytes.Buffer
+	ct.warnOut = &warnings
+
+	ct.RecordUsage(1000, 500)
+	if warnings.Len() != 0 {
+		t.Errorf("expected no warning for a priced model, got %q", warnings.String())
+	}
+}
+
+func TestCostTracker_SetFallbackPricing(t *testing.T) {
+	ct := NewCostTracker("unknown-model", 0, 0)
+	ct.warnOut = io.Discard
+
+	if err := ct.SetFallbackPricing("gpt-4o-mini"); err != nil {
+		t.Fatalf("SetFallbackPricing failed: %v", err)
+	}
+	// gpt-4o-mini: $0.15/1M input, $0.60/1M output
+	if cost := ct.EstimateCost(1_000_000, 1_000_000); cost < 0.7499 || cost > 0.7501 {
+		t.Errorf("expected gpt-4o-mini pricing, got %.4f", cost)
+	}
+
+	if err := ct.SetFallbackPricing("not-a-model"); err == nil {
+		t.Error("expected an unknown fallback to be rejected")
+	}
+}
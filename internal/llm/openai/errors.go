@@ -2,11 +2,16 @@ package openai
 
 import (
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"time"
 )
 
+// ErrStreamTruncated is returned by a stream whose connection closed before
+// OpenAI sent a finish_reason or [DONE] marker
+var ErrStreamTruncated = fmt.Errorf("OpenAI stream ended before completion: %w", io.ErrUnexpectedEOF)
+
 // APIError represents an error from the OpenAI API
 type APIError struct {
 	StatusCode int
@@ -0,0 +1,39 @@
+package openai
+
+import "math"
+
+// logprobToken is a single entry of choices[].logprobs.content
+type logprobToken struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+}
+
+// choiceLogprobs is the logprobs payload attached to a choice or delta
+type choiceLogprobs struct {
+	Content []logprobToken `json:"content"`
+}
+
+// confidenceAccumulator averages the probability of each sampled token
+// across a response, giving a rough per-response confidence indicator
+type confidenceAccumulator struct {
+	sum   float64
+	count int
+}
+
+func (a *confidenceAccumulator) add(lp *choiceLogprobs) {
+	if lp == nil {
+		return
+	}
+	for _, tok := range lp.Content {
+		a.sum += math.Exp(tok.Logprob)
+		a.count++
+	}
+}
+
+// value returns the average top-token probability, if any tokens were seen
+func (a *confidenceAccumulator) value() (float64, bool) {
+	if a.count == 0 {
+		return 0, false
+	}
+	return a.sum / float64(a.count), true
+}
@@ -0,0 +1,143 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// Throttling thresholds: the next request waits for the budget to refill
+// when fewer than lowRequestsRemaining requests, or less than
+// lowTokensFraction of the token limit, remain
+const (
+	lowRequestsRemaining = 1
+	lowTokensFraction    = 0.05
+	maxThrottleDelay     = 60 * time.Second
+)
+
+// rateLimiter records the latest rate-limit headers and computes how long
+// to hold the next request to avoid a 429
+type rateLimiter struct {
+	mu     sync.Mutex
+	limits llm.RateLimits
+}
+
+// observe records the rate-limit headers of a response, if present
+func (r *rateLimiter) observe(h http.Header, now time.Time) {
+	limits, ok := parseRateLimits(h, now)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	r.limits = limits
+	r.mu.Unlock()
+}
+
+// snapshot returns the latest limits
+func (r *rateLimiter) snapshot() llm.RateLimits {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.limits
+}
+
+// delay returns how long to wait before the next request: until the nearly
+// exhausted budget resets, or 0 when enough budget remains
+func (r *rateLimiter) delay(now time.Time) time.Duration {
+	limits := r.snapshot()
+	if !limits.Known {
+		return 0
+	}
+
+	var wait time.Duration
+	if limits.LimitRequests > 0 && limits.RemainingRequests < lowRequestsRemaining {
+		wait = limits.ResetRequests
+	}
+	if limits.LimitTokens > 0 && float64(limits.RemainingTokens) < float64(limits.LimitTokens)*lowTokensFraction {
+		if limits.ResetTokens > wait {
+			wait = limits.ResetTokens
+		}
+	}
+
+	// The reset countdown started when the headers arrived
+	wait -= now.Sub(limits.UpdatedAt)
+	if wait <= 0 {
+		return 0
+	}
+	if wait > maxThrottleDelay {
+		wait = maxThrottleDelay
+	}
+	return wait
+}
+
+// parseRateLimits reads the x-ratelimit-* headers. ok is false when the
+// response carried none.
+func parseRateLimits(h http.Header, now time.Time) (llm.RateLimits, bool) {
+	limits := llm.RateLimits{UpdatedAt: now}
+	found := false
+
+	ints := []struct {
+		header string
+		dst    *int
+	}{
+		{"x-ratelimit-limit-requests", &limits.LimitRequests},
+		{"x-ratelimit-remaining-requests", &limits.RemainingRequests},
+		{"x-ratelimit-limit-tokens", &limits.LimitTokens},
+		{"x-ratelimit-remaining-tokens", &limits.RemainingTokens},
+	}
+	for _, f := range ints {
+		if n, err := strconv.Atoi(h.Get(f.header)); err == nil {
+			*f.dst = n
+			found = true
+		}
+	}
+
+	durations := []struct {
+		header string
+		dst    *time.Duration
+	}{
+		{"x-ratelimit-reset-requests", &limits.ResetRequests},
+		{"x-ratelimit-reset-tokens", &limits.ResetTokens},
+	}
+	for _, f := range durations {
+		if d, err := time.ParseDuration(h.Get(f.header)); err == nil {
+			*f.dst = d
+		}
+	}
+
+	limits.Known = found
+	return limits, found
+}
+
+// RateLimits returns the most recently reported rate limits
+func (c *Client) RateLimits() llm.RateLimits {
+	return c.rateLimiter.snapshot()
+}
+
+// throttle waits out a nearly exhausted rate-limit budget before a request
+func (c *Client) throttle(ctx context.Context) error {
+	wait := c.rateLimiter.delay(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "[OpenAI] Rate limit nearly exhausted, waiting %s before the next request\n", wait.Round(time.Millisecond))
+	return c.sleep(ctx, wait)
+}
+
+// sleep pauses for d unless ctx is cancelled first
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	if c.sleepFn != nil {
+		return c.sleepFn(ctx, d)
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
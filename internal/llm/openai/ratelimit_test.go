@@ -0,0 +1,138 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// rateLimitedServer streams a short answer with the given rate-limit headers
+func rateLimitedServer(t *testing.T, headers map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// drain reads a stream to the end
+func drain(t *testing.T, c *Client) {
+	t.Helper()
+	stream, err := c.Stream(context.Background(), "system", nil)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer stream.Close()
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+	}
+}
+
+// newRateLimitTestClient creates a client whose waits are recorded, not slept
+func newRateLimitTestClient(server *httptest.Server, waits *[]time.Duration) *Client {
+	return &Client{
+		baseURL:        server.URL,
+		model:          "gpt-4o",
+		enableSSE:      true,
+		httpClient:     server.Client(),
+		circuitBreaker: NewCircuitBreaker(5, time.Second),
+		sleepFn: func(ctx context.Context, d time.Duration) error {
+			*waits = append(*waits, d)
+			return nil
+		},
+	}
+}
+
+func TestClient_ReadsRateLimitHeaders(t *testing.T) {
+	server := rateLimitedServer(t, map[string]string{
+		"x-ratelimit-limit-requests":     "500",
+		"x-ratelimit-remaining-requests": "499",
+		"x-ratelimit-limit-tokens":       "30000",
+		"x-ratelimit-remaining-tokens":   "29000",
+		"x-ratelimit-reset-requests":     "120ms",
+		"x-ratelimit-reset-tokens":       "2s",
+	})
+	var waits []time.Duration
+	client := newRateLimitTestClient(server, &waits)
+
+	if client.RateLimits().Known {
+		t.Error("expected no limits before the first response")
+	}
+
+	drain(t, client)
+	drain(t, client)
+
+	limits := client.RateLimits()
+	if !limits.Known || limits.LimitRequests != 500 || limits.RemainingRequests != 499 ||
+		limits.LimitTokens != 30000 || limits.RemainingTokens != 29000 {
+		t.Errorf("unexpected limits: %+v", limits)
+	}
+	if limits.ResetRequests != 120*time.Millisecond || limits.ResetTokens != 2*time.Second {
+		t.Errorf("unexpected reset durations: %+v", limits)
+	}
+	if len(waits) != 0 {
+		t.Errorf("expected no throttling with ample budget, got waits %v", waits)
+	}
+}
+
+func TestClient_ThrottlesNearExhaustion(t *testing.T) {
+	cases := []struct {
+		name    string
+		headers map[string]string
+		minWait time.Duration
+	}{
+		{"requests", map[string]string{
+			"x-ratelimit-limit-requests":     "500",
+			"x-ratelimit-remaining-requests": "0",
+			"x-ratelimit-reset-requests":     "3s",
+		}, 2 * time.Second},
+		{"tokens", map[string]string{
+			"x-ratelimit-limit-tokens":     "30000",
+			"x-ratelimit-remaining-tokens": "100",
+			"x-ratelimit-reset-tokens":     "5s",
+		}, 4 * time.Second},
+		{"capped", map[string]string{
+			"x-ratelimit-limit-requests":     "500",
+			"x-ratelimit-remaining-requests": "0",
+			"x-ratelimit-reset-requests":     "1h",
+		}, maxThrottleDelay - time.Second},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var waits []time.Duration
+			client := newRateLimitTestClient(rateLimitedServer(t, tc.headers), &waits)
+
+			drain(t, client)
+			if len(waits) != 0 {
+				t.Fatalf("expected the first request to go out immediately, got %v", waits)
+			}
+
+			drain(t, client)
+			if len(waits) != 1 || waits[0] < tc.minWait || waits[0] > maxThrottleDelay {
+				t.Errorf("expected one wait of at least %v, got %v", tc.minWait, waits)
+			}
+		})
+	}
+}
+
+func TestParseRateLimitsWithoutHeaders(t *testing.T) {
+	if _, ok := parseRateLimits(http.Header{}, time.Now()); ok {
+		t.Error("expected no limits from a response without rate-limit headers")
+	}
+}
@@ -0,0 +1,162 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// buildRequestBody marshals a complete chat completion request body
+func (c *Client) buildRequestBody(system string, messages []llm.Message) ([]byte, error) {
+	// Build request messages array
+	reqMessages := make([]map[string]string, 0, len(messages)+1)
+
+	// Combine the authoritative self-model with the tool-calling instructions
+	combinedSystemPrompt := BuildSystemPromptForMode(system, c.toolMode, c.toolFilter)
+
+	reqMessages = append(reqMessages, map[string]string{
+		"role":    "system",
+		"content": combinedSystemPrompt,
+	})
+
+	for _, m := range messages {
+		reqMessages = append(reqMessages, map[string]string{
+			"role":    m.Role,
+			"content": m.Content,
+		})
+	}
+
+	// Build request body
+	reqBody := map[string]any{
+		"model":       c.model,
+		"messages":    reqMessages,
+		"stream":      c.enableSSE, // Phase 2: Use SSE streaming
+		"temperature": 0.0,         // Deterministic tool calls per Goshi design
+	}
+	if c.logprobs {
+		reqBody["logprobs"] = true
+	}
+	if c.toolMode == ToolModeNative {
+		// Sort so the body is stable across calls (registry order is not)
+		tools := c.toolFilter.Apply(app.NewDefaultToolRegistry().All())
+		sort.Slice(tools, func(i, j int) bool { return tools[i].ID < tools[j].ID })
+		reqBody["tools"] = ConvertToolsToOpenAIFormat(tools)
+	}
+
+	b, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return b, nil
+}
+
+// requestTemplate is a pre-marshaled request body split around the
+// conversation messages. Everything but the messages (model, options,
+// system prompt, tool definitions) is encoded once and reused.
+type requestTemplate struct {
+	key  templateKey // settings the template was built for
+	head []byte      // body up to and including the system message
+	tail []byte      // body after the last message
+}
+
+// templateKey holds every input that shapes a request body besides the
+// messages; a template is only reused while all of them are unchanged
+type templateKey struct {
+	system    string
+	model     string
+	toolMode  string
+	enableSSE bool
+	logprobs  bool
+}
+
+// templateKey returns the current template key for a system prompt
+func (c *Client) templateKey(system string) templateKey {
+	return templateKey{
+		system:    system,
+		model:     c.model,
+		toolMode:  c.toolMode,
+		enableSSE: c.enableSSE,
+		logprobs:  c.logprobs,
+	}
+}
+
+// messageSentinel marks where conversation messages go when splitting a
+// freshly built body into a template
+const messageSentinel = "\x00goshi-messages\x00"
+
+// newRequestTemplate builds a template from a fresh body so reused
+// requests are byte-for-byte identical to freshly built ones
+func (c *Client) newRequestTemplate(system string) (*requestTemplate, error) {
+	b, err := c.buildRequestBody(system, []llm.Message{{Role: messageSentinel}})
+	if err != nil {
+		return nil, err
+	}
+	marker, err := json.Marshal(map[string]string{"role": messageSentinel, "content": ""})
+	if err != nil {
+		return nil, err
+	}
+	idx := bytes.Index(b, marker)
+	if idx < 0 || b[idx-1] != ',' {
+		return nil, fmt.Errorf("failed to build request template")
+	}
+	return &requestTemplate{
+		key:  c.templateKey(system),
+		head: append([]byte(nil), b[:idx-1]...),
+		tail: append([]byte(nil), b[idx+len(marker):]...),
+	}, nil
+}
+
+// body assembles a request body from the template and the messages
+func (t *requestTemplate) body(messages []llm.Message) ([]byte, error) {
+	encoded := make([][]byte, len(messages))
+	size := len(t.head) + len(t.tail)
+	for i, m := range messages {
+		b, err := json.Marshal(map[string]string{"role": m.Role, "content": m.Content})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		encoded[i] = b
+		size += len(b) + 1
+	}
+
+	body := make([]byte, 0, size)
+	body = append(body, t.head...)
+	for _, b := range encoded {
+		body = append(body, ',')
+		body = append(body, b...)
+	}
+	return append(body, t.tail...), nil
+}
+
+// SetReuseRequestTemplate enables reusing a pre-marshaled request template
+// across calls with the same system prompt, so only the messages are
+// encoded per request. Useful for high-throughput scripted use.
+func (c *Client) SetReuseRequestTemplate(enabled bool) {
+	c.templateMu.Lock()
+	defer c.templateMu.Unlock()
+	c.reuseTemplate = enabled
+	c.template = nil
+}
+
+// requestBody returns the request body, from the cached template when
+// reuse is enabled
+func (c *Client) requestBody(system string, messages []llm.Message) ([]byte, error) {
+	c.templateMu.Lock()
+	defer c.templateMu.Unlock()
+
+	if !c.reuseTemplate {
+		return c.buildRequestBody(system, messages)
+	}
+	if c.template == nil || c.template.key != c.templateKey(system) {
+		template, err := c.newRequestTemplate(system)
+		if err != nil {
+			return nil, err
+		}
+		c.template = template
+	}
+	return c.template.body(messages)
+}
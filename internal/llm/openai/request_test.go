@@ -0,0 +1,170 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+func testMessages(n int) []llm.Message {
+	messages := make([]llm.Message, 0, n)
+	for i := 0; i < n; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages = append(messages, llm.Message{Role: role, Content: strings.Repeat("list the files in <src> & \"docs\"\n", 4)})
+	}
+	return messages
+}
+
+func TestRequestTemplate_MatchesFreshBody(t *testing.T) {
+	modes := []struct {
+		name     string
+		toolMode string
+		logprobs bool
+		sse      bool
+	}{
+		{name: "instructions", toolMode: ToolModeInstructions, sse: true},
+		{name: "native+logprobs", toolMode: ToolModeNative, logprobs: true, sse: true},
+		{name: "none+no-sse", toolMode: ToolModeNone},
+	}
+
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			client := &Client{model: "gpt-4o", enableSSE: mode.sse, logprobs: mode.logprobs, toolMode: mode.toolMode}
+
+			for _, messages := range [][]llm.Message{nil, testMessages(1), testMessages(5)} {
+				fresh, err := client.buildRequestBody("system <laws>", messages)
+				if err != nil {
+					t.Fatalf("buildRequestBody failed: %v", err)
+				}
+
+				client.SetReuseRequestTemplate(true)
+				reused, err := client.requestBody("system <laws>", messages)
+				client.SetReuseRequestTemplate(false)
+				if err != nil {
+					t.Fatalf("requestBody failed: %v", err)
+				}
+
+				if !bytes.Equal(fresh, reused) {
+					t.Errorf("template body differs from fresh body\nfresh:  %s\nreused: %s", fresh, reused)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestTemplate_RebuiltWhenInputsChange(t *testing.T) {
+	client := &Client{model: "gpt-4o", enableSSE: true}
+	client.SetReuseRequestTemplate(true)
+	messages := testMessages(2)
+
+	if _, err := client.requestBody("first", messages); err != nil {
+		t.Fatalf("requestBody failed: %v", err)
+	}
+	first := client.template
+
+	if _, err := client.requestBody("first", messages); err != nil {
+		t.Fatalf("requestBody failed: %v", err)
+	}
+	if client.template != first {
+		t.Error("expected the template to be reused for the same system prompt")
+	}
+
+	for name, change := range map[string]func(){
+		"system":   func() {},
+		"logprobs": func() { client.SetLogprobs(true) },
+		"toolMode": func() { client.SetToolMode(ToolModeNone) },
+		"tools":    func() { client.SetToolFilter(app.NewToolFilter(nil, []string{"fs.write"})) },
+	} {
+		change()
+		system := "first"
+		if name == "system" {
+			system = "second"
+		}
+		got, err := client.requestBody(system, messages)
+		if err != nil {
+			t.Fatalf("requestBody failed: %v", err)
+		}
+		want, _ := client.buildRequestBody(system, messages)
+		if !bytes.Equal(got, want) {
+			t.Errorf("after %s change, expected a rebuilt template matching the fresh body", name)
+		}
+	}
+}
+
+func TestRequestBody_OffersOnlyFilteredTools(t *testing.T) {
+	filter := app.NewToolFilter(nil, []string{"fs.write", "fs.write-many"})
+
+	client := &Client{model: "gpt-4o", toolMode: ToolModeNative}
+	client.SetToolFilter(filter)
+	b, err := client.buildRequestBody("system", testMessages(1))
+	if err != nil {
+		t.Fatalf("buildRequestBody failed: %v", err)
+	}
+	var body struct {
+		Messages []map[string]string `json:"messages"`
+		Tools    []struct {
+			Function struct {
+				Name string `json:"name"`
+			} `json:"function"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	var names []string
+	for _, tool := range body.Tools {
+		names = append(names, tool.Function.Name)
+	}
+	if got := strings.Join(names, ","); got != "audit.query,fs.list,fs.read" {
+		t.Errorf("expected only the enabled native tools, got %s", got)
+	}
+
+	client.SetToolMode(ToolModeInstructions)
+	b, err = client.buildRequestBody("system", testMessages(1))
+	if err != nil {
+		t.Fatalf("buildRequestBody failed: %v", err)
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	system := body.Messages[0]["content"]
+	if strings.Contains(system, "fs.write") {
+		t.Errorf("expected disabled tools left out of the instructions, got:\n%s", system)
+	}
+	if !strings.Contains(system, `"tool": "fs.read"`) || !strings.Contains(system, "3. NEVER guess file contents") {
+		t.Errorf("expected the enabled tools and renumbered rules, got:\n%s", system)
+	}
+}
+
+func BenchmarkRequestBody(b *testing.B) {
+	system := strings.Repeat("You are goshi. Never fabricate file contents.\n", 200)
+	messages := testMessages(6)
+
+	b.Run("fresh", func(b *testing.B) {
+		client := &Client{model: "gpt-4o", enableSSE: true, toolMode: ToolModeNative}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.requestBody(system, messages); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("template", func(b *testing.B) {
+		client := &Client{model: "gpt-4o", enableSSE: true, toolMode: ToolModeNative}
+		client.SetReuseRequestTemplate(true)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.requestBody(system, messages); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// maxStreamResumes bounds how often a dropped stream is re-requested
+const maxStreamResumes = 2
+
+// truncatedContinuePrompt asks the model to pick up after a dropped connection
+const truncatedContinuePrompt = "[Your previous response was truncated by a dropped connection. Continue exactly where it stopped, without repeating any text.]"
+
+// resumingStream wraps an SSE stream and, when the connection drops before
+// a finish_reason arrives, re-issues the request with the partial response
+// included (marked as truncated) so the model can continue it
+type resumingStream struct {
+	client   *Client
+	ctx      context.Context
+	system   string
+	messages []llm.Message
+	current  llm.Stream
+	partial  strings.Builder
+	resumes  int
+}
+
+func newResumingStream(ctx context.Context, c *Client, system string, messages []llm.Message, stream llm.Stream) *resumingStream {
+	return &resumingStream{
+		client:   c,
+		ctx:      ctx,
+		system:   system,
+		messages: messages,
+		current:  stream,
+	}
+}
+
+// Recv returns the next chunk, transparently resuming truncated responses
+func (r *resumingStream) Recv() (string, error) {
+	for {
+		chunk, err := r.current.Recv()
+		if err == nil {
+			r.partial.WriteString(chunk)
+			return chunk, nil
+		}
+		if !errors.Is(err, ErrStreamTruncated) || r.resumes >= maxStreamResumes {
+			return "", err
+		}
+
+		r.resumes++
+		fmt.Fprintf(os.Stderr, "[OpenAI] Stream dropped mid-response, resuming (%d/%d)\n", r.resumes, maxStreamResumes)
+
+		next, resumeErr := r.client.doStream(r.ctx, r.system, r.resumeMessages())
+		if resumeErr != nil {
+			return "", fmt.Errorf("failed to resume truncated stream: %w", resumeErr)
+		}
+		r.current.Close()
+		r.current = next
+	}
+}
+
+// resumeMessages is the original conversation plus the truncated partial
+// response and a request to continue it
+func (r *resumingStream) resumeMessages() []llm.Message {
+	messages := make([]llm.Message, 0, len(r.messages)+2)
+	messages = append(messages, r.messages...)
+	if r.partial.Len() > 0 {
+		messages = append(messages, llm.Message{Role: "assistant", Content: r.partial.String()})
+	}
+	return append(messages, llm.Message{Role: "user", Content: truncatedContinuePrompt})
+}
+
+// Confidence reports the confidence of the most recent stream segment
+func (r *resumingStream) Confidence() (float64, bool) {
+	if reporter, ok := r.current.(llm.ConfidenceReporter); ok {
+		return reporter.Confidence()
+	}
+	return 0, false
+}
+
+// Reasoning reports the reasoning of the most recent stream segment
+func (r *resumingStream) Reasoning() string {
+	if reporter, ok := r.current.(llm.ReasoningReporter); ok {
+		return reporter.Reasoning()
+	}
+	return ""
+}
+
+// Usage reports the token usage of the most recent stream segment
+func (r *resumingStream) Usage() (llm.TokenUsage, bool) {
+	if reporter, ok := r.current.(llm.UsageReporter); ok {
+		return reporter.Usage()
+	}
+	return llm.TokenUsage{}, false
+}
+
+// FinishReason reports why the most recent stream segment stopped
+func (r *resumingStream) FinishReason() string {
+	if reporter, ok := r.current.(llm.FinishReasonReporter); ok {
+		return reporter.FinishReason()
+	}
+	return ""
+}
+
+// Close closes the active underlying stream
+func (r *resumingStream) Close() error {
+	return r.current.Close()
+}
@@ -3,10 +3,13 @@ package openai
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+
+	"github.com/cshaiku/goshi/internal/llm"
 )
 
 // sseStream implements llm.Stream for OpenAI's Server-Sent Events format
@@ -16,10 +19,16 @@ type sseStream struct {
 	closer      io.ReadCloser
 	buffer      strings.Builder
 	done        bool
+	finished    bool   // finish_reason or [DONE] was received
+	finish      string // The finish_reason, if one was sent
 	lastErr     error
 	costTracker *CostTracker // Phase 3: Track costs
 	model       string       // Phase 3: Model for cost calculation
 	usageData   *UsageData   // Phase 3: Accumulated usage stats
+	confidence  confidenceAccumulator
+	reasoning   strings.Builder     // Reasoning deltas, kept apart from the answer
+	refusal     strings.Builder     // Refusal deltas; surfaced as llm.RefusalError
+	toolCalls   toolCallAccumulator // Native tool_calls deltas
 }
 
 // UsageData tracks token usage from streaming responses
@@ -55,8 +64,13 @@ func (s *sseStream) Recv() (string, error) {
 		line, err := s.reader.ReadString('\n')
 		if err != nil {
 			s.done = true
+			if (err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF)) && !s.finished {
+				// The connection closed or dropped before the response
+				// completed
+				err = ErrStreamTruncated
+			}
 			s.lastErr = err
-			if err == io.EOF && s.buffer.Len() > 0 {
+			if s.buffer.Len() > 0 {
 				// Return any buffered content before EOF
 				content := s.buffer.String()
 				s.buffer.Reset()
@@ -76,14 +90,7 @@ func (s *sseStream) Recv() (string, error) {
 
 		// Check for stream end marker
 		if line == "data: [DONE]" {
-			s.done = true
-			s.recordUsage() // Phase 3: Record final usage
-			if s.buffer.Len() > 0 {
-				content := s.buffer.String()
-				s.buffer.Reset()
-				return content, nil
-			}
-			return "", io.EOF
+			return s.complete()
 		}
 
 		// Parse SSE data lines
@@ -92,23 +99,22 @@ func (s *sseStream) Recv() (string, error) {
 
 			// Skip event markers
 			if data == "[DONE]" {
-				s.done = true
-				s.recordUsage() // Phase 3: Record final usage
-				if s.buffer.Len() > 0 {
-					content := s.buffer.String()
-					s.buffer.Reset()
-					return content, nil
-				}
-				return "", io.EOF
+				return s.complete()
 			}
 
 			// Parse JSON chunk
 			var chunk struct {
 				Choices []struct {
+					Index int `json:"index"`
 					Delta struct {
-						Content string `json:"content"`
+						Content          string          `json:"content"`
+						Refusal          string          `json:"refusal"`
+						Reasoning        string          `json:"reasoning"`
+						ReasoningContent string          `json:"reasoning_content"`
+						ToolCalls        []toolCallDelta `json:"tool_calls"`
 					} `json:"delta"`
-					FinishReason *string `json:"finish_reason"`
+					FinishReason *string         `json:"finish_reason"`
+					Logprobs     *choiceLogprobs `json:"logprobs"`
 				} `json:"choices"`
 				Usage *UsageData `json:"usage"` // Phase 3: Usage data (in final chunk)
 			}
@@ -123,26 +129,37 @@ func (s *sseStream) Recv() (string, error) {
 				s.usageData = chunk.Usage
 			}
 
-			if len(chunk.Choices) == 0 {
+			// Only the first choice (index 0) is assembled. Deltas for other
+			// choices (n>1, or proxies that fan out) may arrive interleaved
+			// or out of order and are ignored, finish_reason included.
+			primary := -1
+			for i := range chunk.Choices {
+				if chunk.Choices[i].Index == 0 {
+					primary = i
+					break
+				}
+			}
+			if primary < 0 {
 				continue
 			}
 
-			choice := chunk.Choices[0]
+			choice := chunk.Choices[primary]
+			s.confidence.add(choice.Logprobs)
+			s.reasoning.WriteString(choice.Delta.Reasoning)
+			s.reasoning.WriteString(choice.Delta.ReasoningContent)
+			s.refusal.WriteString(choice.Delta.Refusal)
+			s.toolCalls.add(choice.Delta.ToolCalls)
 
 			// Check if stream finished
 			if choice.FinishReason != nil {
-				s.done = true
-				s.recordUsage() // Phase 3: Record final usage
-				if s.buffer.Len() > 0 {
-					content := s.buffer.String()
-					s.buffer.Reset()
-					return content, nil
-				}
-				return "", io.EOF
+				s.finish = *choice.FinishReason
+				return s.complete()
 			}
 
-			// Accumulate delta content
-			if choice.Delta.Content != "" {
+			// Accumulate delta content. Once the model starts a native tool
+			// call, the response is that call and any remaining content is
+			// dropped.
+			if choice.Delta.Content != "" && s.toolCalls.empty() {
 				s.buffer.WriteString(choice.Delta.Content)
 
 				// Return buffered content periodically for responsiveness
@@ -157,6 +174,74 @@ func (s *sseStream) Recv() (string, error) {
 	}
 }
 
+// complete ends a stream that finished normally, returning any buffered
+// content before the end error. A native tool call replaces the content
+// with the structured action it asks for.
+func (s *sseStream) complete() (string, error) {
+	s.done = true
+	s.finished = true
+	s.lastErr = s.refusalErr()
+	s.recordUsage() // Phase 3: Record final usage
+	if s.lastErr == nil && !s.toolCalls.empty() {
+		action, err := s.toolCalls.action()
+		if err != nil {
+			s.lastErr = err
+			return "", err
+		}
+		s.buffer.Reset()
+		s.buffer.WriteString(action)
+	}
+	if s.buffer.Len() > 0 {
+		content := s.buffer.String()
+		s.buffer.Reset()
+		return content, nil
+	}
+	return "", s.endErr()
+}
+
+// refusalErr returns the accumulated refusal as an error, or nil
+func (s *sseStream) refusalErr() error {
+	if s.refusal.Len() == 0 {
+		return nil
+	}
+	return &llm.RefusalError{Refusal: s.refusal.String()}
+}
+
+// endErr returns the error that ends a completed stream: a refusal if the
+// model refused, io.EOF otherwise
+func (s *sseStream) endErr() error {
+	if s.lastErr != nil {
+		return s.lastErr
+	}
+	return io.EOF
+}
+
+// FinishReason returns the finish_reason OpenAI sent (e.g. "stop" or
+// "length"), empty until it arrives
+func (s *sseStream) FinishReason() string {
+	return s.finish
+}
+
+// Reasoning returns the reasoning the model streamed separately from its
+// answer, if any
+func (s *sseStream) Reasoning() string {
+	return s.reasoning.String()
+}
+
+// Confidence returns the average top-token probability of the response.
+// It is only available when the request asked for logprobs.
+func (s *sseStream) Confidence() (float64, bool) {
+	return s.confidence.value()
+}
+
+// Usage returns the token counts from the final usage chunk, if any
+func (s *sseStream) Usage() (llm.TokenUsage, bool) {
+	if s.usageData == nil || (s.usageData.PromptTokens == 0 && s.usageData.CompletionTokens == 0) {
+		return llm.TokenUsage{}, false
+	}
+	return llm.TokenUsage{PromptTokens: s.usageData.PromptTokens, CompletionTokens: s.usageData.CompletionTokens}, true
+}
+
 // recordUsage records token usage and costs (Phase 3)
 func (s *sseStream) recordUsage() {
 	if s.costTracker == nil || s.usageData == nil {
@@ -1,9 +1,18 @@
 package openai
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/llm"
 )
 
 // mockReadCloser wraps a string reader to make it an io.ReadCloser
@@ -117,6 +126,27 @@ data: {"choices":[{"delta":{},"finish_reason":"stop"}]}
 	if err != io.EOF {
 		t.Errorf("expected EOF after finish_reason, got %v", err)
 	}
+	if reason := stream.FinishReason(); reason != "stop" {
+		t.Errorf("expected finish reason stop, got %q", reason)
+	}
+}
+
+func TestSSEStream_FinishReasonLength(t *testing.T) {
+	sseData := `data: {"choices":[{"delta":{"content":"Cut"}}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"length"}]}
+
+`
+	var stream llm.Stream = newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+	reporter, ok := stream.(llm.FinishReasonReporter)
+	if !ok || reporter.FinishReason() != llm.FinishReasonLength {
+		t.Errorf("expected the stream to report a length finish")
+	}
 }
 
 func TestSSEStream_MalformedJSON(t *testing.T) {
@@ -332,13 +362,12 @@ func TestSSEStream_EarlyEOF(t *testing.T) {
 	stream := newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
 
 	content := ""
+	var err error
 	for {
-		chunk, err := stream.Recv()
-		if err == io.EOF {
-			break
-		}
+		var chunk string
+		chunk, err = stream.Recv()
 		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
+			break
 		}
 		content += chunk
 	}
@@ -347,6 +376,11 @@ func TestSSEStream_EarlyEOF(t *testing.T) {
 	if content != "Hello" {
 		t.Errorf("expected 'Hello', got %q", content)
 	}
+
+	// A missing finish_reason is reported as truncation, not a clean EOF
+	if !errors.Is(err, ErrStreamTruncated) {
+		t.Errorf("expected ErrStreamTruncated, got %v", err)
+	}
 }
 
 func TestUsageData_Integration(t *testing.T) {
@@ -366,3 +400,515 @@ func TestUsageData_Integration(t *testing.T) {
 		t.Errorf("expected 150 total tokens, got %d", usage.TotalTokens)
 	}
 }
+
+func TestSSEStream_LogprobsConfidence(t *testing.T) {
+	// ln(0.5) and ln(1.0) average to a confidence of 0.75
+	sseData := `data: {"choices":[{"delta":{"content":"Hi"},"logprobs":{"content":[{"token":"Hi","logprob":-0.6931471805599453}]}}]}
+
+data: {"choices":[{"delta":{"content":"!"},"logprobs":{"content":[{"token":"!","logprob":0}]},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	stream := newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
+
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+
+	confidence, ok := stream.Confidence()
+	if !ok {
+		t.Fatal("expected confidence to be available")
+	}
+	if confidence < 0.7499 || confidence > 0.7501 {
+		t.Errorf("expected confidence 0.75, got %f", confidence)
+	}
+}
+
+func TestSSEStream_NoLogprobsNoConfidence(t *testing.T) {
+	sseData := `data: {"choices":[{"delta":{"content":"Hi"},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	stream := newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+
+	if _, ok := stream.Confidence(); ok {
+		t.Error("expected no confidence without logprobs")
+	}
+}
+
+func TestClientStream_ResumesAfterMidStreamDrop(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+		w.Header().Set("Content-Type", "text/event-stream")
+		if len(requests) == 1 {
+			// The connection drops mid-body, before finish_reason
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hello \"}}]}\n\n")
+			w.(http.Flusher).Flush()
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack failed: %v", err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"world\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := &Client{
+		baseURL:        server.URL,
+		model:          "gpt-4o",
+		enableSSE:      true,
+		httpClient:     server.Client(),
+		circuitBreaker: NewCircuitBreaker(5, time.Second),
+	}
+
+	stream, err := client.Stream(context.Background(), "system", []llm.Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	content := ""
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		content += chunk
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected a retry after the drop (2 requests), got %d", len(requests))
+	}
+	if content != "Hello world" {
+		t.Errorf("expected 'Hello world', got %q", content)
+	}
+	if !strings.Contains(requests[1], "Hello ") || !strings.Contains(requests[1], "truncated") {
+		t.Errorf("expected retry to include the partial response marked truncated, got %s", requests[1])
+	}
+}
+
+func TestClientStream_FallsBackToJSONResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A gateway that ignores stream:true and answers with a plain body
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"Hello world"}}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		baseURL:        server.URL,
+		model:          "gpt-4o",
+		enableSSE:      true,
+		httpClient:     server.Client(),
+		circuitBreaker: NewCircuitBreaker(5, time.Second),
+	}
+
+	stream, err := client.Stream(context.Background(), "system", []llm.Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	content := ""
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		content += chunk
+	}
+
+	if content != "Hello world" {
+		t.Errorf("expected 'Hello world' from the JSON body, got %q", content)
+	}
+}
+
+func TestClient_SetTimeouts(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	client, err := New("gpt-4o")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client.SetTimeouts(15*time.Second, 5*time.Second)
+
+	if client.httpClient.Timeout != 15*time.Second || client.requestTimeout != 15*time.Second {
+		t.Errorf("expected 15s request timeout, got http=%v request=%v", client.httpClient.Timeout, client.requestTimeout)
+	}
+	if client.idleTimeout != 5*time.Second {
+		t.Errorf("expected 5s idle timeout, got %v", client.idleTimeout)
+	}
+}
+
+func TestClientStream_RequestTimeoutCutsOffSlowResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{
+		baseURL:        server.URL,
+		model:          "gpt-4o",
+		enableSSE:      true,
+		httpClient:     server.Client(),
+		circuitBreaker: NewCircuitBreaker(5, time.Second),
+	}
+	client.SetTimeouts(100*time.Millisecond, 0)
+
+	start := time.Now()
+	_, err := client.Stream(context.Background(), "system", []llm.Message{{Role: "user", Content: "hi"}})
+	if err == nil {
+		t.Fatal("expected slow response to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected request to be cut off near the timeout, took %v", elapsed)
+	}
+}
+
+func TestClientStream_IdleTimeoutBetweenChunks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{
+		baseURL:        server.URL,
+		model:          "gpt-4o",
+		enableSSE:      true,
+		httpClient:     server.Client(),
+		circuitBreaker: NewCircuitBreaker(5, time.Second),
+		requestTimeout: 10 * time.Second,
+		idleTimeout:    100 * time.Millisecond,
+	}
+
+	stream, err := client.Stream(context.Background(), "system", []llm.Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	start := time.Now()
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrStreamIdle) {
+		t.Errorf("expected idle timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected idle timeout to fire quickly, took %v", elapsed)
+	}
+}
+
+func TestClientStream_ToolModes(t *testing.T) {
+	tests := []struct {
+		mode             string
+		wantInstructions bool
+		wantTools        bool
+	}{
+		{mode: "", wantInstructions: true, wantTools: false},
+		{mode: ToolModeInstructions, wantInstructions: true, wantTools: false},
+		{mode: ToolModeNative, wantInstructions: false, wantTools: true},
+		{mode: ToolModeNone, wantInstructions: false, wantTools: false},
+	}
+
+	for _, tt := range tests {
+		t.Run("mode="+tt.mode, func(t *testing.T) {
+			var reqBody map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &reqBody)
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"},\"finish_reason\":\"stop\"}]}\n\n")
+				fmt.Fprint(w, "data: [DONE]\n\n")
+			}))
+			defer server.Close()
+
+			client := &Client{
+				baseURL:        server.URL,
+				model:          "gpt-4o",
+				enableSSE:      true,
+				httpClient:     server.Client(),
+				circuitBreaker: NewCircuitBreaker(5, time.Second),
+			}
+			if err := client.SetToolMode(tt.mode); err != nil {
+				t.Fatalf("SetToolMode(%q): %v", tt.mode, err)
+			}
+
+			stream, err := client.Stream(context.Background(), "system", []llm.Message{{Role: "user", Content: "hi"}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for {
+				if _, err := stream.Recv(); err != nil {
+					break
+				}
+			}
+			stream.Close()
+
+			messages, _ := reqBody["messages"].([]any)
+			if len(messages) == 0 {
+				t.Fatal("expected messages in request body")
+			}
+			system, _ := messages[0].(map[string]any)["content"].(string)
+			if got := strings.Contains(system, "Tool Usage Instructions"); got != tt.wantInstructions {
+				t.Errorf("instructions present = %v, want %v", got, tt.wantInstructions)
+			}
+			if _, got := reqBody["tools"]; got != tt.wantTools {
+				t.Errorf("native tools present = %v, want %v", got, tt.wantTools)
+			}
+		})
+	}
+}
+
+func TestSSEStream_NativeToolCallBecomesAction(t *testing.T) {
+	sseData := `data: {"choices":[{"delta":{"role":"assistant","content":null,"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"fs.read","arguments":""}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"pa"}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"th\": \"go.mod\"}"}}]}}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+
+`
+	stream := newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
+	resp, err := llm.ParseStructuredResponse(readAll(t, stream))
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if resp.Type != llm.ResponseTypeAction || resp.Action.Tool != "fs.read" || resp.Action.Args["path"] != "go.mod" {
+		t.Errorf("expected an fs.read action on go.mod, got %+v", resp)
+	}
+	if reason := stream.FinishReason(); reason != "tool_calls" {
+		t.Errorf("expected finish reason tool_calls, got %q", reason)
+	}
+}
+
+func TestSSEStream_MalformedToolCallArgumentsFail(t *testing.T) {
+	sseData := `data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"name":"fs.read","arguments":"{\"path\":"}}]}}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"tool_calls"}]}
+
+`
+	stream := newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
+	if _, err := stream.Recv(); err == nil || err == io.EOF {
+		t.Errorf("expected an error for truncated tool call arguments, got %v", err)
+	}
+}
+
+func TestClientStream_JSONResponseToolCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"fs.list","arguments":"{\"path\":\".\"}"}}]}}]}`)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		baseURL:        server.URL,
+		model:          "gpt-4o",
+		enableSSE:      true,
+		httpClient:     server.Client(),
+		circuitBreaker: NewCircuitBreaker(5, time.Second),
+	}
+	if err := client.SetToolMode(ToolModeNative); err != nil {
+		t.Fatalf("SetToolMode: %v", err)
+	}
+
+	stream, err := client.Stream(context.Background(), "system", []llm.Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	resp, err := llm.ParseStructuredResponse(readAll(t, stream))
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if resp.Type != llm.ResponseTypeAction || resp.Action.Tool != "fs.list" || resp.Action.Args["path"] != "." {
+		t.Errorf("expected an fs.list action, got %+v", resp)
+	}
+}
+
+func TestClient_SetToolModeRejectsUnknown(t *testing.T) {
+	client := &Client{}
+	if err := client.SetToolMode("magic"); err == nil {
+		t.Error("expected error for unknown tool mode")
+	}
+}
+
+func TestSSEStream_RefusalSurfacesAsError(t *testing.T) {
+	sseData := `data: {"choices":[{"delta":{"refusal":"I can't help "}}]}
+
+data: {"choices":[{"delta":{"refusal":"with that."}}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	stream := newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
+
+	content, err := stream.Recv()
+	if content != "" {
+		t.Errorf("refusal text must not be returned as content, got %q", content)
+	}
+	var refusal *llm.RefusalError
+	if !errors.As(err, &refusal) {
+		t.Fatalf("expected RefusalError, got %v", err)
+	}
+	if refusal.Refusal != "I can't help with that." {
+		t.Errorf("unexpected refusal text: %q", refusal.Refusal)
+	}
+
+	// The refusal keeps being reported on subsequent calls
+	if _, err := stream.Recv(); !errors.As(err, &refusal) {
+		t.Errorf("expected RefusalError again, got %v", err)
+	}
+}
+
+func TestSSEStream_ReasoningKeptSeparate(t *testing.T) {
+	sseData := `data: {"choices":[{"delta":{"reasoning":"The user wants "}}]}
+
+data: {"choices":[{"delta":{"reasoning_content":"a greeting."}}]}
+
+data: {"choices":[{"delta":{"content":"Hello!"}}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"stop"}]}
+
+`
+	stream := newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
+
+	content := ""
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		content += chunk
+	}
+
+	if content != "Hello!" {
+		t.Errorf("expected only the answer as content, got %q", content)
+	}
+	if got := stream.Reasoning(); got != "The user wants a greeting." {
+		t.Errorf("unexpected reasoning: %q", got)
+	}
+}
+
+// readAll collects a stream's content until it ends
+func readAll(t *testing.T, stream llm.Stream) string {
+	t.Helper()
+	var sb strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return sb.String()
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sb.WriteString(chunk)
+	}
+}
+
+func TestSSEStream_MultiChoiceUsesFirstChoice(t *testing.T) {
+	// Two choices streamed interleaved, one chunk listing them out of order
+	sseData := `data: {"choices":[{"index":0,"delta":{"content":"Hello"}},{"index":1,"delta":{"content":"Howdy"}}]}
+
+data: {"choices":[{"index":1,"delta":{"content":" partner"}},{"index":0,"delta":{"content":" there"}}]}
+
+data: {"choices":[{"index":1,"delta":{"content":"!"}}]}
+
+data: {"choices":[{"index":0,"delta":{"content":"."}}]}
+
+data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}
+
+data: {"choices":[{"index":1,"delta":{},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	stream := newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
+	if got := readAll(t, stream); got != "Hello there." {
+		t.Errorf("expected only the first choice's content, got %q", got)
+	}
+	if reason := stream.FinishReason(); reason != "stop" {
+		t.Errorf("expected finish reason stop, got %q", reason)
+	}
+}
+
+func TestSSEStream_OtherChoiceFinishingFirst(t *testing.T) {
+	// A second choice finishing early must not end the first one
+	sseData := `data: {"choices":[{"index":1,"delta":{"content":"Short"}}]}
+
+data: {"choices":[{"index":1,"delta":{},"finish_reason":"length"}]}
+
+data: {"choices":[{"index":0,"delta":{"content":"The full "}}]}
+
+data: {"choices":[{"index":0,"delta":{"content":"answer"}}]}
+
+data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}
+
+`
+	stream := newSSEStream(newMockReadCloser(sseData), nil, "gpt-4o")
+	if got := readAll(t, stream); got != "The full answer" {
+		t.Errorf("expected the first choice assembled in full, got %q", got)
+	}
+	if reason := stream.FinishReason(); reason != "stop" {
+		t.Errorf("expected the first choice's finish reason, got %q", reason)
+	}
+}
+
+func TestClient_NonStreamingUsesFirstChoice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":1,"message":{"content":"second"}},{"index":0,"message":{"content":"first"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	var waits []time.Duration
+	stream, err := newRateLimitTestClient(server, &waits).Stream(context.Background(), "system", nil)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if got := readAll(t, stream); got != "first" {
+		t.Errorf("expected the index 0 choice, got %q", got)
+	}
+}
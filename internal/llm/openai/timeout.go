@@ -0,0 +1,55 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStreamIdle is returned when no streamed data arrives within the idle timeout
+var ErrStreamIdle = errors.New("OpenAI stream idle timeout")
+
+// idleTimeoutBody wraps a streaming response body and cancels the request
+// when no data has been read for the idle timeout. Closing the body also
+// releases the request context.
+type idleTimeoutBody struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	idled   atomic.Bool
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) *idleTimeoutBody {
+	b := &idleTimeoutBody{body: body, timeout: timeout, cancel: cancel}
+	if timeout > 0 {
+		b.timer = time.AfterFunc(timeout, func() {
+			b.idled.Store(true)
+			cancel()
+		})
+	}
+	return b
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if b.idled.Load() {
+		return n, fmt.Errorf("%w: no data received for %s", ErrStreamIdle, b.timeout)
+	}
+	if b.timer != nil && n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	err := b.body.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,80 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// toolCallDelta is one streamed fragment of a native tool call. The id and
+// function name arrive in the first fragment; the arguments are split
+// across fragments and only form valid JSON once concatenated.
+type toolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// toolCall is a native tool call assembled from its deltas
+type toolCall struct {
+	name      string
+	arguments strings.Builder
+}
+
+// toolCallAccumulator assembles the tool_calls deltas of a native-mode
+// response, keyed by their index
+type toolCallAccumulator struct {
+	calls []*toolCall
+}
+
+// add merges a chunk's tool call fragments
+func (a *toolCallAccumulator) add(deltas []toolCallDelta) {
+	for _, d := range deltas {
+		if d.Index < 0 {
+			continue
+		}
+		for len(a.calls) <= d.Index {
+			a.calls = append(a.calls, &toolCall{})
+		}
+		call := a.calls[d.Index]
+		if d.Function.Name != "" {
+			call.name = d.Function.Name
+		}
+		call.arguments.WriteString(d.Function.Arguments)
+	}
+}
+
+// empty reports whether no tool call has been seen
+func (a *toolCallAccumulator) empty() bool {
+	return len(a.calls) == 0
+}
+
+// action renders the first tool call as the structured action response
+// the session parses, so native tool calls run like instruction-mode ones.
+// Goshi runs one tool per response; further calls are dropped and the
+// model can ask for them after seeing the first result.
+func (a *toolCallAccumulator) action() (string, error) {
+	call := a.calls[0]
+	if call.name == "" {
+		return "", fmt.Errorf("tool call without a function name")
+	}
+
+	args := map[string]any{}
+	if raw := strings.TrimSpace(call.arguments.String()); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &args); err != nil {
+			return "", fmt.Errorf("malformed arguments for tool call %s: %w", call.name, err)
+		}
+	}
+
+	b, err := json.Marshal(map[string]any{
+		"type":   "action",
+		"action": map[string]any{"tool": call.name, "args": args},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tool call %s: %w", call.name, err)
+	}
+	return string(b), nil
+}
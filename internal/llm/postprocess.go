@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ResponseProcessor transforms final assistant text before it is displayed
+// or stored in the session history
+type ResponseProcessor interface {
+	Name() string
+	Process(text string) string
+}
+
+// Built-in response processor names, usable in llm.post_processors
+const (
+	ProcessorStripThinking       = "strip_thinking"
+	ProcessorNormalizeWhitespace = "normalize_whitespace"
+	ProcessorRedactSecrets       = "redact_secrets"
+)
+
+// ResponsePipeline applies registered processors in order. A nil pipeline
+// returns text unchanged.
+type ResponsePipeline struct {
+	processors []ResponseProcessor
+}
+
+// NewResponsePipeline creates a pipeline of the given processors
+func NewResponsePipeline(processors ...ResponseProcessor) *ResponsePipeline {
+	return &ResponsePipeline{processors: processors}
+}
+
+// NewResponsePipelineFromNames builds a pipeline of built-in processors,
+// failing on unknown names
+func NewResponsePipelineFromNames(names []string) (*ResponsePipeline, error) {
+	p := NewResponsePipeline()
+	for _, name := range names {
+		proc, err := BuiltinResponseProcessor(name)
+		if err != nil {
+			return nil, err
+		}
+		p.Register(proc)
+	}
+	return p, nil
+}
+
+// Register appends a processor to the end of the pipeline
+func (p *ResponsePipeline) Register(proc ResponseProcessor) {
+	p.processors = append(p.processors, proc)
+}
+
+// Names returns the registered processor names, in order
+func (p *ResponsePipeline) Names() []string {
+	if p == nil {
+		return nil
+	}
+	names := make([]string, 0, len(p.processors))
+	for _, proc := range p.processors {
+		names = append(names, proc.Name())
+	}
+	return names
+}
+
+// Process runs text through every registered processor
+func (p *ResponsePipeline) Process(text string) string {
+	if p == nil {
+		return text
+	}
+	for _, proc := range p.processors {
+		text = proc.Process(text)
+	}
+	return text
+}
+
+// BuiltinResponseProcessor returns the built-in processor with the given name
+func BuiltinResponseProcessor(name string) (ResponseProcessor, error) {
+	switch name {
+	case ProcessorStripThinking:
+		return stripThinking{}, nil
+	case ProcessorNormalizeWhitespace:
+		return normalizeWhitespace{}, nil
+	case ProcessorRedactSecrets:
+		return redactSecrets{}, nil
+	default:
+		return nil, fmt.Errorf("unknown response processor %q (supported: %s, %s, %s)",
+			name, ProcessorStripThinking, ProcessorNormalizeWhitespace, ProcessorRedactSecrets)
+	}
+}
+
+// thinkingBlock matches chain-of-thought blocks some models emit inline
+var thinkingBlock = regexp.MustCompile(`(?is)<(think|thinking|reasoning)>.*?</(think|thinking|reasoning)>`)
+
+// stripThinking removes inline chain-of-thought blocks
+type stripThinking struct{}
+
+func (stripThinking) Name() string { return ProcessorStripThinking }
+
+func (stripThinking) Process(text string) string {
+	return strings.TrimSpace(thinkingBlock.ReplaceAllString(text, ""))
+}
+
+// blankRun matches three or more consecutive newlines
+var blankRun = regexp.MustCompile(`\n{3,}`)
+
+// normalizeWhitespace trims trailing spaces on each line, collapses runs of
+// blank lines and trims the text
+type normalizeWhitespace struct{}
+
+func (normalizeWhitespace) Name() string { return ProcessorNormalizeWhitespace }
+
+func (normalizeWhitespace) Process(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(blankRun.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// secretPatterns match common credential shapes in model output
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`(?i)\b((?:api[_-]?key|secret|token|password)\s*[:=]\s*)["']?[^\s"']{6,}["']?`),
+}
+
+// redactedText replaces secrets found in model output
+const redactedText = "[REDACTED]"
+
+// redactSecrets masks API keys, tokens and passwords in the text
+type redactSecrets struct{}
+
+func (redactSecrets) Name() string { return ProcessorRedactSecrets }
+
+func (redactSecrets) Process(text string) string {
+	for i, re := range secretPatterns {
+		if i == len(secretPatterns)-1 {
+			// Keep the key name so the answer still reads naturally
+			text = re.ReplaceAllString(text, "${1}"+redactedText)
+			continue
+		}
+		text = re.ReplaceAllString(text, redactedText)
+	}
+	return text
+}
@@ -3,29 +3,69 @@ package llm
 import (
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/cshaiku/goshi/internal/config"
 )
 
-func SelectProvider(cfg config.Config) string {
-	// If provider is explicitly set to ollama, use it
-	if cfg.LLM.Provider == "ollama" {
-		return "ollama"
+// ProviderProbe reports what provider auto-detection can use
+type ProviderProbe struct {
+	OllamaHealthy func(cfg config.Config) bool // A local Ollama server answers
+	OpenAIKeySet  func() bool                  // OPENAI_API_KEY is set
+}
+
+// DefaultProviderProbe checks the configured Ollama server and the
+// OPENAI_API_KEY environment variable
+func DefaultProviderProbe() ProviderProbe {
+	return ProviderProbe{
+		OllamaHealthy: detectOllama,
+		OpenAIKeySet:  func() bool { return os.Getenv("OPENAI_API_KEY") != "" },
 	}
+}
+
+// ProviderChoice is the provider selected for a session and why
+type ProviderChoice struct {
+	Provider string
+	Reason   string
+	Detected bool // Chosen by auto-detection rather than configured
+}
+
+// SelectProvider picks the provider for a session, auto-detecting it when
+// the configured provider is "auto" (or unset)
+func SelectProvider(cfg config.Config) ProviderChoice {
+	return SelectProviderWith(cfg, DefaultProviderProbe())
+}
 
-	// If provider is explicitly set to openai, return it
-	if cfg.LLM.Provider == "openai" {
-		return "openai"
+// SelectProviderWith is SelectProvider with the detection checks supplied
+// by the caller. Auto-detection prefers a healthy local Ollama, then OpenAI
+// when an API key is set, and otherwise falls back to Ollama.
+func SelectProviderWith(cfg config.Config, probe ProviderProbe) ProviderChoice {
+	if cfg.LLM.Provider != "" && cfg.LLM.Provider != "auto" {
+		return ProviderChoice{Provider: cfg.LLM.Provider, Reason: "configured"}
 	}
 
-	// Auto-detect: try ollama first
-	if detectOllama(cfg) {
-		return "ollama"
+	if probe.OllamaHealthy != nil && probe.OllamaHealthy(cfg) {
+		return ProviderChoice{Provider: "ollama", Reason: "a local Ollama server is reachable", Detected: true}
+	}
+	if probe.OpenAIKeySet != nil && probe.OpenAIKeySet() {
+		return ProviderChoice{Provider: "openai", Reason: "OPENAI_API_KEY is set and no local Ollama server is reachable", Detected: true}
 	}
 
 	// Default to ollama even if not detected
-	return "ollama"
+	return ProviderChoice{Provider: "ollama", Reason: "no local Ollama server is reachable and OPENAI_API_KEY is not set; start Ollama or set OPENAI_API_KEY", Detected: true}
+}
+
+// localProviders run models on this machine, with no per-request cost
+var localProviders = map[string]bool{"ollama": true}
+
+// PaidProviderWarning returns a warning that requests to provider are
+// billed, or "" for local providers or when llm.warn_paid_provider is off
+func PaidProviderWarning(cfg config.Config, provider string) string {
+	if !cfg.LLM.WarnPaid || provider == "" || localProviders[provider] {
+		return ""
+	}
+	return fmt.Sprintf("%s is a paid provider: requests are billed to your account, unlike local Ollama (set llm.warn_paid_provider: false to hide this)", provider)
 }
 
 func detectOllama(cfg config.Config) bool {
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/config"
+)
+
+// stubProbe reports fixed detection results
+func stubProbe(ollamaHealthy, keySet bool) ProviderProbe {
+	return ProviderProbe{
+		OllamaHealthy: func(config.Config) bool { return ollamaHealthy },
+		OpenAIKeySet:  func() bool { return keySet },
+	}
+}
+
+func TestSelectProviderWith_AutoDetection(t *testing.T) {
+	tests := []struct {
+		name          string
+		ollamaHealthy bool
+		keySet        bool
+		want          string
+		reason        string
+	}{
+		{"ollama healthy, no key", true, false, "ollama", "local Ollama server is reachable"},
+		{"ollama healthy, key set", true, true, "ollama", "local Ollama server is reachable"},
+		{"ollama down, key set", false, true, "openai", "OPENAI_API_KEY is set"},
+		{"ollama down, no key", false, false, "ollama", "start Ollama or set OPENAI_API_KEY"},
+	}
+
+	for _, tt := range tests {
+		for _, provider := range []string{"auto", ""} {
+			cfg := config.LoadDefaults()
+			cfg.LLM.Provider = provider
+
+			choice := SelectProviderWith(cfg, stubProbe(tt.ollamaHealthy, tt.keySet))
+			if choice.Provider != tt.want || !choice.Detected {
+				t.Errorf("%s (provider %q): expected detected %s, got %+v", tt.name, provider, tt.want, choice)
+			}
+			if !strings.Contains(choice.Reason, tt.reason) {
+				t.Errorf("%s: expected reason containing %q, got %q", tt.name, tt.reason, choice.Reason)
+			}
+		}
+	}
+}
+
+func TestSelectProviderWith_ExplicitProviderSkipsDetection(t *testing.T) {
+	probe := ProviderProbe{
+		OllamaHealthy: func(config.Config) bool { t.Error("unexpected Ollama probe"); return true },
+		OpenAIKeySet:  func() bool { t.Error("unexpected key probe"); return true },
+	}
+
+	for _, provider := range []string{"ollama", "openai"} {
+		cfg := config.LoadDefaults()
+		cfg.LLM.Provider = provider
+		if choice := SelectProviderWith(cfg, probe); choice.Provider != provider || choice.Detected {
+			t.Errorf("expected configured %s, got %+v", provider, choice)
+		}
+	}
+}
+
+func TestPaidProviderWarning(t *testing.T) {
+	cfg := config.LoadDefaults()
+
+	warning := PaidProviderWarning(cfg, "openai")
+	if !strings.Contains(warning, "openai is a paid provider") || !strings.Contains(warning, "llm.warn_paid_provider") {
+		t.Errorf("expected a paid-provider warning naming the setting, got %q", warning)
+	}
+	if got := PaidProviderWarning(cfg, "ollama"); got != "" {
+		t.Errorf("expected no warning for local Ollama, got %q", got)
+	}
+
+	cfg.LLM.WarnPaid = false
+	if got := PaidProviderWarning(cfg, "openai"); got != "" {
+		t.Errorf("expected the setting to silence the warning, got %q", got)
+	}
+}
@@ -11,7 +11,9 @@ type ResponseType string
 const (
 	ResponseTypeText   ResponseType = "text"   // Plain text response (planning/reasoning)
 	ResponseTypeAction ResponseType = "action" // Tool call/action request
-	ResponseTypeError  ResponseType = "error"  // Error or clarification
+	ResponseTypeError  ResponseType = "error"  // Error reported by the model
+
+	ResponseTypeClarification ResponseType = "clarification" // Question back to the user
 )
 
 // StructuredResponse represents a parsed LLM response
@@ -22,6 +24,14 @@ type StructuredResponse struct {
 	Action  *ActionCall  `json:"action,omitempty"` // For ResponseTypeAction
 	Error   string       `json:"error,omitempty"`  // For ResponseTypeError
 	RawText string       `json:"-"`                // Original unparsed response
+
+	Clarification *ClarificationRequest `json:"clarification,omitempty"` // For ResponseTypeClarification
+}
+
+// ClarificationRequest is a question the model needs answered before it can proceed
+type ClarificationRequest struct {
+	Question string   `json:"question"`
+	Hints    []string `json:"hints,omitempty"` // Optional expected answers
 }
 
 // ActionCall represents a tool invocation
@@ -113,6 +123,30 @@ func tryParseAsJSON(rawResponse string) *StructuredResponse {
 			resp.Error = errMsg
 			return resp
 		}
+
+	case ResponseTypeClarification:
+		if clarData, ok := data["clarification"].(map[string]any); ok {
+			question, ok := clarData["question"].(string)
+			if !ok {
+				return nil
+			}
+
+			var hints []string
+			if rawHints, ok := clarData["hints"].([]any); ok {
+				for _, h := range rawHints {
+					if hint, ok := h.(string); ok && hint != "" {
+						hints = append(hints, hint)
+					}
+				}
+			}
+
+			resp.Type = ResponseTypeClarification
+			resp.Clarification = &ClarificationRequest{
+				Question: question,
+				Hints:    hints,
+			}
+			return resp
+		}
 	}
 
 	return nil
@@ -256,6 +290,11 @@ func (r *StructuredResponse) Validate() error {
 			return fmt.Errorf("error response cannot be empty")
 		}
 
+	case ResponseTypeClarification:
+		if r.Clarification == nil || r.Clarification.Question == "" {
+			return fmt.Errorf("clarification response must have a question")
+		}
+
 	default:
 		return fmt.Errorf("unknown response type: %s", r.Type)
 	}
@@ -276,6 +315,9 @@ func (r *StructuredResponse) ToMessage() LLMMessage {
 		// Errors are returned as text in the conversation
 		return NewAssistantTextMessage(fmt.Sprintf("[ERROR] %s", r.Error))
 
+	case ResponseTypeClarification:
+		return NewAssistantTextMessage(r.Clarification.Question)
+
 	default:
 		return NewAssistantTextMessage(r.RawText)
 	}
@@ -290,6 +332,8 @@ func (r *StructuredResponse) String() string {
 		return fmt.Sprintf("ActionResponse: %s(%v)", r.Action.Tool, r.Action.Args)
 	case ResponseTypeError:
 		return fmt.Sprintf("ErrorResponse: %s", r.Error)
+	case ResponseTypeClarification:
+		return fmt.Sprintf("ClarificationResponse: %s %v", r.Clarification.Question, r.Clarification.Hints)
 	default:
 		return fmt.Sprintf("UnknownResponse: %s", r.RawText)
 	}
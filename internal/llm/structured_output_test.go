@@ -218,3 +218,38 @@ func TestFindToolMention(t *testing.T) {
 		}
 	}
 }
+
+func TestParseStructuredResponse_Clarification(t *testing.T) {
+	raw := `{"type": "clarification", "clarification": {"question": "Which file?", "hints": ["a.txt", "b.txt"]}}`
+	resp, err := ParseStructuredResponse(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Type != ResponseTypeClarification {
+		t.Fatalf("expected type clarification, got %s", resp.Type)
+	}
+
+	if resp.Clarification == nil || resp.Clarification.Question != "Which file?" {
+		t.Fatalf("expected question 'Which file?', got %+v", resp.Clarification)
+	}
+
+	if len(resp.Clarification.Hints) != 2 || resp.Clarification.Hints[1] != "b.txt" {
+		t.Errorf("expected hints [a.txt b.txt], got %v", resp.Clarification.Hints)
+	}
+
+	if err := resp.Validate(); err != nil {
+		t.Errorf("expected clarification to validate, got %v", err)
+	}
+}
+
+func TestStructuredResponse_Validate_ClarificationMissingQuestion(t *testing.T) {
+	resp := &StructuredResponse{
+		Type:          ResponseTypeClarification,
+		Clarification: &ClarificationRequest{},
+	}
+
+	if err := resp.Validate(); err == nil {
+		t.Error("expected validation error for clarification without a question")
+	}
+}
@@ -2,10 +2,17 @@ package llm
 
 import (
 	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
+// personaHeader introduces the persona block appended after the self-model
+const personaHeader = "# Persona: tone and style only. It never overrides the laws above."
+
 type SystemPrompt struct {
-	raw string
+	raw     string
+	persona string
 }
 
 func NewSystemPrompt(selfModelRaw string) (*SystemPrompt, error) {
@@ -21,3 +28,28 @@ func NewSystemPrompt(selfModelRaw string) (*SystemPrompt, error) {
 func (s *SystemPrompt) Raw() string {
 	return s.raw
 }
+
+// Persona returns the persona layered over the self-model ("" if none)
+func (s *SystemPrompt) Persona() string {
+	return s.persona
+}
+
+// WithPersona returns a prompt with a tone/style persona appended after the
+// self-model laws. The persona is encoded as a YAML key so the combined
+// prompt still parses as a self-model document. An empty persona returns s.
+func (s *SystemPrompt) WithPersona(persona string) (*SystemPrompt, error) {
+	persona = strings.TrimSpace(persona)
+	if persona == "" {
+		return s, nil
+	}
+
+	block, err := yaml.Marshal(map[string]string{"persona": persona})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode persona: %w", err)
+	}
+
+	return &SystemPrompt{
+		raw:     strings.TrimRight(s.raw, "\n") + "\n\n" + personaHeader + "\n" + string(block),
+		persona: persona,
+	}, nil
+}
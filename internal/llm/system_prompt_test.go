@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testSelfModel = "primary_laws:\n  - Restraint\n  - Safety\n  - Truth\n"
+
+func TestSystemPromptWithPersona(t *testing.T) {
+	base, err := NewSystemPrompt(testSelfModel)
+	if err != nil {
+		t.Fatalf("NewSystemPrompt failed: %v", err)
+	}
+
+	prompt, err := base.WithPersona("Warm, concise, and a little playful.")
+	if err != nil {
+		t.Fatalf("WithPersona failed: %v", err)
+	}
+
+	raw := prompt.Raw()
+	if !strings.HasPrefix(raw, testSelfModel) {
+		t.Error("expected the self-model laws to be unchanged at the start of the prompt")
+	}
+	if strings.Index(raw, "playful") < strings.Index(raw, "Truth") {
+		t.Error("expected the persona to follow the laws")
+	}
+	if prompt.Persona() != "Warm, concise, and a little playful." {
+		t.Errorf("unexpected persona: %q", prompt.Persona())
+	}
+
+	// The combined prompt still parses as a self-model document
+	var doc struct {
+		PrimaryLaws []string `yaml:"primary_laws"`
+		Persona     string   `yaml:"persona"`
+	}
+	if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("expected combined prompt to be valid YAML: %v", err)
+	}
+	if len(doc.PrimaryLaws) != 3 || doc.Persona == "" {
+		t.Errorf("expected laws and persona to survive, got %+v", doc)
+	}
+
+	if sha256.Sum256([]byte(raw)) == sha256.Sum256([]byte(base.Raw())) {
+		t.Error("expected the policy hash to reflect the persona")
+	}
+}
+
+func TestSystemPromptWithEmptyPersona(t *testing.T) {
+	base, _ := NewSystemPrompt(testSelfModel)
+
+	prompt, err := base.WithPersona("  ")
+	if err != nil || prompt != base {
+		t.Errorf("expected empty persona to leave the prompt unchanged, got %v", err)
+	}
+}
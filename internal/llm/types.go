@@ -1,9 +1,13 @@
 package llm
 
+import "time"
+
 // Message represents a single chat turn.
 type Message struct {
 	Role    string
 	Content string
+	Pinned  bool // Kept when the history is trimmed to fit the context budget
+	Paired  bool // Belongs with the message before it (a tool result after its call) and is trimmed with it
 }
 
 // Stream represents a streaming LLM response.
@@ -15,3 +19,92 @@ type Stream interface {
 type Chunk struct {
 	Content string
 }
+
+// ConfidenceReporter is implemented by streams that can report a confidence
+// score for the completed response (e.g. from token logprobs). The score is
+// in [0,1]; ok is false when the backend did not provide the data.
+type ConfidenceReporter interface {
+	Confidence() (score float64, ok bool)
+}
+
+// ReasoningReporter is implemented by streams that receive the model's
+// reasoning separately from its answer. Reasoning is never part of the
+// content returned by Recv.
+type ReasoningReporter interface {
+	Reasoning() string
+}
+
+// TokenUsage is the token count a backend reported for one response
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Total returns prompt plus completion tokens
+func (u TokenUsage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// UsageReporter is implemented by streams that report token usage once the
+// response is complete; ok is false when the backend sent no counts
+type UsageReporter interface {
+	Usage() (usage TokenUsage, ok bool)
+}
+
+// FinishReasonLength is the finish reason of a response cut off by the
+// max_tokens limit
+const FinishReasonLength = "length"
+
+// FinishReasonReporter is implemented by streams that report why the model
+// stopped generating (e.g. "stop" or FinishReasonLength). It is empty until
+// the response is complete or when the backend sent no reason.
+type FinishReasonReporter interface {
+	FinishReason() string
+}
+
+// RefusalError is returned by a stream when the model refuses the request
+// instead of answering it
+type RefusalError struct {
+	Refusal string
+}
+
+func (e *RefusalError) Error() string {
+	return "model refused the request: " + e.Refusal
+}
+
+// RateLimits is a snapshot of the provider's rate-limit budget, as reported
+// in response headers. Known is false until a response carried the headers.
+type RateLimits struct {
+	Known             bool
+	LimitRequests     int
+	RemainingRequests int
+	LimitTokens       int
+	RemainingTokens   int
+	ResetRequests     time.Duration // Time until the request budget refills
+	ResetTokens       time.Duration // Time until the token budget refills
+	UpdatedAt         time.Time
+}
+
+// RateLimitReporter is implemented by backends that track provider rate
+// limits
+type RateLimitReporter interface {
+	RateLimits() RateLimits
+}
+
+// CostReporter is implemented by backends that estimate the cost of their
+// requests
+type CostReporter interface {
+	// TotalCost returns the estimated spend so far, in dollars
+	TotalCost() float64
+}
+
+// CircuitStats is a snapshot of a backend's circuit breaker
+type CircuitStats struct {
+	State    string // "closed", "open" or "half-open"
+	Failures int
+}
+
+// CircuitReporter is implemented by backends guarded by a circuit breaker
+type CircuitReporter interface {
+	CircuitStats() CircuitStats
+}
@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// maxLatencySamples bounds how many recent request latencies are kept for
+// percentiles
+const maxLatencySamples = 1000
+
+// Collector accumulates request metrics from the backends it instruments.
+// It is safe for concurrent use.
+type Collector struct {
+	mu       sync.Mutex
+	requests int64
+	failures int64
+	latency  time.Duration // Sum over all requests
+	samples  []time.Duration
+	next     int // Where the next sample goes once samples is full
+	usage    llm.TokenUsage
+	backend  llm.Backend // Most recently instrumented, for cost and circuit state
+}
+
+// NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Snapshot is a point-in-time copy of the collected metrics
+type Snapshot struct {
+	Requests  int64
+	Failures  int64
+	Latency   time.Duration   // Sum over all requests
+	Latencies []time.Duration // Recent request latencies, sorted
+	Usage     llm.TokenUsage
+
+	Cost    float64 // Estimated spend of the current backend, in dollars
+	HasCost bool
+
+	Circuit    llm.CircuitStats
+	HasCircuit bool
+}
+
+// Percentile returns the latency at or below which p (0..1) of the recent
+// requests completed, or zero when none have
+func (s Snapshot) Percentile(p float64) time.Duration {
+	if len(s.Latencies) == 0 {
+		return 0
+	}
+	i := int(p*float64(len(s.Latencies))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(s.Latencies) {
+		i = len(s.Latencies) - 1
+	}
+	return s.Latencies[i]
+}
+
+// Instrument wraps a backend so that each of its requests is recorded in c.
+// Cost and circuit-breaker state are read from the most recently
+// instrumented backend, when it reports them.
+func (c *Collector) Instrument(backend llm.Backend) llm.Backend {
+	c.mu.Lock()
+	c.backend = backend
+	c.mu.Unlock()
+	return &instrumentedBackend{backend: backend, collector: c}
+}
+
+// record adds one finished request
+func (c *Collector) record(latency time.Duration, usage llm.TokenUsage, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests++
+	if failed {
+		c.failures++
+	}
+	c.latency += latency
+	if len(c.samples) < maxLatencySamples {
+		c.samples = append(c.samples, latency)
+	} else {
+		c.samples[c.next] = latency
+		c.next = (c.next + 1) % maxLatencySamples
+	}
+	c.usage.PromptTokens += usage.PromptTokens
+	c.usage.CompletionTokens += usage.CompletionTokens
+}
+
+// Snapshot returns the metrics collected so far
+func (c *Collector) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := Snapshot{
+		Requests:  c.requests,
+		Failures:  c.failures,
+		Latency:   c.latency,
+		Latencies: append([]time.Duration(nil), c.samples...),
+		Usage:     c.usage,
+	}
+	sort.Slice(s.Latencies, func(i, j int) bool { return s.Latencies[i] < s.Latencies[j] })
+
+	if reporter, ok := c.backend.(llm.CostReporter); ok {
+		s.Cost, s.HasCost = reporter.TotalCost(), true
+	}
+	if reporter, ok := c.backend.(llm.CircuitReporter); ok {
+		s.Circuit, s.HasCircuit = reporter.CircuitStats(), true
+	}
+	return s
+}
+
+type instrumentedBackend struct {
+	backend   llm.Backend
+	collector *Collector
+}
+
+func (b *instrumentedBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	start := time.Now()
+	stream, err := b.backend.Stream(ctx, system, messages)
+	if err != nil {
+		b.collector.record(time.Since(start), llm.TokenUsage{}, true)
+		return nil, err
+	}
+	return &instrumentedStream{Stream: stream, collector: b.collector, start: start}, nil
+}
+
+// RateLimits reports the wrapped backend's rate limits, if it tracks them
+func (b *instrumentedBackend) RateLimits() llm.RateLimits {
+	if reporter, ok := b.backend.(llm.RateLimitReporter); ok {
+		return reporter.RateLimits()
+	}
+	return llm.RateLimits{}
+}
+
+// instrumentedStream records its request once the response ends, fails, or
+// is closed early
+type instrumentedStream struct {
+	llm.Stream
+	collector *Collector
+	start     time.Time
+	done      bool
+}
+
+func (s *instrumentedStream) Recv() (string, error) {
+	chunk, err := s.Stream.Recv()
+	if err != nil {
+		s.finish(err != io.EOF)
+	}
+	return chunk, err
+}
+
+func (s *instrumentedStream) Close() error {
+	s.finish(false)
+	return s.Stream.Close()
+}
+
+func (s *instrumentedStream) finish(failed bool) {
+	if s.done {
+		return
+	}
+	s.done = true
+	usage, _ := s.Usage()
+	s.collector.record(time.Since(s.start), usage, failed)
+}
+
+// Confidence reports the wrapped stream's confidence
+func (s *instrumentedStream) Confidence() (float64, bool) {
+	if reporter, ok := s.Stream.(llm.ConfidenceReporter); ok {
+		return reporter.Confidence()
+	}
+	return 0, false
+}
+
+// Reasoning reports the wrapped stream's reasoning
+func (s *instrumentedStream) Reasoning() string {
+	if reporter, ok := s.Stream.(llm.ReasoningReporter); ok {
+		return reporter.Reasoning()
+	}
+	return ""
+}
+
+// Usage reports the wrapped stream's token usage
+func (s *instrumentedStream) Usage() (llm.TokenUsage, bool) {
+	if reporter, ok := s.Stream.(llm.UsageReporter); ok {
+		return reporter.Usage()
+	}
+	return llm.TokenUsage{}, false
+}
+
+// FinishReason reports why the wrapped stream stopped
+func (s *instrumentedStream) FinishReason() string {
+	if reporter, ok := s.Stream.(llm.FinishReasonReporter); ok {
+		return reporter.FinishReason()
+	}
+	return ""
+}
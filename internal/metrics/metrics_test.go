@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// fakeStream yields its chunks, then ends with err (io.EOF if nil)
+type fakeStream struct {
+	chunks []string
+	err    error
+	usage  llm.TokenUsage
+}
+
+func (s *fakeStream) Recv() (string, error) {
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return "", s.err
+		}
+		return "", io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *fakeStream) Close() error                  { return nil }
+func (s *fakeStream) Usage() (llm.TokenUsage, bool) { return s.usage, true }
+
+// fakeBackend serves its streams in order and reports fixed cost and
+// circuit-breaker state
+type fakeBackend struct {
+	streams []*fakeStream
+	err     error
+}
+
+func (b *fakeBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	stream := b.streams[0]
+	b.streams = b.streams[1:]
+	return stream, nil
+}
+
+func (b *fakeBackend) TotalCost() float64 { return 0.0125 }
+func (b *fakeBackend) CircuitStats() llm.CircuitStats {
+	return llm.CircuitStats{State: "half-open", Failures: 3}
+}
+
+// drain reads a stream to its end
+func drain(t *testing.T, backend llm.Backend) error {
+	t.Helper()
+	stream, err := backend.Stream(context.Background(), "system", nil)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func TestCollectorRecordsRequests(t *testing.T) {
+	c := NewCollector()
+	backend := c.Instrument(&fakeBackend{streams: []*fakeStream{
+		{chunks: []string{"hi"}, usage: llm.TokenUsage{PromptTokens: 10, CompletionTokens: 2}},
+		{chunks: []string{"partial"}, err: errors.New("connection reset"), usage: llm.TokenUsage{PromptTokens: 5}},
+	}})
+
+	if err := drain(t, backend); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := drain(t, backend); err == nil {
+		t.Fatal("expected the second stream to fail")
+	}
+
+	s := c.Snapshot()
+	if s.Requests != 2 || s.Failures != 1 {
+		t.Errorf("expected 2 requests with 1 failure, got %d and %d", s.Requests, s.Failures)
+	}
+	if s.Usage.PromptTokens != 15 || s.Usage.CompletionTokens != 2 {
+		t.Errorf("expected summed token usage, got %+v", s.Usage)
+	}
+	if len(s.Latencies) != 2 {
+		t.Errorf("expected a latency sample per request, got %d", len(s.Latencies))
+	}
+	if !s.HasCost || s.Cost != 0.0125 {
+		t.Errorf("expected the backend's cost, got %v (%v)", s.Cost, s.HasCost)
+	}
+	if !s.HasCircuit || s.Circuit.State != "half-open" {
+		t.Errorf("expected the backend's circuit state, got %+v", s.Circuit)
+	}
+}
+
+func TestCollectorRecordsFailedRequest(t *testing.T) {
+	c := NewCollector()
+	backend := c.Instrument(&fakeBackend{err: errors.New("circuit breaker is open")})
+
+	if err := drain(t, backend); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	if s := c.Snapshot(); s.Requests != 1 || s.Failures != 1 {
+		t.Errorf("expected 1 failed request, got %d requests and %d failures", s.Requests, s.Failures)
+	}
+}
+
+func TestSnapshotPercentile(t *testing.T) {
+	s := Snapshot{}
+	if got := s.Percentile(0.5); got != 0 {
+		t.Errorf("expected zero without samples, got %v", got)
+	}
+
+	for i := 1; i <= 10; i++ {
+		s.Latencies = append(s.Latencies, time.Duration(i)*time.Second)
+	}
+	cases := map[float64]time.Duration{0.5: 5 * time.Second, 0.9: 9 * time.Second, 0.99: 10 * time.Second}
+	for p, want := range cases {
+		if got := s.Percentile(p); got != want {
+			t.Errorf("Percentile(%v) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+// sampleLine matches a Prometheus sample: a name, optional labels and a value
+var sampleLine = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{[a-zA-Z_][a-zA-Z0-9_]*="[^"]*"(,[a-zA-Z_][a-zA-Z0-9_]*="[^"]*")*\})? [-+]?[0-9.eE+-]+$`)
+
+func TestWritePrometheus(t *testing.T) {
+	s := Snapshot{
+		Requests:   4,
+		Failures:   1,
+		Latency:    2 * time.Second,
+		Latencies:  []time.Duration{250 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond, 750 * time.Millisecond},
+		Usage:      llm.TokenUsage{PromptTokens: 120, CompletionTokens: 30},
+		Cost:       0.004,
+		HasCost:    true,
+		Circuit:    llm.CircuitStats{State: "closed"},
+		HasCircuit: true,
+	}
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, s); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE ") {
+			continue
+		}
+		if !sampleLine.MatchString(line) {
+			t.Errorf("malformed sample line: %q", line)
+		}
+	}
+
+	for _, want := range []string{
+		"# TYPE goshi_llm_requests_total counter",
+		"goshi_llm_requests_total 4",
+		"goshi_llm_request_failures_total 1",
+		"# TYPE goshi_llm_request_duration_seconds summary",
+		`goshi_llm_request_duration_seconds{quantile="0.5"} 0.5`,
+		`goshi_llm_request_duration_seconds{quantile="0.99"} 0.75`,
+		"goshi_llm_request_duration_seconds_sum 2",
+		"goshi_llm_request_duration_seconds_count 4",
+		`goshi_llm_tokens_total{type="prompt"} 120`,
+		`goshi_llm_tokens_total{type="completion"} 30`,
+		"goshi_llm_cost_dollars 0.004",
+		`goshi_llm_circuit_state{state="closed"} 1`,
+		`goshi_llm_circuit_state{state="open"} 0`,
+		"goshi_llm_circuit_failures 0",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestWritePrometheusOmitsUnreportedMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, Snapshot{}); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "goshi_llm_cost_dollars") || strings.Contains(out, "goshi_llm_circuit_state") {
+		t.Errorf("expected cost and circuit metrics omitted, got:\n%s", out)
+	}
+	if !strings.Contains(out, "goshi_llm_requests_total 0\n") {
+		t.Errorf("expected zero-valued request counter, got:\n%s", out)
+	}
+}
+
+func TestCollectorServeHTTP(t *testing.T) {
+	c := NewCollector()
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("expected Prometheus content type, got %q", got)
+	}
+	if !strings.Contains(rec.Body.String(), "goshi_llm_requests_total 0") {
+		t.Errorf("expected metrics in the body, got:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// ContentType is the media type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// latencyQuantiles are the latency percentiles exported as a summary
+var latencyQuantiles = []float64{0.5, 0.9, 0.99}
+
+// circuitStates are the circuit breaker states exported as a state set
+var circuitStates = []string{"closed", "open", "half-open"}
+
+// WritePrometheus writes a snapshot in the Prometheus text exposition
+// format. Cost and circuit-breaker metrics are omitted when the backend
+// does not report them.
+func WritePrometheus(w io.Writer, s Snapshot) error {
+	bw := bufio.NewWriter(w)
+
+	metric(bw, "goshi_llm_requests_total", "counter", "LLM requests sent.")
+	fmt.Fprintf(bw, "goshi_llm_requests_total %d\n", s.Requests)
+
+	metric(bw, "goshi_llm_request_failures_total", "counter", "LLM requests that failed.")
+	fmt.Fprintf(bw, "goshi_llm_request_failures_total %d\n", s.Failures)
+
+	metric(bw, "goshi_llm_request_duration_seconds", "summary", "LLM request latency, from request to the end of the response.")
+	for _, q := range latencyQuantiles {
+		fmt.Fprintf(bw, "goshi_llm_request_duration_seconds{quantile=%q} %s\n", formatFloat(q), formatFloat(s.Percentile(q).Seconds()))
+	}
+	fmt.Fprintf(bw, "goshi_llm_request_duration_seconds_sum %s\n", formatFloat(s.Latency.Seconds()))
+	fmt.Fprintf(bw, "goshi_llm_request_duration_seconds_count %d\n", s.Requests)
+
+	metric(bw, "goshi_llm_tokens_total", "counter", "Tokens used, by type.")
+	fmt.Fprintf(bw, "goshi_llm_tokens_total{type=\"prompt\"} %d\n", s.Usage.PromptTokens)
+	fmt.Fprintf(bw, "goshi_llm_tokens_total{type=\"completion\"} %d\n", s.Usage.CompletionTokens)
+
+	if s.HasCost {
+		metric(bw, "goshi_llm_cost_dollars", "gauge", "Estimated spend of the current session, in dollars.")
+		fmt.Fprintf(bw, "goshi_llm_cost_dollars %s\n", formatFloat(s.Cost))
+	}
+
+	if s.HasCircuit {
+		metric(bw, "goshi_llm_circuit_state", "gauge", "Circuit breaker state; 1 for the current state.")
+		for _, state := range circuitStates {
+			value := 0
+			if state == s.Circuit.State {
+				value = 1
+			}
+			fmt.Fprintf(bw, "goshi_llm_circuit_state{state=%q} %d\n", state, value)
+		}
+		metric(bw, "goshi_llm_circuit_failures", "gauge", "Failures counted by the circuit breaker.")
+		fmt.Fprintf(bw, "goshi_llm_circuit_failures %d\n", s.Circuit.Failures)
+	}
+
+	return bw.Flush()
+}
+
+// ServeHTTP serves the collected metrics in the Prometheus text format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_ = WritePrometheus(w, c.Snapshot())
+}
+
+// metric writes the HELP and TYPE lines of a metric family
+func metric(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// formatFloat formats a sample value the way Prometheus parses it
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -2,14 +2,22 @@ package repair
 
 import "github.com/cshaiku/goshi/internal/diagnose"
 
-type BasicRepairer struct{}
+type BasicRepairer struct {
+	// MinSeverity skips issues below this severity (empty = plan for all)
+	MinSeverity diagnose.Severity
+}
 
 func (r *BasicRepairer) Plan(diag diagnose.Result) (Plan, error) {
 	out := Plan{
 		Actions: []Action{},
 	}
 
-	for _, issue := range diag.Issues {
+	issues := diag.Issues
+	if r.MinSeverity != "" {
+		issues = diagnose.FilterBySeverity(issues, r.MinSeverity)
+	}
+
+	for _, issue := range issues {
 		switch issue.Code {
 		case "missing_binary":
 			out.Actions = append(out.Actions, Action{
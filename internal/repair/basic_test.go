@@ -0,0 +1,44 @@
+package repair
+
+import (
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/diagnose"
+)
+
+func healDiagnosis() diagnose.Result {
+	return diagnose.Result{Issues: []diagnose.Issue{
+		{Code: "missing_binary", Strategy: "install_git", Severity: diagnose.SeverityError},
+		{Code: "missing_binary", Strategy: "install_jq", Severity: diagnose.SeverityWarn},
+	}}
+}
+
+// TestBasicRepairerPlansAllIssuesByDefault tests that every issue is planned without a threshold
+func TestBasicRepairerPlansAllIssuesByDefault(t *testing.T) {
+	plan, err := (&BasicRepairer{}).Plan(healDiagnosis())
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(plan.Actions) != 2 {
+		t.Fatalf("expected 2 actions, got %d", len(plan.Actions))
+	}
+}
+
+// TestBasicRepairerSkipsIssuesBelowMinSeverity tests the min severity threshold
+func TestBasicRepairerSkipsIssuesBelowMinSeverity(t *testing.T) {
+	plan, err := (&BasicRepairer{MinSeverity: diagnose.SeverityError}).Plan(healDiagnosis())
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(plan.Actions) != 1 || plan.Actions[0].Code != "install_git" {
+		t.Fatalf("expected only the error-level repair, got %+v", plan.Actions)
+	}
+
+	plan, err = (&BasicRepairer{MinSeverity: diagnose.SeverityWarn}).Plan(healDiagnosis())
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(plan.Actions) != 2 {
+		t.Errorf("expected warn to include both repairs, got %d", len(plan.Actions))
+	}
+}
@@ -0,0 +1,229 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/llm"
+	"github.com/cshaiku/goshi/internal/session"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+
+	// CodeTurnLimit rejects chat.send once the session's turn limit is used up
+	CodeTurnLimit = -32001
+)
+
+// maxMessageBytes bounds a single JSON-RPC message read from the input
+const maxMessageBytes = 10 * 1024 * 1024
+
+// Request is a JSON-RPC 2.0 request. Requests without an id are
+// notifications and get no response.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC 2.0 response carrying either a result or an error
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Notification is a server-to-client message with no id, used to stream
+// chat progress while a request is in flight
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Error is a JSON-RPC 2.0 error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ChatSendParams are the parameters of chat.send
+type ChatSendParams struct {
+	Message string `json:"message"`
+}
+
+// ChatSendResult is the result of chat.send
+type ChatSendResult struct {
+	Text         string `json:"text"`
+	Steps        int    `json:"steps"`
+	LimitReached bool   `json:"limit_reached"`
+	Refusal      string `json:"refusal,omitempty"` // The model's refusal, when it refused to answer
+	Stopped      bool   `json:"stopped,omitempty"` // A permission denial ended the turn
+}
+
+// ChunkParams are the parameters of a chat.chunk notification
+type ChunkParams struct {
+	Text string `json:"text"`
+}
+
+// StepParams are the parameters of a chat.step notification, sent after
+// each tool the model runs
+type StepParams struct {
+	Step    int    `json:"step"`
+	Tool    string `json:"tool"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Server exposes a chat session over newline-delimited JSON-RPC 2.0.
+// Methods: chat.send, tools.list and session.reset. While chat.send runs,
+// response chunks stream as chat.chunk notifications and tool steps as
+// chat.step notifications.
+type Server struct {
+	newSession func() (*session.ChatSession, error)
+	sess       *session.ChatSession
+
+	mu  sync.Mutex // serializes writes to the output
+	enc *json.Encoder
+}
+
+// NewServer creates a server whose session (and any reset session) comes
+// from newSession
+func NewServer(newSession func() (*session.ChatSession, error)) (*Server, error) {
+	sess, err := newSession()
+	if err != nil {
+		return nil, err
+	}
+	return &Server{newSession: newSession, sess: sess}, nil
+}
+
+// Serve handles requests read from r, one JSON message per line, writing
+// responses and notifications to w until r is exhausted
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.enc = json.NewEncoder(w)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			s.write(Response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			continue
+		}
+
+		result, rpcErr := s.handle(req)
+		if len(req.ID) == 0 {
+			continue
+		}
+		resp := Response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
+		if rpcErr == nil && result == nil {
+			resp.Result = struct{}{}
+		}
+		s.write(resp)
+	}
+	return scanner.Err()
+}
+
+// handle dispatches one request to its method
+func (s *Server) handle(req Request) (any, *Error) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, &Error{Code: CodeInvalidRequest, Message: "expected a JSON-RPC 2.0 request with a method"}
+	}
+
+	switch req.Method {
+	case "chat.send":
+		var params ChatSendParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || strings.TrimSpace(params.Message) == "" {
+			return nil, &Error{Code: CodeInvalidParams, Message: "chat.send requires a non-empty \"message\""}
+		}
+		return s.chatSend(params.Message)
+
+	case "tools.list":
+		return s.sess.ToolRouter.GetToolDefinitions(), nil
+
+	case "session.reset":
+		sess, err := s.newSession()
+		if err != nil {
+			return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("failed to reset session: %v", err)}
+		}
+		s.sess = sess
+		return map[string]bool{"reset": true}, nil
+
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+	}
+}
+
+// chatSend runs one user turn, streaming chunks and tool steps as
+// notifications
+func (s *Server) chatSend(message string) (any, *Error) {
+	sess := s.sess
+	if sess.TurnLimitReached() {
+		return nil, &Error{Code: CodeTurnLimit, Message: sess.WrapUpMessage()}
+	}
+
+	step := 0
+	turn := sess.NewTurn(message, session.TurnHooks{
+		OnChunk: func(chunk string) {
+			s.notify("chat.chunk", ChunkParams{Text: chunk})
+		},
+		Act: func(action *llm.ActionCall) any {
+			step++
+			// A denial is recorded and the router then refuses the call
+			sess.RequestToolPermission(action.Tool)
+			result := sess.ToolRouter.Execute(app.ToolCall{Name: action.Tool, Args: action.Args})
+			s.notify("chat.step", StepParams{
+				Step:    step,
+				Tool:    action.Tool,
+				Success: result.Success,
+				Error:   result.Error,
+			})
+			return result
+		},
+	})
+
+	if err := turn.Run(); err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	result := ChatSendResult{
+		Text:         turn.Text(),
+		Steps:        len(turn.Tools),
+		LimitReached: turn.LimitReached,
+		Stopped:      turn.Stopped,
+	}
+	var refusal *llm.RefusalError
+	if errors.As(turn.StreamErr(), &refusal) {
+		result.Refusal = refusal.Refusal
+	}
+	return result, nil
+}
+
+// notify writes a notification to the output
+func (s *Server) notify(method string, params any) {
+	s.write(Notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write encodes one message as a line of output
+func (s *Server) write(msg any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.enc.Encode(msg)
+}
@@ -0,0 +1,301 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/llm"
+	"github.com/cshaiku/goshi/internal/session"
+)
+
+// scriptedBackend answers each Stream call with the next scripted response,
+// split into chunks
+type scriptedBackend struct {
+	responses [][]string
+	err       error // Ends every stream instead of io.EOF, if set
+	calls     int
+}
+
+func (b *scriptedBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	chunks := b.responses[len(b.responses)-1]
+	if b.calls < len(b.responses) {
+		chunks = b.responses[b.calls]
+	}
+	b.calls++
+	return &scriptedStream{chunks: chunks, err: b.err}, nil
+}
+
+type scriptedStream struct {
+	chunks []string
+	err    error
+}
+
+func (s *scriptedStream) Recv() (string, error) {
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return "", s.err
+		}
+		return "", io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *scriptedStream) Close() error { return nil }
+
+// rpcClient drives a server over pipes, like an editor plugin would
+type rpcClient struct {
+	t   *testing.T
+	in  *io.PipeWriter
+	out *bufio.Scanner
+}
+
+// startServer runs a server over pipes with sessions backed by backend
+func startServer(t *testing.T, backend llm.Backend) (*rpcClient, *int) {
+	t.Helper()
+	return startServerWith(t, backend, nil)
+}
+
+// startServerWith runs a server whose sessions are adjusted by configure,
+// if set, after being created like goshi serve creates them
+func startServerWith(t *testing.T, backend llm.Backend, configure func(*session.ChatSession)) (*rpcClient, *int) {
+	t.Helper()
+	t.Setenv("GOSHI_AUDIT_ENABLED", "false")
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	sessions := 0
+	srv, err := NewServer(func() (*session.ChatSession, error) {
+		sessions++
+		sess, err := session.NewChatSession(context.Background(), "test", backend)
+		if err != nil {
+			return nil, err
+		}
+		sess.Prompter = nil
+		sess.GrantPermission("FS_READ")
+		if configure != nil {
+			configure(sess)
+		}
+		return sess, nil
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() {
+		inW.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve returned error: %v", err)
+		}
+	})
+
+	return &rpcClient{t: t, in: inW, out: bufio.NewScanner(outR)}, &sessions
+}
+
+// call sends a request and returns the notifications received before its
+// response, and the response itself
+func (c *rpcClient) call(id int, method string, params any) ([]map[string]any, map[string]any) {
+	c.t.Helper()
+	req := map[string]any{"jsonrpc": "2.0", "id": id, "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	line, _ := json.Marshal(req)
+	if _, err := c.in.Write(append(line, '\n')); err != nil {
+		c.t.Fatalf("write request: %v", err)
+	}
+
+	var notifications []map[string]any
+	for c.out.Scan() {
+		var msg map[string]any
+		if err := json.Unmarshal(c.out.Bytes(), &msg); err != nil {
+			c.t.Fatalf("invalid JSON from server: %q", c.out.Text())
+		}
+		if _, ok := msg["id"]; !ok {
+			notifications = append(notifications, msg)
+			continue
+		}
+		return notifications, msg
+	}
+	c.t.Fatal("server closed output before responding")
+	return nil, nil
+}
+
+// TestServerChatSendStreamsNotifications tests that chat.send streams chunks
+// and tool steps before returning the final answer
+func TestServerChatSendStreamsNotifications(t *testing.T) {
+	backend := &scriptedBackend{responses: [][]string{
+		{`{"type": "action", `, `"action": {"tool": "fs.list", "args": {"path": "."}}}`},
+		{"The directory ", "has files."},
+	}}
+	client, _ := startServer(t, backend)
+
+	notifications, resp := client.call(1, "chat.send", map[string]string{"message": "what is here?"})
+
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if resp["id"] != float64(1) || resp["jsonrpc"] != "2.0" {
+		t.Errorf("unexpected envelope: %v", resp)
+	}
+	result, _ := resp["result"].(map[string]any)
+	if result["text"] != "The directory has files." || result["steps"] != float64(1) || result["limit_reached"] != false {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	var chunks []string
+	var steps []map[string]any
+	for _, n := range notifications {
+		params, _ := n["params"].(map[string]any)
+		switch n["method"] {
+		case "chat.chunk":
+			chunks = append(chunks, params["text"].(string))
+		case "chat.step":
+			steps = append(steps, params)
+		default:
+			t.Errorf("unexpected notification: %v", n)
+		}
+	}
+	if len(chunks) != 4 || strings.Join(chunks[2:], "") != "The directory has files." {
+		t.Errorf("expected every chunk streamed, got %q", chunks)
+	}
+	if len(steps) != 1 || steps[0]["tool"] != "fs.list" || steps[0]["success"] != true {
+		t.Errorf("expected one successful fs.list step, got %v", steps)
+	}
+}
+
+// TestServerChatSendReportsRefusal tests that a model refusal reaches the
+// client as the result rather than an internal error
+func TestServerChatSendReportsRefusal(t *testing.T) {
+	backend := &scriptedBackend{
+		responses: [][]string{{}},
+		err:       &llm.RefusalError{Refusal: "I can't help with that."},
+	}
+	client, _ := startServer(t, backend)
+
+	_, resp := client.call(1, "chat.send", map[string]string{"message": "do something bad"})
+
+	if resp["error"] != nil {
+		t.Fatalf("expected the refusal as a result, got error: %v", resp["error"])
+	}
+	result, _ := resp["result"].(map[string]any)
+	if result["refusal"] != "I can't help with that." {
+		t.Errorf("expected the refusal reported, got %v", result)
+	}
+	if text, _ := result["text"].(string); !strings.Contains(text, "I can't help with that.") {
+		t.Errorf("expected the refusal in the text, got %q", text)
+	}
+}
+
+// TestServerChatSendRequestsToolPermission tests that a tool needing a
+// capability that was not granted is refused instead of run
+func TestServerChatSendRequestsToolPermission(t *testing.T) {
+	backend := &scriptedBackend{responses: [][]string{
+		{`{"type": "action", "action": {"tool": "fs.write", "args": {"path": "notes.txt", "content": "hi"}}}`},
+		{"Done."},
+	}}
+	client, _ := startServer(t, backend)
+
+	notifications, resp := client.call(1, "chat.send", map[string]string{"message": "write notes.txt"})
+
+	result, _ := resp["result"].(map[string]any)
+	if result["stopped"] != true {
+		t.Errorf("expected the denial to stop the turn, got %v", resp)
+	}
+	var steps []map[string]any
+	for _, n := range notifications {
+		if n["method"] == "chat.step" {
+			steps = append(steps, n["params"].(map[string]any))
+		}
+	}
+	if len(steps) != 1 || steps[0]["success"] != false || !strings.Contains(steps[0]["error"].(string), "permission denied") {
+		t.Errorf("expected a refused fs.write step, got %v", steps)
+	}
+}
+
+// TestServerChatSendEnforcesTurnLimit tests that chat.send is refused once
+// behavior.max_turns is used up
+func TestServerChatSendEnforcesTurnLimit(t *testing.T) {
+	client, _ := startServerWith(t, &scriptedBackend{responses: [][]string{{"hi"}}}, func(sess *session.ChatSession) {
+		sess.MaxTurns = 1
+	})
+
+	if _, resp := client.call(1, "chat.send", map[string]string{"message": "hello"}); resp["error"] != nil {
+		t.Fatalf("expected the first turn to run, got %v", resp["error"])
+	}
+	_, resp := client.call(2, "chat.send", map[string]string{"message": "hello again"})
+	rpcErr, _ := resp["error"].(map[string]any)
+	if rpcErr["code"] != float64(CodeTurnLimit) || !strings.Contains(rpcErr["message"].(string), "limit of 1 turns") {
+		t.Errorf("expected the turn limit error, got %v", resp)
+	}
+}
+
+// TestServerToolsListAndReset tests tools.list and session.reset
+func TestServerToolsListAndReset(t *testing.T) {
+	client, sessions := startServer(t, &scriptedBackend{responses: [][]string{{"hi"}}})
+
+	_, resp := client.call(1, "tools.list", nil)
+	tools, ok := resp["result"].([]any)
+	if !ok || len(tools) == 0 {
+		t.Fatalf("expected a tool list, got %v", resp)
+	}
+	ids := map[string]bool{}
+	for _, tool := range tools {
+		ids[tool.(map[string]any)["id"].(string)] = true
+	}
+	for _, id := range []string{"fs.read", "fs.write", "fs.list"} {
+		if !ids[id] {
+			t.Errorf("expected %s in tools.list", id)
+		}
+	}
+
+	client.call(2, "chat.send", map[string]string{"message": "hello"})
+	_, resp = client.call(3, "session.reset", nil)
+	if result, _ := resp["result"].(map[string]any); result["reset"] != true {
+		t.Errorf("unexpected reset response: %v", resp)
+	}
+	if *sessions != 2 {
+		t.Errorf("expected reset to start a new session, got %d sessions", *sessions)
+	}
+}
+
+// TestServerErrors tests JSON-RPC error responses
+func TestServerErrors(t *testing.T) {
+	client, _ := startServer(t, &scriptedBackend{responses: [][]string{{"hi"}}})
+
+	cases := []struct {
+		method string
+		params any
+		code   float64
+	}{
+		{"nope", nil, CodeMethodNotFound},
+		{"chat.send", map[string]string{}, CodeInvalidParams},
+	}
+	for i, tc := range cases {
+		_, resp := client.call(i+1, tc.method, tc.params)
+		rpcErr, _ := resp["error"].(map[string]any)
+		if rpcErr["code"] != tc.code {
+			t.Errorf("%s: expected code %v, got %v", tc.method, tc.code, resp)
+		}
+	}
+
+	if _, err := client.in.Write([]byte("{not json\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !client.out.Scan() || !strings.Contains(client.out.Text(), `"code":-32700`) {
+		t.Errorf("expected a parse error response, got %q", client.out.Text())
+	}
+}
@@ -25,6 +25,20 @@ func ExtractHumanGreeting(raw string) string {
 	return doc.HumanGreeting
 }
 
+type personaDoc struct {
+	Persona string `yaml:"persona"`
+}
+
+// ExtractPersona returns the persona layered over the self-model, if any.
+// Failure is non-fatal: returns empty string on any error.
+func ExtractPersona(raw string) string {
+	var doc personaDoc
+	if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+		return ""
+	}
+	return doc.Persona
+}
+
 // SELF MODEL LAW INDEX
 type lawsDoc struct {
 	PrimaryLaws []string `yaml:"primary_laws"`
@@ -37,3 +51,24 @@ func ExtractPrimaryLaws(raw string) []string {
 	}
 	return doc.PrimaryLaws
 }
+
+// ExpectedVersion is the self-model version this build of goshi is written
+// against. Hotfix releases of the self-model do not change intent, so only
+// the major and minor parts must match.
+const ExpectedVersion = "1.0.1"
+
+type versionDoc struct {
+	Model struct {
+		ModelVersion string `yaml:"model_version"`
+	} `yaml:"model"`
+}
+
+// Version returns the self-model's model.model_version.
+// Failure is non-fatal: returns empty string on any error.
+func Version(raw string) string {
+	var doc versionDoc
+	if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+		return ""
+	}
+	return doc.Model.ModelVersion
+}
@@ -1,33 +1,196 @@
 package selfmodel
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 const DefaultPath = "goshi.self.model.yaml"
 
+// FragmentsEnv lists extra self-model fragments (path-list separated)
+// layered after the base self-model, e.g. project-specific policy
+const FragmentsEnv = "GOSHI_SELF_MODEL_FRAGMENTS"
+
 type SelfModel struct {
-	Path string
-	Raw  string
+	Path  string
+	Paths []string
+	Raw   string
 }
 
-func Load(path string) (*SelfModel, error) {
-	if path == "" {
-		path = DefaultPath
+// Load reads the self-model from path, or composes it from several fragments
+// merged in order (e.g. base laws followed by project overrides). A single
+// file is kept verbatim. Fragments are merged as YAML: mapping keys from a
+// later fragment override earlier ones, sequences are appended, and nested
+// mappings merge key by key. A composed self-model must still declare
+// primary laws.
+func Load(paths ...string) (*SelfModel, error) {
+	if len(paths) == 0 || paths[0] == "" {
+		paths = append([]string{DefaultPath}, trimPaths(paths)...)
+	}
+
+	texts := make([][]byte, len(paths))
+	for i, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load self-model file %q: %w", path, err)
+		}
+
+		if len(b) == 0 {
+			return nil, fmt.Errorf("self-model file %q is empty", path)
+		}
+		texts[i] = normalizeText(b)
 	}
 
-	b, err := os.ReadFile(path)
+	sm := &SelfModel{
+		Path:  paths[0],
+		Paths: paths,
+		Raw:   string(texts[0]),
+	}
+	if len(paths) == 1 {
+		return sm, nil
+	}
+
+	raw, err := composeFragments(paths, texts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load self-model file %q: %w", path, err)
+		return nil, err
+	}
+	sm.Raw = raw
+
+	if len(ExtractPrimaryLaws(sm.Raw)) == 0 {
+		return nil, fmt.Errorf("composed self-model from %s declares no primary_laws", strings.Join(paths, ", "))
+	}
+
+	return sm, nil
+}
+
+// composeFragments merges the fragment documents in order and renders the
+// result back to YAML, keeping the fragments' comments
+func composeFragments(paths []string, texts [][]byte) (string, error) {
+	var merged *yaml.Node
+	for i, text := range texts {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(text, &doc); err != nil {
+			return "", fmt.Errorf("failed to parse self-model file %q: %w", paths[i], err)
+		}
+		if len(doc.Content) == 0 {
+			continue // Only comments
+		}
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return "", fmt.Errorf("self-model file %q must be a YAML mapping", paths[i])
+		}
+		if merged == nil {
+			merged = root
+			continue
+		}
+		if err := mergeNode(merged, root, ""); err != nil {
+			return "", fmt.Errorf("failed to merge self-model file %q: %w", paths[i], err)
+		}
+	}
+	if merged == nil {
+		return "", fmt.Errorf("composed self-model from %s is empty", strings.Join(paths, ", "))
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(merged); err != nil {
+		return "", fmt.Errorf("failed to render composed self-model: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to render composed self-model: %w", err)
 	}
+	return out.String(), nil
+}
+
+// mergeNode merges src into dst. Mappings merge key by key, sequences are
+// appended and scalars are replaced. A key may not change kind, so a
+// fragment cannot, say, replace the primary_laws list with a scalar.
+func mergeNode(dst, src *yaml.Node, key string) error {
+	if dst.Kind != src.Kind {
+		return fmt.Errorf("%s cannot change from %s to %s", keyName(key), kindName(dst.Kind), kindName(src.Kind))
+	}
+
+	switch src.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			srcKey, srcValue := src.Content[i], src.Content[i+1]
+			path := srcKey.Value
+			if key != "" {
+				path = key + "." + srcKey.Value
+			}
+			if dstValue := mappingValue(dst, srcKey.Value); dstValue != nil {
+				if err := mergeNode(dstValue, srcValue, path); err != nil {
+					return err
+				}
+				continue
+			}
+			dst.Content = append(dst.Content, srcKey, srcValue)
+		}
+	case yaml.SequenceNode:
+		dst.Content = append(dst.Content, src.Content...)
+	default:
+		*dst = *src
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
 
-	if len(b) == 0 {
-		return nil, fmt.Errorf("self-model file %q is empty", path)
+// keyName describes a merged key for error messages
+func keyName(key string) string {
+	if key == "" {
+		return "the document"
 	}
+	return key
+}
+
+// kindName names a YAML node kind for error messages
+func kindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.AliasNode:
+		return "an alias"
+	default:
+		return "a value"
+	}
+}
+
+// FragmentPaths returns the self-model fragments listed in FragmentsEnv
+func FragmentPaths() []string {
+	return trimPaths(filepath.SplitList(os.Getenv(FragmentsEnv)))
+}
+
+// trimPaths drops blank entries from a fragment list
+func trimPaths(paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
 
-	return &SelfModel{
-		Path: path,
-		Raw:  string(b),
-	}, nil
+// normalizeText strips a UTF-8 byte order mark and converts CRLF line
+// endings to LF, so files authored on Windows parse like any other
+func normalizeText(b []byte) []byte {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	return bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
 }
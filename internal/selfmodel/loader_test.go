@@ -0,0 +1,195 @@
+package selfmodel
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const cleanSelfModel = `human_greeting: "Hello there"
+primary_laws:
+  - "Never fabricate file contents"
+  - "Ask before writing"
+persona: |
+  Calm and precise.
+  Short answers.
+`
+
+// writeSelfModel writes content to a temporary self-model file
+func writeSelfModel(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "goshi.self.model.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write self-model: %v", err)
+	}
+	return path
+}
+
+// TestLoadNormalizesBOMAndCRLF tests that Windows-authored self-model files
+// load identically to clean ones
+func TestLoadNormalizesBOMAndCRLF(t *testing.T) {
+	clean, err := Load(writeSelfModel(t, cleanSelfModel))
+	if err != nil {
+		t.Fatalf("load clean: %v", err)
+	}
+
+	windows := "\xef\xbb\xbf" + strings.ReplaceAll(cleanSelfModel, "\n", "\r\n")
+	sm, err := Load(writeSelfModel(t, windows))
+	if err != nil {
+		t.Fatalf("load BOM/CRLF: %v", err)
+	}
+
+	if sm.Raw != clean.Raw {
+		t.Errorf("expected normalized raw text to match clean file, got %q", sm.Raw)
+	}
+	if got, want := ExtractPrimaryLaws(sm.Raw), ExtractPrimaryLaws(clean.Raw); !reflect.DeepEqual(got, want) || len(got) != 2 {
+		t.Errorf("primary laws = %q, want %q", got, want)
+	}
+	if got := ExtractHumanGreeting(sm.Raw); got != "Hello there" {
+		t.Errorf("greeting = %q, want %q", got, "Hello there")
+	}
+	if got := ExtractPersona(sm.Raw); got != "Calm and precise.\nShort answers.\n" {
+		t.Errorf("persona = %q, want CRLF-free text", got)
+	}
+}
+
+// TestLoadComposesFragments tests that fragments merge in order and that
+// law metrics cover every fragment
+func TestLoadComposesFragments(t *testing.T) {
+	base := writeSelfModel(t, cleanSelfModel)
+	override := filepath.Join(t.TempDir(), "project.self.model.yaml")
+	overrideText := "project_rules:\n  - \"You must never push to main\"\n"
+	if err := os.WriteFile(override, []byte(overrideText), 0644); err != nil {
+		t.Fatalf("write override: %v", err)
+	}
+
+	baseOnly, err := Load(base)
+	if err != nil {
+		t.Fatalf("load base: %v", err)
+	}
+	if baseOnly.Raw != cleanSelfModel {
+		t.Errorf("expected a single file kept verbatim, got %q", baseOnly.Raw)
+	}
+	sm, err := Load(base, override)
+	if err != nil {
+		t.Fatalf("load composed: %v", err)
+	}
+
+	if !strings.HasPrefix(sm.Raw, cleanSelfModel) {
+		t.Errorf("expected the base fragment first and unchanged, got %q", sm.Raw)
+	}
+	if !strings.HasSuffix(sm.Raw, overrideText) {
+		t.Errorf("expected the new key appended, got %q", sm.Raw)
+	}
+	if !reflect.DeepEqual(sm.Paths, []string{base, override}) || sm.Path != base {
+		t.Errorf("paths = %q (path %q), want both fragments in order", sm.Paths, sm.Path)
+	}
+	if got := ExtractPrimaryLaws(sm.Raw); len(got) != 2 {
+		t.Errorf("expected composed prompt to keep primary laws, got %q", got)
+	}
+
+	baseMetrics := ComputeLawMetrics(baseOnly.Raw)
+	overrideMetrics := ComputeLawMetrics(overrideText)
+	got := ComputeLawMetrics(sm.Raw)
+	if got.ConstraintCount != baseMetrics.ConstraintCount+overrideMetrics.ConstraintCount {
+		t.Errorf("constraints = %d, want %d + %d", got.ConstraintCount, baseMetrics.ConstraintCount, overrideMetrics.ConstraintCount)
+	}
+	if got.RuleLines < baseMetrics.RuleLines+overrideMetrics.RuleLines {
+		t.Errorf("rule lines = %d, want at least the union of %d and %d", got.RuleLines, baseMetrics.RuleLines, overrideMetrics.RuleLines)
+	}
+}
+
+// TestLoadMergesRepeatedKeys tests that a fragment repeating a key appends
+// to lists, overrides values and merges nested mappings
+func TestLoadMergesRepeatedKeys(t *testing.T) {
+	base := writeSelfModel(t, cleanSelfModel+"model:\n  model_version: \"1.0.1\"\n  enforcement: \"enforced\"\n")
+	project := filepath.Join(t.TempDir(), "project.self.model.yaml")
+	projectText := `primary_laws:
+  - "Never push to main"
+human_greeting: "Hello, project"
+model:
+  last_reviewed: "2026-10-01"
+`
+	if err := os.WriteFile(project, []byte(projectText), 0644); err != nil {
+		t.Fatalf("write fragment: %v", err)
+	}
+
+	sm, err := Load(base, project)
+	if err != nil {
+		t.Fatalf("load composed: %v", err)
+	}
+
+	laws := ExtractPrimaryLaws(sm.Raw)
+	want := []string{"Never fabricate file contents", "Ask before writing", "Never push to main"}
+	if !reflect.DeepEqual(laws, want) {
+		t.Errorf("primary laws = %q, want %q", laws, want)
+	}
+	var doc struct {
+		Greeting string            `yaml:"human_greeting"`
+		Model    map[string]string `yaml:"model"`
+	}
+	if err := yaml.Unmarshal([]byte(sm.Raw), &doc); err != nil {
+		t.Fatalf("composed self-model is not valid YAML: %v\n%s", err, sm.Raw)
+	}
+	if doc.Greeting != "Hello, project" {
+		t.Errorf("expected the later greeting to win, got %q", doc.Greeting)
+	}
+	if doc.Model["model_version"] != "1.0.1" || doc.Model["enforcement"] != "enforced" || doc.Model["last_reviewed"] != "2026-10-01" {
+		t.Errorf("expected nested mappings merged, got %v", doc.Model)
+	}
+	if strings.Count(sm.Raw, "primary_laws:") != 1 {
+		t.Errorf("expected primary_laws declared once, got:\n%s", sm.Raw)
+	}
+}
+
+// TestLoadRejectsCompositionWithoutLaws tests that a composition that no
+// longer yields primary laws is refused
+func TestLoadRejectsCompositionWithoutLaws(t *testing.T) {
+	base := writeSelfModel(t, cleanSelfModel)
+	scalar := filepath.Join(t.TempDir(), "scalar.self.model.yaml")
+	if err := os.WriteFile(scalar, []byte("primary_laws: \"none\"\n"), 0644); err != nil {
+		t.Fatalf("write fragment: %v", err)
+	}
+	if _, err := Load(base, scalar); err == nil || !strings.Contains(err.Error(), "primary_laws") {
+		t.Errorf("expected replacing the laws with a scalar to fail, got %v", err)
+	}
+
+	noLaws := writeSelfModel(t, "human_greeting: \"Hi\"\n")
+	extra := filepath.Join(t.TempDir(), "extra.self.model.yaml")
+	if err := os.WriteFile(extra, []byte("project_rules:\n  - \"Be brief\"\n"), 0644); err != nil {
+		t.Fatalf("write fragment: %v", err)
+	}
+	if _, err := Load(noLaws, extra); err == nil || !strings.Contains(err.Error(), "primary_laws") {
+		t.Errorf("expected primary_laws validation error, got %v", err)
+	}
+}
+
+// TestVersion tests model_version extraction from the raw self-model
+func TestVersion(t *testing.T) {
+	raw := "model:\n  model_version: \"1.0.1\"\n" + cleanSelfModel
+	if got := Version(raw); got != "1.0.1" {
+		t.Errorf("expected version 1.0.1, got %q", got)
+	}
+	if got := Version(cleanSelfModel); got != "" {
+		t.Errorf("expected no version, got %q", got)
+	}
+	if got := Version("model: ["); got != "" {
+		t.Errorf("expected malformed YAML to yield no version, got %q", got)
+	}
+}
+
+// TestRepoSelfModelVersion tests that the shipped self-model matches the
+// version goshi expects
+func TestRepoSelfModelVersion(t *testing.T) {
+	sm, err := Load(filepath.Join("..", "..", DefaultPath))
+	if err != nil {
+		t.Skipf("self-model not available: %v", err)
+	}
+	if got := Version(sm.Raw); got != ExpectedVersion {
+		t.Errorf("goshi.self.model.yaml is version %q, ExpectedVersion is %q", got, ExpectedVersion)
+	}
+}
@@ -0,0 +1,30 @@
+package session
+
+import "fmt"
+
+// ExplainPrompt re-prompts the model when it requests a tool without first
+// explaining it in explain-before-act mode
+const ExplainPrompt = "[Your tool call was not run. Before using any tool, explain in plain text what you are going to do and why, without calling a tool. You may call it once the user has seen your explanation.]"
+
+// MaxExplainPrompts bounds how often a turn re-prompts for an explanation
+// before giving up
+const MaxExplainPrompts = 2
+
+// ExplainedPrompt asks the model to go ahead with its tool call once its
+// explanation has been shown to the user
+const ExplainedPrompt = "[Your explanation has been shown to the user. Go ahead with the tool call now.]"
+
+// NeedsExplanation reports whether explain-before-act mode refuses the
+// model's next tool call because it has not been explained yet
+func (s *ChatSession) NeedsExplanation() bool {
+	return s.ExplainBeforeAct && !s.explained
+}
+
+// ExplainRefusal builds the error reported when the model keeps requesting
+// a tool without explaining it and records the refusal in the audit log
+func (s *ChatSession) ExplainRefusal(tool string) string {
+	if s.AuditLogger != nil {
+		s.AuditLogger.LogSession("EXPLAIN_REQUIRED", fmt.Sprintf("refused unexplained tool call: %s", tool), s.WorkingDir)
+	}
+	return fmt.Sprintf("refused to run %s: explain-before-act mode requires the model to explain an action before taking it", tool)
+}
@@ -0,0 +1,22 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MOTDFile is the repository-relative path of the optional project banner
+// shown at session start. It is separate from the self-model and carries no
+// authority over the agent's behavior.
+const MOTDFile = ".goshi/motd.txt"
+
+// LoadMOTD returns the trimmed contents of the project MOTD under root,
+// or "" when the file is absent or unreadable
+func LoadMOTD(root string) string {
+	data, err := os.ReadFile(filepath.Join(root, MOTDFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
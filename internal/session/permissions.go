@@ -1,10 +1,13 @@
 package session
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/cshaiku/goshi/internal/app"
 	"github.com/cshaiku/goshi/internal/audit"
 	"github.com/cshaiku/goshi/internal/config"
 	"github.com/manifoldco/promptui"
@@ -88,6 +91,30 @@ func (p *Permissions) AutoConfirm(capability string, cwd string) {
 	}
 }
 
+// StartupGrant grants a permission listed in safety.default_grants when the
+// session starts, recorded separately from interactive grants
+func (p *Permissions) StartupGrant(capability string, cwd string) {
+	entry := PermissionEntry{
+		Capability: capability,
+		Action:     "GRANT",
+		Timestamp:  time.Now(),
+		Reason:     "config-default-grant",
+		RequestCwd: cwd,
+	}
+
+	switch capability {
+	case "FS_READ":
+		p.FSRead = true
+	case "FS_WRITE":
+		p.FSWrite = true
+	}
+
+	p.AuditLog = append(p.AuditLog, entry)
+	if p.Logger != nil {
+		p.Logger.LogPermission("STARTUP_GRANT", capability, entry.Reason, cwd)
+	}
+}
+
 // HasPermission checks if a capability is currently granted
 func (p *Permissions) HasPermission(capability string) bool {
 	switch capability {
@@ -100,6 +127,79 @@ func (p *Permissions) HasPermission(capability string) bool {
 	}
 }
 
+// EnforcementStatus summarizes what the session may do: "STAGED" until a
+// capability is granted, then "ACTIVE" with the granted capabilities
+func (p *Permissions) EnforcementStatus() string {
+	switch {
+	case p.FSRead && p.FSWrite:
+		return "ACTIVE (FS_READ + FS_WRITE)"
+	case p.FSRead:
+		return "ACTIVE (FS_READ)"
+	case p.FSWrite:
+		return "ACTIVE (FS_WRITE)"
+	default:
+		return "STAGED"
+	}
+}
+
+// CapabilityStatus is the current state of one capability in a session
+type CapabilityStatus struct {
+	Capability string     `json:"capability"`
+	State      string     `json:"state"`                // "granted", "denied" or "not requested"
+	Scope      string     `json:"scope,omitempty"`      // Directory the decision applies to
+	Reason     string     `json:"reason,omitempty"`     // Why it was granted or denied
+	DecidedAt  *time.Time `json:"decided_at,omitempty"` // When the deciding entry was recorded
+}
+
+// Status reports each known capability's current state, taken from the
+// latest decision that explains it. Decisions never expire; they last
+// until the session ends.
+func (p *Permissions) Status() []CapabilityStatus {
+	capabilities := []app.Capability{app.CapFSRead, app.CapFSWrite}
+	statuses := make([]CapabilityStatus, 0, len(capabilities))
+	for _, capability := range capabilities {
+		status := CapabilityStatus{Capability: string(capability), State: "not requested"}
+
+		action := "DENY"
+		if p.HasPermission(string(capability)) {
+			status.State, action = "granted", "GRANT"
+		}
+		for i := len(p.AuditLog) - 1; i >= 0; i-- {
+			entry := p.AuditLog[i]
+			if entry.Capability != string(capability) || entry.Action != action {
+				continue
+			}
+			if action == "DENY" {
+				status.State = "denied"
+			}
+			decided := entry.Timestamp
+			status.Scope, status.Reason, status.DecidedAt = entry.RequestCwd, entry.Reason, &decided
+			break
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// FormatStatus renders capability statuses as an aligned table
+func FormatStatus(statuses []CapabilityStatus) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-10s  %-13s  %-20s  %s\n", "CAPABILITY", "STATE", "REASON", "SCOPE")
+	for _, status := range statuses {
+		fmt.Fprintf(&sb, "%-10s  %-13s  %-20s  %s\n", status.Capability, status.State,
+			orDash(status.Reason), orDash(status.Scope))
+	}
+	return sb.String()
+}
+
+// orDash returns s, or "-" when it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
 // GetAuditTrail returns a formatted audit trail for logging
 func (p *Permissions) GetAuditTrail() string {
 	if len(p.AuditLog) == 0 {
@@ -119,9 +219,67 @@ func (p *Permissions) GetAuditTrail() string {
 	return trail
 }
 
+// ExportJSON returns the permission decision history as a JSON array, in
+// the order the decisions were made
+func (p *Permissions) ExportJSON() ([]byte, error) {
+	entries := p.AuditLog
+	if entries == nil {
+		entries = []PermissionEntry{}
+	}
+	return json.MarshalIndent(entries, "", "  ")
+}
+
+// PermissionsFromEvents rebuilds a session's permission history from its
+// audit log events. Non-permission events are ignored; auto-confirmed and
+// startup grants are recorded as GRANT with their original reason.
+func PermissionsFromEvents(events []audit.Event) *Permissions {
+	p := &Permissions{}
+	for _, event := range events {
+		if event.Type != audit.EventTypePermission {
+			continue
+		}
+		capability, _ := event.Details["capability"].(string)
+		reason, _ := event.Details["reason"].(string)
+
+		action := event.Action
+		switch action {
+		case "AUTO_CONFIRM", "STARTUP_GRANT":
+			action = "GRANT"
+		}
+
+		if action == "GRANT" {
+			switch capability {
+			case "FS_READ":
+				p.FSRead = true
+			case "FS_WRITE":
+				p.FSWrite = true
+			}
+		}
+
+		p.AuditLog = append(p.AuditLog, PermissionEntry{
+			Capability: capability,
+			Action:     action,
+			Timestamp:  event.Timestamp,
+			Reason:     reason,
+			RequestCwd: event.Cwd,
+		})
+	}
+	return p
+}
+
+// AutoApproves reports whether the safety config approves a capability
+// without prompting: every capability under auto_confirm_permissions, or
+// only read-only ones under auto_approve_read_only
+func AutoApproves(safety config.SafetyConfig, capability string) bool {
+	if safety.AutoConfirmPermissions {
+		return true
+	}
+	return safety.AutoApproveReadOnly && app.Capability(capability).ReadOnly()
+}
+
 func RequestFSReadPermission(cwd string) bool {
 	cfg := config.Load()
-	if cfg.Safety.AutoConfirmPermissions {
+	if AutoApproves(cfg.Safety, "FS_READ") {
 		return true
 	}
 	items := []string{
@@ -156,7 +314,7 @@ func RequestFSReadPermission(cwd string) bool {
 
 func RequestFSWritePermission(cwd string) bool {
 	cfg := config.Load()
-	if cfg.Safety.AutoConfirmPermissions {
+	if AutoApproves(cfg.Safety, "FS_WRITE") {
 		return true
 	}
 	items := []string{
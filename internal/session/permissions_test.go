@@ -1,8 +1,12 @@
 package session
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/cshaiku/goshi/internal/audit"
 )
 
 func TestPermissions_Grant(t *testing.T) {
@@ -74,6 +78,19 @@ func TestPermissions_AutoConfirm(t *testing.T) {
 	}
 }
 
+func TestPermissions_StartupGrant(t *testing.T) {
+	perms := &Permissions{AuditLog: []PermissionEntry{}}
+
+	perms.StartupGrant("FS_READ", "/test/dir")
+
+	if !perms.FSRead || perms.FSWrite {
+		t.Errorf("expected only FS_READ granted, got read=%v write=%v", perms.FSRead, perms.FSWrite)
+	}
+	if len(perms.AuditLog) != 1 || perms.AuditLog[0].Reason != "config-default-grant" {
+		t.Errorf("expected a config-default-grant audit entry, got %+v", perms.AuditLog)
+	}
+}
+
 func TestPermissions_HasPermission(t *testing.T) {
 	perms := &Permissions{
 		FSRead:   true,
@@ -183,3 +200,151 @@ func TestPermissionEntry_Fields(t *testing.T) {
 		t.Error("request cwd not set correctly")
 	}
 }
+
+func TestPermissions_ExportJSON(t *testing.T) {
+	perms := &Permissions{}
+	perms.Grant("FS_READ", "/repo")
+	perms.Deny("FS_WRITE", "/repo")
+	perms.AutoConfirm("FS_WRITE", "/repo")
+
+	data, err := perms.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+
+	var entries []PermissionEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("exported log is not valid JSON: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	want := []struct{ action, capability, reason string }{
+		{"GRANT", "FS_READ", "user-approved"},
+		{"DENY", "FS_WRITE", "user-denied"},
+		{"GRANT", "FS_WRITE", "auto-confirm-enabled"},
+	}
+	for i, w := range want {
+		if entries[i].Action != w.action || entries[i].Capability != w.capability || entries[i].Reason != w.reason {
+			t.Errorf("entry %d = %+v, want %s %s (%s)", i, entries[i], w.action, w.capability, w.reason)
+		}
+	}
+}
+
+func TestPermissions_ExportJSONEmpty(t *testing.T) {
+	data, err := (&Permissions{}).ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	if string(data) != "[]" {
+		t.Errorf("expected empty JSON array, got %s", data)
+	}
+}
+
+func TestPermissionsFromEvents(t *testing.T) {
+	logger, err := audit.NewLogger(audit.Config{Enabled: true, Dir: t.TempDir()}, t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create audit logger: %v", err)
+	}
+
+	perms := &Permissions{Logger: logger}
+	perms.StartupGrant("FS_READ", "/repo")
+	perms.Deny("FS_WRITE", "/repo")
+	perms.Grant("FS_WRITE", "/repo")
+	logger.LogSession("START", "unrelated", "/repo")
+	logger.Close()
+
+	events, err := audit.ReadEvents(logger.FilePath(), audit.Filter{})
+	if err != nil {
+		t.Fatalf("failed to read events: %v", err)
+	}
+
+	rebuilt := PermissionsFromEvents(events)
+	if len(rebuilt.AuditLog) != 3 {
+		t.Fatalf("expected 3 permission entries, got %d", len(rebuilt.AuditLog))
+	}
+	for i, entry := range perms.AuditLog {
+		got := rebuilt.AuditLog[i]
+		if got.Action != entry.Action || got.Capability != entry.Capability || got.Reason != entry.Reason || got.RequestCwd != entry.RequestCwd {
+			t.Errorf("entry %d = %+v, want %+v", i, got, entry)
+		}
+	}
+	if !rebuilt.FSRead || !rebuilt.FSWrite {
+		t.Error("expected replayed grants to set FSRead and FSWrite")
+	}
+}
+
+func TestPermissions_EnforcementStatus(t *testing.T) {
+	perms := &Permissions{}
+	if got := perms.EnforcementStatus(); got != "STAGED" {
+		t.Errorf("expected STAGED with no grants, got %q", got)
+	}
+
+	perms.Grant("FS_READ", "/repo")
+	if got := perms.EnforcementStatus(); got != "ACTIVE (FS_READ)" {
+		t.Errorf("expected ACTIVE (FS_READ), got %q", got)
+	}
+
+	perms.Grant("FS_WRITE", "/repo")
+	if got := perms.EnforcementStatus(); got != "ACTIVE (FS_READ + FS_WRITE)" {
+		t.Errorf("expected ACTIVE (FS_READ + FS_WRITE), got %q", got)
+	}
+}
+
+func TestPermissions_Status(t *testing.T) {
+	perms := &Permissions{}
+	perms.Grant("FS_READ", "/home/user/project")
+	perms.Deny("FS_WRITE", "/home/user/project")
+
+	statuses := perms.Status()
+	if len(statuses) != 2 {
+		t.Fatalf("expected a status per capability, got %d", len(statuses))
+	}
+
+	read := statuses[0]
+	if read.Capability != "FS_READ" || read.State != "granted" || read.Scope != "/home/user/project" {
+		t.Errorf("expected FS_READ granted in the project, got %+v", read)
+	}
+	if read.Reason != "user-approved" || read.DecidedAt == nil {
+		t.Errorf("expected grant details, got %+v", read)
+	}
+
+	write := statuses[1]
+	if write.Capability != "FS_WRITE" || write.State != "denied" || write.Reason != "user-denied" {
+		t.Errorf("expected FS_WRITE denied, got %+v", write)
+	}
+}
+
+func TestPermissions_StatusUsesLatestGrant(t *testing.T) {
+	perms := &Permissions{}
+	perms.StartupGrant("FS_READ", "/repo")
+	perms.AutoConfirm("FS_READ", "/repo/sub")
+
+	read := perms.Status()[0]
+	if read.Scope != "/repo/sub" || read.Reason != "auto-confirm-enabled" {
+		t.Errorf("expected the latest grant's scope and reason, got %+v", read)
+	}
+	if write := perms.Status()[1]; write.State != "not requested" || write.DecidedAt != nil {
+		t.Errorf("expected FS_WRITE not requested, got %+v", write)
+	}
+}
+
+func TestFormatStatus(t *testing.T) {
+	perms := &Permissions{}
+	perms.Grant("FS_WRITE", "/tmp/work")
+
+	out := FormatStatus(perms.Status())
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "CAPABILITY") {
+		t.Fatalf("expected a header and a row per capability, got:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[1], "FS_READ") || !strings.Contains(lines[1], "not requested") {
+		t.Errorf("expected FS_READ not requested, got %q", lines[1])
+	}
+	for _, want := range []string{"FS_WRITE", "granted", "user-approved", "/tmp/work"} {
+		if !strings.Contains(lines[2], want) {
+			t.Errorf("expected %q in %q", want, lines[2])
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cshaiku/goshi/internal/audit"
+)
+
+// savedSession is the on-disk form of a session written by Save
+type savedSession struct {
+	Title       string            `json:"title,omitempty"`
+	Provider    string            `json:"provider"`
+	Model       string            `json:"model"`
+	WorkingDir  string            `json:"working_dir"`
+	SavedAt     time.Time         `json:"saved_at"`
+	Messages    []map[string]any  `json:"messages"`
+	Permissions []PermissionEntry `json:"permissions"`
+}
+
+// Save writes the message history and permission decisions to a JSON file
+// in dir, named after the audit session when there is one and after the
+// conversation's title once it has one, and returns its path. The file is
+// only readable by the user, and redacted like the audit log when Redact is set.
+func (s *ChatSession) Save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create session dir: %w", err)
+	}
+
+	id := time.Now().UTC().Format("20060102T150405Z")
+	if s.AuditLogger != nil && s.AuditLogger.SessionID() != "" {
+		id = s.AuditLogger.SessionID()
+	}
+
+	saved := savedSession{
+		Title:       s.title,
+		Provider:    s.Provider,
+		Model:       s.Model,
+		WorkingDir:  s.WorkingDir,
+		SavedAt:     time.Now().UTC(),
+		Messages:    make([]map[string]any, 0, len(s.Messages)),
+		Permissions: s.Permissions.AuditLog,
+	}
+	for _, msg := range s.Messages {
+		entry := msg.ToLog()
+		if s.Redact {
+			entry = audit.Redact(entry)
+		}
+		saved.Messages = append(saved.Messages, entry)
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	// Write through a temp file so a crash mid-save never leaves a torn file
+	name := "session-" + id
+	if slug := titleSlug(s.title); slug != "" {
+		name += "-" + slug
+	}
+	path := filepath.Join(dir, name+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write session: %w", err)
+	}
+	return path, nil
+}
+
+// Shutdown ends the session before exit: it saves the history when
+// SessionDir is set and closes the audit log. Only the first call has any
+// effect, so the quit path and a signal handler may both call it.
+func (s *ChatSession) Shutdown() error {
+	var err error
+	s.shutdownOnce.Do(func() {
+		if s.SessionDir != "" {
+			// An untitled session is named after its first message rather
+			// than asking the backend for a title while shutting down
+			if s.title == "" && s.TitleMode != TitleOff && s.TitleMode != "" {
+				if user, _, ok := s.FirstExchange(); ok {
+					s.title = heuristicTitle(user)
+				}
+			}
+			path, saveErr := s.Save(s.SessionDir)
+			if saveErr != nil {
+				err = saveErr
+			} else if s.AuditLogger != nil {
+				s.AuditLogger.LogSession("SAVE", fmt.Sprintf("session saved to %s", path), s.WorkingDir)
+			}
+		}
+		if s.AuditLogger != nil {
+			s.AuditLogger.LogSession("END", "session ended", s.WorkingDir)
+			if closeErr := s.AuditLogger.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,34 @@
+package session
+
+// PermissionPrompter asks the user whether to grant a capability. The CLI
+// prompts on stdin and the TUI shows a modal; tests inject a fixed answer.
+type PermissionPrompter interface {
+	Ask(capability string, cwd string) bool
+}
+
+// StdinPrompter asks with an interactive prompt on the terminal
+type StdinPrompter struct{}
+
+// Ask prompts for FS_READ or FS_WRITE; other capabilities are refused
+func (StdinPrompter) Ask(capability string, cwd string) bool {
+	switch capability {
+	case "FS_READ":
+		return RequestFSReadPermission(cwd)
+	case "FS_WRITE":
+		return RequestFSWritePermission(cwd)
+	default:
+		return false
+	}
+}
+
+// AutoGrantPrompter grants every request without asking and records the
+// capabilities it was asked for
+type AutoGrantPrompter struct {
+	Asked []string
+}
+
+// Ask records the request and grants it
+func (p *AutoGrantPrompter) Ask(capability string, cwd string) bool {
+	p.Asked = append(p.Asked, capability)
+	return true
+}
@@ -5,27 +5,62 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/cshaiku/goshi/internal/app"
 	"github.com/cshaiku/goshi/internal/audit"
 	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/detect"
+	"github.com/cshaiku/goshi/internal/fs"
 	"github.com/cshaiku/goshi/internal/llm"
 )
 
 // ChatSession encapsulates a single chat interaction session with all necessary context
 // This manages message history, permissions, and conversation state
 type ChatSession struct {
-	SystemPrompt string
-	WorkingDir   string
-	Permissions  *Permissions
-	Capabilities *app.Capabilities
-	Messages     []llm.LLMMessage // Structured message history
-	Client       *llm.ClientWithTools
-	ToolRouter   *app.ToolRouter
-	AuditLogger  *audit.Logger
-	Context      context.Context
-	Model        string // LLM model name
-	Provider     string // LLM provider name
+	SystemPrompt  string
+	WorkingDir    string
+	Permissions   *Permissions
+	Capabilities  *app.Capabilities
+	Messages      []llm.LLMMessage // Structured message history
+	Client        *llm.ClientWithTools
+	ToolRouter    *app.ToolRouter
+	AuditLogger   *audit.Logger
+	Context       context.Context
+	Model         string             // LLM model name
+	Provider      string             // LLM provider name
+	MaxTurns      int                // Maximum user turns before the session wraps up (0 = unlimited)
+	MaxSteps      int                // Maximum tool steps per user turn in a ChatTurn (0 = unlimited)
+	ContextTokens int                // Approximate token budget for history sent to the model (0 = unlimited)
+	MOTD          string             // Project banner from .goshi/motd.txt, shown at session start
+	AuditWarning  string             // Set when the audit log could not be set up and auditing is disabled
+	PaidWarning   string             // Set by the caller when requests to the provider are billed
+	Prompter      PermissionPrompter // Asks the user for capabilities not yet granted
+	SessionDir    string             // Where Shutdown saves the history ("" = not saved)
+	Redact        bool               // Redact secret-looking keys in the saved history, as in the audit log
+	TitleMode     string             // How Title names the conversation (see Title*)
+	ResultLines   int                // Lines of each tool result text sent back to the model (0 = unlimited)
+
+	// PostProcessors transform the final answer of each turn before it is
+	// shown or stored in the history (nil leaves it unchanged)
+	PostProcessors *llm.ResponsePipeline
+
+	// ExplainBeforeAct refuses tool calls the model has not first explained
+	// to the user in a text response
+	ExplainBeforeAct bool
+
+	// ContinueAfterDenial tells the model about denied permissions so it can
+	// try another approach, instead of the turn ending
+	ContinueAfterDenial bool
+
+	pinned       map[int]bool // Indexes into Messages kept during context trimming
+	shutdownOnce sync.Once
+	title        string   // Set by Title once generated
+	truncated    bool     // The last response was cut off by the output token limit
+	denied       []string // Capabilities denied since the last TakeDenials
+	explained    bool     // The model has replied in text since the last user message
 }
 
 // NewChatSession initializes a new chat session with the given system prompt
@@ -46,62 +81,112 @@ func NewChatSession(ctx context.Context, systemPrompt string, backend llm.Backen
 		return nil, fmt.Errorf("failed to create system prompt: %w", err)
 	}
 
-	// Initialize LLM client with tools support
-	client := llm.NewClientWithTools(sp, backend)
+	cfg := config.Load()
+
+	// Initialize LLM client with tools support, re-requesting empty
+	// responses if configured
+	client := llm.NewClientWithTools(sp, llm.WithEmptyResponseRetry(backend, cfg.LLM.EmptyRetries))
 
 	// Initialize capabilities and permissions
 	caps := app.NewCapabilities()
-	cfg := config.Load()
 	repoRoot := cfg.Behavior.RepoRoot
 	if repoRoot == "" {
 		repoRoot = cwd
 	}
 
 	auditLogger, err := audit.NewLogger(audit.Config{
-		Enabled:            cfg.Audit.Enabled,
-		Dir:                cfg.Audit.Dir,
-		RetentionDays:      cfg.Audit.RetentionDays,
-		MaxSessions:        cfg.Audit.MaxSessions,
-		Redact:             cfg.Audit.Redact,
-		ToolArgumentsStyle: cfg.Audit.ToolArgumentsStyle,
+		Enabled:              cfg.Audit.Enabled,
+		Dir:                  cfg.Audit.Dir,
+		RetentionDays:        cfg.Audit.RetentionDays,
+		MaxSessions:          cfg.Audit.MaxSessions,
+		Redact:               cfg.Audit.Redact,
+		ToolArgumentsStyle:   cfg.Audit.ToolArgumentsStyle,
+		Format:               cfg.Audit.Format,
+		MaxToolEventsPerTurn: cfg.Audit.MaxToolEventsPerTurn,
 	}, repoRoot)
+	// Auditing is best effort: a read-only or unwritable audit dir must not
+	// stop the session, so fall back to a disabled logger and warn once
+	auditWarning := ""
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize audit logger: %w", err)
+		auditWarning = fmt.Sprintf("audit logging disabled: %v", err)
+		auditLogger, _ = audit.NewLogger(audit.Config{Enabled: false}, repoRoot)
 	}
 	perms := &Permissions{
 		AuditLog: []PermissionEntry{},
 		Logger:   auditLogger,
 	}
 
+	// Grant capabilities the config trusts by default (e.g. read-only workflows)
+	for _, capability := range cfg.Safety.DefaultGrants {
+		perms.StartupGrant(capability, cwd)
+		caps.Grant(app.Capability(capability))
+	}
+
+	// Sessions are saved on exit only when persistence is enabled
+	sessionDir := ""
+	if cfg.Behavior.SaveSessionOnExit {
+		sessionDir = cfg.Behavior.SessionDir
+		if !filepath.IsAbs(sessionDir) {
+			sessionDir = filepath.Join(repoRoot, sessionDir)
+		}
+	}
+
 	// Initialize action service and tool router
 	actionSvc, err := app.NewActionService(cwd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create action service: %w", err)
 	}
+	actionSvc.Dispatcher().SetBackupOnWrite(cfg.Safety.AutoBackupOnWrite)
+	actionSvc.Dispatcher().SetBackupRoot(repoRoot)
+	// Pruning is best effort: stale backups must not stop the session
+	_, _ = fs.PruneBackups(repoRoot, time.Duration(cfg.Safety.BackupRetentionDays)*24*time.Hour)
+	actionSvc.Dispatcher().SetMaxRecursiveBytes(cfg.Safety.MaxRecursiveReadBytes)
 
 	router := app.NewToolRouter(actionSvc.Dispatcher(), caps)
 	router.SetAuditLogger(auditLogger, cwd)
+	router.SetProtectedPaths(cwd, cfg.Safety.ProtectedPaths)
+	router.SetMaxArgsBytes(cfg.Safety.MaxToolArgsBytes)
+	router.SetToolFilter(cfg.Safety.AllowedTools, cfg.Safety.DisabledTools)
 	if auditLogger != nil {
 		auditLogger.LogSession("START", fmt.Sprintf("session started (provider=%s model=%s)", cfg.LLM.Provider, cfg.LLM.Model), cwd)
 	}
 
+	postProcessors, err := llm.NewResponsePipelineFromNames(cfg.LLM.PostProcessors)
+	if err != nil {
+		return nil, fmt.Errorf("invalid llm.post_processors: %w", err)
+	}
+
 	// Set up tool validation in the parser
 	client.SetToolValidator(func(toolName string, args map[string]any) error {
 		return router.ValidateToolCall(toolName, args)
 	})
 
 	return &ChatSession{
-		SystemPrompt: systemPrompt,
-		WorkingDir:   cwd,
-		Permissions:  perms,
-		Capabilities: caps,
-		Messages:     []llm.LLMMessage{},
-		Client:       client,
-		ToolRouter:   router,
-		AuditLogger:  auditLogger,
-		Context:      ctx,
-		Model:        cfg.LLM.Model,
-		Provider:     cfg.LLM.Provider,
+		SystemPrompt:        systemPrompt,
+		WorkingDir:          cwd,
+		Permissions:         perms,
+		Capabilities:        caps,
+		Messages:            []llm.LLMMessage{},
+		Client:              client,
+		ToolRouter:          router,
+		AuditLogger:         auditLogger,
+		Context:             ctx,
+		Model:               cfg.LLM.Model,
+		Provider:            cfg.LLM.Provider,
+		MaxTurns:            cfg.Behavior.MaxTurns,
+		MaxSteps:            cfg.Behavior.MaxStepsPerTurn,
+		ContextTokens:       cfg.LLM.ContextTokens,
+		MOTD:                LoadMOTD(repoRoot),
+		AuditWarning:        auditWarning,
+		Prompter:            StdinPrompter{},
+		SessionDir:          sessionDir,
+		Redact:              cfg.Audit.Redact,
+		TitleMode:           cfg.Behavior.AutoTitle,
+		ResultLines:         cfg.Behavior.ToolResultMaxLines,
+		PostProcessors:      postProcessors,
+		ExplainBeforeAct:    cfg.Behavior.ExplainBeforeAct,
+		ContinueAfterDenial: cfg.Safety.ContinueAfterDenial,
+		pinned:              map[int]bool{},
 	}, nil
 }
 
@@ -111,6 +196,7 @@ func (s *ChatSession) AddUserMessage(content string) {
 		Content: content,
 	}
 	s.Messages = append(s.Messages, &msg)
+	s.explained = false
 
 	// Log user message
 	if s.AuditLogger != nil {
@@ -118,12 +204,28 @@ func (s *ChatSession) AddUserMessage(content string) {
 	}
 }
 
+// AddClarificationAnswer adds a user reply to a clarification request,
+// tagged so the model can tell it apart from a new instruction
+func (s *ChatSession) AddClarificationAnswer(content string) {
+	msg := llm.UserMessage{
+		Content:       content,
+		Clarification: true,
+	}
+	s.Messages = append(s.Messages, &msg)
+	s.explained = false
+
+	if s.AuditLogger != nil {
+		s.AuditLogger.LogMessage(content, s.WorkingDir)
+	}
+}
+
 // AddAssistantTextMessage adds an assistant text message to the conversation history
 func (s *ChatSession) AddAssistantTextMessage(content string) {
 	msg := llm.AssistantTextMessage{
 		Content: content,
 	}
 	s.Messages = append(s.Messages, &msg)
+	s.explained = true
 
 	// Log LLM text response
 	if s.AuditLogger != nil {
@@ -131,6 +233,28 @@ func (s *ChatSession) AddAssistantTextMessage(content string) {
 	}
 }
 
+// AppendAssistantText extends the latest assistant text message, e.g. with
+// the continuation of a truncated response. Without one, it adds a new
+// message.
+func (s *ChatSession) AppendAssistantText(content string) {
+	if n := len(s.Messages); n > 0 {
+		if last, ok := s.Messages[n-1].(*llm.AssistantTextMessage); ok {
+			last.Content += content
+			if s.AuditLogger != nil {
+				s.AuditLogger.LogResponse(content, false, s.WorkingDir)
+			}
+			return
+		}
+	}
+	s.AddAssistantTextMessage(content)
+}
+
+// CanContinue reports whether the last response was cut off by the output
+// token limit and can be extended with a continue turn
+func (s *ChatSession) CanContinue() bool {
+	return s.truncated
+}
+
 // AddAssistantActionMessage adds an assistant action message to the conversation history
 func (s *ChatSession) AddAssistantActionMessage(toolName string, toolArgs map[string]any) {
 	msg := llm.AssistantActionMessage{
@@ -147,10 +271,18 @@ func (s *ChatSession) AddAssistantActionMessage(toolName string, toolArgs map[st
 }
 
 // AddToolResultMessage adds a tool result message to the conversation history
+// The result is normalized into an app.ToolResult; failures are recorded with their error.
+// Long text in the result is truncated to ResultLines for the model; the
+// caller's result is left untouched.
 func (s *ChatSession) AddToolResultMessage(toolName string, result interface{}) {
+	normalized := app.NormalizeToolResult(result)
 	msg := llm.ToolResultMessage{
 		ToolName: toolName,
-		Result:   result,
+		Success:  normalized.Success,
+		Error:    normalized.Error,
+	}
+	if normalized.Success {
+		msg.Result = summarizeToolValue(normalized.Value, s.ResultLines)
 	}
 	s.Messages = append(s.Messages, &msg)
 }
@@ -166,9 +298,97 @@ func (s *ChatSession) GrantPermission(capability string) {
 	}
 }
 
+// AutoApprovePermission grants a capability without prompting when the
+// safety config auto-approves it, recording an auto-confirm in the audit
+// log. It returns false when the user must still be asked.
+func (s *ChatSession) AutoApprovePermission(capability string) bool {
+	if !AutoApproves(config.Load().Safety, capability) {
+		return false
+	}
+	s.Permissions.AutoConfirm(capability, s.WorkingDir)
+	s.Capabilities.Grant(app.Capability(capability))
+	return true
+}
+
+// RequestPermission grants a capability that is already held, auto-approved
+// by the safety config, or approved through the session's prompter. Any
+// other outcome is recorded as a denial.
+func (s *ChatSession) RequestPermission(capability string) bool {
+	if s.HasPermission(capability) || s.AutoApprovePermission(capability) {
+		return true
+	}
+	if s.Prompter != nil && s.Prompter.Ask(capability, s.WorkingDir) {
+		s.GrantPermission(capability)
+		return true
+	}
+	s.DenyPermission(capability)
+	return false
+}
+
+// RequestToolPermission requests the capability a tool requires, if any.
+// Unknown tools pass through so the router can refuse them itself.
+func (s *ChatSession) RequestToolPermission(tool string) bool {
+	def, ok := s.ToolRouter.ToolDefinition(tool)
+	if !ok || def.RequiredPermission == "" || s.Capabilities.Has(def.RequiredPermission) {
+		return true
+	}
+	return s.RequestPermission(string(def.RequiredPermission))
+}
+
+// detectedPermissions maps detected intents to the capabilities they need
+var detectedPermissions = map[detect.Capability]app.Capability{
+	detect.CapabilityFSRead:  app.CapFSRead,
+	detect.CapabilityFSWrite: app.CapFSWrite,
+}
+
+// RequestDetectedPermissions requests the capability each detected match
+// needs. Before asking for a capability not yet held, explain (if set) is
+// given the capability and the rule that triggered the request. It stops
+// at the first denial and returns the denied capability, or "" when every
+// request was granted.
+func (s *ChatSession) RequestDetectedPermissions(matches []detect.Match, explain func(capability, reason string)) string {
+	for _, match := range matches {
+		capability, ok := detectedPermissions[match.Capability]
+		if !ok {
+			continue
+		}
+		if explain != nil && !s.HasPermission(string(capability)) {
+			explain(string(capability), DetectionReason(capability, match))
+		}
+		if !s.RequestPermission(string(capability)) {
+			return string(capability)
+		}
+	}
+	return ""
+}
+
+// DetectionReason explains why a detected match requests a capability
+func DetectionReason(capability app.Capability, match detect.Match) string {
+	return fmt.Sprintf("Requesting %s because your message matched %s", capability, match)
+}
+
 // DenyPermission denies a capability and records it in the audit log
 func (s *ChatSession) DenyPermission(capability string) {
 	s.Permissions.Deny(capability, s.WorkingDir)
+	s.denied = append(s.denied, capability)
+}
+
+// TakeDenials returns the capabilities denied since the last call
+func (s *ChatSession) TakeDenials() []string {
+	denied := s.denied
+	s.denied = nil
+	return denied
+}
+
+// AddDenialNote tells the model that the user denied a capability, so it
+// can continue without it
+func (s *ChatSession) AddDenialNote(capability string) {
+	s.Messages = append(s.Messages, llm.NewSystemContextMessage(DenialNote(capability)))
+}
+
+// DenialNote is the note the model receives for a denied capability
+func DenialNote(capability string) string {
+	return fmt.Sprintf("[The user denied the %s permission. Do not retry actions that need it; continue without it, for example by answering from what you know, suggesting another approach, or asking the user.]", capability)
 }
 
 // HasPermission checks if a capability is currently granted
@@ -181,24 +401,127 @@ func (s *ChatSession) GetAuditLog() string {
 	return s.Permissions.GetAuditTrail()
 }
 
+// TogglePin pins or unpins the message at index so it survives context
+// trimming, returning the new pinned state
+func (s *ChatSession) TogglePin(index int) bool {
+	if index < 0 || index >= len(s.Messages) {
+		return false
+	}
+	if s.pinned == nil {
+		s.pinned = map[int]bool{}
+	}
+	if s.pinned[index] {
+		delete(s.pinned, index)
+		return false
+	}
+	s.pinned[index] = true
+	return true
+}
+
+// IsPinned reports whether the message at index is pinned
+func (s *ChatSession) IsPinned(index int) bool {
+	return s.pinned[index]
+}
+
+// ContextMessages returns the history to send to the model, trimmed to the
+// session's token budget while keeping pinned messages
+func (s *ChatSession) ContextMessages() []llm.Message {
+	return llm.TrimToTokenBudget(s.ConvertMessagesToLegacy(), s.ContextTokens)
+}
+
+// TurnCount returns the number of user turns recorded in the conversation
+func (s *ChatSession) TurnCount() int {
+	turns := 0
+	for _, msg := range s.Messages {
+		if _, ok := msg.(*llm.UserMessage); ok {
+			turns++
+		}
+	}
+	return turns
+}
+
+// TurnLimitReached reports whether the session has used up its configured turns
+func (s *ChatSession) TurnLimitReached() bool {
+	return s.MaxTurns > 0 && s.TurnCount() >= s.MaxTurns
+}
+
+// WrapUpMessage returns the summary shown when the turn limit ends the session
+// and records the wrap-up in the audit log
+func (s *ChatSession) WrapUpMessage() string {
+	toolCalls := 0
+	for _, msg := range s.Messages {
+		if _, ok := msg.(*llm.AssistantActionMessage); ok {
+			toolCalls++
+		}
+	}
+
+	var granted []string
+	if s.Permissions != nil {
+		if s.Permissions.FSRead {
+			granted = append(granted, "FS_READ")
+		}
+		if s.Permissions.FSWrite {
+			granted = append(granted, "FS_WRITE")
+		}
+	}
+	grants := "none"
+	if len(granted) > 0 {
+		grants = strings.Join(granted, ", ")
+	}
+
+	summary := fmt.Sprintf(
+		"This session has reached its limit of %d turns. Thanks for working with me!\n"+
+			"Summary: %d turns, %d messages, %d tool calls, permissions granted: %s.\n"+
+			"Please start a new session to continue.",
+		s.MaxTurns, s.TurnCount(), len(s.Messages), toolCalls, grants)
+
+	if s.AuditLogger != nil {
+		s.AuditLogger.LogSession("LIMIT", fmt.Sprintf("turn limit reached (max_turns=%d)", s.MaxTurns), s.WorkingDir)
+	}
+
+	return summary
+}
+
+// previousMessage returns the message before index i, or nil at the start
+func (s *ChatSession) previousMessage(i int) llm.LLMMessage {
+	if i == 0 {
+		return nil
+	}
+	return s.Messages[i-1]
+}
+
 // ConvertMessagesToLegacy converts structured LLMMessages back to legacy Message format
 // This is temporary for backward compatibility during transition
 func (s *ChatSession) ConvertMessagesToLegacy() []llm.Message {
 	var legacyMessages []llm.Message
 
-	for _, msg := range s.Messages {
+	for i, msg := range s.Messages {
 		if userMsg, ok := msg.(*llm.UserMessage); ok {
 			legacyMessages = append(legacyMessages, llm.Message{
 				Role:    "user",
-				Content: userMsg.Content,
+				Content: userMsg.ToAPIFormat()["content"],
+				Pinned:  s.pinned[i],
 			})
 		} else if assistantMsg, ok := msg.(*llm.AssistantTextMessage); ok {
 			legacyMessages = append(legacyMessages, llm.Message{
 				Role:    "assistant",
 				Content: assistantMsg.Content,
+				Pinned:  s.pinned[i],
 			})
+		} else if actionMsg, ok := msg.(*llm.AssistantActionMessage); ok {
+			// Tool calls and their results are replayed so multi-step turns
+			// see what has already been done
+			api := actionMsg.ToAPIFormat()
+			legacyMessages = append(legacyMessages, llm.Message{Role: api["role"], Content: api["content"], Pinned: s.pinned[i]})
+		} else if resultMsg, ok := msg.(*llm.ToolResultMessage); ok {
+			// A result is trimmed with the call before it, never on its own
+			api := resultMsg.ToAPIFormat()
+			_, paired := s.previousMessage(i).(*llm.AssistantActionMessage)
+			legacyMessages = append(legacyMessages, llm.Message{Role: api["role"], Content: api["content"], Pinned: s.pinned[i], Paired: paired})
+		} else if noteMsg, ok := msg.(*llm.SystemContextMessage); ok {
+			api := noteMsg.ToAPIFormat()
+			legacyMessages = append(legacyMessages, llm.Message{Role: api["role"], Content: api["content"], Pinned: s.pinned[i]})
 		}
-		// Note: We'll need to handle action messages differently in the chat loop
 	}
 
 	return legacyMessages
@@ -2,9 +2,19 @@ package session
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/cshaiku/goshi/internal/actions/runtime"
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/audit"
 	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/detect"
 	"github.com/cshaiku/goshi/internal/llm"
 )
 
@@ -12,6 +22,7 @@ import (
 type MockBackend struct {
 	Responses []string // Allow customization of responses
 	CallCount int      // Track call count
+	StreamErr error    // Ends each stream instead of io.EOF, if set
 }
 
 func (m *MockBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
@@ -20,18 +31,22 @@ func (m *MockBackend) Stream(ctx context.Context, system string, messages []llm.
 		data = []string{"test response"}
 	}
 	m.CallCount++
-	return &MockStream{Index: 0, Data: data}, nil
+	return &MockStream{Index: 0, Data: data, Err: m.StreamErr}, nil
 }
 
 // MockStream implements llm.Stream for testing
 type MockStream struct {
 	Index int
 	Data  []string
+	Err   error // Returned after Data instead of io.EOF, if set
 }
 
 func (m *MockStream) Recv() (string, error) {
 	if m.Index >= len(m.Data) {
-		return "", &MockStreamError{}
+		if m.Err != nil {
+			return "", m.Err
+		}
+		return "", io.EOF
 	}
 	chunk := m.Data[m.Index]
 	m.Index++
@@ -42,12 +57,6 @@ func (m *MockStream) Close() error {
 	return nil
 }
 
-type MockStreamError struct{}
-
-func (e *MockStreamError) Error() string   { return "end of stream" }
-func (e *MockStreamError) Timeout() bool   { return false }
-func (e *MockStreamError) Temporary() bool { return false }
-
 func newTestSession(t *testing.T) *ChatSession {
 	t.Helper()
 	t.Setenv("GOSHI_AUDIT_ENABLED", "false")
@@ -153,3 +162,1077 @@ func TestChatSession_GetAuditLog(t *testing.T) {
 		t.Errorf("expected 2 audit entries, got %d", len(session.Permissions.AuditLog))
 	}
 }
+
+func TestChatSession_TurnLimit(t *testing.T) {
+	session := newTestSession(t)
+	session.MaxTurns = 2
+
+	session.AddUserMessage("first")
+	session.AddAssistantTextMessage("reply")
+	if session.TurnLimitReached() {
+		t.Fatal("turn limit should not be reached after 1 turn")
+	}
+
+	session.AddUserMessage("second")
+	if !session.TurnLimitReached() {
+		t.Fatal("turn limit should be reached after 2 turns")
+	}
+
+	wrapUp := session.WrapUpMessage()
+	if !strings.Contains(wrapUp, "limit of 2 turns") {
+		t.Errorf("expected wrap-up to mention the limit, got %q", wrapUp)
+	}
+	if !strings.Contains(wrapUp, "2 turns, 3 messages") {
+		t.Errorf("expected wrap-up to summarize the session, got %q", wrapUp)
+	}
+}
+
+func TestChatSession_TurnLimitDisabled(t *testing.T) {
+	session := newTestSession(t)
+	session.MaxTurns = 0
+
+	for i := 0; i < 5; i++ {
+		session.AddUserMessage("hello")
+	}
+
+	if session.TurnLimitReached() {
+		t.Error("turn limit should never be reached when MaxTurns is 0")
+	}
+}
+
+func TestChatSession_PinnedMessagesSurviveTrimming(t *testing.T) {
+	session := newTestSession(t)
+	session.ContextTokens = 50
+
+	long := strings.Repeat("x", 120) // ~30 tokens
+	session.AddUserMessage("remember: " + long)
+	session.AddAssistantTextMessage(long)
+	session.AddUserMessage(long)
+	session.AddAssistantTextMessage("latest")
+
+	if !session.TogglePin(0) {
+		t.Fatal("expected first message to become pinned")
+	}
+
+	msgs := session.ContextMessages()
+	if len(msgs) == 0 || !strings.HasPrefix(msgs[0].Content, "remember:") || !msgs[0].Pinned {
+		t.Fatalf("expected pinned message to be kept first, got %+v", msgs)
+	}
+	if msgs[len(msgs)-1].Content != "latest" {
+		t.Errorf("expected latest message to be kept, got %q", msgs[len(msgs)-1].Content)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("expected unpinned history to be trimmed, got %d messages", len(msgs))
+	}
+
+	// Unpinning lets the message be trimmed again
+	if session.TogglePin(0) {
+		t.Fatal("expected second toggle to unpin")
+	}
+	if msgs := session.ContextMessages(); strings.HasPrefix(msgs[0].Content, "remember:") {
+		t.Error("expected unpinned message to be trimmed")
+	}
+}
+
+func TestChatSession_TrimmingKeepsToolResultsWithCalls(t *testing.T) {
+	session := newTestSession(t)
+	session.ContextTokens = 30
+
+	session.AddUserMessage("list files")
+	session.AddAssistantActionMessage("fs.list", map[string]any{"path": "."})
+	session.AddToolResultMessage("fs.list", map[string]any{"entries": strings.Repeat("x", 200)})
+	session.AddAssistantTextMessage("done")
+
+	for i, msg := range session.ContextMessages() {
+		if msg.Paired && i == 0 {
+			t.Fatalf("expected no tool result kept without its call, got %+v", msg)
+		}
+	}
+
+	msgs := session.ConvertMessagesToLegacy()
+	if !msgs[2].Paired || msgs[1].Paired {
+		t.Errorf("expected only the tool result paired with its call, got %+v", msgs)
+	}
+}
+
+func TestLoadMOTD(t *testing.T) {
+	root := t.TempDir()
+	if got := LoadMOTD(root); got != "" {
+		t.Errorf("expected no MOTD without the file, got %q", got)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, ".goshi"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, MOTDFile), []byte("Run make test before committing.\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if got := LoadMOTD(root); got != "Run make test before committing." {
+		t.Errorf("expected MOTD contents, got %q", got)
+	}
+}
+
+func TestNewChatSession_UnwritableAuditDirDisablesAuditing(t *testing.T) {
+	tmp := t.TempDir()
+
+	// A regular file where the audit dir should be makes it impossible to
+	// create, like a read-only parent (but also when tests run as root)
+	blocker := filepath.Join(tmp, "blocked")
+	if err := os.WriteFile(blocker, []byte("x"), 0444); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfgPath := filepath.Join(tmp, "goshi.yaml")
+	cfgData := "audit:\n  enabled: true\n  dir: " + filepath.Join(blocker, "audit") + "\n"
+	if err := os.WriteFile(cfgPath, []byte(cfgData), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	t.Setenv("GOSHI_AUDIT_ENABLED", "true")
+	config.Reset()
+	defer config.Reset()
+
+	session, err := NewChatSession(context.Background(), "test", &MockBackend{})
+	if err != nil {
+		t.Fatalf("expected session to start despite audit failure, got %v", err)
+	}
+
+	if !strings.Contains(session.AuditWarning, "audit logging disabled") {
+		t.Errorf("expected audit warning, got %q", session.AuditWarning)
+	}
+	if session.AuditLogger == nil || session.AuditLogger.FilePath() != "" {
+		t.Error("expected a disabled audit logger")
+	}
+
+	// Logging through the disabled logger is a no-op
+	session.AddUserMessage("hello")
+}
+
+func TestNewChatSession_DefaultGrants(t *testing.T) {
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "goshi.yaml")
+	cfgData := "safety:\n  default_grants: [\"FS_READ\"]\naudit:\n  dir: " + filepath.Join(tmp, "audit") + "\n"
+	if err := os.WriteFile(cfgPath, []byte(cfgData), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	t.Setenv("GOSHI_AUDIT_ENABLED", "true")
+	config.Reset()
+	defer config.Reset()
+
+	session, err := NewChatSession(context.Background(), "test", &MockBackend{})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.AuditLogger.Close()
+
+	if !session.HasPermission("FS_READ") || !session.Capabilities.Has(app.CapFSRead) {
+		t.Error("expected FS_READ to be granted at startup")
+	}
+	if session.HasPermission("FS_WRITE") || session.Capabilities.Has(app.CapFSWrite) {
+		t.Error("expected FS_WRITE to remain ungranted")
+	}
+
+	events, err := audit.ReadEvents(session.AuditLogger.FilePath(), audit.Filter{
+		Types: map[audit.EventType]bool{audit.EventTypePermission: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if len(events) != 1 || events[0].Action != "STARTUP_GRANT" {
+		t.Errorf("expected one STARTUP_GRANT audit event, got %+v", events)
+	}
+}
+
+func TestNewChatSession_DisabledTools(t *testing.T) {
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "goshi.yaml")
+	cfgData := "safety:\n  disabled_tools: [\"fs.write\"]\n"
+	if err := os.WriteFile(cfgPath, []byte(cfgData), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	t.Setenv("GOSHI_AUDIT_ENABLED", "false")
+	config.Reset()
+	defer config.Reset()
+
+	session, err := NewChatSession(context.Background(), "test", &MockBackend{})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	for _, tool := range session.ToolRouter.GetToolDefinitions() {
+		if tool.ID == "fs.write" {
+			t.Fatal("expected fs.write to be hidden from the model")
+		}
+	}
+	session.GrantPermission("FS_WRITE")
+	result := session.ToolRouter.Execute(app.ToolCall{Name: "fs.write", Args: map[string]any{"path": "x.txt", "content": "x"}})
+	if result.Success || !strings.Contains(result.Error, "disabled") {
+		t.Errorf("expected fs.write to be refused, got %+v", result)
+	}
+}
+
+func TestChatSession_AutoApproveReadOnly(t *testing.T) {
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "goshi.yaml")
+	if err := os.WriteFile(cfgPath, []byte("safety:\n  auto_approve_read_only: true\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	t.Setenv("GOSHI_AUDIT_ENABLED", "false")
+	config.Reset()
+	defer config.Reset()
+
+	session, err := NewChatSession(context.Background(), "test", &MockBackend{})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	// Read tools run without a prompt
+	if !session.AutoApprovePermission("FS_READ") {
+		t.Fatal("expected FS_READ to be auto-approved")
+	}
+	if !session.HasPermission("FS_READ") || !session.Capabilities.Has(app.CapFSRead) {
+		t.Error("expected FS_READ to be granted")
+	}
+	last := session.Permissions.AuditLog[len(session.Permissions.AuditLog)-1]
+	if last.Reason != "auto-confirm-enabled" {
+		t.Errorf("expected an auto-confirm audit entry, got %+v", last)
+	}
+	if result := session.ToolRouter.Execute(app.ToolCall{Name: "fs.list", Args: map[string]any{"path": "."}}); !result.Success {
+		t.Errorf("expected fs.list to run, got %q", result.Error)
+	}
+
+	// Write tools still require confirmation
+	if session.AutoApprovePermission("FS_WRITE") {
+		t.Fatal("expected FS_WRITE to still require confirmation")
+	}
+	if session.HasPermission("FS_WRITE") || session.Capabilities.Has(app.CapFSWrite) {
+		t.Error("expected FS_WRITE to remain ungranted")
+	}
+	result := session.ToolRouter.Execute(app.ToolCall{Name: "fs.write", Args: map[string]any{"path": "x.txt", "content": "x"}})
+	if result.Success {
+		t.Error("expected fs.write to be refused without confirmation")
+	}
+}
+
+// denyingPrompter refuses every request
+type denyingPrompter struct{ asked int }
+
+func (p *denyingPrompter) Ask(capability string, cwd string) bool {
+	p.asked++
+	return false
+}
+
+func TestChatSession_RequestPermissionConsultsPrompter(t *testing.T) {
+	session := newTestSession(t)
+	prompter := &AutoGrantPrompter{}
+	session.Prompter = prompter
+
+	if !session.RequestToolPermission("fs.list") {
+		t.Fatal("expected the prompter's grant to be honored")
+	}
+	if len(prompter.Asked) != 1 || prompter.Asked[0] != "FS_READ" {
+		t.Errorf("expected the prompter to be asked for FS_READ once, got %v", prompter.Asked)
+	}
+	if !session.HasPermission("FS_READ") || !session.Capabilities.Has(app.CapFSRead) {
+		t.Error("expected FS_READ to be granted")
+	}
+	last := session.Permissions.AuditLog[len(session.Permissions.AuditLog)-1]
+	if last.Action != "GRANT" || last.Capability != "FS_READ" || last.Reason != "user-approved" {
+		t.Errorf("expected a recorded grant, got %+v", last)
+	}
+
+	// A held capability is not asked for again
+	if !session.RequestPermission("FS_READ") || len(prompter.Asked) != 1 {
+		t.Errorf("expected no second prompt for a granted capability, got %v", prompter.Asked)
+	}
+
+	denier := &denyingPrompter{}
+	session.Prompter = denier
+	if session.RequestToolPermission("fs.write") {
+		t.Fatal("expected the prompter's denial to be honored")
+	}
+	if denier.asked != 1 {
+		t.Errorf("expected the prompter to be asked once, got %d", denier.asked)
+	}
+	if session.HasPermission("FS_WRITE") || session.Capabilities.Has(app.CapFSWrite) {
+		t.Error("expected FS_WRITE to remain ungranted")
+	}
+	last = session.Permissions.AuditLog[len(session.Permissions.AuditLog)-1]
+	if last.Action != "DENY" || last.Capability != "FS_WRITE" {
+		t.Errorf("expected a recorded denial, got %+v", last)
+	}
+}
+
+func TestChatSession_ShutdownSavesAndClosesAudit(t *testing.T) {
+	tmp := t.TempDir()
+	sessionDir := filepath.Join(tmp, "sessions")
+	cfgPath := filepath.Join(tmp, "goshi.yaml")
+	cfgData := "audit:\n  dir: " + filepath.Join(tmp, "audit") + "\nbehavior:\n  save_session_on_exit: true\n  session_dir: " + sessionDir + "\n"
+	if err := os.WriteFile(cfgPath, []byte(cfgData), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	t.Setenv("GOSHI_AUDIT_ENABLED", "true")
+	config.Reset()
+	defer config.Reset()
+
+	session, err := NewChatSession(context.Background(), "test", &MockBackend{})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	session.AddUserMessage("remember this")
+	session.GrantPermission("FS_READ")
+
+	if err := session.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	// A second shutdown (e.g. quit key and signal) is a no-op
+	if err := session.Shutdown(); err != nil {
+		t.Fatalf("second Shutdown failed: %v", err)
+	}
+
+	path := filepath.Join(sessionDir, "session-"+session.AuditLogger.SessionID()+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected the session to be saved: %v", err)
+	}
+	if !strings.Contains(string(data), "remember this") || !strings.Contains(string(data), "FS_READ") {
+		t.Errorf("expected messages and permissions in the saved session, got %s", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected the saved session to be private to the user, got %v", info.Mode().Perm())
+	}
+
+	if !session.AuditLogger.Closed() {
+		t.Error("expected the audit logger to be closed")
+	}
+	events, err := audit.ReadEvents(session.AuditLogger.FilePath(), audit.Filter{
+		Types: map[audit.EventType]bool{audit.EventTypeSession: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	var actions []string
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	if got := strings.Join(actions, ","); got != "START,SAVE,END" {
+		t.Errorf("expected START,SAVE,END session events, got %s", got)
+	}
+}
+
+func TestChatSession_SaveRedactsSecrets(t *testing.T) {
+	session := newTestSession(t)
+	session.AddUserMessage("call the api")
+	session.Messages = append(session.Messages, llm.NewAssistantActionMessage("http.get", map[string]any{
+		"url":     "https://example.com",
+		"api_key": "sk-secret",
+	}))
+
+	session.Redact = true
+	path, err := session.Save(t.TempDir())
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read saved session: %v", err)
+	}
+	if strings.Contains(string(data), "sk-secret") || !strings.Contains(string(data), "***redacted***") {
+		t.Errorf("expected the api key redacted, got %s", data)
+	}
+	if !strings.Contains(string(data), "https://example.com") {
+		t.Errorf("expected other arguments kept, got %s", data)
+	}
+}
+
+func TestChatSession_ShutdownWithoutPersistence(t *testing.T) {
+	session := newTestSession(t)
+	if session.SessionDir != "" {
+		t.Fatalf("expected persistence to be off by default, got %q", session.SessionDir)
+	}
+	if err := session.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if !session.AuditLogger.Closed() {
+		t.Error("expected the audit logger to be closed")
+	}
+}
+
+func TestAutoApproves(t *testing.T) {
+	cases := []struct {
+		safety     config.SafetyConfig
+		capability string
+		want       bool
+	}{
+		{config.SafetyConfig{}, "FS_READ", false},
+		{config.SafetyConfig{}, "FS_WRITE", false},
+		{config.SafetyConfig{AutoApproveReadOnly: true}, "FS_READ", true},
+		{config.SafetyConfig{AutoApproveReadOnly: true}, "FS_WRITE", false},
+		{config.SafetyConfig{AutoConfirmPermissions: true}, "FS_WRITE", true},
+	}
+	for _, tc := range cases {
+		if got := AutoApproves(tc.safety, tc.capability); got != tc.want {
+			t.Errorf("AutoApproves(%+v, %s) = %v, want %v", tc.safety, tc.capability, got, tc.want)
+		}
+	}
+}
+
+func TestChatTurn_CapsLoggedToolEvents(t *testing.T) {
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "goshi.yaml")
+	cfgData := "audit:\n  dir: " + filepath.Join(tmp, "audit") + "\n  max_tool_events_per_turn: 3\nbehavior:\n  max_steps_per_turn: 10\n"
+	if err := os.WriteFile(cfgPath, []byte(cfgData), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	t.Setenv("GOSHI_AUDIT_ENABLED", "true")
+	config.Reset()
+	defer config.Reset()
+
+	backend := &MockBackend{
+		Responses: []string{`{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`},
+	}
+	session, err := NewChatSession(context.Background(), "test", backend)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.AuditLogger.Close()
+	session.GrantPermission("FS_READ")
+
+	turn := session.NewTurn("keep listing", TurnHooks{
+		Act: func(action *llm.ActionCall) any {
+			return session.ToolRouter.Execute(app.ToolCall{Name: action.Tool, Args: action.Args})
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(turn.Tools) != 10 {
+		t.Fatalf("expected 10 tool steps, got %d", len(turn.Tools))
+	}
+
+	events, err := audit.ReadEvents(session.AuditLogger.FilePath(), audit.Filter{
+		Types: map[audit.EventType]bool{audit.EventTypeTool: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 3 tool events and a summary, got %d: %+v", len(events), events)
+	}
+	for _, e := range events[:3] {
+		if e.Action != "fs.list" {
+			t.Errorf("expected the first tool events to be kept, got %+v", e)
+		}
+	}
+	if summary := events[3]; summary.Action != "suppressed" || !strings.Contains(summary.Message, "...7 more suppressed") {
+		t.Errorf("expected a suppression summary for 7 events, got %+v", summary)
+	}
+}
+
+// failingBackend streams one chunk and then fails with err
+type failingBackend struct{ err error }
+
+func (b failingBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	return &MockStream{Data: []string{`{"type": "text", "text": "partial`}, Err: b.err}, nil
+}
+
+func TestChatTurn_PropagatesStreamError(t *testing.T) {
+	session := newTestSession(t)
+	session.Client = llm.NewClientWithTools(session.Client.System(), failingBackend{err: errors.New("connection reset")})
+
+	turn := session.NewTurn("hello", TurnHooks{})
+	err := turn.Run()
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("expected the stream error, got %v", err)
+	}
+	if !strings.Contains(turn.Raw, "partial") {
+		t.Errorf("expected the partial response kept, got %q", turn.Raw)
+	}
+	if _, ok := session.Messages[len(session.Messages)-1].(*llm.UserMessage); !ok {
+		t.Error("expected no assistant message recorded for a failed response")
+	}
+}
+
+func TestChatTurn_RunsAllPhases(t *testing.T) {
+	session := newTestSession(t)
+	backend := &finishBackend{streams: []*finishStream{
+		{text: `{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`},
+		{text: `{"type": "text", "text": "main.go and go.mod"}`},
+	}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	var phases []Phase
+	var detected []detect.Match
+	var chunks, acted int
+	turn := session.NewTurn("list the files", TurnHooks{
+		OnPhase: func(_ *ChatTurn, phase Phase) { phases = append(phases, phase) },
+		Detect: func(matches []detect.Match) bool {
+			detected = matches
+			return true
+		},
+		OnChunk: func(string) { chunks++ },
+		Act: func(action *llm.ActionCall) any {
+			acted++
+			return map[string]any{"result": "ok"}
+		},
+	})
+
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	want := []Phase{PhaseListen, PhaseDetect, PhasePlan, PhaseParse, PhaseAct, PhasePlan, PhaseParse, PhaseReport}
+	if fmt.Sprint(phases) != fmt.Sprint(want) || fmt.Sprint(turn.Phases) != fmt.Sprint(want) {
+		t.Errorf("expected phases %v, got hooks=%v turn=%v", want, phases, turn.Phases)
+	}
+	if turn.Phase() != PhaseReport {
+		t.Errorf("expected turn to end in report, got %s", turn.Phase())
+	}
+	if len(detected) != 1 || detected[0].Capability != detect.CapabilityFSRead || detected[0].Verb != "list" || detected[0].Noun != "files" {
+		t.Errorf("expected the triggering rule match passed to Detect, got %+v", detected)
+	}
+	if len(turn.Detected) != 1 || turn.Detected[0] != detect.CapabilityFSRead {
+		t.Errorf("expected FS_READ detected, got %v", turn.Detected)
+	}
+	if chunks != 2 || acted != 1 {
+		t.Errorf("expected 2 chunks and 1 act call, got %d and %d", chunks, acted)
+	}
+	if _, ok := session.Messages[len(session.Messages)-2].(*llm.ToolResultMessage); !ok {
+		t.Error("expected the tool result recorded in history")
+	}
+}
+
+func TestChatTurn_FeedsToolResultsBack(t *testing.T) {
+	session := newTestSession(t)
+	backend := &finishBackend{streams: []*finishStream{
+		{text: `{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`},
+		{text: `{"type": "action", "action": {"tool": "fs.read", "args": {"path": "go.mod"}}}`},
+		{text: `{"type": "text", "text": "The module is example.com/demo."}`},
+	}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	var acted []string
+	turn := session.NewTurn("what is the module name?", TurnHooks{
+		Act: func(action *llm.ActionCall) any {
+			acted = append(acted, action.Tool)
+			return map[string]any{"result": action.Tool + " ok"}
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if fmt.Sprint(acted) != "[fs.list fs.read]" || fmt.Sprint(turn.Tools) != "[fs.list fs.read]" {
+		t.Errorf("expected both tools run in order, got acted=%v tools=%v", acted, turn.Tools)
+	}
+	if turn.Response == nil || turn.Response.Text != "The module is example.com/demo." || turn.LimitReached {
+		t.Fatalf("expected the turn to end with the final text, got %+v", turn.Response)
+	}
+	if len(backend.requests) != 3 {
+		t.Fatalf("expected 3 model calls, got %d", len(backend.requests))
+	}
+
+	// Each call after a tool ends with that tool's result
+	for i, tool := range []string{"fs.list", "fs.read"} {
+		sent := backend.requests[i+1]
+		if last := sent[len(sent)-1]; !strings.Contains(last.Content, tool+" ok") {
+			t.Errorf("expected call %d to carry the %s result, got %+v", i+2, tool, last)
+		}
+	}
+	last, ok := session.Messages[len(session.Messages)-1].(*llm.AssistantTextMessage)
+	if !ok || last.Content != "The module is example.com/demo." {
+		t.Errorf("expected the final text recorded last, got %+v", session.Messages[len(session.Messages)-1])
+	}
+}
+
+func TestChatTurn_StopsAtStepLimit(t *testing.T) {
+	session := newTestSession(t)
+	session.MaxSteps = 2
+	backend := &MockBackend{
+		Responses: []string{`{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`},
+	}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	acted := 0
+	turn := session.NewTurn("keep listing", TurnHooks{
+		Act: func(*llm.ActionCall) any {
+			acted++
+			return map[string]any{"result": "ok"}
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if acted != 2 || backend.CallCount != 3 || !turn.LimitReached {
+		t.Errorf("expected 2 tools over 3 calls then the limit, got acted=%d calls=%d limit=%v", acted, backend.CallCount, turn.LimitReached)
+	}
+	if turn.Response == nil || !strings.Contains(turn.Response.Text, "2 tool steps") {
+		t.Errorf("expected the step-limit summary as the response, got %+v", turn.Response)
+	}
+	last, ok := session.Messages[len(session.Messages)-1].(*llm.AssistantTextMessage)
+	if !ok || last.Content != turn.Response.Text {
+		t.Error("expected the summary recorded as the final assistant message")
+	}
+}
+
+func TestChatTurn_DetectCanStopTurn(t *testing.T) {
+	session := newTestSession(t)
+	backend := &MockBackend{Responses: []string{`{"type": "text", "text": "hi"}`}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	turn := session.NewTurn("read main.go", TurnHooks{
+		Detect: func([]detect.Match) bool { return false },
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !turn.Stopped || turn.Phase() != PhaseDetect {
+		t.Errorf("expected turn stopped in detect, got stopped=%v phase=%s", turn.Stopped, turn.Phase())
+	}
+	if backend.CallCount != 0 {
+		t.Errorf("expected no model calls, got %d", backend.CallCount)
+	}
+}
+
+func TestChatTurn_DenialIsFedBackToModel(t *testing.T) {
+	session := newTestSession(t)
+	session.ContinueAfterDenial = true
+	backend := &finishBackend{streams: []*finishStream{
+		{text: `{"type": "text", "text": "Without file access I can't read main.go; paste it here and I'll review it."}`},
+	}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	// The user refuses the detected read
+	turn := session.NewTurn("read main.go", TurnHooks{
+		Detect: func([]detect.Match) bool {
+			session.DenyPermission("FS_READ")
+			return false
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if turn.Stopped || fmt.Sprint(turn.Denied) != "[FS_READ]" {
+		t.Fatalf("expected the turn to go on after the denial, got stopped=%v denied=%v", turn.Stopped, turn.Denied)
+	}
+	if len(backend.requests) != 1 {
+		t.Fatalf("expected the model to be called, got %d calls", len(backend.requests))
+	}
+	sent := backend.requests[0]
+	if last := sent[len(sent)-1]; last.Role != "system" || last.Content != DenialNote("FS_READ") {
+		t.Errorf("expected the denial note sent last, got %+v", last)
+	}
+	if turn.Response == nil || !strings.Contains(turn.Response.Text, "paste it here") {
+		t.Errorf("expected the model's alternative answer, got %+v", turn.Response)
+	}
+	if session.TurnCount() != 1 {
+		t.Errorf("expected the note not to count as a user turn, got %d turns", session.TurnCount())
+	}
+	if denied := session.TakeDenials(); len(denied) != 0 {
+		t.Errorf("expected the denial to be consumed, got %v", denied)
+	}
+}
+
+func TestChatTurn_DenialStopsTurnByDefault(t *testing.T) {
+	session := newTestSession(t)
+	backend := &MockBackend{Responses: []string{`{"type": "text", "text": "hi"}`}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	turn := session.NewTurn("read main.go", TurnHooks{
+		Detect: func([]detect.Match) bool {
+			session.DenyPermission("FS_READ")
+			return false
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !turn.Stopped || backend.CallCount != 0 || len(turn.Denied) != 0 {
+		t.Errorf("expected the denial to end the turn, got stopped=%v calls=%d denied=%v", turn.Stopped, backend.CallCount, turn.Denied)
+	}
+}
+
+// denyAllPrompter refuses every permission request
+type denyAllPrompter struct{}
+
+func (denyAllPrompter) Ask(string, string) bool { return false }
+
+func TestChatTurn_ToolDenialStopsTurnByDefault(t *testing.T) {
+	session := newTestSession(t)
+	session.Prompter = denyAllPrompter{}
+	backend := &MockBackend{
+		Responses: []string{`{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`},
+	}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	turn := session.NewTurn("show me around", TurnHooks{
+		Act: func(action *llm.ActionCall) any {
+			session.RequestToolPermission(action.Tool)
+			return session.ToolRouter.Execute(app.ToolCall{Name: action.Tool, Args: action.Args})
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !turn.Stopped || backend.CallCount != 1 || len(turn.Tools) != 1 {
+		t.Errorf("expected the denied tool to end the turn, got stopped=%v calls=%d tools=%v", turn.Stopped, backend.CallCount, turn.Tools)
+	}
+}
+
+func TestChatTurn_ToolDenialIsFedBackToModel(t *testing.T) {
+	session := newTestSession(t)
+	session.Prompter = denyAllPrompter{}
+	session.ContinueAfterDenial = true
+	backend := &finishBackend{streams: []*finishStream{
+		{text: `{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`},
+		{text: `{"type": "text", "text": "I can't list files without access."}`},
+	}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	turn := session.NewTurn("show me around", TurnHooks{
+		Act: func(action *llm.ActionCall) any {
+			session.RequestToolPermission(action.Tool)
+			return session.ToolRouter.Execute(app.ToolCall{Name: action.Tool, Args: action.Args})
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if turn.Stopped || fmt.Sprint(turn.Denied) != "[FS_READ]" {
+		t.Fatalf("expected the turn to go on after the denial, got stopped=%v denied=%v", turn.Stopped, turn.Denied)
+	}
+	sent := backend.requests[1]
+	if last := sent[len(sent)-1]; last.Content != DenialNote("FS_READ") {
+		t.Errorf("expected the denial note sent after the tool result, got %+v", last)
+	}
+	if turn.Text() != "I can't list files without access." {
+		t.Errorf("expected the model's answer, got %q", turn.Text())
+	}
+}
+
+func TestChatTurn_TextSkipsAct(t *testing.T) {
+	session := newTestSession(t)
+	backend := &MockBackend{Responses: []string{`{"type": "text", "text": "all done"}`}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	turn := session.NewTurn("hello", TurnHooks{})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	want := []Phase{PhaseListen, PhaseDetect, PhasePlan, PhaseParse, PhaseReport}
+	if fmt.Sprint(turn.Phases) != fmt.Sprint(want) {
+		t.Errorf("expected phases %v, got %v", want, turn.Phases)
+	}
+	last, ok := session.Messages[len(session.Messages)-1].(*llm.AssistantTextMessage)
+	if !ok || last.Content != "all done" {
+		t.Error("expected the text reply recorded in history")
+	}
+}
+
+func TestChatTurn_AppliesPostProcessors(t *testing.T) {
+	session := newTestSession(t)
+	strip, _ := llm.BuiltinResponseProcessor(llm.ProcessorStripThinking)
+	session.PostProcessors = llm.NewResponsePipeline(strip)
+	backend := &MockBackend{Responses: []string{`{"type": "text", "text": "<think>draft</think>all done"}`}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	var reported string
+	turn := session.NewTurn("hello", TurnHooks{
+		OnPhase: func(turn *ChatTurn, phase Phase) {
+			if phase == PhaseReport {
+				reported = turn.Text()
+			}
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if reported != "all done" || turn.Text() != "all done" {
+		t.Errorf("expected the processed answer reported, got %q and %q", reported, turn.Text())
+	}
+	last, ok := session.Messages[len(session.Messages)-1].(*llm.AssistantTextMessage)
+	if !ok || last.Content != "all done" {
+		t.Errorf("expected the processed answer in history, got %+v", session.Messages[len(session.Messages)-1])
+	}
+}
+
+// refusingBackend streams nothing and ends with a refusal
+type refusingBackend struct{}
+
+func (refusingBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	return &refusingStream{}, nil
+}
+
+type refusingStream struct{}
+
+func (*refusingStream) Recv() (string, error) {
+	return "", &llm.RefusalError{Refusal: "I can't help with that."}
+}
+func (*refusingStream) Close() error { return nil }
+
+func TestChatTurn_RefusalBecomesErrorResponse(t *testing.T) {
+	session := newTestSession(t)
+	session.Client = llm.NewClientWithTools(session.Client.System(), refusingBackend{})
+
+	turn := session.NewTurn("do something bad", TurnHooks{})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if turn.Response == nil || turn.Response.Type != llm.ResponseTypeError {
+		t.Fatalf("expected an error response, got %+v", turn.Response)
+	}
+	if !strings.Contains(turn.Response.Error, "I can't help with that.") {
+		t.Errorf("expected the refusal text in the error, got %q", turn.Response.Error)
+	}
+}
+
+func TestAddToolResultMessageSummarizesLargeResults(t *testing.T) {
+	sess := newTestSession(t)
+	sess.ResultLines = 3
+
+	var content strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	result := app.ToolResult{
+		Success: true,
+		Value:   runtime.ActionOutput{"path": "big.txt", "content": content.String(), "size": content.Len()},
+	}
+
+	sess.AddToolResultMessage("fs.read", result)
+
+	msg, ok := sess.Messages[len(sess.Messages)-1].(*llm.ToolResultMessage)
+	if !ok {
+		t.Fatalf("expected a tool result message, got %T", sess.Messages[len(sess.Messages)-1])
+	}
+	sent := msg.Result.(runtime.ActionOutput)
+	want := "line 1\nline 2\nline 3\n[truncated: showing 3 of 10 lines]"
+	if sent["content"] != want {
+		t.Errorf("expected the content summarized for the model, got %q", sent["content"])
+	}
+	if sent["path"] != "big.txt" || sent["size"] != content.Len() {
+		t.Errorf("expected other fields sent unchanged, got %v", sent)
+	}
+
+	// The caller's result, which the UI renders, keeps the full content
+	if full := result.Value.(runtime.ActionOutput)["content"]; full != content.String() {
+		t.Errorf("expected the full result to stay available, got %q", full)
+	}
+}
+
+func TestAddToolResultMessageSendsSmallResultsInFull(t *testing.T) {
+	sess := newTestSession(t)
+	sess.ResultLines = 3
+
+	sess.AddToolResultMessage("fs.read", app.ToolResult{
+		Success: true,
+		Value:   runtime.ActionOutput{"content": "one\ntwo\nthree\n"},
+	})
+
+	msg := sess.Messages[len(sess.Messages)-1].(*llm.ToolResultMessage)
+	if got := msg.Result.(runtime.ActionOutput)["content"]; got != "one\ntwo\nthree\n" {
+		t.Errorf("expected a result within the limit sent in full, got %q", got)
+	}
+}
+
+// finishStream is a single-chunk stream that reports a finish reason
+type finishStream struct {
+	text   string
+	reason string
+	sent   bool
+}
+
+func (s *finishStream) Recv() (string, error) {
+	if s.sent {
+		return "", io.EOF
+	}
+	s.sent = true
+	return s.text, nil
+}
+
+func (s *finishStream) Close() error         { return nil }
+func (s *finishStream) FinishReason() string { return s.reason }
+
+// finishBackend returns its scripted streams in order, recording the
+// messages each request was sent
+type finishBackend struct {
+	streams  []*finishStream
+	requests [][]llm.Message
+}
+
+func (b *finishBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	b.requests = append(b.requests, messages)
+	next := b.streams[0]
+	b.streams = b.streams[1:]
+	return next, nil
+}
+
+func TestContinueTurnMergesTruncatedResponse(t *testing.T) {
+	sess := newTestSession(t)
+	backend := &finishBackend{streams: []*finishStream{
+		{text: "The three steps are: first, back up", reason: llm.FinishReasonLength},
+		{text: " the files; second, migrate; third, verify.", reason: "stop"},
+	}}
+	sess.Client = llm.NewClientWithTools(sess.Client.System(), backend)
+
+	first := sess.NewTurn("list the migration steps", TurnHooks{})
+	if err := first.Run(); err != nil {
+		t.Fatalf("first turn failed: %v", err)
+	}
+	if first.FinishReason != llm.FinishReasonLength || !sess.CanContinue() {
+		t.Fatalf("expected a truncated response that can be continued, got finish %q", first.FinishReason)
+	}
+
+	cont := sess.NewContinueTurn(TurnHooks{})
+	if err := cont.Run(); err != nil {
+		t.Fatalf("continue turn failed: %v", err)
+	}
+	if sess.CanContinue() {
+		t.Error("expected a complete continuation to clear the truncated state")
+	}
+
+	// The continuation request carries the continue instruction
+	req := backend.requests[1]
+	if last := req[len(req)-1]; last.Role != "user" || last.Content != ContinuePrompt {
+		t.Errorf("expected the continue instruction last, got %+v", last)
+	}
+
+	// The history holds one merged answer and no extra user message
+	if len(sess.Messages) != 2 {
+		t.Fatalf("expected the user message and one merged answer, got %d messages", len(sess.Messages))
+	}
+	answer, ok := sess.Messages[1].(*llm.AssistantTextMessage)
+	if !ok {
+		t.Fatalf("expected an assistant text message, got %T", sess.Messages[1])
+	}
+	want := "The three steps are: first, back up the files; second, migrate; third, verify."
+	if answer.Content != want {
+		t.Errorf("expected the merged answer %q, got %q", want, answer.Content)
+	}
+}
+
+func TestChatTurn_ExplainBeforeActRequiresExplanation(t *testing.T) {
+	session := newTestSession(t)
+	session.ExplainBeforeAct = true
+	backend := &finishBackend{streams: []*finishStream{
+		{text: `{"type": "action", "action": {"tool": "fs.read", "args": {"path": "go.mod"}}}`},
+		{text: `{"type": "text", "text": "I will read go.mod to find the module name."}`},
+		{text: `{"type": "action", "action": {"tool": "fs.read", "args": {"path": "go.mod"}}}`},
+		{text: `{"type": "text", "text": "The module is example.com/demo."}`},
+	}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	var acted []string
+	turn := session.NewTurn("what is the module name?", TurnHooks{
+		Act: func(action *llm.ActionCall) any {
+			acted = append(acted, action.Tool)
+			return map[string]any{"result": "module example.com/demo"}
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// The bare action is refused and the model asked to explain it
+	if turn.Unexplained != 1 {
+		t.Fatalf("expected one refused action, got %d", turn.Unexplained)
+	}
+	sent := backend.requests[1]
+	if last := sent[len(sent)-1]; last.Role != "user" || last.Content != ExplainPrompt {
+		t.Errorf("expected the re-prompt for an explanation, got %+v", last)
+	}
+
+	// Once the explanation has been recorded, the model may act
+	sent = backend.requests[2]
+	if last := sent[len(sent)-1]; last.Role != "user" || last.Content != ExplainedPrompt {
+		t.Errorf("expected the model told to go ahead, got %+v", last)
+	}
+	if fmt.Sprint(acted) != "[fs.read]" {
+		t.Errorf("expected the explained action to run once, got %v", acted)
+	}
+	if turn.Response == nil || turn.Response.Text != "The module is example.com/demo." {
+		t.Errorf("expected the final answer, got %+v", turn.Response)
+	}
+
+	// The explanation precedes the action in the history; the refused
+	// action stays out of it
+	var kinds []string
+	for _, msg := range session.Messages {
+		switch msg := msg.(type) {
+		case *llm.AssistantTextMessage:
+			kinds = append(kinds, "text:"+msg.Content)
+		case *llm.AssistantActionMessage:
+			kinds = append(kinds, "action")
+		}
+	}
+	want := "[text:I will read go.mod to find the module name. action text:The module is example.com/demo.]"
+	if fmt.Sprint(kinds) != want {
+		t.Errorf("expected history %s, got %v", want, kinds)
+	}
+}
+
+func TestChatTurn_ExplainBeforeActIgnoresEarlierReplies(t *testing.T) {
+	session := newTestSession(t)
+	session.ExplainBeforeAct = true
+	session.AddUserMessage("hello")
+	session.AddAssistantTextMessage("Hi! How can I help?")
+	backend := &MockBackend{
+		Responses: []string{`{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`},
+	}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	acted := 0
+	turn := session.NewTurn("list the files", TurnHooks{
+		Act: func(*llm.ActionCall) any {
+			acted++
+			return map[string]any{"result": "ok"}
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// A reply from an earlier exchange does not explain this action
+	if acted != 0 || turn.Unexplained == 0 {
+		t.Errorf("expected the bare action refused, got acted=%d unexplained=%d", acted, turn.Unexplained)
+	}
+}
+
+func TestChatTurn_ExplainBeforeActGivesUp(t *testing.T) {
+	session := newTestSession(t)
+	session.ExplainBeforeAct = true
+	backend := &MockBackend{
+		Responses: []string{`{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`},
+	}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+
+	acted := 0
+	turn := session.NewTurn("list the files", TurnHooks{
+		Act: func(*llm.ActionCall) any {
+			acted++
+			return map[string]any{"result": "ok"}
+		},
+	})
+	if err := turn.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if acted != 0 || backend.CallCount != MaxExplainPrompts+1 {
+		t.Errorf("expected no tools over %d calls, got acted=%d calls=%d", MaxExplainPrompts+1, acted, backend.CallCount)
+	}
+	if turn.Response == nil || turn.Response.Type != llm.ResponseTypeError || !strings.Contains(turn.Response.Error, "fs.list") {
+		t.Errorf("expected a refusal naming the tool, got %+v", turn.Response)
+	}
+}
@@ -0,0 +1,66 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cshaiku/goshi/internal/actions/runtime"
+)
+
+// summarizeToolValue returns a copy of a tool result value with every
+// string longer than maxLines lines cut down to its first maxLines lines
+// plus a note saying how much was left out. Maps and slices are always
+// copied, so the original is never modified.
+// A maxLines of zero or less returns the value unchanged.
+func summarizeToolValue(v any, maxLines int) any {
+	if maxLines <= 0 {
+		return v
+	}
+	switch val := v.(type) {
+	case string:
+		return truncateLines(val, maxLines)
+	case runtime.ActionOutput:
+		return runtime.ActionOutput(summarizeMap(val, maxLines))
+	case map[string]any:
+		return summarizeMap(val, maxLines)
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = summarizeToolValue(item, maxLines)
+		}
+		return out
+	case []map[string]any:
+		out := make([]map[string]any, len(val))
+		for i, item := range val {
+			out[i] = summarizeMap(item, maxLines)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// summarizeMap summarizes each value of a result map into a new map
+func summarizeMap(m map[string]any, maxLines int) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, item := range m {
+		out[k] = summarizeToolValue(item, maxLines)
+	}
+	return out
+}
+
+// truncateLines keeps the first maxLines lines of s, noting what was cut
+func truncateLines(s string, maxLines int) string {
+	lines := strings.SplitAfter(s, "\n")
+	if strings.HasSuffix(s, "\n") {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= maxLines {
+		return s
+	}
+	kept := strings.Join(lines[:maxLines], "")
+	if !strings.HasSuffix(kept, "\n") {
+		kept += "\n"
+	}
+	return kept + fmt.Sprintf("[truncated: showing %d of %d lines]", maxLines, len(lines))
+}
@@ -0,0 +1,169 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// Title modes (behavior.auto_title)
+const (
+	TitleOff       = "off"       // No title; saved sessions keep their plain names
+	TitleHeuristic = "heuristic" // Derived from the first user message
+	TitleModel     = "model"     // Asked of the backend after the first exchange
+)
+
+// Title bounds
+const (
+	maxTitleWords = 6
+	maxTitleRunes = 60
+	maxSlugRunes  = 40
+)
+
+// titlePrompt asks the backend for a title instead of a reply
+const titlePrompt = "Reply with a short title (at most 6 words) summarizing the conversation below. Reply with the title only: no quotes, no punctuation at the end, no JSON."
+
+// Title returns a short title for the conversation, generating it once the
+// first user message has been answered. Until then, and when TitleMode is
+// off, it returns "". A generated title is kept for the rest of the session
+// and used to name saved sessions.
+func (s *ChatSession) Title(ctx context.Context) (string, error) {
+	if s.title != "" || s.TitleMode == TitleOff || s.TitleMode == "" {
+		return s.title, nil
+	}
+
+	user, assistant, ok := s.FirstExchange()
+	if !ok {
+		return "", nil
+	}
+	title, err := s.TitleFor(ctx, user, assistant)
+	if err != nil {
+		return "", err
+	}
+	s.title = title
+	return s.title, nil
+}
+
+// TitleFor titles an exchange taken from FirstExchange, without reading or
+// changing the history, so a caller can generate the title in the
+// background and hand it to SetTitle. It returns "" when TitleMode is off.
+func (s *ChatSession) TitleFor(ctx context.Context, user, assistant string) (string, error) {
+	switch s.TitleMode {
+	case TitleHeuristic:
+		return heuristicTitle(user), nil
+	case TitleModel:
+		generated, err := s.generateTitle(ctx, user, assistant)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate title: %w", err)
+		}
+		if generated == "" {
+			return heuristicTitle(user), nil
+		}
+		return generated, nil
+	}
+	return "", nil
+}
+
+// SetTitle sets the conversation title, e.g. one generated by TitleFor
+func (s *ChatSession) SetTitle(title string) {
+	s.title = title
+}
+
+// FirstExchange returns the first user message and the assistant text
+// that answered it; ok is false until there is one
+func (s *ChatSession) FirstExchange() (user, assistant string, ok bool) {
+	for _, msg := range s.Messages {
+		switch m := msg.(type) {
+		case *llm.UserMessage:
+			if user == "" {
+				user = m.Content
+			}
+		case *llm.AssistantTextMessage:
+			if user != "" {
+				return user, m.Content, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// generateTitle asks the backend to title the first exchange
+func (s *ChatSession) generateTitle(ctx context.Context, user, assistant string) (string, error) {
+	stream, err := s.Client.Backend().Stream(ctx, titlePrompt, []llm.Message{
+		{Role: "user", Content: "User: " + user + "\n\nAssistant: " + assistant},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	var sb strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(chunk)
+	}
+
+	// Models prompted for structured output may still wrap the title
+	raw := sb.String()
+	if resp, err := llm.NewStructuredParser().ParseAndValidate(raw); err == nil && resp.Type == llm.ResponseTypeText {
+		raw = resp.Text
+	}
+	return cleanTitle(raw), nil
+}
+
+// heuristicTitle derives a title from the first words of a message
+func heuristicTitle(message string) string {
+	words := strings.Fields(message)
+	if len(words) > maxTitleWords {
+		words = words[:maxTitleWords]
+	}
+	return cleanTitle(strings.Join(words, " "))
+}
+
+// cleanTitle reduces a model reply to a single trimmed line of bounded length
+func cleanTitle(raw string) string {
+	line := strings.TrimSpace(raw)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	line = strings.Join(strings.Fields(line), " ")
+	line = strings.TrimPrefix(line, "Title:")
+	line = strings.Trim(line, " \"'`*.!?:;,")
+
+	if runes := []rune(line); len(runes) > maxTitleRunes {
+		line = strings.TrimSpace(string(runes[:maxTitleRunes]))
+	}
+	return line
+}
+
+// titleSlug turns a title into a lowercase, hyphenated file name component
+func titleSlug(title string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := []rune(strings.TrimSuffix(sb.String(), "-"))
+	if len(slug) > maxSlugRunes {
+		slug = slug[:maxSlugRunes]
+	}
+	return strings.Trim(string(slug), "-")
+}
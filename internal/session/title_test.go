@@ -0,0 +1,153 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+func TestChatSession_TitleFromModelNamesSavedSession(t *testing.T) {
+	session := newTestSession(t)
+	backend := &MockBackend{Responses: []string{`"Fixing the flaky build."`}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+	session.TitleMode = TitleModel
+
+	// No title until the first exchange is complete
+	session.AddUserMessage("why does CI fail on main?")
+	if title, err := session.Title(context.Background()); err != nil || title != "" {
+		t.Fatalf("expected no title before a reply, got %q (%v)", title, err)
+	}
+	if backend.CallCount != 0 {
+		t.Fatalf("expected no title request before a reply, got %d", backend.CallCount)
+	}
+
+	session.AddAssistantTextMessage("A test depends on the wall clock.")
+	title, err := session.Title(context.Background())
+	if err != nil {
+		t.Fatalf("Title failed: %v", err)
+	}
+	if title != "Fixing the flaky build" {
+		t.Errorf("expected cleaned model title, got %q", title)
+	}
+
+	// The title is generated once
+	if again, _ := session.Title(context.Background()); again != title || backend.CallCount != 1 {
+		t.Errorf("expected the cached title after 1 request, got %q after %d", again, backend.CallCount)
+	}
+
+	path, err := session.Save(t.TempDir())
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if !strings.HasSuffix(filepath.Base(path), "-fixing-the-flaky-build.json") {
+		t.Errorf("expected the title in the saved file name, got %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read saved session: %v", err)
+	}
+	var saved struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Title != title {
+		t.Errorf("expected title %q in the saved session, got %q (%v)", title, saved.Title, err)
+	}
+}
+
+func TestChatSession_TitleHeuristic(t *testing.T) {
+	session := newTestSession(t)
+	backend := &MockBackend{}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+	session.TitleMode = TitleHeuristic
+
+	session.AddUserMessage("  please   list every Go file in internal/tui and explain it")
+	session.AddAssistantTextMessage("Sure.")
+
+	title, err := session.Title(context.Background())
+	if err != nil {
+		t.Fatalf("Title failed: %v", err)
+	}
+	if title != "please list every Go file in" {
+		t.Errorf("expected the first words of the message, got %q", title)
+	}
+	if backend.CallCount != 0 {
+		t.Errorf("expected no model request, got %d", backend.CallCount)
+	}
+}
+
+func TestChatSession_TitleReportsStreamErrors(t *testing.T) {
+	session := newTestSession(t)
+	backend := &MockBackend{Responses: []string{"Half a ti"}, StreamErr: errors.New("connection reset")}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+	session.TitleMode = TitleModel
+	session.AddUserMessage("why does CI fail on main?")
+	session.AddAssistantTextMessage("A test depends on the wall clock.")
+
+	// A cut-off reply must not become the title
+	if title, err := session.Title(context.Background()); err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("expected the stream error, got %q (%v)", title, err)
+	}
+	if title, _ := session.Title(context.Background()); title != "" {
+		t.Errorf("expected no title kept after a failed request, got %q", title)
+	}
+}
+
+func TestChatSession_ShutdownDoesNotAskModelForTitle(t *testing.T) {
+	session := newTestSession(t)
+	backend := &MockBackend{Responses: []string{"Model title"}}
+	session.Client = llm.NewClientWithTools(session.Client.System(), backend)
+	session.TitleMode = TitleModel
+	session.SessionDir = t.TempDir()
+	session.AddUserMessage("why does CI fail on main?")
+	session.AddAssistantTextMessage("A test depends on the wall clock.")
+
+	if err := session.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if backend.CallCount != 0 {
+		t.Errorf("expected no title request while shutting down, got %d", backend.CallCount)
+	}
+	entries, err := os.ReadDir(session.SessionDir)
+	if err != nil || len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), "-why-does-ci-fail-on-main.json") {
+		t.Errorf("expected the session named after its first message, got %v (%v)", entries, err)
+	}
+}
+
+func TestChatSession_TitleOff(t *testing.T) {
+	session := newTestSession(t)
+	session.TitleMode = TitleOff
+	session.AddUserMessage("hello")
+	session.AddAssistantTextMessage("hi")
+
+	if title, err := session.Title(context.Background()); err != nil || title != "" {
+		t.Errorf("expected no title when off, got %q (%v)", title, err)
+	}
+	path, err := session.Save(t.TempDir())
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if strings.Count(filepath.Base(path), "-") != 1 {
+		t.Errorf("expected an untitled file name, got %s", filepath.Base(path))
+	}
+}
+
+func TestTitleSlug(t *testing.T) {
+	tests := map[string]string{
+		"Fixing the flaky build":    "fixing-the-flaky-build",
+		"  C++ / Go: interop?! ":    "c-go-interop",
+		"Ünïcode Titles Stay":       "ünïcode-titles-stay",
+		strings.Repeat("word ", 20): "word-word-word-word-word-word-word-word",
+		"":                          "",
+	}
+	for in, want := range tests {
+		if got := titleSlug(in); got != want {
+			t.Errorf("titleSlug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cshaiku/goshi/internal/detect"
+	"github.com/cshaiku/goshi/internal/llm"
+)
+
+// Phase is one stage of a chat turn
+type Phase string
+
+const (
+	PhaseListen Phase = "listen" // Record user input
+	PhaseDetect Phase = "detect" // Detect implicit capability requests
+	PhasePlan   Phase = "plan"   // Stream the model response
+	PhaseParse  Phase = "parse"  // Parse the structured response
+	PhaseAct    Phase = "act"    // Execute a requested tool call
+	PhaseReport Phase = "report" // Record the outcome in history
+)
+
+// TurnHooks customize a ChatTurn. All hooks are optional.
+type TurnHooks struct {
+	// OnPhase is called when the turn enters a phase
+	OnPhase func(turn *ChatTurn, phase Phase)
+
+	// Detect handles the rule matches behind detected capabilities (e.g. by
+	// prompting for permission). Returning false ends the turn before the
+	// model is called.
+	Detect func(matches []detect.Match) bool
+
+	// OnChunk receives each streamed response chunk during Plan
+	OnChunk func(chunk string)
+
+	// Act executes a requested tool call and returns its result. Without
+	// it, actions are parsed but not executed.
+	Act func(action *llm.ActionCall) any
+}
+
+// ContinuePrompt asks the model to pick up a response cut off by max_tokens
+const ContinuePrompt = "[Your previous response was cut off by the output token limit. Continue exactly where it stopped, without repeating any text.]"
+
+// ChatTurn drives a single user turn through the Listen, Detect, Plan,
+// Parse, Act and Report phases. Plan, Parse and Act repeat while the model
+// asks for tools, each result being fed back to it, until it answers or the
+// per-turn step limit is reached. Every phase transition is audited.
+type ChatTurn struct {
+	Input               string
+	ClarificationAnswer bool // Input answers a clarification request
+	Continue            bool // Extend the previous truncated response instead of answering Input
+
+	Phases       []Phase                 // Phases entered, in order
+	Detected     []detect.Capability     // Capabilities detected from the input
+	Matches      []detect.Match          // Rule matches behind each detected capability
+	Raw          string                  // Full raw output of the last model response
+	Response     *llm.StructuredResponse // Last parsed response (nil if parsing failed)
+	ToolResult   any                     // Result of the last tool run, if any
+	Tools        []string                // Tools run during the turn, in order
+	LimitReached bool                    // True if the step limit ended the turn
+	Unexplained  int                     // Tool calls refused for lack of an explanation
+	Stopped      bool                    // True if a permission denial ended the turn early
+	Denied       []string                // Capabilities denied during the turn, when it went on without them
+
+	// Details of the last model response, when the backend reports them
+	FinishReason  string         // Why the model stopped
+	Reasoning     string         // Reasoning streamed separately from the answer
+	Confidence    float64        // Confidence score of the response
+	HasConfidence bool           // Confidence was reported
+	Usage         llm.TokenUsage // Tokens used by the request
+	HasUsage      bool           // Usage was reported
+
+	session   *ChatSession
+	hooks     TurnHooks
+	collector *llm.ResponseCollector // Collects the response being planned
+	streamErr error                  // Error that ended the last stream
+	explain   bool                   // The model was asked to explain a refused tool call
+	proceed   bool                   // The model's explanation was shown; it may now act
+}
+
+// NewTurn creates a turn for the given user input
+func (s *ChatSession) NewTurn(input string, hooks TurnHooks) *ChatTurn {
+	return &ChatTurn{
+		Input:   input,
+		session: s,
+		hooks:   hooks,
+	}
+}
+
+// NewContinueTurn creates a turn that asks the model to continue its
+// previous response, which was cut off by the output token limit. The
+// continuation is appended to that response in the history.
+func (s *ChatSession) NewContinueTurn(hooks TurnHooks) *ChatTurn {
+	return &ChatTurn{
+		Continue: true,
+		session:  s,
+		hooks:    hooks,
+	}
+}
+
+// Phase returns the phase the turn is in (empty before Run)
+func (t *ChatTurn) Phase() Phase {
+	if len(t.Phases) == 0 {
+		return ""
+	}
+	return t.Phases[len(t.Phases)-1]
+}
+
+// enter records a phase transition and notifies the OnPhase hook
+func (t *ChatTurn) enter(phase Phase) {
+	t.Phases = append(t.Phases, phase)
+	if t.session.AuditLogger != nil {
+		t.session.AuditLogger.LogSession("TURN_PHASE", fmt.Sprintf("turn phase: %s", phase), t.session.WorkingDir)
+	}
+	if t.hooks.OnPhase != nil {
+		t.hooks.OnPhase(t, phase)
+	}
+}
+
+// Run drives the turn through its phases. It returns an error if the
+// model could not be reached or its response stream failed; Raw then holds
+// whatever streamed before the failure.
+func (t *ChatTurn) Run() error {
+	s := t.session
+	if s.AuditLogger != nil {
+		defer s.AuditLogger.EndTurn(s.WorkingDir)
+	}
+
+	// PHASE 1: Listen - Record user input
+	t.enter(PhaseListen)
+	switch {
+	case t.Continue:
+		// Nothing new to record; the continuation extends the last answer
+	case t.ClarificationAnswer:
+		s.AddClarificationAnswer(t.Input)
+	default:
+		s.AddUserMessage(t.Input)
+	}
+
+	// PHASE 2: Detect intent - Check for implicit capability requests
+	// This is a transition mechanism; eventually LLM should handle all intent
+	t.enter(PhaseDetect)
+	t.Matches = nil
+	if !t.Continue {
+		t.Matches = detect.DetectMatches(t.Input, detect.FSReadRules)
+		t.Matches = append(t.Matches, detect.DetectMatches(t.Input, detect.FSWriteRules)...)
+	}
+	t.Detected = nil
+	for _, match := range t.Matches {
+		t.Detected = append(t.Detected, match.Capability)
+	}
+	s.TakeDenials()
+	if t.hooks.Detect != nil && !t.hooks.Detect(t.Matches) {
+		if !s.ContinueAfterDenial {
+			t.Stopped = true
+			return nil
+		}
+		// Tell the model what was refused so it can try another approach
+		t.Denied = s.TakeDenials()
+		for _, capability := range t.Denied {
+			s.AddDenialNote(capability)
+		}
+	}
+
+	// PHASES 3-5 repeat while the model asks for tools
+	for {
+		if err := t.plan(); err != nil {
+			return err
+		}
+		t.parse()
+
+		resp := t.Response
+
+		// The explanation the model was asked for is shown to the user and
+		// recorded, then the model is asked again to make its tool call
+		if t.explain && resp != nil && resp.Type == llm.ResponseTypeText && resp.Text != "" {
+			resp.Text = s.PostProcessors.Process(resp.Text)
+			s.AddAssistantTextMessage(resp.Text)
+			t.explain, t.proceed = false, true
+			continue
+		}
+		if resp == nil || resp.Type != llm.ResponseTypeAction || resp.Action == nil {
+			break
+		}
+
+		// In explain-before-act mode, refuse tool calls the user has not
+		// seen explained in this exchange and ask the model for the
+		// explanation instead
+		if s.NeedsExplanation() {
+			t.Unexplained++
+			if t.Unexplained > MaxExplainPrompts {
+				t.Response = &llm.StructuredResponse{Type: llm.ResponseTypeError, Error: s.ExplainRefusal(resp.Action.Tool)}
+				break
+			}
+			t.explain = true
+			continue
+		}
+
+		// Stop before running another tool once the step budget is spent
+		if s.MaxSteps > 0 && len(t.Tools) >= s.MaxSteps {
+			t.LimitReached = true
+			t.Response = &llm.StructuredResponse{Type: llm.ResponseTypeText, Text: s.stepLimitSummary(t.Tools)}
+			break
+		}
+
+		// PHASE 5: Act - Execute the requested tool call and feed the
+		// result back to the model
+		t.enter(PhaseAct)
+		t.proceed = false
+		if t.hooks.Act == nil {
+			break
+		}
+		s.AddAssistantActionMessage(resp.Action.Tool, resp.Action.Args)
+		t.ToolResult = t.hooks.Act(resp.Action)
+		s.AddToolResultMessage(resp.Action.Tool, t.ToolResult)
+		t.Tools = append(t.Tools, resp.Action.Tool)
+
+		// A permission denied while acting ends the turn, unless the model
+		// is told about it and left to continue without it
+		if denied := s.TakeDenials(); len(denied) > 0 {
+			if !s.ContinueAfterDenial {
+				t.Stopped = true
+				break
+			}
+			t.Denied = append(t.Denied, denied...)
+			for _, capability := range denied {
+				s.AddDenialNote(capability)
+			}
+		}
+	}
+
+	// Post-processors shape the final answer before it is shown or stored
+	if resp := t.Response; resp != nil && resp.Type == llm.ResponseTypeText {
+		resp.Text = s.PostProcessors.Process(resp.Text)
+	}
+
+	// PHASE 6: Report - Record the outcome in history
+	t.enter(PhaseReport)
+	if resp := t.Response; resp != nil {
+		switch resp.Type {
+		case llm.ResponseTypeClarification:
+			if resp.Clarification != nil {
+				s.AddAssistantTextMessage(resp.Clarification.Question)
+			}
+		case llm.ResponseTypeAction:
+			// Recorded during Act
+		default:
+			if resp.Text != "" && t.Continue && len(t.Tools) == 0 {
+				s.AppendAssistantText(resp.Text)
+			} else if resp.Text != "" {
+				s.AddAssistantTextMessage(resp.Text)
+			}
+		}
+	}
+
+	return nil
+}
+
+// plan runs PHASE 3: stream the model's response to the history
+func (t *ChatTurn) plan() error {
+	s := t.session
+	t.enter(PhasePlan)
+	t.collector = llm.NewResponseCollector(llm.NewStructuredParser()).EnableDedup()
+	messages := s.ContextMessages()
+	if t.Continue && len(t.Tools) == 0 {
+		messages = append(messages, llm.Message{Role: "user", Content: ContinuePrompt})
+	}
+	switch {
+	case t.explain:
+		messages = append(messages, llm.Message{Role: "user", Content: ExplainPrompt})
+	case t.proceed:
+		messages = append(messages, llm.Message{Role: "user", Content: ExplainedPrompt})
+	}
+	stream, err := s.Client.Backend().Stream(s.Context, s.Client.System().Raw(), messages)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	t.streamErr = nil
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			t.streamErr = err
+			break
+		}
+		if t.collector.AddChunk(chunk) && t.hooks.OnChunk != nil {
+			t.hooks.OnChunk(chunk)
+		}
+	}
+	t.Raw = t.collector.GetFullResponse()
+
+	// A refusal ends the stream with an answer of its own, handled in
+	// Parse; any other failure ends the turn
+	var refusal *llm.RefusalError
+	if t.streamErr != io.EOF && !errors.As(t.streamErr, &refusal) {
+		return t.streamErr
+	}
+
+	t.recordStreamDetails(stream)
+	s.truncated = t.FinishReason == llm.FinishReasonLength
+	return nil
+}
+
+// recordStreamDetails keeps what the backend reported about the response
+func (t *ChatTurn) recordStreamDetails(stream llm.Stream) {
+	t.FinishReason, t.Reasoning = "", ""
+	t.Confidence, t.HasConfidence = 0, false
+	t.Usage, t.HasUsage = llm.TokenUsage{}, false
+	if reporter, ok := stream.(llm.FinishReasonReporter); ok {
+		t.FinishReason = reporter.FinishReason()
+	}
+	if reporter, ok := stream.(llm.ReasoningReporter); ok {
+		t.Reasoning = reporter.Reasoning()
+	}
+	if reporter, ok := stream.(llm.ConfidenceReporter); ok {
+		t.Confidence, t.HasConfidence = reporter.Confidence()
+	}
+	if reporter, ok := stream.(llm.UsageReporter); ok {
+		t.Usage, t.HasUsage = reporter.Usage()
+	}
+}
+
+// parse runs PHASE 4: interpret the structured response
+func (t *ChatTurn) parse() {
+	t.enter(PhaseParse)
+	t.Response = nil
+	if parseResult, parseErr := t.collector.Parse(); parseErr == nil && parseResult != nil {
+		t.Response = parseResult.Response
+	}
+	var refusal *llm.RefusalError
+	if errors.As(t.streamErr, &refusal) {
+		t.Response = &llm.StructuredResponse{Type: llm.ResponseTypeError, Error: refusal.Error()}
+	}
+}
+
+// StreamErr returns the error that ended the last response stream: io.EOF
+// for a complete response, or an *llm.RefusalError when the model refused
+func (t *ChatTurn) StreamErr() error {
+	return t.streamErr
+}
+
+// Text returns the user-facing text of the turn's last response: the
+// answer, clarification question or error, or the post-processed raw output
+// when it could not be parsed. It is empty when the turn ended on a tool call.
+func (t *ChatTurn) Text() string {
+	if t.Response == nil {
+		return t.session.PostProcessors.Process(t.Raw)
+	}
+	if t.Response.Type == llm.ResponseTypeAction {
+		return ""
+	}
+	return responseText(t.Response)
+}
+
+// stepLimitSummary builds the message returned when the step limit ends a turn
+func (s *ChatSession) stepLimitSummary(toolsRun []string) string {
+	if s.AuditLogger != nil {
+		s.AuditLogger.LogSession("STEP_LIMIT", fmt.Sprintf("step limit reached (max_steps_per_turn=%d)", s.MaxSteps), s.WorkingDir)
+	}
+
+	return fmt.Sprintf(
+		"I stopped after %d tool steps, the limit for a single turn, without reaching a final answer.\n"+
+			"Tools run: %s.\n"+
+			"Send another message to let me continue or to adjust the plan.",
+		len(toolsRun), strings.Join(toolsRun, ", "))
+}
+
+// responseText returns the user-facing text for a non-action response
+func responseText(resp *llm.StructuredResponse) string {
+	switch resp.Type {
+	case llm.ResponseTypeText:
+		return resp.Text
+	case llm.ResponseTypeError:
+		return fmt.Sprintf("Error: %s", resp.Error)
+	case llm.ResponseTypeClarification:
+		return resp.Clarification.Question
+	default:
+		return resp.RawText
+	}
+}
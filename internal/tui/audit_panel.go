@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -22,6 +23,8 @@ type AuditPanel struct {
 	// Data
 	events   []audit.Event
 	filePath string
+	textOnly bool // The log is human-readable text, which the panel cannot list
+	focused  bool
 }
 
 // NewAuditPanel creates a new audit panel
@@ -31,6 +34,7 @@ func NewAuditPanel(filePath string) *AuditPanel {
 		viewport: vp,
 		ready:    false,
 		filePath: filePath,
+		textOnly: strings.HasSuffix(filePath, ".log"),
 		events:   []audit.Event{},
 	}
 
@@ -42,7 +46,7 @@ func NewAuditPanel(filePath string) *AuditPanel {
 
 // loadEvents reads events from the audit log file
 func (p *AuditPanel) loadEvents() {
-	if p.filePath == "" {
+	if p.filePath == "" || p.textOnly {
 		return
 	}
 
@@ -90,6 +94,11 @@ func (p *AuditPanel) Update(msg tea.Msg) tea.Cmd {
 	return cmd
 }
 
+// SetFocused marks whether the panel has keyboard focus
+func (p *AuditPanel) SetFocused(focused bool) {
+	p.focused = focused
+}
+
 // Refresh reloads events from the file (call this periodically to see new events)
 func (p *AuditPanel) Refresh() {
 	p.events = []audit.Event{}
@@ -100,13 +109,16 @@ func (p *AuditPanel) Refresh() {
 func (p *AuditPanel) Render() string {
 	borderStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
+		BorderForeground(borderColor(p.focused)).
 		Padding(0, 1)
 
 	// Build content
 	content := p.renderHeader()
 
-	if len(p.events) == 0 {
+	if p.textOnly {
+		notice := fmt.Sprintf("(audit.format is text; events are in %s)\nSet audit.format to jsonl or both to list them here.", p.filePath)
+		content += "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(notice)
+	} else if len(p.events) == 0 {
 		content += "\n\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("(no audit events yet)")
 	} else {
 		content += "\n\n" + p.renderEvents()
@@ -127,7 +139,7 @@ func (p *AuditPanel) Render() string {
 	}
 
 	styled := borderStyle.Width(contentWidth).Render(viewportContent)
-	return styled
+	return withBorderTitle(styled, regionTitle(FocusAuditPanel, p.focused))
 }
 
 func (p *AuditPanel) renderHeader() string {
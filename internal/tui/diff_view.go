@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cshaiku/goshi/internal/actions/runtime"
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/fs"
+)
+
+var (
+	diffAddStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	diffRemoveStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	diffHunkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	diffHeaderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("250")).Bold(true)
+)
+
+// toolResultFields extracts the action output map from a tool router result value
+func toolResultFields(v any) (map[string]any, bool) {
+	switch out := v.(type) {
+	case runtime.ActionOutput:
+		return out, true
+	case map[string]any:
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+// renderWriteDiff renders an fs.write result as a before/after diff.
+// The "before" side comes from the backup taken when the proposal was
+// created (or is empty for new files); the "after" side is the proposed content.
+func renderWriteDiff(result app.ToolResult, args map[string]any) (string, bool) {
+	if !result.Success {
+		return "", false
+	}
+	fields, ok := toolResultFields(result.Value)
+	if !ok {
+		return "", false
+	}
+
+	content, ok := args["content"].(string)
+	if !ok {
+		return "", false
+	}
+
+	path, _ := args["path"].(string)
+	if path == "" {
+		path, _ = fields["path"].(string)
+	}
+
+	var before []byte
+	if backupPath, ok := fields["backup_path"].(string); ok && backupPath != "" {
+		data, err := fs.LoadBackup(backupPath)
+		if err != nil {
+			return "", false
+		}
+		before = data
+	} else if isNew, _ := fields["is_new_file"].(bool); !isNew {
+		// No backup available: fall back to the diff recorded with the proposal
+		diff, ok := fields["diff"].(string)
+		if !ok || diff == "" {
+			return "", false
+		}
+		return colorizeDiff(diff), true
+	}
+
+	diff := fs.UnifiedDiff(path, before, []byte(content))
+	if diff == "" {
+		return styleStatus("(no changes)"), true
+	}
+	return colorizeDiff(diff), true
+}
+
+// colorizeDiff applies add/remove/hunk styling to a unified diff
+func colorizeDiff(diff string) string {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = diffHeaderStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = diffHunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = diffAddStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = diffRemoveStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// focusLabel announces the focused region in its title
+const focusLabel = " (focused)"
+
+// Border colors for focused and unfocused regions
+var (
+	focusedBorderColor   = lipgloss.Color("12")
+	unfocusedBorderColor = lipgloss.Color("240")
+)
+
+// String returns the region's human-readable name
+func (r FocusRegion) String() string {
+	switch r {
+	case FocusOutputStream:
+		return "Output Stream"
+	case FocusInspectPanel:
+		return "Inspect Panel"
+	case FocusAuditPanel:
+		return "Audit Panel"
+	case FocusInput:
+		return "Input"
+	default:
+		return "Unknown"
+	}
+}
+
+// regionTitle is a region's name, labelled when it has focus
+func regionTitle(region FocusRegion, focused bool) string {
+	if focused {
+		return region.String() + focusLabel
+	}
+	return region.String()
+}
+
+// borderColor returns the border color for a region's focus state
+func borderColor(focused bool) lipgloss.Color {
+	if focused {
+		return focusedBorderColor
+	}
+	return unfocusedBorderColor
+}
+
+// withBorderTitle writes title into the top edge of a rounded border
+// ("╭─ Title ───╮"), matching the input area's title. The border is left
+// as is when it is too narrow for the title.
+func withBorderTitle(rendered, title string) string {
+	const corner, edge = "╭", "─"
+
+	i := strings.Index(rendered, corner)
+	if i < 0 {
+		return rendered
+	}
+	start := i + len(corner)
+
+	label := edge + " " + title + " "
+	width := utf8.RuneCountInString(label)
+
+	// Keep at least one edge segment before the closing corner
+	available := 0
+	for strings.HasPrefix(rendered[start+available*len(edge):], edge) {
+		available++
+	}
+	if available < width+1 {
+		return rendered
+	}
+
+	return rendered[:start] + label + rendered[start+width*len(edge):]
+}
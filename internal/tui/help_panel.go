@@ -44,6 +44,12 @@ MODE & TOGGLES:
   Ctrl+D             - Toggle dry run
   Ctrl+T             - Toggle deterministic mode
 
+MESSAGES:
+  Ctrl+Up/Down       - Select message (output focused)
+  Ctrl+P             - Pin/unpin selected or latest message
+  Ctrl+G, /continue  - Continue a response cut off at the token limit
+  /permissions       - Show granted and denied capabilities
+
 PANELS & VIEWS:
   Ctrl+A             - Toggle audit panel
   Ctrl+H             - Toggle this help panel
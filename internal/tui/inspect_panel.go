@@ -3,10 +3,12 @@ package tui
 import (
 	"crypto/sha256"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/cshaiku/goshi/internal/selfmodel"
 )
 
 // InspectPanel renders the right-side inspect panel with all 4 sections
@@ -24,6 +26,7 @@ type InspectPanel struct {
 	constCount   int
 	guardrailsOn bool
 	capabilities *Capabilities
+	focused      bool
 }
 
 // Capabilities represents system capabilities state
@@ -35,6 +38,13 @@ type Capabilities struct {
 	NetworkStatus     string // "allowed", "denied", "restricted"
 }
 
+// placeholderValue stands in for values that are not known yet, such as
+// telemetry before it is wired to the panel
+const placeholderValue = "n/a"
+
+// memoryBarWidth is the width of the memory usage bar, in cells
+const memoryBarWidth = 20
+
 // NewInspectPanel creates a new inspect panel
 func NewInspectPanel(telemetry *Telemetry) *InspectPanel {
 	vp := viewport.New(30, 20)
@@ -97,11 +107,16 @@ func (p *InspectPanel) SetGuardrails(enabled bool) {
 	p.guardrailsOn = enabled
 }
 
+// SetFocused marks whether the panel has keyboard focus
+func (p *InspectPanel) SetFocused(focused bool) {
+	p.focused = focused
+}
+
 // Render returns the inspect panel content with all 4 sections
 func (p *InspectPanel) Render(systemPrompt string) string {
 	borderStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
+		BorderForeground(borderColor(p.focused)).
 		Padding(0, 1)
 
 	// Render all sections as viewport content
@@ -126,7 +141,7 @@ func (p *InspectPanel) Render(systemPrompt string) string {
 	}
 
 	styled := borderStyle.Width(contentWidth).Render(viewportContent)
-	return styled
+	return withBorderTitle(styled, regionTitle(FocusInspectPanel, p.focused))
 }
 
 func (p *InspectPanel) renderHeader() string {
@@ -148,14 +163,19 @@ func (p *InspectPanel) renderMemorySection() string {
 	dimStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("240"))
 
-	// Calculate memory usage percentage
-	memPercent := 0.0
-	if p.telemetry.MemoryMax > 0 {
-		memPercent = (float64(p.telemetry.MemoryEntries) / float64(p.telemetry.MemoryMax)) * 100
+	// Without telemetry there is nothing to measure yet
+	if p.telemetry == nil {
+		return sectionStyle.Render("MEMORY") + "\n" +
+			valueStyle.Render("Entries: "+placeholderValue) + "\n" +
+			dimStyle.Render(strings.Repeat("░", memoryBarWidth)) + " " + valueStyle.Render(placeholderValue) + "\n" +
+			dimStyle.Render("Scope: ") + valueStyle.Render("session")
 	}
 
+	// Calculate memory usage percentage
+	memPercent := p.telemetry.MemoryPercent()
+
 	// Memory bar
-	barWidth := 20
+	barWidth := memoryBarWidth
 	filled := int((memPercent / 100.0) * float64(barWidth))
 	if filled > barWidth {
 		filled = barWidth
@@ -191,9 +211,43 @@ func (p *InspectPanel) renderPromptInfoSection(systemPrompt string) string {
 	hash := sha256.Sum256([]byte(systemPrompt))
 	policyHash := fmt.Sprintf("%X", hash[:3]) // First 6 hex chars
 
-	return sectionStyle.Render("PROMPT INFO") + "\n" +
+	temperature := placeholderValue
+	if p.telemetry != nil {
+		temperature = fmt.Sprintf("%.1f", p.telemetry.Temperature)
+	}
+
+	info := sectionStyle.Render("PROMPT INFO") + "\n" +
 		dimStyle.Render("Policy Hash: ") + valueStyle.Render(policyHash) + "\n" +
-		dimStyle.Render("Temperature: ") + valueStyle.Render(fmt.Sprintf("%.1f", p.telemetry.Temperature))
+		dimStyle.Render("Temperature: ") + valueStyle.Render(temperature)
+
+	// A persona layers tone/style over the laws; the hash above covers both
+	if selfmodel.ExtractPersona(systemPrompt) != "" {
+		info += "\n" + dimStyle.Render("Persona: ") + valueStyle.Render("active")
+	}
+
+	if p.telemetry == nil {
+		return info
+	}
+
+	// Confidence is only known when the backend returned logprobs
+	if p.telemetry.HasConfidence {
+		info += "\n" + dimStyle.Render("Confidence: ") +
+			valueStyle.Render(fmt.Sprintf("%.0f%%", p.telemetry.Confidence*100))
+	}
+
+	// Rate limits are only known when the backend reports them
+	if limits := p.telemetry.RateLimits; limits.Known {
+		if limits.LimitRequests > 0 {
+			info += "\n" + dimStyle.Render("Requests left: ") +
+				valueStyle.Render(fmt.Sprintf("%d/%d", limits.RemainingRequests, limits.LimitRequests))
+		}
+		if limits.LimitTokens > 0 {
+			info += "\n" + dimStyle.Render("Tokens left: ") +
+				valueStyle.Render(fmt.Sprintf("%d/%d", limits.RemainingTokens, limits.LimitTokens))
+		}
+	}
+
+	return info
 }
 
 func (p *InspectPanel) renderGuardrailsSection() string {
@@ -236,22 +290,27 @@ func (p *InspectPanel) renderCapabilitiesSection() string {
 	deniedStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("9"))
 
+	caps := p.capabilities
+	if caps == nil {
+		caps = &Capabilities{FilesystemStatus: placeholderValue, NetworkStatus: placeholderValue}
+	}
+
 	// Tools status
 	toolsStatus := deniedStyle.Render("disabled")
-	if p.capabilities.ToolsEnabled {
+	if caps.ToolsEnabled {
 		toolsStatus = enabledStyle.Render("enabled")
 	}
 
 	// Filesystem status
-	fsStatus := deniedStyle.Render(p.capabilities.FilesystemStatus)
-	if p.capabilities.FilesystemAllowed {
-		fsStatus = enabledStyle.Render(p.capabilities.FilesystemStatus)
+	fsStatus := deniedStyle.Render(caps.FilesystemStatus)
+	if caps.FilesystemAllowed {
+		fsStatus = enabledStyle.Render(caps.FilesystemStatus)
 	}
 
 	// Network status
-	netStatus := deniedStyle.Render(p.capabilities.NetworkStatus)
-	if p.capabilities.NetworkAllowed {
-		netStatus = enabledStyle.Render(p.capabilities.NetworkStatus)
+	netStatus := deniedStyle.Render(caps.NetworkStatus)
+	if caps.NetworkAllowed {
+		netStatus = enabledStyle.Render(caps.NetworkStatus)
 	}
 
 	return sectionStyle.Render("CAPABILITIES") + "\n" +
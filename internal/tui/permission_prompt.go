@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// permissionRequest is a prompt raised by a tool command waiting on the user
+type permissionRequest struct {
+	capability string
+	cwd        string
+	reason     string // The detection rule behind the request, if explained
+	reply      chan bool
+}
+
+// permissionRequestMsg delivers a permission request to the update loop
+type permissionRequestMsg struct {
+	req permissionRequest
+}
+
+// TUIPrompter implements session.PermissionPrompter with a modal prompt in
+// the input area. Ask runs on a tool command's goroutine and blocks until
+// the user answers in the update loop.
+type TUIPrompter struct {
+	requests chan permissionRequest
+
+	mu      sync.Mutex
+	reasons map[string]string
+}
+
+// NewTUIPrompter creates a prompter for a TUI session
+func NewTUIPrompter() *TUIPrompter {
+	return &TUIPrompter{
+		requests: make(chan permissionRequest),
+		reasons:  make(map[string]string),
+	}
+}
+
+// Explain records why the next request for a capability is being made, so
+// the modal can show the rule that triggered it
+func (p *TUIPrompter) Explain(capability, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reasons[capability] = reason
+}
+
+// Ask shows the modal and waits for the user's decision
+func (p *TUIPrompter) Ask(capability string, cwd string) bool {
+	p.mu.Lock()
+	reason := p.reasons[capability]
+	delete(p.reasons, capability)
+	p.mu.Unlock()
+
+	reply := make(chan bool, 1)
+	p.requests <- permissionRequest{capability: capability, cwd: cwd, reason: reason, reply: reply}
+	return <-reply
+}
+
+// waitForRequest returns a command that delivers the next permission request
+func (p *TUIPrompter) waitForRequest() tea.Cmd {
+	return func() tea.Msg {
+		return permissionRequestMsg{req: <-p.requests}
+	}
+}
+
+// handlePermissionKey answers the pending permission request: y grants,
+// n or Esc denies. Other keys are ignored while the modal is open.
+func (m model) handlePermissionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var granted bool
+	switch {
+	case msg.Type == tea.KeyEsc || msg.String() == "n" || msg.String() == "N":
+		granted = false
+	case msg.String() == "y" || msg.String() == "Y":
+		granted = true
+	default:
+		return m, nil
+	}
+
+	req := m.pendingPermission
+	m.pendingPermission = nil
+	req.reply <- granted
+	if granted {
+		m.statusLine = fmt.Sprintf("Granted %s", req.capability)
+	} else {
+		m.statusLine = fmt.Sprintf("Denied %s", req.capability)
+	}
+	return m, m.prompter.waitForRequest()
+}
+
+// renderPermissionPrompt renders the modal that replaces the input area
+// while a permission request is pending
+func (m model) renderPermissionPrompt() string {
+	req := m.pendingPermission
+	reason := ""
+	if req.reason != "" {
+		reason = "│ " + req.reason + "\n"
+	}
+	return fmt.Sprintf(
+		"┌─ Permission required\n│ Goshi requests %s access in:\n│   %s\n%s│ [y] Allow (this session)   [n/Esc] Deny",
+		req.capability,
+		req.cwd,
+		reason,
+	)
+}
@@ -48,13 +48,13 @@ func (s *StatusBar) Render(width int) string {
 }
 
 // renderLine1 renders the first status line
-// Format: goshi | Laws: 312 | C: 9 | ACTIVE | 8k/16k | temp: 0.2 | mem: 14/128
+// Format: goshi | Laws: 312 | C: 9 | enf: ACTIVE (FS_READ) | 8k/16k | temp: 0.2 | mem: 14/128
 func (s *StatusBar) renderLine1() string {
 	tokensUsedK := s.telemetry.TokensUsed / 1024
 	tokensLimitK := s.telemetry.TokensLimit / 1024
 
 	return fmt.Sprintf(
-		"goshi │ Laws: %d │ C: %d │ %s │ %dk/%dk │ temp: %.1f │ mem: %d/%d",
+		"goshi │ Laws: %d │ C: %d │ enf: %s │ %dk/%dk │ temp: %.1f │ mem: %d/%d",
 		s.lawsCount,
 		s.constraintCount,
 		s.telemetry.Status,
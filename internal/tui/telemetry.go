@@ -2,6 +2,8 @@ package tui
 
 import (
 	"time"
+
+	"github.com/cshaiku/goshi/internal/llm"
 )
 
 // Telemetry tracks real-time metrics for the TUI
@@ -28,8 +30,36 @@ type Telemetry struct {
 	ModelName   string
 	Backend     string
 
+	// Response confidence (average top-token probability from logprobs)
+	Confidence    float64
+	HasConfidence bool
+
+	// Provider rate-limit budget from response headers (Known is false
+	// for backends that do not report it)
+	RateLimits llm.RateLimits
+
 	// Status
-	Status string // STAGED, ACTIVE, PENDING
+	Status string // Enforcement status: STAGED, or ACTIVE with granted capabilities
+}
+
+// memoryEntryTokens approximates the context tokens one history entry
+// takes, for sizing the memory bar from the context window
+const memoryEntryTokens = 128
+
+// memoryCapacity returns the number of history entries the memory bar
+// measures against: the configured maximum, or else one entry per
+// memoryEntryTokens of the context window
+func memoryCapacity(configured, contextTokens int) int {
+	if configured > 0 {
+		return configured
+	}
+	if contextTokens > 0 {
+		if capacity := contextTokens / memoryEntryTokens; capacity > 0 {
+			return capacity
+		}
+		return 1
+	}
+	return NewTelemetry().MemoryMax
 }
 
 // NewTelemetry creates a new telemetry tracker
@@ -52,11 +82,39 @@ func (t *Telemetry) RecordRequest(latency time.Duration, tokensUsed int, cost fl
 	t.SessionCost += cost
 }
 
+// RecordRateLimits records the backend's latest rate-limit budget
+func (t *Telemetry) RecordRateLimits(limits llm.RateLimits) {
+	t.RateLimits = limits
+}
+
+// RecordConfidence records the confidence of the latest response
+// ok is false when the backend did not report logprobs
+func (t *Telemetry) RecordConfidence(confidence float64, ok bool) {
+	t.Confidence = confidence
+	t.HasConfidence = ok
+}
+
 // UpdateMemory updates memory usage
 func (t *Telemetry) UpdateMemory(entries int) {
+	if entries < 0 {
+		entries = 0
+	}
 	t.MemoryEntries = entries
 }
 
+// MemoryPercent returns memory usage as a percentage of MemoryMax, capped
+// at 100
+func (t *Telemetry) MemoryPercent() float64 {
+	if t.MemoryMax <= 0 {
+		return 0
+	}
+	percent := float64(t.MemoryEntries) / float64(t.MemoryMax) * 100
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
 // UpdateStatus updates the system status
 func (t *Telemetry) UpdateStatus(status string) {
 	t.Status = status
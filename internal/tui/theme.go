@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cshaiku/goshi/internal/config"
+)
+
+// roleLabels are the prefixes rendered before each message, by role
+var roleLabels = map[string]string{
+	"user":          "USER: ",
+	"assistant":     "ASSISTANT: ",
+	"system":        "SYSTEM: ",
+	"tool":          "TOOL: ",
+	"clarification": "QUESTION: ",
+	"reasoning":     "REASONING: ",
+}
+
+// Theme holds the configurable parts of message rendering: the streaming
+// cursor and the role label styles
+type Theme struct {
+	Cursor      string // Glyph after streaming text ("" = none)
+	cursorStyle lipgloss.Style
+	labelStyles map[string]lipgloss.Style // Overrides roleStyle, by role
+}
+
+// DefaultTheme returns the built-in theme
+func DefaultTheme() Theme {
+	return NewTheme(config.LoadDefaults().TUI.Theme)
+}
+
+// NewTheme builds a theme from config. Colors left empty keep the
+// built-in styles.
+func NewTheme(cfg config.ThemeConfig) Theme {
+	t := Theme{
+		Cursor:      cfg.Cursor,
+		cursorStyle: lipgloss.NewStyle(),
+		labelStyles: map[string]lipgloss.Style{},
+	}
+	if cfg.CursorColor != "" {
+		t.cursorStyle = t.cursorStyle.Foreground(lipgloss.Color(cfg.CursorColor))
+	}
+	for role, color := range cfg.RoleColors {
+		if color != "" {
+			t.labelStyles[role] = roleStyle.Foreground(lipgloss.Color(color))
+		}
+	}
+	return t
+}
+
+// cursor renders the streaming cursor, or nothing when it is disabled
+func (t Theme) cursor() string {
+	if t.Cursor == "" {
+		return ""
+	}
+	return t.cursorStyle.Render(t.Cursor)
+}
+
+// labelStyle returns the style of a role's label
+func (t Theme) labelStyle(role string) lipgloss.Style {
+	if style, ok := t.labelStyles[role]; ok {
+		return style
+	}
+	return roleStyle
+}
+
+// renderMessage renders a message with its role label; unknown roles are
+// rendered as assistant messages
+func (t Theme) renderMessage(role, text string) string {
+	label, ok := roleLabels[role]
+	if !ok {
+		role, label = "assistant", roleLabels["assistant"]
+	}
+	return messageStyles[role].Render(t.labelStyle(role).Render(label) + text)
+}
@@ -1,14 +1,19 @@
 package tui
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/detect"
 	"github.com/cshaiku/goshi/internal/llm"
 	"github.com/cshaiku/goshi/internal/selfmodel"
 	"github.com/cshaiku/goshi/internal/session"
@@ -22,14 +27,27 @@ func Run(systemPrompt string, sess *session.ChatSession) error {
 		tea.WithMouseCellMotion(),
 	)
 	_, err := p.Run()
+
+	// Quitting shuts the session down from Update; this also covers
+	// SIGINT/SIGTERM, which end the program without a quit key
+	if sess != nil {
+		if shutdownErr := sess.Shutdown(); err == nil {
+			err = shutdownErr
+		}
+	}
 	return err
 }
 
 // Message represents a chat message
 type Message struct {
-	Role       string // "user", "assistant", "system", or "tool"
+	Role       string // "user", "assistant", "system", "tool", or "clarification"
 	Content    string
 	InProgress bool // True if still streaming
+	Pinned     bool // Kept during context trimming; rendered with a pin marker
+
+	// 1-based position of the matching entry in the session history
+	// (0 when the message is UI-only, e.g. system notices)
+	sessionPos int
 }
 
 // Mode represents the TUI operational mode
@@ -55,6 +73,19 @@ func (m Mode) String() string {
 	}
 }
 
+// ParseMode returns the mode named by s (chat, command, or diff),
+// falling back to ModeChat for anything else
+func ParseMode(s string) Mode {
+	switch strings.ToLower(s) {
+	case "command":
+		return ModeCommand
+	case "diff":
+		return ModeDiff
+	default:
+		return ModeChat
+	}
+}
+
 // InputToggles represents the state of input toggles
 type InputToggles struct {
 	DryRun        bool
@@ -89,16 +120,66 @@ type model struct {
 	chatSession  *session.ChatSession
 	systemPrompt string
 
+	// What View shows of the session. A running turn owns the session, so
+	// this is refreshed from snapshots its messages carry rather than read
+	// from the session directly.
+	session sessionState
+
 	// Streaming state
 	streaming bool
+
+	// A tool call is executing
+	toolRunning bool
+
+	// Messages sent while busy, in order; each is sent once the current
+	// stream or tool call completes
+	queuedSends []string
+
+	// Set once the session turn limit has been hit and the wrap-up shown
+	sessionEnded bool
+
+	// True while the model is waiting for an answer to a clarification request
+	awaitingClarification bool
+
+	// Index of the message selected in the output stream (-1 = none)
+	selectedMsg int
+
+	// Display reasoning streamed separately from the answer
+	showReasoning bool
+
+	// Asks for capabilities from tool commands; the pending request, if
+	// any, replaces the input area with a modal until answered
+	prompter          *TUIPrompter
+	pendingPermission *permissionRequest
+
+	// Conversation title, generated once after the first exchange
+	title        string
+	titlePending bool
+
+	// Streaming cursor and role label styles
+	theme Theme
+
+	// The last response was cut off by the output token limit
+	canContinue bool
+	// Set while a continuation streams into the response it extends
+	continuing   bool
+	continueBase string
+
+	// Set once the current draft has been cut off at the input limit
+	inputTruncated bool
+
+	// Input line prefix for each mode, and the input width it fits within
+	prompts    map[Mode]string
+	inputWidth int
 }
 
 func newModel(systemPrompt string, sess *session.ChatSession) model {
+	cfg := config.Load()
+
 	ta := textarea.New()
 	ta.Placeholder = "Type your message..."
 	ta.Focus()
-	ta.Prompt = "│ "
-	ta.CharLimit = 4000
+	ta.CharLimit = cfg.TUI.InputCharLimit
 	ta.SetWidth(80)
 	ta.SetHeight(3)
 	ta.ShowLineNumbers = false
@@ -128,10 +209,44 @@ func newModel(systemPrompt string, sess *session.ChatSession) model {
 		auditPanel = NewAuditPanel(sess.AuditLogger.FilePath())
 	}
 
-	return model{
+	// Permission requests from tool calls are answered in a modal
+	prompter := NewTUIPrompter()
+	if sess != nil {
+		sess.Prompter = prompter
+	}
+
+	// Surface one-time warnings: auditing had to be disabled, or the
+	// provider bills requests
+	messages := []Message{}
+	if sess != nil && sess.AuditWarning != "" {
+		messages = append(messages, Message{Role: "system", Content: "Warning: " + sess.AuditWarning})
+	}
+	if sess != nil && sess.PaidWarning != "" {
+		messages = append(messages, Message{Role: "system", Content: "Warning: " + sess.PaidWarning})
+	}
+
+	// Start in the configured mode and toggle states
+	tuiCfg := cfg.TUI
+
+	// Size the token and memory gauges to the session's context window
+	contextTokens := cfg.LLM.ContextTokens
+	if sess != nil {
+		contextTokens = sess.ContextTokens
+	}
+	if contextTokens > 0 {
+		telemetry.TokensLimit = int64(contextTokens)
+	}
+	telemetry.MemoryMax = memoryCapacity(tuiCfg.MemoryMax, contextTokens)
+
+	prompts := map[Mode]string{}
+	for name, prompt := range tuiCfg.Prompts {
+		prompts[ParseMode(name)] = prompt
+	}
+
+	m := model{
 		viewport:          vp,
 		textarea:          ta,
-		messages:          []Message{},
+		messages:          messages,
 		inspectPanel:      inspectPanel,
 		auditPanel:        auditPanel,
 		helpPanel:         helpPanel,
@@ -139,19 +254,48 @@ func newModel(systemPrompt string, sess *session.ChatSession) model {
 		layout:            layout,
 		telemetry:         telemetry,
 		focusedRegion:     FocusInput,
-		mode:              ModeChat,
-		toggles:           InputToggles{DryRun: false, Deterministic: false},
+		toggles:           InputToggles{DryRun: tuiCfg.DryRun, Deterministic: tuiCfg.Deterministic},
 		chatSession:       sess,
 		systemPrompt:      systemPrompt,
 		statusLine:        "Ready",
 		auditPanelVisible: false,
 		helpPanelVisible:  false,
 		auditPanelRefresh: 0,
+		selectedMsg:       -1,
+		showReasoning:     cfg.LLM.ShowReasoning,
+		prompter:          prompter,
+		theme:             NewTheme(tuiCfg.Theme),
+		prompts:           prompts,
+		inputWidth:        80,
 	}
+	m.setMode(ParseMode(tuiCfg.Mode))
+	m.session = snapshotSession(sess)
+	return m
+}
+
+// setMode switches mode and shows its prefix on the first input line
+func (m *model) setMode(mode Mode) {
+	m.mode = mode
+	prompt := m.inputPrompt()
+	m.textarea.SetPromptFunc(lipgloss.Width(prompt), func(line int) string {
+		if line == 0 {
+			return prompt
+		}
+		return ""
+	})
+	m.textarea.SetWidth(m.inputWidth)
+}
+
+// inputPrompt returns the input line prefix for the current mode
+func (m model) inputPrompt() string {
+	if prompt, ok := m.prompts[m.mode]; ok {
+		return prompt
+	}
+	return "│ "
 }
 
 func (m model) Init() tea.Cmd {
-	return textarea.Blink
+	return tea.Batch(textarea.Blink, m.prompter.waitForRequest())
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -161,7 +305,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		ipCmd tea.Cmd
 	)
 
+	// A pending permission request owns the keyboard until answered
+	if key, ok := msg.(tea.KeyMsg); ok && m.pendingPermission != nil {
+		if key.Type == tea.KeyCtrlC || key.Type == tea.KeyCtrlQ {
+			m.shutdown()
+			return m, tea.Quit
+		}
+		return m.handlePermissionKey(key)
+	}
+
+	inputBefore := m.textarea.Length()
 	m.textarea, taCmd = m.textarea.Update(msg)
+	m.checkInputTruncation(msg, inputBefore)
 
 	// Route viewport/scrolling updates based on focused region
 	if m.focusedRegion == FocusInspectPanel {
@@ -179,6 +334,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyCtrlQ:
+			m.shutdown()
 			return m, tea.Quit
 		case tea.KeyEnter:
 			// Send message only when focused on input
@@ -200,7 +356,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case tea.KeyCtrlL:
 			// Cycle through modes
-			m.mode = (m.mode + 1) % 3
+			m.setMode((m.mode + 1) % 3)
 			return m, nil
 		case tea.KeyCtrlD:
 			// Toggle dry run
@@ -210,6 +366,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Toggle deterministic
 			m.toggles.Deterministic = !m.toggles.Deterministic
 			return m, nil
+		case tea.KeyCtrlUp, tea.KeyCtrlDown:
+			// Move the message selection while the output stream is focused
+			if m.focusedRegion == FocusOutputStream {
+				m.moveSelection(msg.Type == tea.KeyCtrlUp)
+				m.updateViewportContent()
+			}
+			return m, nil
+		case tea.KeyCtrlG:
+			// Continue a response cut off by the output token limit
+			return m.handleContinue()
+		case tea.KeyCtrlP:
+			// Toggle pin on the selected (or latest) message
+			m.togglePin()
+			m.updateViewportContent()
+			return m, nil
 		case tea.KeyTab:
 			// Cycle focus forward (only through visible regions)
 			if m.auditPanelVisible {
@@ -254,7 +425,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.Height = m.layout.OutputStreamHeight - 2
 
 		// Update textarea dimensions
-		m.textarea.SetWidth(m.layout.OutputStreamWidth - 4)
+		m.inputWidth = m.layout.OutputStreamWidth - 4
+		m.textarea.SetWidth(m.inputWidth)
 
 		// Update inspect panel dimensions
 		m.inspectPanel.SetSize(m.layout.InspectPanelWidth, m.layout.OutputStreamHeight)
@@ -278,13 +450,54 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.messages[len(m.messages)-1].Content += msg.chunk
 			m.updateViewportContent()
 		}
-		return m, nil
+		return m, msg.next
 
 	case llmCompleteMsg:
-		// Finalize the assistant message
+		m.syncSession(msg.state)
+		m.telemetry.RecordConfidence(msg.confidence, msg.hasConfidence)
+		if msg.hasUsage {
+			m.telemetry.RecordRequest(msg.latency, msg.usage.Total(), 0)
+		}
+
+		// Show separately streamed reasoning, dimmed, above the answer
+		if m.showReasoning && msg.reasoning != "" && len(m.messages) > 0 {
+			last := len(m.messages) - 1
+			m.messages = append(m.messages[:last], Message{Role: "reasoning", Content: msg.reasoning}, m.messages[last])
+		}
+
+		// The turn goes on with a tool call: either it runs, or in
+		// explain-before-act mode it is refused and the model re-prompted.
+		// An explanation the model was asked for is shown before it acts.
+		if msg.more {
+			if last := len(m.messages) - 1; last >= 0 && m.messages[last].InProgress {
+				m.messages[last].InProgress = false
+				var response *llm.StructuredResponse
+				if msg.parseResult != nil {
+					response = msg.parseResult.Response
+				}
+				tool := ""
+				if response != nil && response.Action != nil {
+					tool = response.Action.Tool
+				}
+				if response != nil && response.Type == llm.ResponseTypeText {
+					m.messages[last].Content = response.Text
+				} else if msg.unexplained {
+					m.messages[last].Content = fmt.Sprintf("[Not running %s until it is explained]", tool)
+				} else {
+					m.messages[last].Content = fmt.Sprintf("[Executing tool: %s]", tool)
+					m.toolRunning = true
+					m.statusLine = "Running " + tool
+				}
+			}
+			m.continuing = false
+			m.updateViewportContent()
+			return m, msg.next
+		}
+
+		// Finalize the assistant message; the turn has recorded the
+		// outcome in the session
 		m.streaming = false
 		m.statusLine = "Ready"
-
 		if len(m.messages) > 0 && m.messages[len(m.messages)-1].InProgress {
 			m.messages[len(m.messages)-1].InProgress = false
 
@@ -294,77 +507,134 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 				// Handle different response types
 				switch response.Type {
-				case llm.ResponseTypeAction:
-					// Tool execution requested
-					if response.Action != nil {
-						m.messages[len(m.messages)-1].Content = fmt.Sprintf(
-							"[Executing tool: %s]",
-							response.Action.Tool,
-						)
-						m.updateViewportContent()
-						return m, executeTool(m.chatSession, response.Action)
-					}
-
 				case llm.ResponseTypeText:
-					// Regular text response
-					m.messages[len(m.messages)-1].Content = response.Text
-					if m.chatSession != nil {
-						m.chatSession.AddAssistantTextMessage(response.Text)
-					}
+					// Regular text response, already post-processed by the turn
+					m.showAssistantText(response.Text)
 
 				case llm.ResponseTypeError:
 					// LLM reported an error
 					m.messages[len(m.messages)-1].Content = fmt.Sprintf("Error: %s", response.Error)
 					m.err = fmt.Errorf("%s", response.Error)
+
+				case llm.ResponseTypeClarification:
+					// Model needs more information; the next input answers it
+					m.messages[len(m.messages)-1].Role = "clarification"
+					m.messages[len(m.messages)-1].Content = formatClarification(response.Clarification)
+					m.awaitingClarification = true
+					m.statusLine = "Awaiting clarification"
 				}
 			} else {
-				m.messages[len(m.messages)-1].Content = msg.fullResponse
-				if m.chatSession != nil {
-					m.chatSession.AddAssistantTextMessage(msg.fullResponse)
-				}
+				m.showAssistantText(msg.fullResponse)
 			}
 
 			m.updateViewportContent()
 		}
-		return m, nil
+		m.continuing = false
+
+		// Offer to continue a response cut off by the output token limit
+		m.canContinue = msg.finishReason == llm.FinishReasonLength
+		if m.canContinue {
+			m.statusLine = "Response cut off at the token limit - /continue or Ctrl+G to extend it"
+		}
+		titleCmd := m.requestTitle()
+		return m.sendQueued(titleCmd)
+
+	case llmStepMsg:
+		// The turn asks the model again, after a tool ran or a tool call
+		// was refused; its response streams into a new message
+		m.syncSession(msg.state)
+		m.messages = append(m.messages, Message{
+			Role:       "assistant",
+			Content:    "",
+			InProgress: true,
+		})
+		switch msg.reason {
+		case stepExplain:
+			m.statusLine = "Asking for an explanation..."
+		case stepAfterDenial:
+			m.statusLine = "Permission denied - asking for another approach..."
+		default:
+			m.statusLine = "Thinking..."
+		}
+		m.updateViewportContent()
+		return m, msg.next
+
+	case titleMsg:
+		m.titlePending = false
+		if msg.title == "" {
+			return m, nil
+		}
+		m.title = msg.title
+		m.chatSession.SetTitle(msg.title)
+		return m, tea.SetWindowTitle("goshi · " + msg.title)
 
 	case toolExecutionMsg:
 		// Tool execution completed
+		m.syncSession(msg.state)
+		m.toolRunning = false
 		m.statusLine = "Ready"
+		result := app.NormalizeToolResult(msg.result)
+
+		// In diff mode, show file writes as a diff against the pre-write backup
+		if m.mode == ModeDiff && msg.toolName == "fs.write" {
+			if diff, ok := renderWriteDiff(result, msg.args); ok {
+				m.messages = append(m.messages, Message{
+					Role:    "assistant",
+					Content: fmt.Sprintf("✓ Tool executed: %s%s\n\n%s", msg.toolName, formatToolDuration(result.Duration), diff),
+				})
+				m.updateViewportContent()
+				return m.afterTool(msg.next)
+			}
+		}
 
 		// Add tool result as a new assistant message
-		if resultStr, ok := msg.result["result"].(string); ok {
+		if result.Success {
 			m.messages = append(m.messages, Message{
 				Role:    "assistant",
-				Content: fmt.Sprintf("✓ Tool executed: %s\n\nResult: %s", msg.toolName, resultStr),
+				Content: fmt.Sprintf("✓ Tool executed: %s%s\n\nResult: %s", msg.toolName, formatToolDuration(result.Duration), formatToolValue(result.Value)),
 			})
-		} else if errStr, ok := msg.result["error"].(string); ok {
-			m.messages = append(m.messages, Message{
-				Role:    "assistant",
-				Content: fmt.Sprintf("✗ Tool failed: %s\n\nError: %s", msg.toolName, errStr),
-			})
-			m.err = fmt.Errorf("%s", errStr)
 		} else {
 			m.messages = append(m.messages, Message{
 				Role:    "assistant",
-				Content: fmt.Sprintf("✓ Tool executed: %s\n\nResult: %v", msg.toolName, msg.result),
+				Content: fmt.Sprintf("✗ Tool failed: %s%s\n\nError: %s", msg.toolName, formatToolDuration(result.Duration), result.Error),
 			})
+			m.err = fmt.Errorf("%s", result.Error)
 		}
 
 		m.updateViewportContent()
-		return m, nil
+		return m.afterTool(msg.next)
 
 	case llmErrorMsg:
+		m.syncSession(msg.state)
 		m.streaming = false
 		m.err = msg.err
 		m.statusLine = "Error"
 
-		// Remove the in-progress message
+		// Keep whatever streamed before the failure, marked as interrupted;
+		// otherwise remove the in-progress message. A failed continuation
+		// keeps the response it was extending.
 		if len(m.messages) > 0 && m.messages[len(m.messages)-1].InProgress {
-			m.messages = m.messages[:len(m.messages)-1]
+			if m.continuing {
+				last := &m.messages[len(m.messages)-1]
+				last.Content = m.continueBase + msg.partial
+				last.InProgress = false
+			} else if msg.partial != "" {
+				last := &m.messages[len(m.messages)-1]
+				last.Content = fmt.Sprintf("%s\n\n✗ Stream interrupted: %v", msg.partial, msg.err)
+				last.InProgress = false
+			} else {
+				m.messages = m.messages[:len(m.messages)-1]
+			}
 		}
 
+		m.continuing = false
 		m.updateViewportContent()
+		return m.sendQueued(nil)
+
+	case permissionRequestMsg:
+		req := msg.req
+		m.pendingPermission = &req
+		m.statusLine = "Awaiting permission"
 		return m, nil
 
 	case errMsg:
@@ -384,6 +654,12 @@ func (m model) View() string {
 	metrics := selfmodel.ComputeLawMetrics(m.systemPrompt)
 	m.statusBar.UpdateMetrics(metrics.RuleLines, metrics.ConstraintCount)
 
+	// Reflect the session's enforcement status (same semantics as the CLI)
+	state := m.sessionView()
+	if state.permissions {
+		m.telemetry.UpdateStatus(state.enforcement)
+	}
+
 	// Update inspect panel metrics
 	m.inspectPanel.UpdateMetrics(metrics.RuleLines, metrics.ConstraintCount)
 	m.inspectPanel.SetGuardrails(true)
@@ -397,27 +673,20 @@ func (m model) View() string {
 		}
 	}
 
-	// Update telemetry status and capabilities based on chat session
-	if m.chatSession != nil && m.chatSession.Permissions != nil {
-		perms := m.chatSession.Permissions
-		if perms.FSRead && perms.FSWrite {
-			m.telemetry.UpdateStatus("ACTIVE")
-		} else if perms.FSRead || perms.FSWrite {
-			m.telemetry.UpdateStatus("ACTIVE")
-		}
-
-		// Update capabilities
+	// Update capabilities based on chat session
+	if state.permissions {
+		perms := state
 		caps := &Capabilities{
 			ToolsEnabled:      true,
-			FilesystemAllowed: perms.FSRead || perms.FSWrite,
+			FilesystemAllowed: perms.fsRead || perms.fsWrite,
 			FilesystemStatus:  "denied",
 			NetworkAllowed:    false,
 			NetworkStatus:     "denied",
 		}
 
-		if perms.FSRead && perms.FSWrite {
+		if perms.fsRead && perms.fsWrite {
 			caps.FilesystemStatus = "allowed"
-		} else if perms.FSRead {
+		} else if perms.fsRead {
 			caps.FilesystemStatus = "read-only"
 		}
 
@@ -426,9 +695,20 @@ func (m model) View() string {
 
 	// Update memory count
 	if m.chatSession != nil {
-		m.telemetry.UpdateMemory(len(m.chatSession.Messages))
+		m.telemetry.UpdateMemory(state.messages)
 	}
 
+	// Show the provider's rate-limit budget when the backend reports it
+	if m.chatSession != nil && m.chatSession.Client != nil {
+		if reporter, ok := m.chatSession.Client.Backend().(llm.RateLimitReporter); ok {
+			m.telemetry.RecordRateLimits(reporter.RateLimits())
+		}
+	}
+
+	// Announce focus on the panels that own it
+	m.inspectPanel.SetFocused(m.focusedRegion == FocusInspectPanel)
+	m.auditPanel.SetFocused(m.focusedRegion == FocusAuditPanel)
+
 	// Render output stream (left side)
 	outputStream := m.renderOutputStream()
 
@@ -471,8 +751,11 @@ func (m model) View() string {
 	// Render status bar (2 lines)
 	statusBar := m.statusBar.Render(m.layout.TerminalWidth)
 
-	// Render input area
+	// Render input area, or the permission modal in its place
 	inputArea := m.renderInput()
+	if m.pendingPermission != nil {
+		inputArea = m.renderPermissionPrompt()
+	}
 
 	// Combine vertically
 	return lipgloss.JoinVertical(
@@ -488,20 +771,124 @@ type errMsg error
 
 type llmChunkMsg struct {
 	chunk string
+	next  tea.Cmd // Waits for the next message from the same stream
 }
 
 type llmCompleteMsg struct {
-	fullResponse string
-	parseResult  *llm.ParseResult
+	fullResponse  string
+	parseResult   *llm.ParseResult
+	confidence    float64 // Average top-token probability, when reported
+	hasConfidence bool
+	reasoning     string // Reasoning streamed separately from the answer
+	usage         llm.TokenUsage
+	hasUsage      bool          // Backend reported token counts for the response
+	latency       time.Duration // Time from request to the end of the stream
+	finishReason  string        // Why the model stopped, when reported
+
+	// The turn goes on after this response, which requested a tool: the
+	// tool runs, or is refused until explained when unexplained is set.
+	// A text response that goes on is an explanation of the next tool call.
+	more        bool
+	unexplained bool
+	next        tea.Cmd // Waits for the turn's next message, when more is set
+
+	state *sessionState // The session when the response was handled
+}
+
+// stepReason says why a turn asks the model again within the same turn
+type stepReason int
+
+const (
+	stepAfterTool   stepReason = iota // A tool ran and its result is fed back
+	stepExplain                       // A tool call was refused until explained
+	stepAfterDenial                   // A permission was denied and the model told
+)
+
+// llmStepMsg announces another model request within the current turn
+type llmStepMsg struct {
+	reason stepReason
+	next   tea.Cmd       // Waits for the turn's next message
+	state  *sessionState // The session as the model is asked again
+}
+
+// titleMsg carries the conversation title once generated
+type titleMsg struct {
+	title string
+}
+
+// requestTitle titles the conversation in the background once the first
+// exchange is complete. The exchange is read here, on the update loop, so
+// the background request never touches the history a turn may be changing.
+func (m *model) requestTitle() tea.Cmd {
+	if m.chatSession == nil || m.title != "" || m.titlePending {
+		return nil
+	}
+	sess := m.chatSession
+	user, assistant, ok := sess.FirstExchange()
+	if !ok {
+		return nil
+	}
+	m.titlePending = true
+	return func() tea.Msg {
+		title, _ := sess.TitleFor(sess.Context, user, assistant)
+		return titleMsg{title: title}
+	}
 }
 
 type llmErrorMsg struct {
-	err error
+	err     error
+	partial string        // Content received before the stream failed, if any
+	state   *sessionState // The session when the turn failed
 }
 
 type toolExecutionMsg struct {
 	toolName string
-	result   map[string]any
+	args     map[string]any
+	result   any           // app.ToolResult, or any handler return value (normalized on receipt)
+	next     tea.Cmd       // Waits for the turn's next message, when a turn ran the tool
+	state    *sessionState // The session after the tool ran, when a turn ran it
+}
+
+// sessionState is what the TUI shows of a chat session, snapshotted by
+// whichever goroutine owns the session at the time
+type sessionState struct {
+	messages    int    // Messages in the history
+	permissions bool   // The session has permissions; the fields below are set
+	enforcement string // Permissions.EnforcementStatus
+	fsRead      bool
+	fsWrite     bool
+}
+
+// snapshotSession captures the session state View shows. It must be called
+// from the goroutine that owns the session: the turn while one runs, the
+// UI loop otherwise.
+func snapshotSession(sess *session.ChatSession) sessionState {
+	if sess == nil {
+		return sessionState{}
+	}
+	state := sessionState{messages: len(sess.Messages)}
+	if perms := sess.Permissions; perms != nil {
+		state.permissions = true
+		state.enforcement = perms.EnforcementStatus()
+		state.fsRead, state.fsWrite = perms.FSRead, perms.FSWrite
+	}
+	return state
+}
+
+// sessionView returns the session state for View: the latest snapshot while
+// a turn owns the session, the session itself otherwise
+func (m model) sessionView() sessionState {
+	if m.busy() {
+		return m.session
+	}
+	return snapshotSession(m.chatSession)
+}
+
+// syncSession adopts a snapshot carried by a turn's message, if any
+func (m *model) syncSession(state *sessionState) {
+	if state != nil {
+		m.session = *state
+	}
 }
 
 func (m model) handleSendMessage() (tea.Model, tea.Cmd) {
@@ -510,23 +897,57 @@ func (m model) handleSendMessage() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Don't allow sending while streaming
-	if m.streaming {
+	m.textarea.Reset()
+
+	// Queue sends while a response streams or a tool runs, so only one of
+	// them updates the messages at a time
+	if m.busy() {
+		m.queuedSends = append(m.queuedSends, userInput)
+		m.statusLine = fmt.Sprintf("Busy - %d queued", len(m.queuedSends))
+		m.updateViewportContent()
 		return m, nil
 	}
 
-	// Add user message to history
+	return m.sendMessage(userInput)
+}
+
+// sendMessage sends user input to the model and starts streaming the reply
+func (m model) sendMessage(userInput string) (tea.Model, tea.Cmd) {
+	if userInput == "/continue" {
+		return m.handleContinue()
+	}
+	if userInput == "/permissions" {
+		return m.showPermissions()
+	}
+
+	// Refuse new input once the session turn limit is reached
+	if m.chatSession != nil && m.chatSession.TurnLimitReached() {
+		if !m.sessionEnded {
+			m.sessionEnded = true
+			m.messages = append(m.messages, Message{
+				Role:    "system",
+				Content: m.chatSession.WrapUpMessage(),
+			})
+			m.statusLine = "Session limit reached"
+		}
+		m.updateViewportContent()
+		return m, nil
+	}
+
+	// Add user message to history; the turn records it in the session,
+	// tagging answers to a pending clarification request
 	m.messages = append(m.messages, Message{
 		Role:    "user",
 		Content: userInput,
 	})
-
-	// Add to session
 	if m.chatSession != nil {
-		m.chatSession.AddUserMessage(userInput)
+		// No turn is running yet, so the session can be read directly
+		m.session = snapshotSession(m.chatSession)
+		m.messages[len(m.messages)-1].sessionPos = m.session.messages + 1
 	}
+	clarification := m.awaitingClarification
+	m.awaitingClarification = false
 
-	m.textarea.Reset()
 	m.updateViewportContent()
 
 	// Start streaming assistant response
@@ -541,81 +962,455 @@ func (m model) handleSendMessage() (tea.Model, tea.Cmd) {
 	})
 	m.updateViewportContent()
 
-	return m, streamLLMResponse(m.chatSession)
+	sess := m.chatSession
+	return m, streamTurn(sess, func(hooks session.TurnHooks) *session.ChatTurn {
+		turn := sess.NewTurn(userInput, hooks)
+		turn.ClarificationAnswer = clarification
+		return turn
+	})
 }
 
-// streamLLMResponse creates a command that streams LLM response chunks
-func streamLLMResponse(sess *session.ChatSession) tea.Cmd {
-	return func() tea.Msg {
-		// Get stream from backend
-		stream, err := sess.Client.Backend().Stream(
-			sess.Context,
-			sess.Client.System().Raw(),
-			sess.ConvertMessagesToLegacy(),
-		)
-		if err != nil {
-			return llmErrorMsg{err: err}
-		}
-		defer stream.Close()
+// busy reports whether a response is streaming or a tool call is running
+func (m model) busy() bool {
+	return m.streaming || m.toolRunning
+}
+
+// afterTool follows a tool result with the rest of its turn (next), or
+// with the oldest queued message when no turn is waiting on it
+func (m model) afterTool(next tea.Cmd) (tea.Model, tea.Cmd) {
+	if next != nil {
+		return m, next
+	}
+	return m.sendQueued(nil)
+}
 
-		// Collect response
-		collector := llm.NewResponseCollector(llm.NewStructuredParser())
+// sendQueued sends queued messages, oldest first, once nothing is running,
+// alongside cmd. It keeps going until one starts a turn, since commands
+// like /permissions are handled at once and would otherwise strand the rest.
+func (m model) sendQueued(cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	cmds := []tea.Cmd{cmd}
+	for !m.busy() && len(m.queuedSends) > 0 {
+		next := m.queuedSends[0]
+		m.queuedSends = m.queuedSends[1:]
+		updated, sendCmd := m.sendMessage(next)
+		m = updated.(model)
+		cmds = append(cmds, sendCmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// showPermissions shows the session's capability grants as a system message
+func (m model) showPermissions() (tea.Model, tea.Cmd) {
+	if m.chatSession == nil || m.chatSession.Permissions == nil {
+		m.statusLine = "No session permissions"
+		return m, nil
+	}
+	m.messages = append(m.messages, Message{
+		Role:    "system",
+		Content: "Capabilities:\n" + session.FormatStatus(m.chatSession.Permissions.Status()),
+	})
+	m.updateViewportContent()
+	return m, nil
+}
+
+// handleContinue asks the model to extend a response cut off by the output
+// token limit, streaming the continuation into that response's message
+func (m model) handleContinue() (tea.Model, tea.Cmd) {
+	if m.busy() {
+		return m, nil
+	}
+	last := len(m.messages) - 1
+	if !m.canContinue || m.chatSession == nil || last < 0 || m.messages[last].Role != "assistant" {
+		m.statusLine = "Nothing to continue"
+		return m, nil
+	}
+
+	m.canContinue = false
+	m.continuing = true
+	m.continueBase = m.messages[last].Content
+	m.messages[last].InProgress = true
+	m.statusLine = "Continuing..."
+	m.streaming = true
+	m.updateViewportContent()
+
+	sess := m.chatSession
+	return m, streamTurn(sess, sess.NewContinueTurn)
+}
 
-		// Stream chunks back to TUI
-		for {
-			chunk, err := stream.Recv()
-			if err != nil {
-				// Stream completed
+// showAssistantText shows final answer text in the in-progress message. A
+// continuation is shown appended to the response it extends.
+func (m *model) showAssistantText(text string) {
+	last := &m.messages[len(m.messages)-1]
+	if m.continuing {
+		last.Content = m.continueBase + text
+		return
+	}
+	last.Content = text
+	if m.chatSession != nil {
+		last.sessionPos = m.session.messages
+	}
+}
+
+// shutdown saves the session (when persistence is enabled) and closes the
+// audit log before the program quits
+func (m *model) shutdown() {
+	if m.chatSession == nil {
+		return
+	}
+	if err := m.chatSession.Shutdown(); err != nil {
+		m.err = err
+	}
+}
+
+// checkInputTruncation warns, once per draft, when typed or pasted text
+// did not fit under the input limit and was cut off
+func (m *model) checkInputTruncation(msg tea.Msg, before int) {
+	limit := m.textarea.CharLimit
+	if limit <= 0 {
+		return
+	}
+	if m.textarea.Length() < limit {
+		m.inputTruncated = false
+		return
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok || key.Type != tea.KeyRunes || m.inputTruncated {
+		return
+	}
+	dropped := before + len(key.Runes) - m.textarea.Length()
+	if dropped <= 0 {
+		return
+	}
+
+	m.inputTruncated = true
+	m.statusLine = "Input truncated"
+	warning := Message{
+		Role: "system",
+		Content: fmt.Sprintf("⚠ Input truncated at %d characters (%d dropped). Raise tui.input_char_limit in goshi.yaml, "+
+			"or save the content to a file and ask goshi to read it.", limit, dropped),
+	}
+
+	// A streaming response must stay the last message, so the warning
+	// goes before it
+	at := len(m.messages)
+	if at > 0 && m.messages[at-1].InProgress {
+		at--
+	}
+	m.messages = append(m.messages[:at], append([]Message{warning}, m.messages[at:]...)...)
+	if m.selectedMsg >= at {
+		m.selectedMsg++
+	}
+	m.updateViewportContent()
+}
+
+// moveSelection moves the output stream selection one message up or down,
+// starting from the latest message when nothing is selected
+func (m *model) moveSelection(up bool) {
+	if len(m.messages) == 0 {
+		return
+	}
+	switch {
+	case m.selectedMsg < 0:
+		m.selectedMsg = len(m.messages) - 1
+	case up && m.selectedMsg > 0:
+		m.selectedMsg--
+	case !up && m.selectedMsg < len(m.messages)-1:
+		m.selectedMsg++
+	}
+}
+
+// togglePin pins or unpins the selected message, or the latest user or
+// assistant message when nothing is selected. Pins are mirrored into the
+// session so the message survives context trimming.
+func (m *model) togglePin() {
+	idx := m.selectedMsg
+	if idx < 0 || idx >= len(m.messages) {
+		idx = -1
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if role := m.messages[i].Role; role == "user" || role == "assistant" {
+				idx = i
 				break
 			}
-			collector.AddChunk(chunk)
-
-			// TODO: Send individual chunks for progressive display
-			// For now, we accumulate and send at end
 		}
+	}
+	if idx < 0 {
+		return
+	}
 
-		// Parse complete response
-		fullResponse := collector.GetFullResponse()
-		parseResult, _ := collector.Parse()
+	msg := &m.messages[idx]
+	if msg.Role != "user" && msg.Role != "assistant" {
+		m.statusLine = "Only user and assistant messages can be pinned"
+		return
+	}
 
-		return llmCompleteMsg{
-			fullResponse: fullResponse,
-			parseResult:  parseResult,
-		}
+	msg.Pinned = !msg.Pinned
+	if m.chatSession != nil && msg.sessionPos > 0 && m.chatSession.IsPinned(msg.sessionPos-1) != msg.Pinned {
+		m.chatSession.TogglePin(msg.sessionPos - 1)
+	}
+
+	if msg.Pinned {
+		m.statusLine = "Message pinned"
+	} else {
+		m.statusLine = "Message unpinned"
 	}
 }
 
-// executeTool executes a tool call via the ToolRouter
-func executeTool(sess *session.ChatSession, action *llm.ActionCall) tea.Cmd {
+// streamTurn creates a command that runs a chat turn in the background.
+// newTurn creates the turn with hooks that deliver its progress as
+// messages: coalesced response chunks, each completed response, each tool
+// result and each further model request within the turn.
+func streamTurn(sess *session.ChatSession, newTurn func(session.TurnHooks) *session.ChatTurn) tea.Cmd {
 	return func() tea.Msg {
-		if sess == nil || sess.ToolRouter == nil {
-			return toolExecutionMsg{
-				toolName: action.Tool,
-				result: map[string]any{
-					"error": "session or tool router not initialized",
-				},
+		msgs := make(chan tea.Msg, 64)
+		go runTurn(sess, newTurn, msgs)
+		return waitForStreamMsg(msgs)()
+	}
+}
+
+// runTurn runs a turn, sending its progress to msgs. The turn ends with a
+// final llmCompleteMsg, or an llmErrorMsg if the model could not be reached.
+func runTurn(sess *session.ChatSession, newTurn func(session.TurnHooks) *session.ChatTurn, msgs chan<- tea.Msg) {
+	defer close(msgs)
+
+	chunks := newChunkCoalescer(chunkCoalesceWindow, func(batch string) {
+		msgs <- llmChunkMsg{chunk: batch}
+	})
+
+	// Each message carries a snapshot of the session, which this goroutine
+	// owns until the turn ends
+	snapshot := func() *sessionState {
+		state := snapshotSession(sess)
+		return &state
+	}
+
+	// A completed response is reported once the turn has decided what to
+	// do with it: run a tool, re-prompt, or end with it
+	var (
+		start    time.Time
+		latency  time.Duration
+		planned  bool
+		parsed   bool
+		denials  int
+		refused  string
+		complete = func(turn *session.ChatTurn, more bool) llmCompleteMsg {
+			parsed = false
+			msg := llmCompleteMsg{
+				fullResponse:  turn.Raw,
+				confidence:    turn.Confidence,
+				hasConfidence: turn.HasConfidence,
+				reasoning:     turn.Reasoning,
+				usage:         turn.Usage,
+				hasUsage:      turn.HasUsage,
+				latency:       latency,
+				finishReason:  turn.FinishReason,
+				more:          more,
+				state:         snapshot(),
 			}
+			if turn.Response != nil {
+				msg.parseResult = &llm.ParseResult{Response: turn.Response, Valid: true}
+			} else {
+				msg.fullResponse = turn.Text()
+			}
+			return msg
 		}
+	)
 
-		// Execute via ToolRouter
-		result := sess.ToolRouter.Handle(app.ToolCall{
-			Name: action.Tool,
-			Args: action.Args,
-		})
+	var final *llmCompleteMsg
+	hooks := session.TurnHooks{
+		OnPhase: func(turn *session.ChatTurn, phase session.Phase) {
+			switch phase {
+			case session.PhasePlan:
+				if planned {
+					reason := stepAfterTool
+					if parsed && turn.Response != nil && turn.Response.Type == llm.ResponseTypeText {
+						// The model explained its tool call and is asked to make it
+						msgs <- complete(turn, true)
+					} else if parsed {
+						// The last tool call was refused until explained
+						msg := complete(turn, true)
+						msg.unexplained = true
+						msgs <- msg
+						reason = stepExplain
+					} else if len(turn.Denied) > denials {
+						reason = stepAfterDenial
+					}
+					denials = len(turn.Denied)
+					msgs <- llmStepMsg{reason: reason, state: snapshot()}
+				}
+				planned = true
+				start = time.Now()
+			case session.PhaseParse:
+				chunks.Flush()
+				latency = time.Since(start)
+				parsed = true
+			case session.PhaseAct:
+				msgs <- complete(turn, true)
+			case session.PhaseReport:
+				if parsed {
+					msg := complete(turn, false)
+					final = &msg
+				}
+			}
+		},
+		Detect: func(matches []detect.Match) bool {
+			// Show the rule behind a detected request in the modal
+			var explain func(capability, reason string)
+			if prompter, ok := sess.Prompter.(*TUIPrompter); ok && config.Load().Safety.ExplainDetection {
+				explain = prompter.Explain
+			}
+			refused = sess.RequestDetectedPermissions(matches, explain)
+			return refused == ""
+		},
+		OnChunk: chunks.Add,
+		Act: func(action *llm.ActionCall) any {
+			msg := executeTool(sess, action)
+			msg.state = snapshot()
+			msgs <- msg
+			return msg.result
+		},
+	}
 
-		// Convert result to map
-		resultMap, ok := result.(map[string]any)
+	turn := newTurn(hooks)
+	if err := turn.Run(); err != nil {
+		chunks.Flush()
+		msgs <- llmErrorMsg{err: err, partial: turn.Raw, state: snapshot()}
+		return
+	}
+	if final == nil {
+		// The turn ended on a tool call, or stopped on a refused detection
+		final = &llmCompleteMsg{}
+		if turn.Stopped && refused != "" {
+			final.fullResponse = "Permission denied: " + refused
+		}
+	}
+	final.state = snapshot()
+	msgs <- *final
+}
+
+// waitForStreamMsg returns a command that delivers the next message of an
+// in-flight turn. Messages the turn follows up carry a command for the next one.
+func waitForStreamMsg(msgs <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-msgs
 		if !ok {
-			resultMap = map[string]any{
-				"result": fmt.Sprintf("%v", result),
+			return nil
+		}
+		switch msg := msg.(type) {
+		case llmChunkMsg:
+			msg.next = waitForStreamMsg(msgs)
+			return msg
+		case llmStepMsg:
+			msg.next = waitForStreamMsg(msgs)
+			return msg
+		case toolExecutionMsg:
+			msg.next = waitForStreamMsg(msgs)
+			return msg
+		case llmCompleteMsg:
+			if msg.more {
+				msg.next = waitForStreamMsg(msgs)
 			}
+			return msg
 		}
+		return msg
+	}
+}
 
+// chunkCoalesceWindow is how long stream chunks are batched before being
+// handed to the TUI, so fast backends don't flood the update loop
+const chunkCoalesceWindow = 16 * time.Millisecond
+
+// chunkCoalescer batches stream chunks, emitting those that arrived within
+// each window joined together
+type chunkCoalescer struct {
+	window time.Duration
+	emit   func(string)
+
+	mu      sync.Mutex
+	pending strings.Builder
+	timer   *time.Timer
+}
+
+// newChunkCoalescer creates a coalescer that passes batches to emit
+func newChunkCoalescer(window time.Duration, emit func(string)) *chunkCoalescer {
+	return &chunkCoalescer{window: window, emit: emit}
+}
+
+// Add queues a chunk, starting a window if none is open
+func (c *chunkCoalescer) Add(chunk string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending.WriteString(chunk)
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.window, c.Flush)
+	}
+}
+
+// Flush emits the pending chunks now, closing the open window
+func (c *chunkCoalescer) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if c.pending.Len() > 0 {
+		c.emit(c.pending.String())
+		c.pending.Reset()
+	}
+}
+
+// executeTool executes a tool call via the ToolRouter, asking for the
+// tool's capability first
+func executeTool(sess *session.ChatSession, action *llm.ActionCall) toolExecutionMsg {
+	if sess == nil || sess.ToolRouter == nil {
 		return toolExecutionMsg{
 			toolName: action.Tool,
-			result:   resultMap,
+			result:   app.ToolResult{Error: "session or tool router not initialized"},
 		}
 	}
+
+	// A denial is recorded and the router then refuses the call
+	sess.RequestToolPermission(action.Tool)
+
+	// Execute via ToolRouter, which normalizes the result
+	result := sess.ToolRouter.Execute(app.ToolCall{
+		Name: action.Tool,
+		Args: action.Args,
+	})
+
+	return toolExecutionMsg{
+		toolName: action.Tool,
+		args:     action.Args,
+		result:   result,
+	}
+}
+
+// formatToolDuration renders how long a tool ran as a suffix for its
+// message, e.g. " (1.2s)", or "" when the duration is unknown
+func formatToolDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return ""
+	case d < time.Millisecond:
+		return " (<1ms)"
+	case d < time.Second:
+		return fmt.Sprintf(" (%dms)", d.Milliseconds())
+	default:
+		return fmt.Sprintf(" (%.1fs)", d.Seconds())
+	}
+}
+
+// formatToolValue renders a tool result value: strings as-is, structured
+// values as indented JSON so their shape is preserved
+func formatToolValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
 }
 
 func (m *model) updateViewportContent() {
@@ -624,6 +1419,12 @@ func (m *model) updateViewportContent() {
 	sb.WriteString(styleWelcome("Welcome to Goshi TUI\n\nCommands:\n  Enter - Send message\n  Ctrl+C/Ctrl+Q - Quit\n  ↑/↓ - Scroll chat\n"))
 	sb.WriteString("\n")
 
+	// Project-specific banner, if the repo ships one
+	if m.chatSession != nil && m.chatSession.MOTD != "" {
+		sb.WriteString(m.theme.renderMessage("system", m.chatSession.MOTD))
+		sb.WriteString("\n\n")
+	}
+
 	if m.streaming {
 		sb.WriteString(styleStatus("✨ Streaming response...\n\n"))
 	}
@@ -641,19 +1442,17 @@ func (m *model) updateViewportContent() {
 		msg := m.messages[i]
 		content := msg.Content
 		if msg.InProgress {
-			content += "▊" // Show cursor for streaming
+			content += m.theme.cursor() // Show cursor for streaming
 		}
-
-		switch msg.Role {
-		case "user":
-			sb.WriteString(styleUserMessage(content))
-		case "system":
-			sb.WriteString(styleSystemMessage(content))
-		case "tool":
-			sb.WriteString(styleToolMessage(content))
-		default: // "assistant" or any other
-			sb.WriteString(styleAssistantMessage(content))
+		if msg.Pinned {
+			content = "📌 " + content
 		}
+		if i == m.selectedMsg {
+			content = "▸ " + content
+		}
+		content = hardWrap(content, m.viewport.Width-maxRolePrefixWidth)
+
+		sb.WriteString(m.theme.renderMessage(msg.Role, content))
 		sb.WriteString("\n\n")
 	}
 
@@ -661,12 +1460,47 @@ func (m *model) updateViewportContent() {
 	m.viewport.GotoBottom()
 }
 
-func (m model) renderInput() string {
-	focusIndicator := ""
-	if m.focusedRegion == FocusInput {
-		focusIndicator = " (focused)"
+// maxRolePrefixWidth is the widest role label ("ASSISTANT: ") plus the
+// selection marker, reserved so wrapped lines still fit after styling
+const maxRolePrefixWidth = 13
+
+// minWrapWidth keeps hard-wrapping readable in very narrow viewports
+const minWrapWidth = 20
+
+// hardWrap breaks lines longer than width runes into width-sized pieces.
+// The viewport does not wrap, so a single multi-kilobyte line (e.g. a
+// minified tool result) would otherwise overflow and stall rendering.
+func hardWrap(text string, width int) string {
+	if width < minWrapWidth {
+		width = minWrapWidth
+	}
+	if len(text) <= width {
+		return text // fast path: no line can exceed width runes
 	}
 
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		runes := []rune(line)
+		if len(runes) <= width {
+			continue
+		}
+		var sb strings.Builder
+		sb.Grow(len(line) + len(runes)/width)
+		for start := 0; start < len(runes); start += width {
+			if start > 0 {
+				sb.WriteByte('\n')
+			}
+			end := min(start+width, len(runes))
+			sb.WriteString(string(runes[start:end]))
+		}
+		lines[i] = sb.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m model) renderInput() string {
+	title := regionTitle(FocusInput, m.focusedRegion == FocusInput)
+
 	// Mode selector display
 	modeDisplay := fmt.Sprintf(" │ Mode: %s (Ctrl+M)", m.mode.String())
 
@@ -695,16 +1529,44 @@ func (m model) renderInput() string {
 		auditDisplay = " │ Audit: ○ (Ctrl+A to show)"
 	}
 
+	// Busy indicator while a response streams or a tool runs
+	busyDisplay := ""
+	if m.busy() {
+		busyDisplay = " │ ⏳ Busy"
+		if n := len(m.queuedSends); n > 0 {
+			busyDisplay += fmt.Sprintf(" (%d queued)", n)
+		}
+	}
+
 	return fmt.Sprintf(
-		"┌─ Input (Enter: send, Tab: focus, Ctrl+L: mode, Ctrl+D/T: toggle, Ctrl+A: audit, Ctrl+H: help, Ctrl+Q: quit)%s%s%s%s\n%s",
-		focusIndicator,
+		"┌─ %s (Enter: send, Tab: focus, Ctrl+L: mode, Ctrl+D/T: toggle, Ctrl+A: audit, Ctrl+H: help, Ctrl+Q: quit)%s%s%s%s\n%s",
+		title,
 		modeDisplay,
 		toglesDisplay,
 		auditDisplay,
+		busyDisplay,
 		m.textarea.View(),
 	)
 }
 
+// formatClarification renders a clarification question with its answer hints
+func formatClarification(c *llm.ClarificationRequest) string {
+	if c == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(c.Question)
+	if len(c.Hints) > 0 {
+		sb.WriteString("\n")
+		for i, hint := range c.Hints {
+			sb.WriteString(fmt.Sprintf("\n  %d) %s", i+1, hint))
+		}
+	}
+	sb.WriteString("\n\n(Your next message answers this question)")
+	return sb.String()
+}
+
 // CodeBlock represents a collapsible code block in the output
 type CodeBlock struct {
 	Language  string
@@ -808,6 +1670,8 @@ func (msg *Message) AccessibilityInfo() string {
 		roleLabel = "System message"
 	case "tool":
 		roleLabel = "Tool message"
+	case "clarification":
+		roleLabel = "Clarification request"
 	default:
 		roleLabel = "Message"
 	}
@@ -816,6 +1680,9 @@ func (msg *Message) AccessibilityInfo() string {
 	if msg.InProgress {
 		status = ", currently streaming"
 	}
+	if msg.Pinned {
+		status += ", pinned"
+	}
 
 	return fmt.Sprintf("%s%s: %s", roleLabel, status, msg.Content)
 }
@@ -823,10 +1690,11 @@ func (msg *Message) AccessibilityInfo() string {
 // AccessibilityDescription returns a description suitable for ARIA labels
 func (m *model) AccessibilityDescription() string {
 	return fmt.Sprintf(
-		"Goshi TUI. Current mode: %s. Toggles: Dry Run %s, Deterministic %s. "+
-			"Focus: Use Tab to cycle between output stream, inspect panel, and input area. "+
+		"Goshi TUI. Current mode: %s. Focused region: %s. Toggles: Dry Run %s, Deterministic %s. "+
+			"Focus: Use Tab to cycle between output stream, inspect panel, audit panel (when shown), and input area. "+
 			"Commands: Enter to send, Ctrl+L to change mode, Ctrl+D/T to toggle, Ctrl+Q to quit.",
 		m.mode.String(),
+		m.focusedRegion.String(),
 		func() string {
 			if m.toggles.DryRun {
 				return "on"
@@ -845,20 +1713,21 @@ func (m *model) AccessibilityDescription() string {
 // renderOutputStream renders the main output stream (left region)
 func (m model) renderOutputStream() string {
 	// Create a border with focus indicator
+	focused := m.focusedRegion == FocusOutputStream
 	borderStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
+		BorderForeground(borderColor(focused)).
 		Width(m.layout.OutputStreamWidth - 2).
 		Height(m.layout.OutputStreamHeight - 2)
 
-	if m.focusedRegion == FocusOutputStream {
-		borderStyle = borderStyle.BorderForeground(lipgloss.Color("12"))
-	}
-
 	// Content is the viewport
 	content := m.viewport.View()
 
-	return borderStyle.Render(content)
+	title := regionTitle(FocusOutputStream, focused)
+	if m.title != "" {
+		title += " · " + m.title
+	}
+	return withBorderTitle(borderStyle.Render(content), title)
 }
 
 // Styles using lipgloss
@@ -884,6 +1753,18 @@ var (
 			Foreground(lipgloss.Color("141")).
 			PaddingLeft(2)
 
+	clarificationStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("226")).
+				Border(lipgloss.NormalBorder(), false, false, false, true).
+				BorderForeground(lipgloss.Color("226")).
+				PaddingLeft(1).
+				MarginLeft(1)
+
+	reasoningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("240")).
+			Italic(true).
+			PaddingLeft(2)
+
 	roleStyle = lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("250"))
@@ -901,22 +1782,37 @@ var (
 			Italic(true)
 )
 
+// messageStyles are the message body styles, by role
+var messageStyles = map[string]lipgloss.Style{
+	"user":          userStyle,
+	"assistant":     assistantStyle,
+	"system":        systemStyle,
+	"tool":          toolStyle,
+	"clarification": clarificationStyle,
+	"reasoning":     reasoningStyle,
+}
+
+// defaultTheme renders messages outside a model, e.g. in tests
+var defaultTheme = DefaultTheme()
+
 func styleHeader(text string) string { return headerStyle.Render(text) }
 func styleUserMessage(text string) string {
-	role := roleStyle.Render("USER: ")
-	return userStyle.Render(role + text)
+	return defaultTheme.renderMessage("user", text)
 }
 func styleAssistantMessage(text string) string {
-	role := roleStyle.Render("ASSISTANT: ")
-	return assistantStyle.Render(role + text)
+	return defaultTheme.renderMessage("assistant", text)
 }
 func styleSystemMessage(text string) string {
-	role := roleStyle.Render("SYSTEM: ")
-	return systemStyle.Render(role + text)
+	return defaultTheme.renderMessage("system", text)
 }
 func styleToolMessage(text string) string {
-	role := roleStyle.Render("TOOL: ")
-	return toolStyle.Render(role + text)
+	return defaultTheme.renderMessage("tool", text)
+}
+func styleClarificationMessage(text string) string {
+	return defaultTheme.renderMessage("clarification", text)
+}
+func styleReasoningMessage(text string) string {
+	return defaultTheme.renderMessage("reasoning", text)
 }
 func styleStatus(text string) string  { return statusStyle.Render(text) }
 func styleError(text string) string   { return errorStyle.Render(text) }
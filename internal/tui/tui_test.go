@@ -1,12 +1,24 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cshaiku/goshi/internal/actions/runtime"
+	"github.com/cshaiku/goshi/internal/app"
+	"github.com/cshaiku/goshi/internal/config"
+	"github.com/cshaiku/goshi/internal/llm"
+	"github.com/cshaiku/goshi/internal/session"
 )
 
 func TestNewModel(t *testing.T) {
@@ -51,6 +63,20 @@ func TestModelQuitOnEscape(t *testing.T) {
 	}
 }
 
+func TestQuitShutsDownSession(t *testing.T) {
+	sess := newTestChatSession(t, "ok")
+	m := newModel("test", sess)
+	m.ready = true
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlQ})
+	if cmd == nil {
+		t.Fatal("expected Quit command on Ctrl+Q")
+	}
+	if !sess.AuditLogger.Closed() {
+		t.Error("expected the session to be shut down before quitting")
+	}
+}
+
 func TestWindowSizeUpdate(t *testing.T) {
 	m := newModel("test", nil)
 
@@ -271,6 +297,66 @@ func TestLayoutMinimumSize(t *testing.T) {
 	}
 }
 
+func TestLLMCompleteRecordsTokenUsage(t *testing.T) {
+	m := newModel("test", nil)
+	m.ready = true
+	m.streaming = true
+	m.messages = append(m.messages, Message{Role: "assistant", InProgress: true})
+
+	updatedModel, _ := m.Update(llmCompleteMsg{
+		fullResponse: "hi",
+		usage:        llm.TokenUsage{PromptTokens: 26, CompletionTokens: 298},
+		hasUsage:     true,
+		latency:      250 * time.Millisecond,
+	})
+	updated := updatedModel.(model)
+
+	if updated.telemetry.TokensUsed != 324 || updated.telemetry.RequestCount != 1 {
+		t.Errorf("expected 324 tokens over 1 request, got %d over %d", updated.telemetry.TokensUsed, updated.telemetry.RequestCount)
+	}
+	if updated.telemetry.LastLatency != 250*time.Millisecond {
+		t.Errorf("expected latency 250ms, got %v", updated.telemetry.LastLatency)
+	}
+}
+
+func TestLLMCompleteTitlesConversation(t *testing.T) {
+	sess := newTestChatSession(t, "Greeting the assistant")
+	sess.TitleMode = session.TitleModel
+	sess.AddUserMessage("hello there")
+	sess.AddAssistantTextMessage("hi")
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.messages = append(m.messages, Message{Role: "assistant", InProgress: true})
+
+	updatedModel, cmd := m.Update(llmCompleteMsg{fullResponse: "hi"})
+	if cmd == nil {
+		t.Fatal("expected a title request after the first exchange")
+	}
+	msg, ok := cmd().(titleMsg)
+	if !ok {
+		t.Fatalf("expected titleMsg, got %T", msg)
+	}
+
+	updatedModel, cmd = updatedModel.(model).Update(msg)
+	updated := updatedModel.(model)
+	if updated.title != "Greeting the assistant" {
+		t.Errorf("expected the generated title, got %q", updated.title)
+	}
+	if title, _ := sess.Title(context.Background()); title != "Greeting the assistant" {
+		t.Errorf("expected the title handed to the session, got %q", title)
+	}
+	if cmd == nil {
+		t.Error("expected the window title to be set")
+	}
+
+	// Later completions keep the title without asking again
+	updated.messages = append(updated.messages, Message{Role: "assistant", InProgress: true})
+	if _, cmd := updated.Update(llmCompleteMsg{fullResponse: "again"}); cmd != nil {
+		t.Error("expected no further title requests")
+	}
+}
+
 func TestTelemetryRecordRequest(t *testing.T) {
 	telemetry := NewTelemetry()
 
@@ -446,6 +532,131 @@ func TestInspectPanelMemorySection(t *testing.T) {
 	}
 }
 
+func TestInspectPanelMemoryBarUsesConfiguredMax(t *testing.T) {
+	telemetry := NewTelemetry()
+	telemetry.MemoryMax = memoryCapacity(40, 16384)
+	telemetry.UpdateMemory(10)
+
+	panel := NewInspectPanel(telemetry)
+	section := panel.renderMemorySection()
+
+	if !strings.Contains(section, "10/40") || !strings.Contains(section, "25%") {
+		t.Errorf("expected 10/40 at 25%%, got %q", section)
+	}
+	if filled := strings.Count(section, "█"); filled != memoryBarWidth/4 {
+		t.Errorf("expected %d filled cells, got %d", memoryBarWidth/4, filled)
+	}
+
+	// Past the capacity the bar stays full rather than overflowing
+	telemetry.UpdateMemory(60)
+	section = panel.renderMemorySection()
+	if !strings.Contains(section, "60/40") || !strings.Contains(section, "100%") {
+		t.Errorf("expected 60/40 capped at 100%%, got %q", section)
+	}
+	if filled := strings.Count(section, "█"); filled != memoryBarWidth {
+		t.Errorf("expected a full bar, got %d filled cells", filled)
+	}
+}
+
+func TestMemoryCapacity(t *testing.T) {
+	tests := []struct {
+		configured, contextTokens, want int
+	}{
+		{40, 16384, 40}, // configured wins
+		{0, 16384, 128}, // derived from the context window
+		{0, 4096, 32},   // smaller window, smaller capacity
+		{0, 64, 1},      // never below one entry
+		{0, 0, 128},     // unknown window falls back to the default
+	}
+	for _, tt := range tests {
+		if got := memoryCapacity(tt.configured, tt.contextTokens); got != tt.want {
+			t.Errorf("memoryCapacity(%d, %d) = %d, want %d", tt.configured, tt.contextTokens, got, tt.want)
+		}
+	}
+}
+
+func TestNewModelSizesGaugesFromContextWindow(t *testing.T) {
+	sess := newTestChatSession(t)
+	sess.ContextTokens = 4096
+	sess.AddUserMessage("hello")
+
+	m := newModel("test", sess)
+	if m.telemetry.TokensLimit != 4096 || m.telemetry.MemoryMax != 32 {
+		t.Errorf("expected gauges sized to 4096 tokens and 32 entries, got %d and %d", m.telemetry.TokensLimit, m.telemetry.MemoryMax)
+	}
+}
+
+func TestInspectPanelNilTelemetry(t *testing.T) {
+	panel := NewInspectPanel(nil)
+	panel.SetSize(30, 40)
+
+	rendered := panel.Render("test")
+
+	for _, want := range []string{"MEMORY", "Entries: n/a", "Temperature: n/a", "CAPABILITIES"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("expected %q with nil telemetry, got:\n%s", want, rendered)
+		}
+	}
+	if strings.Contains(rendered, "Confidence") {
+		t.Error("expected no confidence line without telemetry")
+	}
+}
+
+func TestInspectPanelConfidence(t *testing.T) {
+	telemetry := NewTelemetry()
+
+	panel := NewInspectPanel(telemetry)
+	panel.SetSize(30, 40)
+
+	if strings.Contains(panel.Render("test"), "Confidence") {
+		t.Error("expected no confidence line without logprobs")
+	}
+
+	telemetry.RecordConfidence(0.875, true)
+	rendered := panel.Render("test")
+	if !strings.Contains(rendered, "Confidence") || !strings.Contains(rendered, "88%") {
+		t.Errorf("expected confidence of 88%%, got:\n%s", rendered)
+	}
+}
+
+func TestInspectPanelRateLimits(t *testing.T) {
+	telemetry := NewTelemetry()
+
+	panel := NewInspectPanel(telemetry)
+	panel.SetSize(30, 40)
+
+	if strings.Contains(panel.Render("test"), "Requests left") {
+		t.Error("expected no rate-limit lines before the backend reports them")
+	}
+
+	telemetry.RecordRateLimits(llm.RateLimits{
+		Known:             true,
+		LimitRequests:     500,
+		RemainingRequests: 12,
+		LimitTokens:       30000,
+		RemainingTokens:   1500,
+	})
+	rendered := panel.Render("test")
+	if !strings.Contains(rendered, "Requests left: 12/500") || !strings.Contains(rendered, "Tokens left: 1500/30000") {
+		t.Errorf("expected rate limits, got:\n%s", rendered)
+	}
+}
+
+func TestInspectPanelPersona(t *testing.T) {
+	panel := NewInspectPanel(NewTelemetry())
+	panel.SetSize(30, 40)
+
+	laws := "primary_laws:\n  - Truth\n"
+	if strings.Contains(panel.Render(laws), "Persona") {
+		t.Error("expected no persona line without a persona")
+	}
+
+	rendered := panel.Render(laws + "persona: terse and dry\n")
+	if !strings.Contains(rendered, "Persona") || !strings.Contains(rendered, "active") {
+		t.Errorf("expected persona to be shown as active, got:\n%s", rendered)
+	}
+}
+
 func TestInspectPanelPromptInfo(t *testing.T) {
 	telemetry := NewTelemetry()
 	telemetry.Temperature = 0.7
@@ -682,6 +893,41 @@ func TestModeSelector(t *testing.T) {
 	}
 }
 
+func TestInputPromptFollowsMode(t *testing.T) {
+	m := newModel("test", nil)
+	m.textarea.SetValue("hello")
+
+	for _, want := range []string{"chat> ", "cmd> ", "diff> ", "chat> "} {
+		if got := m.inputPrompt(); got != want {
+			t.Errorf("expected prompt %q in %s mode, got %q", want, m.mode, got)
+		}
+		if firstLine := strings.SplitN(m.textarea.View(), "\n", 2)[0]; !strings.Contains(firstLine, want+"hello") {
+			t.Errorf("expected the input line to show %q, got %q", want+"hello", firstLine)
+		}
+		result, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+		m = result.(model)
+	}
+}
+
+func TestInputPromptFromConfig(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "goshi.yaml")
+	if err := os.WriteFile(cfgPath, []byte("tui:\n  mode: diff\n  prompts:\n    diff: \"Δ \"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	m := newModel("test", nil)
+	if m.mode != ModeDiff || m.inputPrompt() != "Δ " {
+		t.Errorf("expected the configured diff prompt, got mode=%s prompt=%q", m.mode, m.inputPrompt())
+	}
+	m.textarea.SetValue("x")
+	if first := strings.SplitN(m.textarea.View(), "\n", 2)[0]; !strings.Contains(first, "Δ x") {
+		t.Errorf("expected the input line to show the configured prompt, got %q", first)
+	}
+}
+
 func TestModeString(t *testing.T) {
 	tests := []struct {
 		mode     Mode
@@ -1011,6 +1257,51 @@ func TestAccessibilityFocusIndicators(t *testing.T) {
 	}
 }
 
+func TestFocusCycleAnnouncesEachRegion(t *testing.T) {
+	m := newModel("test", nil)
+	m.auditPanelVisible = true
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+	m = updated.(model)
+
+	// Input starts focused; Tab visits every region and wraps around
+	want := []FocusRegion{FocusOutputStream, FocusInspectPanel, FocusAuditPanel, FocusInput}
+	for _, region := range want {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+		m = updated.(model)
+
+		if m.focusedRegion != region {
+			t.Fatalf("expected focus on %s, got %s", region, m.focusedRegion)
+		}
+		if desc := m.AccessibilityDescription(); !strings.Contains(desc, "Focused region: "+region.String()) {
+			t.Errorf("expected description to announce %s, got %q", region, desc)
+		}
+
+		view := m.View()
+		if !strings.Contains(view, region.String()+" (focused)") {
+			t.Errorf("expected %q label in view", region.String()+" (focused)")
+		}
+		if n := strings.Count(view, "(focused)"); n != 1 {
+			t.Errorf("expected exactly one focus label with %s focused, got %d", region, n)
+		}
+	}
+}
+
+func TestWithBorderTitle(t *testing.T) {
+	border := "╭" + strings.Repeat("─", 20) + "╮\n│ body │"
+
+	titled := withBorderTitle(border, "Input")
+	if !strings.HasPrefix(titled, "╭─ Input ───") {
+		t.Errorf("expected title in the top border, got %q", titled)
+	}
+	if got, want := len([]rune(strings.Split(titled, "\n")[0])), 22; got != want {
+		t.Errorf("expected border width to be preserved (%d runes), got %d", want, got)
+	}
+
+	if got := withBorderTitle(border, strings.Repeat("x", 30)); got != border {
+		t.Errorf("expected a too-long title to leave the border untouched, got %q", got)
+	}
+}
+
 func TestMultipleRolesInOutput(t *testing.T) {
 	m := newModel("test", nil)
 	m.messages = []Message{
@@ -1031,3 +1322,1280 @@ func TestMultipleRolesInOutput(t *testing.T) {
 		}
 	}
 }
+
+// mockBackend implements llm.Backend for TUI tests
+type mockBackend struct {
+	responses []string
+}
+
+func (b *mockBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	return &mockStream{data: b.responses}, nil
+}
+
+// mockStream implements llm.Stream over a fixed set of chunks
+type mockStream struct {
+	data  []string
+	index int
+}
+
+func (s *mockStream) Recv() (string, error) {
+	if s.index >= len(s.data) {
+		return "", io.EOF
+	}
+	chunk := s.data[s.index]
+	s.index++
+	return chunk, nil
+}
+
+func (s *mockStream) Close() error { return nil }
+
+func newTestChatSession(t *testing.T, responses ...string) *session.ChatSession {
+	t.Helper()
+	t.Setenv("GOSHI_AUDIT_ENABLED", "false")
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	sess, err := session.NewChatSession(context.Background(), "test", &mockBackend{responses: responses})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	return sess
+}
+
+func TestToolPermissionUsesModalPrompt(t *testing.T) {
+	sess := newTestChatSession(t, "ok")
+	m := newModel("test", sess)
+	m.ready = true
+
+	// The tool command blocks on the prompter until the modal is answered
+	done := make(chan tea.Msg, 1)
+	go func() {
+		done <- executeTool(sess, &llm.ActionCall{Tool: "fs.list", Args: map[string]any{"path": "."}})
+	}()
+
+	updated, _ := m.Update(m.prompter.waitForRequest()())
+	m = updated.(model)
+	if m.pendingPermission == nil || m.pendingPermission.capability != "FS_READ" {
+		t.Fatalf("expected a pending FS_READ request, got %+v", m.pendingPermission)
+	}
+	if view := m.View(); !strings.Contains(view, "Permission required") || !strings.Contains(view, "FS_READ") {
+		t.Error("expected the permission modal in the view")
+	}
+
+	// Other keys are ignored while the modal is open
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(model)
+	if m.pendingPermission == nil {
+		t.Fatal("expected the modal to stay open")
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = updated.(model)
+	if m.pendingPermission != nil || cmd == nil {
+		t.Error("expected the modal to close and the prompter to be re-armed")
+	}
+
+	select {
+	case msg := <-done:
+		result := app.NormalizeToolResult(msg.(toolExecutionMsg).result)
+		if !result.Success {
+			t.Errorf("expected the tool to run after the grant, got %q", result.Error)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("tool command did not resume after the answer")
+	}
+	if !sess.HasPermission("FS_READ") {
+		t.Error("expected the grant to be recorded in the session")
+	}
+}
+
+func TestDetectedPermissionPromptShowsTriggeringRule(t *testing.T) {
+	sess := newTestChatSession(t, "ok")
+	sess.ContinueAfterDenial = false
+	m := newModel("test", sess)
+	m.ready = true
+	m.textarea.SetValue("list files")
+	updatedModel, cmd := m.handleSendMessage()
+	m = updatedModel.(model)
+
+	// The turn blocks in its Detect phase until the modal is answered
+	first := make(chan tea.Msg, 1)
+	go func() { first <- cmd() }()
+
+	updated, _ := m.Update(m.prompter.waitForRequest()())
+	m = updated.(model)
+	if m.pendingPermission == nil || m.pendingPermission.capability != "FS_READ" {
+		t.Fatalf("expected a pending FS_READ request, got %+v", m.pendingPermission)
+	}
+	if view := m.View(); !strings.Contains(view, "because your message matched") || !strings.Contains(view, `verb "list"`) {
+		t.Errorf("expected the triggering rule in the modal, got:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = updated.(model)
+
+	var msg tea.Msg
+	select {
+	case msg = <-first:
+	case <-time.After(2 * time.Second):
+		t.Fatal("turn did not resume after the answer")
+	}
+	for msg != nil {
+		updated, _ = m.Update(msg)
+		m = updated.(model)
+		next := turnNext(msg)
+		if next == nil {
+			break
+		}
+		msg = next()
+	}
+
+	last := m.messages[len(m.messages)-1]
+	if last.Role != "assistant" || !strings.Contains(last.Content, "Permission denied: FS_READ") {
+		t.Errorf("expected the refusal shown, got %+v", last)
+	}
+}
+
+func TestPaidProviderWarningShownAsSystemMessage(t *testing.T) {
+	sess := newTestChatSession(t, "ok")
+	sess.PaidWarning = "openai is a paid provider: requests are billed to your account"
+
+	m := newModel("test", sess)
+	if len(m.messages) != 1 || m.messages[0].Role != "system" || !strings.Contains(m.messages[0].Content, "openai is a paid provider") {
+		t.Errorf("expected the paid-provider warning as a system message, got %+v", m.messages)
+	}
+}
+
+func TestMOTDDisplayedWhenPresent(t *testing.T) {
+	sess := newTestChatSession(t, "ok")
+
+	m := newModel("test", sess)
+	m.updateViewportContent()
+	if strings.Contains(m.viewport.View(), "Project notes") {
+		t.Fatal("expected no MOTD when none is loaded")
+	}
+
+	sess.MOTD = "Project notes: run make test"
+	m.updateViewportContent()
+	if !strings.Contains(m.viewport.View(), "Project notes: run make test") {
+		t.Error("expected MOTD in the welcome area")
+	}
+}
+
+func TestTogglePinMarksMessage(t *testing.T) {
+	sess := newTestChatSession(t, "ok")
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.textarea.SetValue("keep this")
+	updatedModel, cmd := m.handleSendMessage()
+	updated := runStream(t, updatedModel.(model), cmd)
+
+	// Select the user message and pin it
+	updated.focusedRegion = FocusOutputStream
+	updated.moveSelection(true)
+	updated.moveSelection(true)
+	if updated.selectedMsg != 0 {
+		t.Fatalf("expected first message selected, got %d", updated.selectedMsg)
+	}
+	updated.togglePin()
+
+	if !updated.messages[0].Pinned {
+		t.Fatal("expected selected message to be pinned")
+	}
+	if !sess.IsPinned(0) {
+		t.Error("expected pin to be mirrored into the session")
+	}
+
+	updated.updateViewportContent()
+	if !strings.Contains(updated.viewport.View(), "📌") {
+		t.Error("expected pinned message to render with a pin marker")
+	}
+	if !strings.Contains(updated.messages[0].AccessibilityInfo(), "pinned") {
+		t.Error("expected accessibility info to mention the pin")
+	}
+
+	updated.togglePin()
+	if updated.messages[0].Pinned || sess.IsPinned(0) {
+		t.Error("expected second toggle to unpin")
+	}
+}
+
+func TestChunkCoalescerBatchesRapidChunks(t *testing.T) {
+	data := make([]string, 200)
+	for i := range data {
+		data[i] = fmt.Sprintf("c%d ", i)
+	}
+
+	var batches []string
+	chunks := newChunkCoalescer(50*time.Millisecond, func(batch string) {
+		batches = append(batches, batch)
+	})
+	for _, chunk := range data {
+		chunks.Add(chunk)
+	}
+	chunks.Flush()
+
+	if len(batches) >= len(data) {
+		t.Errorf("expected rapid chunks to be coalesced, got %d batches for %d chunks", len(batches), len(data))
+	}
+	if got := strings.Join(batches, ""); got != strings.Join(data, "") {
+		t.Errorf("coalescing changed content: %q", got)
+	}
+}
+
+func TestChunkCoalescerFlushesSlowChunks(t *testing.T) {
+	data := []string{"one ", "two ", "three"}
+
+	var mu sync.Mutex
+	var batches []string
+	chunks := newChunkCoalescer(5*time.Millisecond, func(batch string) {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, batch)
+	})
+	for _, chunk := range data {
+		chunks.Add(chunk)
+		time.Sleep(60 * time.Millisecond)
+	}
+	chunks.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != len(data) {
+		t.Errorf("expected slow chunks to flush individually, got %q", batches)
+	}
+}
+
+func TestStreamTurnDeliversChunksThenCompletes(t *testing.T) {
+	sess := newTestChatSession(t, "hello world")
+
+	var content strings.Builder
+	msg := newTurnCmd(sess, "hi")()
+	for {
+		chunk, ok := msg.(llmChunkMsg)
+		if !ok {
+			break
+		}
+		content.WriteString(chunk.chunk)
+		if chunk.next == nil {
+			t.Fatal("expected chunk message to carry the next command")
+		}
+		msg = chunk.next()
+	}
+
+	complete, ok := msg.(llmCompleteMsg)
+	if !ok {
+		t.Fatalf("expected llmCompleteMsg, got %T", msg)
+	}
+	if content.String() != "hello world" || complete.fullResponse != "hello world" || complete.more {
+		t.Errorf("expected streamed and full content to match, got %q / %q", content.String(), complete.fullResponse)
+	}
+	if sess.TurnCount() != 1 {
+		t.Errorf("expected the turn to record the input, got %d turns", sess.TurnCount())
+	}
+}
+
+func TestHardWrap(t *testing.T) {
+	if got := hardWrap("short line", 40); got != "short line" {
+		t.Errorf("short text should be unchanged, got %q", got)
+	}
+
+	got := hardWrap("keep\n"+strings.Repeat("ab", 25), 20)
+	lines := strings.Split(got, "\n")
+	if len(lines) != 4 || lines[0] != "keep" {
+		t.Fatalf("expected the long line split into 3 pieces after \"keep\", got %q", lines)
+	}
+	for _, line := range lines {
+		if len([]rune(line)) > 20 {
+			t.Errorf("line exceeds wrap width: %q", line)
+		}
+	}
+	if strings.ReplaceAll(got, "\n", "") != "keep"+strings.Repeat("ab", 25) {
+		t.Error("wrapping must not drop or reorder characters")
+	}
+}
+
+func TestViewportWrapsPathologicalLongLine(t *testing.T) {
+	m := newModel("test", nil)
+	m.viewport.Width = 80
+	m.messages = []Message{
+		{Role: "tool", Content: strings.Repeat("x", 64*1024)},
+	}
+
+	start := time.Now()
+	m.updateViewportContent()
+	view := m.viewport.View()
+	elapsed := time.Since(start)
+
+	if elapsed > 2*time.Second {
+		t.Errorf("rendering a long line took %v", elapsed)
+	}
+	wantLines := 64 * 1024 / (80 - maxRolePrefixWidth)
+	if m.viewport.TotalLineCount() < wantLines {
+		t.Errorf("expected the line hard-wrapped into at least %d lines, got %d", wantLines, m.viewport.TotalLineCount())
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > 80 {
+			t.Fatalf("rendered line is %d cells wide, exceeds viewport width 80", w)
+		}
+	}
+}
+
+func TestNewModelUsesConfiguredDefaults(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "goshi.yaml")
+	if err := os.WriteFile(cfgPath, []byte("tui:\n  mode: diff\n  dry_run: true\n  deterministic: true\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("GOSHI_CONFIG", cfgPath)
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	m := newModel("test", nil)
+	if m.mode != ModeDiff {
+		t.Errorf("expected initial mode Diff, got %s", m.mode)
+	}
+	if !m.toggles.DryRun || !m.toggles.Deterministic {
+		t.Errorf("expected both toggles on, got %+v", m.toggles)
+	}
+}
+
+func TestNewModelDefaultsToChatWithTogglesOff(t *testing.T) {
+	t.Setenv("GOSHI_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	m := newModel("test", nil)
+	if m.mode != ModeChat {
+		t.Errorf("expected initial mode Chat, got %s", m.mode)
+	}
+	if m.toggles.DryRun || m.toggles.Deterministic {
+		t.Errorf("expected toggles off, got %+v", m.toggles)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	cases := map[string]Mode{"chat": ModeChat, "Command": ModeCommand, "diff": ModeDiff, "": ModeChat, "bogus": ModeChat}
+	for input, want := range cases {
+		if got := ParseMode(input); got != want {
+			t.Errorf("ParseMode(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+// scriptedStream yields its chunks, then ends with err (io.EOF if nil)
+type scriptedStream struct {
+	data      []string
+	err       error
+	reasoning string
+	finish    string
+	index     int
+}
+
+func (s *scriptedStream) Recv() (string, error) {
+	if s.index >= len(s.data) {
+		if s.err != nil {
+			return "", s.err
+		}
+		return "", io.EOF
+	}
+	chunk := s.data[s.index]
+	s.index++
+	return chunk, nil
+}
+
+func (s *scriptedStream) Close() error         { return nil }
+func (s *scriptedStream) Reasoning() string    { return s.reasoning }
+func (s *scriptedStream) FinishReason() string { return s.finish }
+
+type scriptedBackend struct {
+	stream *scriptedStream
+}
+
+func (b *scriptedBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	return b.stream, nil
+}
+
+// sequenceBackend serves its streams in order, one per request, and keeps
+// the messages of each request
+type sequenceBackend struct {
+	streams  []*scriptedStream
+	requests [][]llm.Message
+}
+
+func (b *sequenceBackend) Stream(ctx context.Context, system string, messages []llm.Message) (llm.Stream, error) {
+	b.requests = append(b.requests, messages)
+	stream := b.streams[0]
+	b.streams = b.streams[1:]
+	return stream, nil
+}
+
+// newTurnCmd starts a turn for input, as sending it from the TUI does
+func newTurnCmd(sess *session.ChatSession, input string) tea.Cmd {
+	return streamTurn(sess, func(hooks session.TurnHooks) *session.ChatTurn {
+		return sess.NewTurn(input, hooks)
+	})
+}
+
+// turnNext returns the command that waits for a turn's next message after
+// msg, or nil once the turn has ended
+func turnNext(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case llmChunkMsg:
+		return msg.next
+	case llmStepMsg:
+		return msg.next
+	case toolExecutionMsg:
+		return msg.next
+	case llmCompleteMsg:
+		return msg.next
+	}
+	return nil
+}
+
+// runStream feeds a turn's messages through the model until the turn
+// completes or fails
+func runStream(t *testing.T, m model, cmd tea.Cmd) model {
+	t.Helper()
+	msg := cmd()
+	for {
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+		next := turnNext(msg)
+		if next == nil {
+			return m
+		}
+		msg = next()
+	}
+}
+
+func TestContinueExtendsTruncatedResponse(t *testing.T) {
+	sess := newTestChatSession(t)
+	backend := &sequenceBackend{streams: []*scriptedStream{
+		{data: []string{"The first three primes are 2, "}, finish: llm.FinishReasonLength},
+		{data: []string{"3 and 5."}, finish: "stop"},
+	}}
+	sess.Client = llm.NewClientWithTools(sess.Client.System(), backend)
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.streaming = true
+	m.messages = []Message{{Role: "user", Content: "List the first three primes"}, {Role: "assistant", InProgress: true}}
+	m = runStream(t, m, newTurnCmd(sess, "List the first three primes"))
+
+	if !m.canContinue || !strings.Contains(m.statusLine, "cut off") {
+		t.Fatalf("expected a truncated response to offer /continue, got canContinue=%v status=%q", m.canContinue, m.statusLine)
+	}
+
+	m.textarea.SetValue("/continue")
+	updated, cmd := m.handleSendMessage()
+	m = updated.(model)
+	if cmd == nil || !m.continuing {
+		t.Fatal("expected /continue to start a continuation")
+	}
+	m = runStream(t, m, cmd)
+
+	if len(m.messages) != 2 {
+		t.Fatalf("expected the continuation merged into one message, got %d messages", len(m.messages))
+	}
+	if got := m.messages[1].Content; got != "The first three primes are 2, 3 and 5." {
+		t.Errorf("expected merged content, got %q", got)
+	}
+	if m.canContinue || m.continuing || m.streaming {
+		t.Errorf("expected the continuation finished, got canContinue=%v continuing=%v streaming=%v", m.canContinue, m.continuing, m.streaming)
+	}
+
+	last := backend.requests[1][len(backend.requests[1])-1]
+	if last.Role != "user" || last.Content != session.ContinuePrompt {
+		t.Errorf("expected the continue instruction sent last, got %+v", last)
+	}
+	msgs := sess.ContextMessages()
+	if final := msgs[len(msgs)-1]; final.Role != "assistant" || final.Content != "The first three primes are 2, 3 and 5." {
+		t.Errorf("expected the session to hold the merged response, got %+v", final)
+	}
+}
+
+func TestContinueWithoutTruncatedResponse(t *testing.T) {
+	m := newModel("test", newTestChatSession(t))
+	m.textarea.SetValue("/continue")
+	updated, cmd := m.handleSendMessage()
+	um := updated.(model)
+	if cmd != nil || um.continuing || um.statusLine != "Nothing to continue" {
+		t.Errorf("expected nothing to continue, got continuing=%v status=%q", um.continuing, um.statusLine)
+	}
+}
+
+func TestStreamTurnSurfacesRefusalAsError(t *testing.T) {
+	sess := newTestChatSession(t)
+	sess.Client = llm.NewClientWithTools(sess.Client.System(), &scriptedBackend{
+		stream: &scriptedStream{err: &llm.RefusalError{Refusal: "I can't help with that."}},
+	})
+
+	msg := newTurnCmd(sess, "do something bad")()
+	complete, ok := msg.(llmCompleteMsg)
+	if !ok {
+		t.Fatalf("expected llmCompleteMsg, got %T", msg)
+	}
+	if complete.parseResult == nil || complete.parseResult.Response.Type != llm.ResponseTypeError {
+		t.Fatalf("expected an error response, got %+v", complete.parseResult)
+	}
+
+	m := newModel("test", sess)
+	m.messages = append(m.messages, Message{Role: "assistant", InProgress: true})
+	updated, _ := m.Update(complete)
+	got := updated.(model).messages
+	if last := got[len(got)-1]; !strings.Contains(last.Content, "Error: model refused the request: I can't help with that.") {
+		t.Errorf("expected the refusal shown as an error, got %q", last.Content)
+	}
+}
+
+func TestStreamErrorKeepsPartialContent(t *testing.T) {
+	sess := newTestChatSession(t)
+	sess.Client = llm.NewClientWithTools(sess.Client.System(), &scriptedBackend{
+		stream: &scriptedStream{data: []string{"The answer is ", "forty"}, err: errors.New("connection reset")},
+	})
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.streaming = true
+	m.messages = []Message{{Role: "assistant", InProgress: true}}
+
+	var msg tea.Msg = newTurnCmd(sess, "what is the answer?")()
+	for {
+		chunk, ok := msg.(llmChunkMsg)
+		if !ok {
+			break
+		}
+		updated, _ := m.Update(chunk)
+		m = updated.(model)
+		msg = chunk.next()
+	}
+	errMsg, ok := msg.(llmErrorMsg)
+	if !ok {
+		t.Fatalf("expected llmErrorMsg, got %T", msg)
+	}
+	if errMsg.partial != "The answer is forty" {
+		t.Errorf("expected the partial response on the error, got %q", errMsg.partial)
+	}
+
+	updated, _ := m.Update(errMsg)
+	um := updated.(model)
+	if um.streaming || um.err == nil {
+		t.Errorf("expected streaming stopped with an error, got streaming=%v err=%v", um.streaming, um.err)
+	}
+	if len(um.messages) != 1 {
+		t.Fatalf("expected the partial message to be kept, got %d messages", len(um.messages))
+	}
+	last := um.messages[0]
+	if last.InProgress {
+		t.Error("expected the partial message to be finalized")
+	}
+	if !strings.HasPrefix(last.Content, "The answer is forty") || !strings.Contains(last.Content, "✗ Stream interrupted: connection reset") {
+		t.Errorf("expected partial text with an error marker, got %q", last.Content)
+	}
+}
+
+func TestReasoningRenderedSeparatelyFromAnswer(t *testing.T) {
+	sess := newTestChatSession(t)
+	sess.Client = llm.NewClientWithTools(sess.Client.System(), &scriptedBackend{
+		stream: &scriptedStream{data: []string{"Hello!"}, reasoning: "The user wants a greeting."},
+	})
+
+	var msg tea.Msg = newTurnCmd(sess, "hi")()
+	for {
+		chunk, ok := msg.(llmChunkMsg)
+		if !ok {
+			break
+		}
+		msg = chunk.next()
+	}
+	complete := msg.(llmCompleteMsg)
+	if complete.reasoning != "The user wants a greeting." {
+		t.Fatalf("expected reasoning on the completion, got %q", complete.reasoning)
+	}
+
+	for _, show := range []bool{true, false} {
+		m := newModel("test", sess)
+		m.showReasoning = show
+		m.messages = []Message{{Role: "assistant", InProgress: true}}
+		updated, _ := m.Update(complete)
+		um := updated.(model)
+
+		hasReasoning := len(um.messages) == 2 && um.messages[0].Role == "reasoning"
+		if hasReasoning != show {
+			t.Errorf("showReasoning=%v: reasoning message present = %v (%+v)", show, hasReasoning, um.messages)
+		}
+		last := um.messages[len(um.messages)-1]
+		if last.Role != "assistant" || strings.Contains(last.Content, "greeting") {
+			t.Errorf("expected the answer alone in the assistant message, got %+v", last)
+		}
+		if show && !strings.Contains(um.viewport.View(), "REASONING:") {
+			t.Error("expected reasoning rendered with its own label")
+		}
+	}
+}
+
+func TestToolExecutionRendersStructuredResult(t *testing.T) {
+	m := newModel("test", nil)
+	m.ready = true
+
+	updatedModel, _ := m.Update(toolExecutionMsg{
+		toolName: "fs.list",
+		result: app.ToolResult{
+			Success: true,
+			Value:   runtime.ActionOutput{"path": "src", "count": 2},
+		},
+	})
+	content := updatedModel.(model).messages[0].Content
+
+	for _, want := range []string{"✓ Tool executed: fs.list", `"path": "src"`, `"count": 2`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in rendered result, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestToolExecutionShowsDuration(t *testing.T) {
+	m := newModel("test", nil)
+	m.ready = true
+
+	updatedModel, _ := m.Update(toolExecutionMsg{
+		toolName: "fs.read",
+		result:   app.ToolResult{Success: true, Value: "slow", Duration: 1500 * time.Millisecond},
+	})
+	if content := updatedModel.(model).messages[0].Content; !strings.HasPrefix(content, "✓ Tool executed: fs.read (1.5s)") {
+		t.Errorf("expected the duration in the tool message, got:\n%s", content)
+	}
+
+	for d, want := range map[time.Duration]string{0: "", 300 * time.Microsecond: " (<1ms)", 42 * time.Millisecond: " (42ms)"} {
+		if got := formatToolDuration(d); got != want {
+			t.Errorf("formatToolDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestStatusBarShowsEnforcementStatus(t *testing.T) {
+	sess := newTestChatSession(t, "ok")
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+
+	if view := m.View(); !strings.Contains(view, "enf: STAGED") {
+		t.Errorf("expected STAGED enforcement with no grants, got:\n%s", view)
+	}
+
+	sess.GrantPermission("FS_READ")
+	if view := m.View(); !strings.Contains(view, "enf: ACTIVE (FS_READ)") {
+		t.Errorf("expected ACTIVE enforcement after granting FS_READ, got:\n%s", view)
+	}
+}
+
+// shoutProcessor is a custom response processor for tests
+type shoutProcessor struct{}
+
+func (shoutProcessor) Name() string               { return "shout" }
+func (shoutProcessor) Process(text string) string { return strings.ToUpper(text) + "!" }
+
+func TestTurnShowsPostProcessedText(t *testing.T) {
+	sess := newTestChatSession(t, "<think>draft</think>done")
+	builtin, _ := llm.BuiltinResponseProcessor(llm.ProcessorStripThinking)
+	sess.PostProcessors = llm.NewResponsePipeline(builtin, shoutProcessor{})
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.textarea.SetValue("finish up")
+	updated, cmd := m.handleSendMessage()
+	m = runStream(t, updated.(model), cmd)
+
+	if got := m.messages[len(m.messages)-1].Content; got != "DONE!" {
+		t.Errorf("expected processed display text, got %q", got)
+	}
+	stored := sess.Messages[len(sess.Messages)-1].(*llm.AssistantTextMessage)
+	if stored.Content != "DONE!" {
+		t.Errorf("expected processed text in session history, got %q", stored.Content)
+	}
+}
+
+func TestTurnLimitRefusesInput(t *testing.T) {
+	sess := newTestChatSession(t, "ok")
+	sess.MaxTurns = 1
+	sess.AddUserMessage("first")
+
+	m := newModel("test", sess)
+	m.ready = true
+
+	m.textarea.SetValue("second")
+	updatedModel, cmd := m.handleSendMessage()
+	updated := updatedModel.(model)
+
+	if cmd != nil {
+		t.Error("expected no stream command once the turn limit is reached")
+	}
+	if sess.TurnCount() != 1 {
+		t.Errorf("expected refused input not to be recorded, got %d turns", sess.TurnCount())
+	}
+	if len(updated.messages) != 1 || updated.messages[0].Role != "system" {
+		t.Fatalf("expected a single system wrap-up message, got %+v", updated.messages)
+	}
+	if !strings.Contains(updated.messages[0].Content, "limit of 1 turns") {
+		t.Errorf("expected wrap-up message, got %q", updated.messages[0].Content)
+	}
+
+	// Further input is refused without repeating the wrap-up
+	updated.textarea.SetValue("third")
+	updatedModel, _ = updated.handleSendMessage()
+	updated = updatedModel.(model)
+	if len(updated.messages) != 1 {
+		t.Errorf("expected wrap-up to be emitted once, got %d messages", len(updated.messages))
+	}
+}
+
+func TestToolExecutionDiffMode(t *testing.T) {
+	backup := filepath.Join(t.TempDir(), "backup.bak")
+	if err := os.WriteFile(backup, []byte("alpha\nbeta\n"), 0644); err != nil {
+		t.Fatalf("failed to write backup: %v", err)
+	}
+
+	m := newModel("test", nil)
+	m.ready = true
+	m.mode = ModeDiff
+
+	toolMsg := toolExecutionMsg{
+		toolName: "fs.write",
+		args: map[string]any{
+			"path":    "notes.txt",
+			"content": "alpha\ngamma\n",
+		},
+		result: map[string]any{
+			"result": runtime.ActionOutput{
+				"path":        "notes.txt",
+				"is_new_file": false,
+				"backup_path": backup,
+			},
+		},
+	}
+
+	updatedModel, _ := m.Update(toolMsg)
+	updated := updatedModel.(model)
+
+	if len(updated.messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(updated.messages))
+	}
+
+	content := updated.messages[0].Content
+	for _, want := range []string{"--- a/notes.txt", "-beta", "+gamma"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected diff output to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestToolExecutionWriteChatModeShowsRaw(t *testing.T) {
+	m := newModel("test", nil)
+	m.ready = true
+
+	toolMsg := toolExecutionMsg{
+		toolName: "fs.write",
+		args:     map[string]any{"path": "notes.txt", "content": "new"},
+		result: map[string]any{
+			"result": runtime.ActionOutput{"path": "notes.txt", "is_new_file": true},
+		},
+	}
+
+	updatedModel, _ := m.Update(toolMsg)
+	updated := updatedModel.(model)
+
+	if strings.Contains(updated.messages[0].Content, "+++ b/notes.txt") {
+		t.Error("expected no diff rendering outside diff mode")
+	}
+}
+
+func TestLLMCompleteClarification(t *testing.T) {
+	sess := newTestChatSession(t, "ok")
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.streaming = true
+	m.messages = append(m.messages, Message{Role: "assistant", InProgress: true})
+
+	completeMsg := llmCompleteMsg{
+		parseResult: &llm.ParseResult{
+			Valid: true,
+			Response: &llm.StructuredResponse{
+				Type: llm.ResponseTypeClarification,
+				Clarification: &llm.ClarificationRequest{
+					Question: "Which file should I edit?",
+					Hints:    []string{"main.go", "util.go"},
+				},
+			},
+		},
+	}
+
+	updatedModel, _ := m.Update(completeMsg)
+	updated := updatedModel.(model)
+
+	last := updated.messages[len(updated.messages)-1]
+	if last.Role != "clarification" {
+		t.Fatalf("expected clarification role, got %q", last.Role)
+	}
+	if !strings.Contains(last.Content, "1) main.go") || !strings.Contains(last.Content, "2) util.go") {
+		t.Errorf("expected hints to be listed, got %q", last.Content)
+	}
+	if !updated.awaitingClarification {
+		t.Error("expected model to await a clarification answer")
+	}
+
+	updated.updateViewportContent()
+	if !strings.Contains(updated.viewport.View(), "QUESTION:") {
+		t.Error("expected clarification to render with a QUESTION label")
+	}
+
+	// The next input is recorded as a clarification answer
+	updated.streaming = false
+	updated.textarea.SetValue("main.go")
+	updatedModel, cmd := updated.handleSendMessage()
+	updated = updatedModel.(model)
+	if updated.awaitingClarification {
+		t.Error("expected clarification state to clear after answering")
+	}
+	updated = runStream(t, updated, cmd)
+
+	answer, ok := sess.Messages[0].(*llm.UserMessage)
+	if !ok || !answer.Clarification || answer.Content != "main.go" {
+		t.Fatalf("expected answer to be tagged as clarification, got %+v", sess.Messages[0])
+	}
+}
+
+func TestThemeCustomizesCursorAndRoleLabels(t *testing.T) {
+	theme := NewTheme(config.ThemeConfig{
+		Cursor:      "_",
+		CursorColor: "39",
+		RoleColors:  map[string]string{"user": "#ff0000"},
+	})
+	if theme.Cursor != "_" || theme.cursorStyle.GetForeground() != lipgloss.Color("39") {
+		t.Errorf("expected a custom cursor glyph and color, got %q %v", theme.Cursor, theme.cursorStyle.GetForeground())
+	}
+	if got := theme.labelStyle("user").GetForeground(); got != lipgloss.Color("#ff0000") {
+		t.Errorf("expected the user label recolored, got %v", got)
+	}
+	if got := theme.labelStyle("assistant").GetForeground(); got != roleStyle.GetForeground() {
+		t.Errorf("expected other labels to keep the default color, got %v", got)
+	}
+	if !theme.labelStyle("user").GetBold() {
+		t.Error("expected a recolored label to keep the label styling")
+	}
+
+	m := newModel("test", nil)
+	m.ready = true
+	m.theme = theme
+	m.messages = []Message{{Role: "assistant", Content: "partial", InProgress: true}}
+	m.updateViewportContent()
+	if view := m.viewport.View(); !strings.Contains(view, "partial_") || strings.Contains(view, "▊") {
+		t.Errorf("expected the custom cursor after streaming text, got %q", view)
+	}
+}
+
+func TestThemeCursorCanBeDisabled(t *testing.T) {
+	m := newModel("test", nil)
+	m.ready = true
+	if !strings.Contains(m.theme.cursor(), "▊") {
+		t.Errorf("expected the default cursor glyph, got %q", m.theme.cursor())
+	}
+
+	m.theme = NewTheme(config.ThemeConfig{Cursor: ""})
+	m.messages = []Message{{Role: "assistant", Content: "partial", InProgress: true}}
+	m.updateViewportContent()
+	if view := m.viewport.View(); strings.Contains(view, "▊") || !strings.Contains(view, "ASSISTANT: partial") {
+		t.Errorf("expected no cursor glyph, got %q", view)
+	}
+}
+
+func TestPasteOverInputLimitWarns(t *testing.T) {
+	m := newModel("test", nil)
+	m.ready = true
+	m.textarea.CharLimit = 10
+
+	paste := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("0123456789abcdef"), Paste: true}
+	updated, _ := m.Update(paste)
+	um := updated.(model)
+
+	if got := um.textarea.Value(); got != "0123456789" {
+		t.Fatalf("expected the paste cut off at the limit, got %q", got)
+	}
+	if len(um.messages) != 1 || um.messages[0].Role != "system" {
+		t.Fatalf("expected one system warning, got %+v", um.messages)
+	}
+	warning := um.messages[0].Content
+	for _, want := range []string{"Input truncated at 10 characters (6 dropped)", "tui.input_char_limit", "save the content to a file"} {
+		if !strings.Contains(warning, want) {
+			t.Errorf("expected %q in warning %q", want, warning)
+		}
+	}
+	if um.statusLine != "Input truncated" {
+		t.Errorf("expected a truncation status, got %q", um.statusLine)
+	}
+
+	// Further typing at the limit does not repeat the warning
+	updated, _ = um.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if got := len(updated.(model).messages); got != 1 {
+		t.Errorf("expected the warning once per draft, got %d messages", got)
+	}
+}
+
+func TestInputTruncationWarningKeepsStreamingResponseLast(t *testing.T) {
+	m := newModel("test", nil)
+	m.ready = true
+	m.textarea.CharLimit = 10
+	m.streaming = true
+	m.messages = []Message{{Role: "user", Content: "hi"}, {Role: "assistant", InProgress: true}}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("0123456789abcdef"), Paste: true})
+	updated, _ = updated.(model).Update(llmChunkMsg{chunk: "streamed"})
+	um := updated.(model)
+
+	if len(um.messages) != 3 || um.messages[1].Role != "system" || !strings.Contains(um.messages[1].Content, "Input truncated") {
+		t.Fatalf("expected the warning before the streaming response, got %+v", um.messages)
+	}
+	last := um.messages[2]
+	if last.Role != "assistant" || !last.InProgress || last.Content != "streamed" {
+		t.Errorf("expected the response to keep streaming into the last message, got %+v", last)
+	}
+}
+
+func TestInputWithinLimitDoesNotWarn(t *testing.T) {
+	m := newModel("test", nil)
+	m.ready = true
+	m.textarea.CharLimit = 10
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("0123456789"), Paste: true})
+	um := updated.(model)
+	if um.textarea.Value() != "0123456789" {
+		t.Fatalf("expected the input to fit exactly, got %q", um.textarea.Value())
+	}
+	if len(um.messages) != 0 || um.inputTruncated {
+		t.Errorf("expected no warning for input that fits, got %+v", um.messages)
+	}
+}
+
+func TestSendDuringToolExecutionIsQueued(t *testing.T) {
+	sess := newTestChatSession(t, "done")
+	m := newModel("test", sess)
+	m.ready = true
+	m.streaming = true
+	m.messages = []Message{{Role: "user", Content: "list files"}, {Role: "assistant", InProgress: true}}
+
+	// The response asks for a tool; the model stays busy while it runs
+	waitForTurn := func() tea.Msg { return nil }
+	updatedModel, cmd := m.Update(llmCompleteMsg{
+		parseResult: &llm.ParseResult{
+			Valid: true,
+			Response: &llm.StructuredResponse{
+				Type:   llm.ResponseTypeAction,
+				Action: &llm.ActionCall{Tool: "fs.list", Args: map[string]any{"path": "."}},
+			},
+		},
+		more: true,
+		next: waitForTurn,
+	})
+	m = updatedModel.(model)
+	if cmd == nil || !m.toolRunning || !m.busy() {
+		t.Fatalf("expected the tool to be running, got toolRunning=%v", m.toolRunning)
+	}
+
+	m.textarea.SetValue("now summarize them")
+	updatedModel, cmd = m.handleSendMessage()
+	m = updatedModel.(model)
+	if cmd != nil {
+		t.Error("expected no stream to start while the tool runs")
+	}
+	if len(m.queuedSends) != 1 || len(m.messages) != 2 {
+		t.Fatalf("expected the send queued without touching messages, got queue=%v messages=%d", m.queuedSends, len(m.messages))
+	}
+	if m.textarea.Value() != "" {
+		t.Errorf("expected the input cleared once queued, got %q", m.textarea.Value())
+	}
+	if !strings.Contains(m.renderInput(), "Busy (1 queued)") {
+		t.Errorf("expected a busy indicator, got %q", m.renderInput())
+	}
+
+	// The tool result is shown while the turn goes on with its answer
+	updatedModel, _ = m.Update(toolExecutionMsg{
+		toolName: "fs.list",
+		result:   app.ToolResult{Success: true, Value: "main.go"},
+		next:     waitForTurn,
+	})
+	m = updatedModel.(model)
+	if m.toolRunning || !m.busy() || len(m.queuedSends) != 1 {
+		t.Fatalf("expected the turn still busy after the tool, got toolRunning=%v queue=%v", m.toolRunning, m.queuedSends)
+	}
+	updatedModel, _ = m.Update(llmStepMsg{next: waitForTurn})
+	m = updatedModel.(model)
+
+	// Once the turn answers, the queued send goes out
+	updatedModel, cmd = m.Update(llmCompleteMsg{fullResponse: "main.go is the only file"})
+	m = updatedModel.(model)
+	if cmd == nil || !m.streaming || len(m.queuedSends) != 0 {
+		t.Fatalf("expected the queued send to stream, got streaming=%v queue=%v", m.streaming, m.queuedSends)
+	}
+	roles := []string{}
+	for _, msg := range m.messages {
+		roles = append(roles, msg.Role)
+	}
+	if got := strings.Join(roles, ","); got != "user,assistant,assistant,assistant,user,assistant" {
+		t.Fatalf("expected the tool result and answer before the queued send, got %s", got)
+	}
+	if !strings.Contains(m.messages[2].Content, "Tool executed: fs.list") || m.messages[4].Content != "now summarize them" {
+		t.Errorf("unexpected messages: %+v", m.messages[2:])
+	}
+}
+
+func TestQueuedSendsGoOutInOrder(t *testing.T) {
+	sess := newTestChatSession(t, "one", "two")
+	m := newModel("test", sess)
+	m.ready = true
+	m.streaming = true
+	m.messages = []Message{{Role: "assistant", InProgress: true}}
+
+	for _, input := range []string{"first", "second"} {
+		m.textarea.SetValue(input)
+		updatedModel, _ := m.handleSendMessage()
+		m = updatedModel.(model)
+	}
+
+	updatedModel, _ := m.Update(llmCompleteMsg{fullResponse: "hi"})
+	m = updatedModel.(model)
+	if len(m.queuedSends) != 1 || m.queuedSends[0] != "second" {
+		t.Fatalf("expected only the first queued send to go out, got queue=%v", m.queuedSends)
+	}
+	if last := m.messages[len(m.messages)-2]; last.Content != "first" {
+		t.Errorf("expected the first queued send next, got %q", last.Content)
+	}
+
+	updatedModel, _ = m.Update(llmCompleteMsg{fullResponse: "one"})
+	m = updatedModel.(model)
+	if len(m.queuedSends) != 0 || m.messages[len(m.messages)-2].Content != "second" {
+		t.Errorf("expected the second queued send last, got queue=%v", m.queuedSends)
+	}
+}
+
+func TestQueuedCommandDoesNotStrandLaterSends(t *testing.T) {
+	sess := newTestChatSession(t, "one")
+	m := newModel("test", sess)
+	m.ready = true
+	m.streaming = true
+	m.messages = []Message{{Role: "assistant", InProgress: true}}
+
+	for _, input := range []string{"/permissions", "after the command"} {
+		m.textarea.SetValue(input)
+		updatedModel, _ := m.handleSendMessage()
+		m = updatedModel.(model)
+	}
+
+	// /permissions is answered at once, so the next send goes out with it
+	updatedModel, cmd := m.Update(llmCompleteMsg{fullResponse: "hi"})
+	m = updatedModel.(model)
+	if len(m.queuedSends) != 0 || !m.streaming || cmd == nil {
+		t.Fatalf("expected every queued send to go out, got queue=%v streaming=%v", m.queuedSends, m.streaming)
+	}
+	if got := m.messages[len(m.messages)-3]; got.Role != "system" || !strings.Contains(got.Content, "Capabilities") {
+		t.Errorf("expected the capabilities shown first, got %+v", got)
+	}
+	if got := m.messages[len(m.messages)-2]; got.Content != "after the command" {
+		t.Errorf("expected the later send to stream, got %+v", got)
+	}
+}
+
+func TestPermissionsCommandShowsCapabilities(t *testing.T) {
+	sess := newTestChatSession(t)
+	sess.GrantPermission("FS_READ")
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.textarea.SetValue("/permissions")
+	updated, cmd := m.handleSendMessage()
+	um := updated.(model)
+
+	if cmd != nil {
+		t.Error("expected no request to the model")
+	}
+	last := um.messages[len(um.messages)-1]
+	if last.Role != "system" || !strings.Contains(last.Content, "Capabilities:") {
+		t.Fatalf("expected a capabilities message, got %+v", last)
+	}
+	for _, want := range []string{"FS_READ", "granted", "FS_WRITE", "not requested"} {
+		if !strings.Contains(last.Content, want) {
+			t.Errorf("expected %q in %q", want, last.Content)
+		}
+	}
+	if sess.TurnCount() != 0 {
+		t.Errorf("expected /permissions not recorded as a turn, got %d", sess.TurnCount())
+	}
+}
+
+func TestExplainBeforeActRefusesUnexplainedTool(t *testing.T) {
+	sess := newTestChatSession(t)
+	sess.ExplainBeforeAct = true
+	sess.GrantPermission("FS_READ")
+	backend := &sequenceBackend{streams: []*scriptedStream{
+		{data: []string{`{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`}},
+		{data: []string{`{"type": "text", "text": "I will list the directory to see what it holds."}`}},
+		{data: []string{`{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`}},
+		{data: []string{`{"type": "text", "text": "The directory holds the package sources."}`}},
+	}}
+	sess.Client = llm.NewClientWithTools(sess.Client.System(), backend)
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.textarea.SetValue("What is in this directory?")
+	updated, cmd := m.handleSendMessage()
+	m = runStream(t, updated.(model), cmd)
+
+	if m.toolRunning || m.streaming {
+		t.Fatalf("expected the turn finished, got toolRunning=%v streaming=%v", m.toolRunning, m.streaming)
+	}
+	req := backend.requests[1]
+	if last := req[len(req)-1]; last.Content != session.ExplainPrompt {
+		t.Errorf("expected the re-prompt for an explanation, got %+v", last)
+	}
+
+	// The refusal, then the explanation, then the tool call and its answer
+	var contents []string
+	for _, msg := range m.messages {
+		contents = append(contents, msg.Content)
+	}
+	if len(m.messages) != 6 || !strings.Contains(contents[1], "Not running fs.list") ||
+		contents[2] != "I will list the directory to see what it holds." ||
+		!strings.Contains(contents[3], "Executing tool: fs.list") ||
+		contents[5] != "The directory holds the package sources." {
+		t.Fatalf("unexpected messages: %q", contents)
+	}
+}
+
+// denyingPrompter refuses every permission request
+type denyingPrompter struct{}
+
+func (denyingPrompter) Ask(string, string) bool { return false }
+
+func TestDeniedToolPermissionIsFedBackToModel(t *testing.T) {
+	sess := newTestChatSession(t)
+	sess.ContinueAfterDenial = true
+	backend := &sequenceBackend{streams: []*scriptedStream{
+		{data: []string{`{"type": "action", "action": {"tool": "fs.write", "args": {"path": "README.md", "content": "new"}}}`}},
+		{data: []string{`{"type": "text", "text": "Here is the change as a diff you can apply yourself."}`}},
+	}}
+	sess.Client = llm.NewClientWithTools(sess.Client.System(), backend)
+
+	m := newModel("test", sess)
+	m.ready = true
+	sess.Prompter = denyingPrompter{} // The user refuses FS_WRITE
+
+	m.textarea.SetValue("Update the README")
+	updated, cmd := m.handleSendMessage()
+	m = runStream(t, updated.(model), cmd)
+
+	req := backend.requests[1]
+	if last := req[len(req)-1]; last.Content != session.DenialNote("FS_WRITE") {
+		t.Errorf("expected the denial note sent last, got %+v", last)
+	}
+	if !strings.Contains(m.messages[2].Content, "Tool failed: fs.write") {
+		t.Errorf("expected the refused tool shown, got %+v", m.messages)
+	}
+	if got := m.messages[len(m.messages)-1].Content; got != "Here is the change as a diff you can apply yourself." {
+		t.Errorf("expected the model's alternative shown, got %q", got)
+	}
+}
+
+func TestToolResultsAreFedBackToModel(t *testing.T) {
+	sess := newTestChatSession(t)
+	sess.GrantPermission("FS_READ")
+	backend := &sequenceBackend{streams: []*scriptedStream{
+		{data: []string{`{"type": "action", "action": {"tool": "fs.list", "args": {"path": "."}}}`}},
+		{data: []string{`{"type": "text", "text": "The directory holds the package sources."}`}},
+	}}
+	sess.Client = llm.NewClientWithTools(sess.Client.System(), backend)
+
+	m := newModel("test", sess)
+	m.ready = true
+	m.textarea.SetValue("What is in this directory?")
+	updated, cmd := m.handleSendMessage()
+	m = runStream(t, updated.(model), cmd)
+
+	if len(backend.requests) != 2 {
+		t.Fatalf("expected the model asked again after the tool, got %d requests", len(backend.requests))
+	}
+	req := backend.requests[1]
+	if last := req[len(req)-1]; !strings.Contains(last.Content, "Tool fs.list result") {
+		t.Errorf("expected the tool result sent back, got %+v", last)
+	}
+	roles := []string{}
+	for _, msg := range m.messages {
+		roles = append(roles, msg.Role)
+	}
+	if got := strings.Join(roles, ","); got != "user,assistant,assistant,assistant" {
+		t.Fatalf("expected the call, its result and the answer, got %s", got)
+	}
+	if !strings.Contains(m.messages[1].Content, "Executing tool: fs.list") || m.messages[3].Content != "The directory holds the package sources." {
+		t.Errorf("unexpected messages: %+v", m.messages)
+	}
+	if m.streaming || m.toolRunning || m.statusLine != "Ready" {
+		t.Errorf("expected the turn finished, got streaming=%v toolRunning=%v status=%q", m.streaming, m.toolRunning, m.statusLine)
+	}
+}
+
+func TestViewDuringTurnReadsSessionSnapshot(t *testing.T) {
+	sess := newTestChatSession(t)
+	sess.GrantPermission("FS_READ")
+	backend := &sequenceBackend{streams: []*scriptedStream{
+		{data: []string{`{"type": "action", `, `"action": {"tool": "fs.list", "args": {"path": "."}}}`}},
+		{data: []string{`{"type": "text", `, `"text": "The directory holds the package sources."}`}},
+	}}
+	sess.Client = llm.NewClientWithTools(sess.Client.System(), backend)
+
+	m := newModel("test", sess)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(model)
+	m.textarea.SetValue("What is in this directory?")
+	updated, cmd := m.handleSendMessage()
+	m = updated.(model)
+
+	// Render after every message while the turn goroutine keeps going;
+	// under -race this fails if View reads the session the turn writes
+	msg := cmd()
+	for {
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+		if m.View() == "" {
+			t.Fatal("expected a rendered view")
+		}
+		next := turnNext(msg)
+		if next == nil {
+			break
+		}
+		msg = next()
+	}
+
+	if m.session.messages != len(sess.Messages) {
+		t.Errorf("expected the final snapshot to count %d messages, got %d", len(sess.Messages), m.session.messages)
+	}
+	if !m.session.fsRead || m.session.enforcement != sess.Permissions.EnforcementStatus() {
+		t.Errorf("expected the snapshot to carry the granted read permission, got %+v", m.session)
+	}
+}
+
+func TestAuditPanelExplainsTextOnlyLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session-1.log")
+	if err := os.WriteFile(path, []byte("12:00:00 [SESSION] OK start\n"), 0644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	panel := NewAuditPanel(path)
+	panel.SetSize(120, 20)
+	view := panel.Render()
+	if !strings.Contains(view, "audit.format is text") {
+		t.Errorf("expected a text-only notice, got:\n%s", view)
+	}
+	if strings.Contains(view, "no audit events yet") {
+		t.Errorf("expected a text-only log not to read as empty, got:\n%s", view)
+	}
+}
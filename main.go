@@ -9,7 +9,7 @@ import (
 )
 
 func main() {
-	sm, err := selfmodel.Load("")
+	sm, err := selfmodel.Load(append([]string{""}, selfmodel.FragmentPaths()...)...)
 	if err != nil {
 		log.Fatalf("startup aborted: %v", err)
 	}